@@ -23,7 +23,9 @@ import (
 	"api/internal/logs"
 	"api/internal/logs/sources"
 	"api/internal/nftables"
+	"api/internal/reports"
 	"api/internal/router"
+	"api/internal/scheduler"
 	"api/internal/settings"
 	"api/internal/setup"
 	"api/internal/stats"
@@ -35,6 +37,9 @@ import (
 )
 
 func main() {
+	// Keep recent log lines in memory for the app logs endpoint
+	logs.InstallAppLogSink()
+
 	// Initialize stats (records start time for uptime)
 	stats.Init()
 
@@ -60,6 +65,10 @@ func main() {
 	// Initialize encryption (must be before services that use encryption)
 	helper.InitEncryption()
 
+	// Detect the public IP on dynamic-IP hosts (SERVER_IP_AUTODETECT or no SERVER_IP)
+	helper.StartPublicIPDetection()
+	settings.RecoverEncryptionKey(dataDir)
+
 	// Initialize and register services
 	// Auth must be first (other services depend on it)
 	if config.IsServiceEnabled("auth") {
@@ -76,6 +85,7 @@ func main() {
 				_, err := authSvc.ValidateSession(token)
 				return err == nil
 			})
+			router.SetAPITokenValidator(authSvc.ValidateAPIToken)
 
 			log.Println("Auth service registered")
 		}
@@ -152,6 +162,14 @@ func main() {
 		db, _ := database.GetDB()
 		nftSvc.RegisterTable(nftables.NewVPNACLTable(db))
 
+		// Report whether rules can actually be enforced on this host
+		router.RegisterHealthCheck("nftables", func() router.HealthCheck {
+			if a := nftSvc.Availability(); !a.Available {
+				return router.HealthCheck{Status: "error", Message: a.Error}
+			}
+			return router.HealthCheck{Status: "ok"}
+		})
+
 		log.Println("nftables service initialized")
 	}
 
@@ -161,6 +179,7 @@ func main() {
 			log.Printf("Warning: Failed to initialize firewall service: %v", err)
 		} else {
 			r.RegisterService("firewall", fwSvc.Handlers())
+			setup.EnsureFirewallDefaults = fwSvc.EnsureDefaults
 			log.Println("Firewall service registered")
 		}
 	}
@@ -246,6 +265,12 @@ func main() {
 		// Start traffic sync goroutine
 		vpn.StartTrafficSync()
 
+		// The VPN ACL rules reference the server's public IP
+		helper.OnPublicIPChange(func(oldIP, newIP string) {
+			log.Printf("Public IP changed from %s to %s, re-applying VPN ACL", oldIP, newIP)
+			vpnSvc.RequestApply()
+		})
+
 		log.Println("VPN ACL service registered")
 	}
 
@@ -260,6 +285,21 @@ func main() {
 		log.Println("Turbotunnels service registered")
 	}
 
+	if config.IsServiceEnabled("scheduler") {
+		r.RegisterService("scheduler", scheduler.New().Handlers())
+		log.Println("Scheduler service registered")
+	}
+
+	if config.IsServiceEnabled("reports") {
+		reportsSvc, err := reports.New()
+		if err != nil {
+			log.Printf("Warning: Failed to initialize reports service: %v", err)
+		} else {
+			r.RegisterService("reports", reportsSvc.Handlers())
+			log.Println("Reports service registered")
+		}
+	}
+
 	if config.IsServiceEnabled("logs") {
 		logsSvc, err := logs.New()
 		if err != nil {
@@ -410,6 +450,12 @@ func main() {
 		// Stop VPN traffic sync
 		vpn.StopTrafficSync()
 
+		// Stop public IP detection
+		helper.StopPublicIPDetection()
+
+		// Stop scheduled jobs
+		scheduler.StopAll()
+
 		// Close database
 		if err := database.Close(); err != nil {
 			log.Printf("Error closing database: %v", err)
@@ -2,7 +2,9 @@ package adguard
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -81,11 +83,17 @@ func (s *Service) Handlers() router.ServiceHandlers {
 		"UpdateFiltering": s.handleFilteringAction,
 		"GetRewrites":     s.handleGetRewrites,
 		"UpdateRewrites":  s.handleRewriteAction,
+		"GetQueryLog":     s.handleGetQueryLog,
+		"TestConnection":  s.handleTestConnection,
 	}
 }
 
 func (s *Service) doRequest(method, path string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest(method, s.adguardAPI+path, body)
+	return s.doRequestContext(context.Background(), method, path, body)
+}
+
+func (s *Service) doRequestContext(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.adguardAPI+path, body)
 	if err != nil {
 		return nil, err
 	}
@@ -137,7 +145,12 @@ func proxyError(w http.ResponseWriter, resp *http.Response) bool {
 
 // fetchJSON fetches JSON from AdGuard API and decodes it
 func (s *Service) fetchJSON(path string) (interface{}, error) {
-	resp, err := s.doRequest("GET", path, nil)
+	return s.fetchJSONContext(context.Background(), path)
+}
+
+// fetchJSONContext is fetchJSON bounded by ctx
+func (s *Service) fetchJSONContext(ctx context.Context, path string) (interface{}, error) {
+	resp, err := s.doRequestContext(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -160,14 +173,69 @@ func (s *Service) fetchJSON(path string) (interface{}, error) {
 	return result, nil
 }
 
-// handleOverview fetches status, stats, and protection settings in parallel
+// ConnectionStatus is the result of an AdGuard credentials check
+type ConnectionStatus struct {
+	Reachable     bool   `json:"reachable"`
+	Authenticated bool   `json:"authenticated"`
+	Running       bool   `json:"running"`
+	Version       string `json:"version,omitempty"`
+	Message       string `json:"message"`
+}
+
+// TestConnection calls /control/status with the configured credentials
+func (s *Service) TestConnection() ConnectionStatus {
+	var status ConnectionStatus
+
+	resp, err := s.doRequest("GET", "/control/status", nil)
+	if err != nil {
+		status.Message = fmt.Sprintf("AdGuard is unreachable at %s: %v", s.adguardAPI, err)
+		return status
+	}
+	defer resp.Body.Close()
+	status.Reachable = true
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		if username, password := s.getCredentials(); username == "" || password == "" {
+			status.Message = "AdGuard credentials are not configured"
+		} else {
+			status.Message = "AdGuard is reachable but rejected the credentials"
+		}
+		return status
+	}
+	if resp.StatusCode != http.StatusOK {
+		status.Message = fmt.Sprintf("AdGuard returned unexpected status: %s", resp.Status)
+		return status
+	}
+	status.Authenticated = true
+
+	var body struct {
+		Version string `json:"version"`
+		Running bool   `json:"running"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil {
+		status.Version = body.Version
+		status.Running = body.Running
+	}
+	status.Message = "Connected to AdGuard"
+	return status
+}
+
+func (s *Service) handleTestConnection(w http.ResponseWriter, r *http.Request) {
+	router.JSON(w, s.TestConnection())
+}
+
+// handleOverview fetches status, stats, and protection settings in parallel.
+// Fetches share a deadline; any that miss it are left out of the response.
 func (s *Service) handleOverview(w http.ResponseWriter, r *http.Request) {
 	type result struct {
 		data interface{}
 		err  error
 	}
 
-	// Fetch all endpoints in parallel
+	ctx, cancel := context.WithTimeout(r.Context(), helper.FetchTimeout())
+	defer cancel()
+
+	// Fetch all endpoints in parallel (buffered so late senders never block)
 	statusCh := make(chan result, 1)
 	statsCh := make(chan result, 1)
 	safeBrowsingCh := make(chan result, 1)
@@ -177,42 +245,54 @@ func (s *Service) handleOverview(w http.ResponseWriter, r *http.Request) {
 	availableCh := make(chan result, 1)
 
 	go func() {
-		data, err := s.fetchJSON("/control/status")
+		data, err := s.fetchJSONContext(ctx, "/control/status")
 		statusCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchJSON("/control/stats")
+		data, err := s.fetchJSONContext(ctx, "/control/stats")
 		statsCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchJSON("/control/safebrowsing/status")
+		data, err := s.fetchJSONContext(ctx, "/control/safebrowsing/status")
 		safeBrowsingCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchJSON("/control/parental/status")
+		data, err := s.fetchJSONContext(ctx, "/control/parental/status")
 		parentalCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchJSON("/control/safesearch/status")
+		data, err := s.fetchJSONContext(ctx, "/control/safesearch/status")
 		safeSearchCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchJSON("/control/blocked_services/list")
+		// /get returns ids + schedule; older AdGuard versions only have /list
+		data, err := s.fetchJSONContext(ctx, "/control/blocked_services/get")
+		if err != nil {
+			data, err = s.fetchJSONContext(ctx, "/control/blocked_services/list")
+		}
 		blockedCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchJSON("/control/blocked_services/all")
+		data, err := s.fetchJSONContext(ctx, "/control/blocked_services/all")
 		availableCh <- result{data, err}
 	}()
 
-	// Collect results
-	status := <-statusCh
-	stats := <-statsCh
-	safeBrowsing := <-safeBrowsingCh
-	parental := <-parentalCh
-	safeSearch := <-safeSearchCh
-	blocked := <-blockedCh
-	available := <-availableCh
+	// Collect results, giving up on any fetch still running at the deadline
+	collect := func(ch chan result) result {
+		select {
+		case res := <-ch:
+			return res
+		case <-ctx.Done():
+			return result{err: ctx.Err()}
+		}
+	}
+	status := collect(statusCh)
+	stats := collect(statsCh)
+	safeBrowsing := collect(safeBrowsingCh)
+	parental := collect(parentalCh)
+	safeSearch := collect(safeSearchCh)
+	blocked := collect(blockedCh)
+	available := collect(availableCh)
 
 	// Check for critical errors (status is required)
 	if status.err != nil {
@@ -239,6 +319,11 @@ func (s *Service) handleOverview(w http.ResponseWriter, r *http.Request) {
 	}
 	if blocked.err == nil {
 		response["blockedServices"] = blocked.data
+		if m, ok := blocked.data.(map[string]interface{}); ok {
+			if raw, ok := m["schedule"].(map[string]interface{}); ok {
+				response["blockedServicesSchedule"] = scheduleFromAdGuard(raw)
+			}
+		}
 	}
 	if available.err == nil {
 		response["availableServices"] = available.data
@@ -252,9 +337,10 @@ var validConfigTypes = []string{"protection", "safeBrowsing", "parental", "safeS
 // handleConfig handles unified config updates
 func (s *Service) handleConfig(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Type     string   `json:"type"`
-		Enabled  *bool    `json:"enabled,omitempty"`
-		Services []string `json:"services,omitempty"`
+		Type     string           `json:"type"`
+		Enabled  *bool            `json:"enabled,omitempty"`
+		Services []string         `json:"services,omitempty"`
+		Schedule *ServiceSchedule `json:"schedule,omitempty"`
 	}
 	if !router.DecodeJSONOrError(w, r, &req) {
 		return
@@ -353,11 +439,20 @@ func (s *Service) handleConfig(w http.ResponseWriter, r *http.Request) {
 			router.JSONError(w, "services field required for type: blockedServices", http.StatusBadRequest)
 			return
 		}
+		var schedule map[string]interface{}
+		if req.Schedule != nil {
+			var err error
+			if schedule, err = req.Schedule.toAdGuard(); err != nil {
+				router.JSONError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else {
+			// Keep the existing schedule when only the service list changes
+			schedule = s.currentServiceSchedule()
+		}
 		payload := map[string]interface{}{
-			"ids": req.Services,
-			"schedule": map[string]string{
-				"time_zone": "UTC",
-			},
+			"ids":      req.Services,
+			"schedule": schedule,
 		}
 		body, _ := json.Marshal(payload)
 		resp, err := s.doRequest("PUT", "/control/blocked_services/update", newBytesReader(body))
@@ -369,7 +464,11 @@ func (s *Service) handleConfig(w http.ResponseWriter, r *http.Request) {
 		if proxyError(w, resp) {
 			return
 		}
-		router.JSON(w, map[string]interface{}{"type": req.Type, "services": req.Services})
+		router.JSON(w, map[string]interface{}{
+			"type":     req.Type,
+			"services": req.Services,
+			"schedule": scheduleFromAdGuard(schedule),
+		})
 
 	default:
 		w.WriteHeader(http.StatusBadRequest)
@@ -386,11 +485,12 @@ func (s *Service) handleGetFiltering(w http.ResponseWriter, r *http.Request) {
 
 // Filter represents a filter entry for batch operations
 type Filter struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Enabled *bool  `json:"enabled,omitempty"` // setLists only, nil = enabled
 }
 
-var validFilteringActions = []string{"add", "remove", "toggle", "refresh", "setRules"}
+var validFilteringActions = []string{"add", "remove", "toggle", "refresh", "setRules", "setLists"}
 
 // handleFilteringAction handles unified filtering actions
 func (s *Service) handleFilteringAction(w http.ResponseWriter, r *http.Request) {
@@ -529,6 +629,31 @@ func (s *Service) handleFilteringAction(w http.ResponseWriter, r *http.Request)
 		}
 		router.JSON(w, map[string]interface{}{"action": "setRules", "count": len(req.Rules)})
 
+	case "setLists":
+		if req.Filters == nil {
+			router.JSONError(w, "filters field required for action: setLists", http.StatusBadRequest)
+			return
+		}
+		result, err := s.SetFilterLists(req.Filters)
+		if errors.Is(err, ErrInvalidFilterList) {
+			router.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusFailedDependency)
+			return
+		}
+		resp := map[string]interface{}{
+			"action":  "setLists",
+			"added":   result.Added,
+			"removed": result.Removed,
+			"updated": result.Updated,
+		}
+		if len(result.Errors) > 0 {
+			resp["errors"] = result.Errors
+		}
+		router.JSON(w, resp)
+
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 		router.JSON(w, map[string]interface{}{
@@ -736,4 +861,3 @@ func SyncDomainRewrites(domains []DomainRoute, targetIP string) []string {
 func DeleteDomainRewrite(domain, targetIP string) error {
 	return DeleteRewrite(domain, targetIP)
 }
-
@@ -0,0 +1,161 @@
+package adguard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidFilterList is returned when the desired list itself is malformed
+var ErrInvalidFilterList = errors.New("invalid filter list")
+
+// FilterSyncResult reports what setLists changed
+type FilterSyncResult struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Updated []string `json:"updated"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// FilterLists returns the configured block lists (allowlists are not included)
+func (s *Service) FilterLists() ([]Filter, error) {
+	resp, err := s.doRequest("GET", "/control/filtering/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("AdGuard authentication failed. Check credentials in Settings.")
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("AdGuard API error: %s", resp.Status)
+	}
+
+	var status struct {
+		Filters []struct {
+			Name    string `json:"name"`
+			URL     string `json:"url"`
+			Enabled bool   `json:"enabled"`
+		} `json:"filters"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+
+	filters := make([]Filter, 0, len(status.Filters))
+	for _, f := range status.Filters {
+		enabled := f.Enabled
+		filters = append(filters, Filter{Name: f.Name, URL: f.URL, Enabled: &enabled})
+	}
+	return filters, nil
+}
+
+// SetFilterLists reconciles AdGuard's block lists with the desired set: missing
+// lists are added, extra ones removed and name/enabled differences updated.
+// Failures are collected per list so one bad URL doesn't abort the rest.
+func (s *Service) SetFilterLists(desired []Filter) (FilterSyncResult, error) {
+	result := FilterSyncResult{Added: []string{}, Removed: []string{}, Updated: []string{}}
+
+	wanted := make(map[string]Filter, len(desired))
+	for _, f := range desired {
+		f.URL = strings.TrimSpace(f.URL)
+		if f.URL == "" {
+			return result, fmt.Errorf("%w: filter url is required", ErrInvalidFilterList)
+		}
+		if _, dup := wanted[f.URL]; dup {
+			return result, fmt.Errorf("%w: duplicate url %s", ErrInvalidFilterList, f.URL)
+		}
+		wanted[f.URL] = f
+	}
+
+	current, err := s.FilterLists()
+	if err != nil {
+		return result, err
+	}
+	existing := make(map[string]Filter, len(current))
+	for _, f := range current {
+		existing[f.URL] = f
+	}
+
+	for _, f := range current {
+		if _, ok := wanted[f.URL]; ok {
+			continue
+		}
+		if err := s.filterControl("/control/filtering/remove_url", map[string]interface{}{
+			"url":       f.URL,
+			"whitelist": false,
+		}); err != nil {
+			result.Errors = append(result.Errors, f.URL+": "+err.Error())
+			continue
+		}
+		result.Removed = append(result.Removed, f.URL)
+	}
+
+	// Walk the request order so lists are added in the order given
+	for _, d := range desired {
+		f := wanted[strings.TrimSpace(d.URL)]
+		name := f.Name
+		if name == "" {
+			name = f.URL
+		}
+		enabled := f.Enabled == nil || *f.Enabled
+
+		cur, ok := existing[f.URL]
+		if !ok {
+			if err := s.filterControl("/control/filtering/add_url", map[string]interface{}{
+				"name":      name,
+				"url":       f.URL,
+				"whitelist": false,
+			}); err != nil {
+				result.Errors = append(result.Errors, f.URL+": "+err.Error())
+				continue
+			}
+			result.Added = append(result.Added, f.URL)
+			// New lists start enabled
+			if enabled {
+				continue
+			}
+		} else if cur.Name == name && *cur.Enabled == enabled {
+			continue
+		}
+
+		if err := s.filterControl("/control/filtering/set_url", map[string]interface{}{
+			"url":       f.URL,
+			"whitelist": false,
+			"data": map[string]interface{}{
+				"enabled": enabled,
+				"url":     f.URL,
+				"name":    name,
+			},
+		}); err != nil {
+			result.Errors = append(result.Errors, f.URL+": "+err.Error())
+			continue
+		}
+		if ok {
+			result.Updated = append(result.Updated, f.URL)
+		}
+	}
+
+	return result, nil
+}
+
+// filterControl posts a JSON payload to a filtering endpoint
+func (s *Service) filterControl(path string, payload interface{}) error {
+	body, _ := json.Marshal(payload)
+	resp, err := s.doRequest("POST", path, newBytesReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("AdGuard authentication failed. Check credentials in Settings.")
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("failed: %s", resp.Status)
+	}
+	return nil
+}
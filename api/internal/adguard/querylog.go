@@ -0,0 +1,139 @@
+package adguard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"api/internal/database"
+	"api/internal/router"
+)
+
+// Response status filters accepted by AdGuard's query log
+var validQueryLogStatuses = map[string]bool{
+	"all": true, "filtered": true, "blocked": true, "blocked_safebrowsing": true,
+	"blocked_parental": true, "whitelisted": true, "rewritten": true,
+	"safe_search": true, "processed": true,
+}
+
+const (
+	defaultQueryLogLimit = 100
+	maxQueryLogLimit     = 1000
+)
+
+// handleGetQueryLog proxies AdGuard's query log. With ?client=<ip> (a known VPN
+// client) only that client's queries are returned; ?search then filters domains.
+// Paging uses ?olderThan with the "oldest" value of the previous page.
+func (s *Service) handleGetQueryLog(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := defaultQueryLogLimit
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = min(l, maxQueryLogLimit)
+	}
+	status := q.Get("status")
+	if status != "" && !validQueryLogStatuses[status] {
+		router.JSONError(w, "invalid status filter", http.StatusBadRequest)
+		return
+	}
+	search := strings.TrimSpace(q.Get("search"))
+
+	clientIP := strings.TrimSpace(q.Get("client"))
+	var clientName string
+	if clientIP != "" {
+		if net.ParseIP(clientIP) == nil {
+			router.JSONError(w, "invalid client IP", http.StatusBadRequest)
+			return
+		}
+		db, err := database.GetDB()
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		err = db.QueryRow("SELECT name FROM vpn_clients WHERE ip = ?", clientIP).Scan(&clientName)
+		if err == sql.ErrNoRows {
+			router.JSONError(w, "no VPN client with IP "+clientIP, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	if olderThan := q.Get("olderThan"); olderThan != "" {
+		params.Set("older_than", olderThan)
+	}
+	if status != "" {
+		params.Set("response_status", status)
+	}
+	// AdGuard's search matches domain or client, so the client takes the slot
+	// and the domain search is applied locally
+	if clientIP != "" {
+		params.Set("search", clientIP)
+	} else if search != "" {
+		params.Set("search", search)
+	}
+
+	resp, err := s.doRequestContext(r.Context(), "GET", "/control/querylog?"+params.Encode(), nil)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusFailedDependency)
+		return
+	}
+	defer resp.Body.Close()
+	if proxyError(w, resp) {
+		return
+	}
+
+	var queryLog struct {
+		Data   []map[string]interface{} `json:"data"`
+		Oldest string                   `json:"oldest"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&queryLog); err != nil {
+		router.JSONError(w, "invalid query log response: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	entries := queryLog.Data
+	if clientIP != "" {
+		// Search is a substring match (10.0.0.1 also hits 10.0.0.12), keep exact matches only
+		entries = make([]map[string]interface{}, 0, len(queryLog.Data))
+		for _, e := range queryLog.Data {
+			if c, _ := e["client"].(string); c != clientIP {
+				continue
+			}
+			if search != "" && !strings.Contains(strings.ToLower(queryLogDomain(e)), strings.ToLower(search)) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+	}
+	if entries == nil {
+		entries = []map[string]interface{}{}
+	}
+
+	result := map[string]interface{}{
+		"data":   entries,
+		"oldest": queryLog.Oldest,
+	}
+	if clientIP != "" {
+		result["client"] = clientIP
+		result["clientName"] = clientName
+	}
+	router.JSON(w, result)
+}
+
+// queryLogDomain returns the queried name of a query log entry
+func queryLogDomain(e map[string]interface{}) string {
+	if question, ok := e["question"].(map[string]interface{}); ok {
+		name, _ := question["name"].(string)
+		return name
+	}
+	return ""
+}
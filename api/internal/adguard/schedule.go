@@ -0,0 +1,133 @@
+package adguard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleDays are the day keys used by AdGuard's blocked services schedule
+var scheduleDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+const dayMillis = 24 * 60 * 60 * 1000
+
+// ServiceSchedule is the blocked services schedule in panel format.
+// Follows AdGuard semantics: each day range is a window during which service
+// blocking is paused, outside of it the selected services are blocked.
+type ServiceSchedule struct {
+	TimeZone string               `json:"timeZone"`
+	Days     map[string]DayWindow `json:"days"`
+}
+
+// DayWindow is a time range within a day as "HH:MM" (end may be "24:00")
+type DayWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// toAdGuard validates the schedule and converts it to the AdGuard payload
+// (day ranges in milliseconds since midnight)
+func (sc *ServiceSchedule) toAdGuard() (map[string]interface{}, error) {
+	tz := sc.TimeZone
+	if tz == "" {
+		tz = "UTC"
+	}
+	if tz != "Local" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid time zone: %s", tz)
+		}
+	}
+
+	result := map[string]interface{}{"time_zone": tz}
+	for day, window := range sc.Days {
+		if !isScheduleDay(day) {
+			return nil, fmt.Errorf("invalid day %q: must be one of %s", day, strings.Join(scheduleDays, ", "))
+		}
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return nil, fmt.Errorf("%s start: %v", day, err)
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return nil, fmt.Errorf("%s end: %v", day, err)
+		}
+		if start >= end {
+			return nil, fmt.Errorf("%s: start must be before end", day)
+		}
+		result[day] = map[string]int64{"start": start, "end": end}
+	}
+	return result, nil
+}
+
+// currentServiceSchedule returns the schedule currently set in AdGuard,
+// or an empty UTC schedule if it can't be read
+func (s *Service) currentServiceSchedule() map[string]interface{} {
+	data, err := s.fetchJSON("/control/blocked_services/get")
+	if err == nil {
+		if m, ok := data.(map[string]interface{}); ok {
+			if schedule, ok := m["schedule"].(map[string]interface{}); ok {
+				return schedule
+			}
+		}
+	}
+	return map[string]interface{}{"time_zone": "UTC"}
+}
+
+// scheduleFromAdGuard converts an AdGuard schedule payload to panel format
+func scheduleFromAdGuard(raw map[string]interface{}) ServiceSchedule {
+	sc := ServiceSchedule{TimeZone: "UTC", Days: map[string]DayWindow{}}
+	if tz, ok := raw["time_zone"].(string); ok && tz != "" {
+		sc.TimeZone = tz
+	}
+	for _, day := range scheduleDays {
+		var start, end int64
+		switch v := raw[day].(type) {
+		case map[string]interface{}:
+			s, _ := v["start"].(float64)
+			e, _ := v["end"].(float64)
+			start, end = int64(s), int64(e)
+		case map[string]int64:
+			start, end = v["start"], v["end"]
+		default:
+			continue
+		}
+		sc.Days[day] = DayWindow{Start: formatClock(start), End: formatClock(end)}
+	}
+	return sc
+}
+
+func isScheduleDay(day string) bool {
+	for _, d := range scheduleDays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses "HH:MM" into milliseconds since midnight ("24:00" allowed)
+func parseClock(s string) (int64, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	return int64(h*60+m) * 60 * 1000, nil
+}
+
+// formatClock formats milliseconds since midnight as "HH:MM"
+func formatClock(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	if ms > dayMillis {
+		ms = dayMillis
+	}
+	minutes := ms / 60000
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
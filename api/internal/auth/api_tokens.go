@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"api/internal/helper"
+	"api/internal/router"
+)
+
+// maxAPITokenDays caps a token's lifetime; 0 means it never expires
+const maxAPITokenDays = 3650
+
+// APIToken is a read-only token for monitoring integrations. The token itself
+// is only returned once, when created; the database keeps its SHA-256.
+type APIToken struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Hint      string     `json:"hint"` // last characters, to tell tokens apart
+	Scope     string     `json:"scope"`
+	CreatedAt time.Time  `json:"createdAt"`
+	LastUsed  *time.Time `json:"lastUsed,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// hashAPIToken returns the stored form of a token. Tokens carry 256 random
+// bits, so a plain SHA-256 can't be brute-forced and keeps lookups indexed.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken mints a read-only token and returns it with its record
+func (s *Service) CreateAPIToken(name string, days int, userID int64) (string, *APIToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("crypto/rand failed: %w", err)
+	}
+	token := router.APITokenPrefix + hex.EncodeToString(b)
+
+	t := &APIToken{Name: name, Hint: token[len(token)-4:], Scope: "read", CreatedAt: time.Now().UTC()}
+	var expiresAt interface{}
+	if days > 0 {
+		exp := t.CreatedAt.Add(time.Duration(days) * 24 * time.Hour)
+		t.ExpiresAt = &exp
+		expiresAt = exp.Format("2006-01-02 15:04:05")
+	}
+
+	result, err := s.db.Exec(`INSERT INTO api_tokens (name, token_hash, token_hint, scope, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, 'read', ?, ?, ?)`,
+		name, hashAPIToken(token), t.Hint, userID, t.CreatedAt.Format("2006-01-02 15:04:05"), expiresAt)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store API token: %v", err)
+	}
+	t.ID, _ = result.LastInsertId()
+	return token, t, nil
+}
+
+// ValidateAPIToken checks a read-only token and records its use
+func (s *Service) ValidateAPIToken(token string) bool {
+	hash := hashAPIToken(token)
+	var id int64
+	err := s.db.QueryRow(`SELECT id FROM api_tokens
+		WHERE token_hash = ? AND (expires_at IS NULL OR expires_at > datetime('now'))`, hash).Scan(&id)
+	if err != nil {
+		return false
+	}
+	go s.db.Exec(`UPDATE api_tokens SET last_used = datetime('now')
+		WHERE id = ? AND (last_used IS NULL OR last_used < datetime('now', '-1 minute'))`, id)
+	return true
+}
+
+// ListAPITokens returns all tokens, newest first
+func (s *Service) ListAPITokens() ([]APIToken, error) {
+	rows, err := s.db.Query(`SELECT id, name, COALESCE(token_hint, ''), scope, created_at, last_used, expires_at
+		FROM api_tokens ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []APIToken{}
+	for rows.Next() {
+		var t APIToken
+		var lastUsed, expiresAt sql.NullTime
+		if rows.Scan(&t.ID, &t.Name, &t.Hint, &t.Scope, &t.CreatedAt, &lastUsed, &expiresAt) != nil {
+			continue
+		}
+		if lastUsed.Valid {
+			t.LastUsed = &lastUsed.Time
+		}
+		if expiresAt.Valid {
+			t.ExpiresAt = &expiresAt.Time
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// sessionUser returns the user of the request's session; API tokens don't
+// have one, so they can't manage tokens even if an endpoint were opened up
+func (s *Service) sessionUser(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	token := helper.ExtractBearerToken(r)
+	if token == "" {
+		router.JSONError(w, "No token provided", http.StatusUnauthorized)
+		return nil, false
+	}
+	user, err := s.ValidateSession(token)
+	if err != nil {
+		router.JSONError(w, "Invalid or expired session", http.StatusUnauthorized)
+		return nil, false
+	}
+	return user, true
+}
+
+func (s *Service) handleListAPITokens(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.sessionUser(w, r); !ok {
+		return
+	}
+	tokens, err := s.ListAPITokens()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{"tokens": tokens})
+}
+
+// handleCreateAPIToken mints a read-only token; the response is the only
+// time the token is shown
+func (s *Service) handleCreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.sessionUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+		Days int    `json:"days"` // 0 = no expiry
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		router.JSONError(w, "name is required (max 100 characters)", http.StatusBadRequest)
+		return
+	}
+	if req.Days < 0 || req.Days > maxAPITokenDays {
+		router.JSONError(w, fmt.Sprintf("days must be between 0 and %d", maxAPITokenDays), http.StatusBadRequest)
+		return
+	}
+
+	token, t, err := s.CreateAPIToken(req.Name, req.Days, user.ID)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("API token %q created by %s", t.Name, user.Username)
+	router.JSONWithStatus(w, map[string]interface{}{
+		"token":    token,
+		"apiToken": t,
+	}, http.StatusCreated)
+}
+
+func (s *Service) handleRevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.sessionUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := router.ParseIDOrError(w, router.ExtractPathParam(r, "/api/auth/tokens/"))
+	if !ok {
+		return
+	}
+	result, err := s.db.Exec(`DELETE FROM api_tokens WHERE id = ?`, id)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		router.JSONError(w, "API token not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("API token %d revoked by %s", id, user.Username)
+	router.JSON(w, map[string]string{"message": "API token revoked"})
+}
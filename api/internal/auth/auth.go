@@ -115,6 +115,9 @@ func (s *Service) Handlers() router.ServiceHandlers {
 		"Setup2FA":            s.handleSetup2FA,
 		"Enable2FA":           s.handleEnable2FA,
 		"Disable2FA":          s.handleDisable2FA,
+		"ListAPITokens":       s.handleListAPITokens,
+		"CreateAPIToken":      s.handleCreateAPIToken,
+		"RevokeAPIToken":      s.handleRevokeAPIToken,
 	}
 }
 
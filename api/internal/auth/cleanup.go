@@ -1,28 +1,24 @@
 package auth
 
 import (
+	"context"
 	"log"
 	"time"
+
+	"api/internal/scheduler"
 )
 
 const sessionCleanupInterval = 1 * time.Hour
 
 // Start begins background tasks like session cleanup
 func (s *Service) Start() {
-	go s.runSessionCleanup()
-}
-
-// runSessionCleanup periodically removes expired sessions
-func (s *Service) runSessionCleanup() {
-	ticker := time.NewTicker(sessionCleanupInterval)
-	defer ticker.Stop()
-
 	// Run once at startup
 	s.cleanupExpiredSessions()
 
-	for range ticker.C {
+	scheduler.Every("session-cleanup", "Remove expired login sessions", sessionCleanupInterval, func(ctx context.Context) error {
 		s.cleanupExpiredSessions()
-	}
+		return nil
+	})
 }
 
 // cleanupExpiredSessions removes sessions past their expiry time
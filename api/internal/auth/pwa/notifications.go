@@ -2,6 +2,7 @@ package pwa
 
 import (
 	"encoding/json"
+	"fmt"
 	"sync"
 )
 
@@ -138,6 +139,25 @@ func (s *Service) NotifyFirewallAlert(ip, reason string) {
 	})
 }
 
+// NotifyCountryWatchlist sends notification when the watchlist flags or blocks a country
+func (s *Service) NotifyCountryWatchlist(code, name string, attempts int, blocked bool) {
+	body := fmt.Sprintf("%s (%s): %d attempts, confirm or dismiss in the panel", name, code, attempts)
+	if blocked {
+		body = fmt.Sprintf("%s (%s) blocked automatically after %d attempts", name, code, attempts)
+	}
+	s.SendNotification(NotifyFirewallAlert, &Notification{
+		Title: "Country Watchlist",
+		Body:  body,
+		Icon:  DefaultNotificationIcon,
+		Tag:   "watchlist-" + code,
+		Data: map[string]string{
+			"type":    "country_watchlist",
+			"country": code,
+			"url":     "/firewall",
+		},
+	})
+}
+
 // NotifyNewLogin sends notification when user logs in from new device
 // Sends to all OTHER devices (excludes the device that just logged in)
 func (s *Service) NotifyNewLogin(userID int64, ip, device, currentUserAgent string) {
@@ -10,6 +10,7 @@ import (
 	"api/internal/auth"
 	"api/internal/database"
 	"api/internal/firewall"
+	"api/internal/geolocation"
 	"api/internal/helper"
 	"api/internal/settings"
 	"api/internal/ws"
@@ -83,6 +84,9 @@ func Init() (*Service, error) {
 		firewall.SetBlockNotifyCallback(func(ip, reason string) {
 			svc.NotifyFirewallAlert(ip, reason)
 		})
+
+		// Register country watchlist callback for push notifications
+		geolocation.SetWatchlistNotifyCallback(svc.NotifyCountryWatchlist)
 	})
 
 	return instance, initErr
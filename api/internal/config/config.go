@@ -12,6 +12,7 @@ type EndpointConfig struct {
 	Methods     []string `json:"methods"`
 	Handler     string   `json:"handler"`
 	Description string   `json:"description"`
+	ReadToken   bool     `json:"readToken,omitempty"` // GET also accepts read-only API tokens
 }
 
 // ServiceConfig represents a service configuration
@@ -50,10 +51,12 @@ type MiddlewareConfig struct {
 
 // FirewallAppConfig holds firewall-specific configuration
 type FirewallAppConfig struct {
-	MaxAttempts          int `json:"maxAttempts"`
-	JailCheckIntervalSec int `json:"jailCheckIntervalSec"`
-	CleanupIntervalMin   int `json:"cleanupIntervalMin"`
-	DNSLookupTimeoutSec  int `json:"dnsLookupTimeoutSec"`
+	MaxAttempts           int `json:"maxAttempts"`
+	JailCheckIntervalSec  int `json:"jailCheckIntervalSec"`
+	CleanupIntervalMin    int `json:"cleanupIntervalMin"`
+	DNSLookupTimeoutSec   int `json:"dnsLookupTimeoutSec"`
+	DriftCheckIntervalMin int `json:"driftCheckIntervalMin"`
+	MaxJailMonitors       int `json:"maxJailMonitors"`
 }
 
 // SessionAppConfig holds session-specific configuration
@@ -147,10 +150,12 @@ func GetApp() *AppConfig {
 func GetFirewallConfig() FirewallAppConfig {
 	if config == nil {
 		return FirewallAppConfig{
-			MaxAttempts:          10000,
-			JailCheckIntervalSec: 10,
-			CleanupIntervalMin:   5,
-			DNSLookupTimeoutSec:  2,
+			MaxAttempts:           10000,
+			JailCheckIntervalSec:  10,
+			CleanupIntervalMin:    5,
+			DNSLookupTimeoutSec:   2,
+			DriftCheckIntervalMin: 5,
+			MaxJailMonitors:       50,
 		}
 	}
 	cfg := config.App.Firewall
@@ -167,6 +172,12 @@ func GetFirewallConfig() FirewallAppConfig {
 	if cfg.DNSLookupTimeoutSec == 0 {
 		cfg.DNSLookupTimeoutSec = 2
 	}
+	if cfg.DriftCheckIntervalMin == 0 {
+		cfg.DriftCheckIntervalMin = 5
+	}
+	if cfg.MaxJailMonitors == 0 {
+		cfg.MaxJailMonitors = 50
+	}
 	return cfg
 }
 
@@ -126,6 +126,43 @@ func createSchema(db *sql.DB) error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Countries flagged by the geo watchlist (pending, blocked or dismissed)
+	CREATE TABLE IF NOT EXISTS geo_watchlist (
+		country_code TEXT PRIMARY KEY,
+		attempts INTEGER DEFAULT 0,
+		unique_ips INTEGER DEFAULT 0,
+		status TEXT DEFAULT 'pending' CHECK(status IN ('pending', 'blocked', 'dismissed')),
+		detected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Ranges inside a blocked country that stay reachable (IPv4 CIDRs)
+	CREATE TABLE IF NOT EXISTS country_exceptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		country_code TEXT NOT NULL,
+		cidr TEXT NOT NULL,
+		description TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(country_code, cidr)
+	);
+
+	-- IPs/CIDRs that jails, escalation and blocklist imports never block
+	CREATE TABLE IF NOT EXISTS firewall_allowlist (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cidr TEXT NOT NULL UNIQUE,
+		description TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Dropped packets per destination port and day, tallied from the portscan jail's log
+	CREATE TABLE IF NOT EXISTS port_probe_stats (
+		port INTEGER NOT NULL,
+		day DATE NOT NULL,
+		hits INTEGER DEFAULT 0,
+		last_seen DATETIME,
+		PRIMARY KEY (port, day)
+	);
+
 	-- Unified firewall entries table (IPs, ranges, countries, ports)
 	CREATE TABLE IF NOT EXISTS firewall_entries (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -190,6 +227,20 @@ func createSchema(db *sql.DB) error {
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 	);
 
+	-- Read-only API tokens for monitoring integrations (only the SHA-256 is stored)
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		token_hint TEXT DEFAULT '',
+		scope TEXT DEFAULT 'read' CHECK(scope IN ('read')),
+		created_by INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_used DATETIME,
+		expires_at DATETIME,
+		FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+	);
+
 	-- Domain routes for Traefik reverse proxy
 	CREATE TABLE IF NOT EXISTS domain_routes (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -448,6 +499,63 @@ func runMigrations(db *sql.DB) {
 		}
 	}
 
+	// Add log_traffic column to vpn_clients if missing (per-client traffic logging opt-out)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('vpn_clients') WHERE name = 'log_traffic'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE vpn_clients ADD COLUMN log_traffic INTEGER DEFAULT 1`); err == nil {
+			log.Printf("Migration: added log_traffic column to vpn_clients")
+		}
+	}
+
+	// Add tags column to vpn_clients if missing (JSON array of client tags)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('vpn_clients') WHERE name = 'tags'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE vpn_clients ADD COLUMN tags TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added tags column to vpn_clients")
+		}
+	}
+
+	// Add dns_server column to vpn_clients if missing (per-client DNS override)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('vpn_clients') WHERE name = 'dns_server'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE vpn_clients ADD COLUMN dns_server TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added dns_server column to vpn_clients")
+		}
+	}
+
+	// Add last_seen_at column to vpn_clients if missing (survives WireGuard handshake resets)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('vpn_clients') WHERE name = 'last_seen_at'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE vpn_clients ADD COLUMN last_seen_at DATETIME`); err == nil {
+			log.Printf("Migration: added last_seen_at column to vpn_clients")
+		}
+	}
+
+	// Add is_default column to jails if missing (built-in jails can be disabled, not deleted)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('jails') WHERE name = 'is_default'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE jails ADD COLUMN is_default INTEGER DEFAULT 0`); err == nil {
+			log.Printf("Migration: added is_default column to jails")
+		}
+	}
+
+	// Add jail effectiveness counters if missing (bans vs. manual unblocks of those bans)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('jails') WHERE name = 'manual_unblocks'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE jails ADD COLUMN manual_unblocks INTEGER DEFAULT 0`); err == nil {
+			log.Printf("Migration: added manual_unblocks column to jails")
+		}
+	}
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('jails') WHERE name = 'ban_count'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE jails ADD COLUMN ban_count INTEGER DEFAULT 0`); err == nil {
+			// Seed from the bans still on record so ratios start from something sensible
+			db.Exec(`UPDATE jails SET ban_count = (SELECT COUNT(*) FROM firewall_entries f
+				WHERE f.name = jails.name AND f.entry_type IN ('ip', 'range') AND f.action = 'block')`)
+			log.Printf("Migration: added ban_count column to jails")
+		}
+	}
+
 	// Add sentinel_config column to domain_routes if missing (JSON config for per-domain sentinel middleware)
 	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('domain_routes') WHERE name = 'sentinel_config'`).Scan(&count)
 	if err == nil && count == 0 {
@@ -464,6 +572,24 @@ func runMigrations(db *sql.DB) {
 		}
 	}
 
+	// Add backends column to domain_routes if missing (JSON list of additional load-balanced targets)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('domain_routes') WHERE name = 'backends'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE domain_routes ADD COLUMN backends TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added backends column to domain_routes")
+		}
+	}
+
+	// Add per-route TLS columns to domain_routes if missing (minimum version, SNI override)
+	for _, col := range []string{"tls_min_version", "tls_server_name"} {
+		err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('domain_routes') WHERE name = ?`, col).Scan(&count)
+		if err == nil && count == 0 {
+			if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE domain_routes ADD COLUMN %s TEXT DEFAULT ''`, col)); err == nil {
+				log.Printf("Migration: added %s column to domain_routes", col)
+			}
+		}
+	}
+
 	// Add skip_cert_verify column to domain_routes if missing (skip TLS verification for HTTPS backends)
 	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('domain_routes') WHERE name = 'skip_cert_verify'`).Scan(&count)
 	if err == nil && count == 0 {
@@ -471,6 +597,90 @@ func runMigrations(db *sql.DB) {
 			log.Printf("Migration: added skip_cert_verify column to domain_routes")
 		}
 	}
+
+	// Add access_log column to domain_routes if missing (JSON per-route access logging override)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('domain_routes') WHERE name = 'access_log'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE domain_routes ADD COLUMN access_log TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added access_log column to domain_routes")
+		}
+	}
+
+	// Add logs_extra column to logs if missing (JSON of per-route extra access log fields)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('logs') WHERE name = 'logs_extra'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE logs ADD COLUMN logs_extra TEXT`); err == nil {
+			log.Printf("Migration: added logs_extra column to logs")
+		}
+	}
+
+	// Add category column to firewall_entries if missing (structured reason for manual blocks)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('firewall_entries') WHERE name = 'category'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE firewall_entries ADD COLUMN category TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added category column to firewall_entries")
+		}
+	}
+
+	// Add action_command column to jails if missing (command run on each ban)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('jails') WHERE name = 'action_command'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE jails ADD COLUMN action_command TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added action_command column to jails")
+		}
+	}
+
+	// Add ACL elevation columns to vpn_clients if missing (temporary allow_all with the policy to restore)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('vpn_clients') WHERE name = 'acl_elevation'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE vpn_clients ADD COLUMN acl_elevation TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added acl_elevation column to vpn_clients")
+		}
+	}
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('vpn_clients') WHERE name = 'acl_elevated_until'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE vpn_clients ADD COLUMN acl_elevated_until DATETIME`); err == nil {
+			log.Printf("Migration: added acl_elevated_until column to vpn_clients")
+		}
+	}
+
+	// Normalize country entries: firewall_entries is the only record of blocked
+	// countries, keyed by upper-case code. Zone loading ignored the action, so
+	// "allow" country rows were blocked anyway; store them as what they did.
+	if res, err := db.Exec(`UPDATE OR IGNORE firewall_entries SET value = UPPER(TRIM(value))
+		WHERE entry_type = 'country' AND value != UPPER(TRIM(value))`); err == nil {
+		if n, _ := res.RowsAffected(); n > 0 {
+			log.Printf("Migration: upper-cased %d country entries", n)
+		}
+	}
+	if res, err := db.Exec(`DELETE FROM firewall_entries
+		WHERE entry_type = 'country' AND value != UPPER(TRIM(value))`); err == nil {
+		if n, _ := res.RowsAffected(); n > 0 {
+			log.Printf("Migration: removed %d duplicate lower-case country entries", n)
+		}
+	}
+	if res, err := db.Exec(`UPDATE firewall_entries SET action = 'block'
+		WHERE entry_type = 'country' AND action != 'block'`); err == nil {
+		if n, _ := res.RowsAffected(); n > 0 {
+			log.Printf("Migration: set action block on %d country entries", n)
+		}
+	}
+
+	// Add allowed_ips column to vpn_clients if missing (extra networks routed to a WireGuard peer)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('vpn_clients') WHERE name = 'allowed_ips'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE vpn_clients ADD COLUMN allowed_ips TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added allowed_ips column to vpn_clients")
+		}
+	}
+
+	// Add rate_limit column to domain_routes if missing (JSON per-route rate limit)
+	err = db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('domain_routes') WHERE name = 'rate_limit'`).Scan(&count)
+	if err == nil && count == 0 {
+		if _, err := db.Exec(`ALTER TABLE domain_routes ADD COLUMN rate_limit TEXT DEFAULT ''`); err == nil {
+			log.Printf("Migration: added rate_limit column to domain_routes")
+		}
+	}
 }
 
 // Close closes the database connection
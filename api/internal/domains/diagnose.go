@@ -0,0 +1,261 @@
+package domains
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"api/internal/adguard"
+	"api/internal/database"
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/traefik"
+)
+
+// diagnoseDNSTimeout bounds the lookup against the panel's resolver
+const diagnoseDNSTimeout = 5 * time.Second
+
+// RouteDiagnosis combines the DNS, Traefik and certificate state of a route.
+// Problems lists what looks wrong, in the order a request would hit it.
+type RouteDiagnosis struct {
+	RouteID     int                  `json:"routeId"`
+	Domain      string               `json:"domain"`
+	AccessMode  string               `json:"accessMode"`
+	Enabled     bool                 `json:"enabled"`
+	FrontendSSL bool                 `json:"frontendSsl"`
+	Healthy     bool                 `json:"healthy"`
+	Problems    []string             `json:"problems"`
+	DNS         DNSDiagnosis         `json:"dns"`
+	Traefik     TraefikDiagnosis     `json:"traefik"`
+	Certificate CertificateDiagnosis `json:"certificate"`
+}
+
+// DNSDiagnosis is the AdGuard rewrite and what the panel's resolver answers
+type DNSDiagnosis struct {
+	Expected     string           `json:"expected,omitempty"` // VPN IP for vpn routes, public server IP otherwise
+	Rewrite      *adguard.Rewrite `json:"rewrite,omitempty"`
+	RewriteError string           `json:"rewriteError,omitempty"`
+	Resolver     string           `json:"resolver"`
+	QueryName    string           `json:"queryName"`
+	Resolved     []string         `json:"resolved"`
+	ResolveError string           `json:"resolveError,omitempty"`
+}
+
+// TraefikDiagnosis reports the route's routers in the domains config on disk
+type TraefikDiagnosis struct {
+	ConfigFile  string `json:"configFile"`
+	Router      string `json:"router"`
+	HTTPRouter  bool   `json:"httpRouter"`
+	HTTPSRouter bool   `json:"httpsRouter"`
+	Error       string `json:"error,omitempty"`
+}
+
+// CertificateDiagnosis is the acme.json certificate covering the domain, if any
+type CertificateDiagnosis struct {
+	Required    bool                     `json:"required"` // frontend SSL is on
+	Certificate *traefik.CertificateInfo `json:"certificate,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+}
+
+// handleDiagnoseRoute checks a route's AdGuard rewrite, DNS answer, Traefik
+// routers and certificate in one go, to tell which layer breaks a route
+func (s *Service) handleDiagnoseRoute(w http.ResponseWriter, r *http.Request) {
+	idStr := router.ExtractPathParam(r, "/api/domains/")
+	id, ok := router.ParseIDOrError(w, idStr)
+	if !ok {
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var d RouteDiagnosis
+	var accessMode sql.NullString
+	var frontendSSL sql.NullBool
+	err = db.QueryRow(`SELECT id, domain, enabled, access_mode, frontend_ssl FROM domain_routes WHERE id = ?`, id).
+		Scan(&d.RouteID, &d.Domain, &d.Enabled, &accessMode, &frontendSSL)
+	if err == sql.ErrNoRows {
+		router.JSONError(w, "route not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	d.AccessMode = database.StringFromNullNotEmpty(accessMode, "vpn")
+	d.FrontendSSL = database.BoolFromNull(frontendSSL, false)
+	d.Problems = []string{}
+	if !d.Enabled {
+		d.Problems = append(d.Problems, "route is disabled, so Traefik has no router for it")
+	}
+
+	s.diagnoseDNS(&d)
+	s.diagnoseTraefik(&d)
+	diagnoseCertificate(&d)
+
+	d.Healthy = len(d.Problems) == 0
+	router.JSON(w, d)
+}
+
+// diagnoseDNS looks up the AdGuard rewrite for the domain and resolves it
+// through AdGuard. VPN routes must rewrite to the VPN IP; public routes must
+// not be rewritten and should resolve to the server's public IP.
+func (s *Service) diagnoseDNS(d *RouteDiagnosis) {
+	dns := &d.DNS
+	dns.QueryName = d.Domain
+	if helper.IsWildcardDomain(d.Domain) {
+		dns.QueryName = helper.WildcardBaseDomain(d.Domain)
+	}
+	if d.AccessMode == "vpn" {
+		dns.Expected = s.vpnIP
+	} else {
+		dns.Expected = helper.ServerIP()
+	}
+
+	if rewrites, err := adguard.GetRewrites(); err != nil {
+		dns.RewriteError = err.Error()
+	} else {
+		dns.Rewrite = findRewrite(rewrites, d.Domain)
+	}
+	switch {
+	case dns.RewriteError != "":
+		d.Problems = append(d.Problems, "could not read AdGuard rewrites: "+dns.RewriteError)
+	case d.AccessMode == "vpn" && dns.Rewrite == nil:
+		d.Problems = append(d.Problems, "no AdGuard rewrite for the domain (re-apply routes)")
+	case d.AccessMode == "vpn" && dns.Rewrite.Answer != s.vpnIP:
+		d.Problems = append(d.Problems, "AdGuard rewrite answers "+dns.Rewrite.Answer+" instead of the VPN IP "+s.vpnIP)
+	case d.AccessMode != "vpn" && dns.Rewrite != nil:
+		d.Problems = append(d.Problems, "public route has an AdGuard rewrite to "+dns.Rewrite.Answer+", VPN clients won't reach the public IP")
+	}
+
+	dns.Resolver = net.JoinHostPort("127.0.0.1", helper.GetEnvOptional("DNS_PORT", "53"))
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, dns.Resolver)
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), diagnoseDNSTimeout)
+	defer cancel()
+	dns.Resolved = []string{}
+	ips, err := resolver.LookupIP(ctx, "ip4", dns.QueryName)
+	if err != nil {
+		dns.ResolveError = err.Error()
+		d.Problems = append(d.Problems, "DNS lookup of "+dns.QueryName+" failed: "+err.Error())
+		return
+	}
+	for _, ip := range ips {
+		dns.Resolved = append(dns.Resolved, ip.String())
+	}
+	if dns.Expected != "" && !slices.Contains(dns.Resolved, dns.Expected) {
+		d.Problems = append(d.Problems, dns.QueryName+" resolves to "+strings.Join(dns.Resolved, ", ")+", expected "+dns.Expected)
+	}
+}
+
+// findRewrite returns the rewrite for domain: an exact entry, or a wildcard
+// entry for its parent
+func findRewrite(rewrites []adguard.Rewrite, domain string) *adguard.Rewrite {
+	domain = strings.ToLower(domain)
+	parent := ""
+	if i := strings.Index(domain, "."); i != -1 && !helper.IsWildcardDomain(domain) {
+		parent = "*" + domain[i:]
+	}
+	var wildcard *adguard.Rewrite
+	for i := range rewrites {
+		switch strings.ToLower(rewrites[i].Domain) {
+		case domain:
+			return &rewrites[i]
+		case parent:
+			wildcard = &rewrites[i]
+		}
+	}
+	return wildcard
+}
+
+// diagnoseTraefik checks the routers generated for the route in domains.yml
+func (s *Service) diagnoseTraefik(d *RouteDiagnosis) {
+	t := &d.Traefik
+	t.ConfigFile = filepath.Join(s.traefikConfigDir, traefik.DomainsConfigFile)
+	t.Router = "domain-" + helper.SanitizeDomainName(d.Domain)
+
+	data, err := os.ReadFile(t.ConfigFile)
+	if err != nil {
+		t.Error = err.Error()
+		d.Problems = append(d.Problems, "could not read the Traefik domains config: "+err.Error())
+		return
+	}
+	var cfg struct {
+		HTTP struct {
+			Routers map[string]interface{} `yaml:"routers"`
+		} `yaml:"http"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Error = "invalid YAML: " + err.Error()
+		d.Problems = append(d.Problems, "Traefik domains config is invalid YAML, Traefik keeps its previous config")
+		return
+	}
+	_, t.HTTPRouter = cfg.HTTP.Routers[t.Router]
+	_, t.HTTPSRouter = cfg.HTTP.Routers[t.Router+"-secure"]
+
+	if d.Enabled && !t.HTTPRouter {
+		d.Problems = append(d.Problems, "no Traefik router "+t.Router+" in "+traefik.DomainsConfigFile+" (re-apply routes)")
+	}
+	if d.Enabled && d.FrontendSSL && !t.HTTPSRouter {
+		d.Problems = append(d.Problems, "no Traefik HTTPS router "+t.Router+"-secure in "+traefik.DomainsConfigFile)
+	}
+}
+
+// diagnoseCertificate finds the certificate covering the domain in acme.json;
+// a wildcard certificate for the parent counts
+func diagnoseCertificate(d *RouteDiagnosis) {
+	c := &d.Certificate
+	c.Required = d.FrontendSSL
+
+	certs, err := traefik.GetCertificates()
+	if err != nil {
+		c.Error = err.Error()
+		if c.Required {
+			d.Problems = append(d.Problems, "could not read certificates: "+err.Error())
+		}
+		return
+	}
+
+	domain := strings.ToLower(d.Domain)
+	names := map[string]bool{domain: true}
+	if helper.IsWildcardDomain(domain) {
+		names[helper.WildcardBaseDomain(domain)] = true
+	} else if i := strings.Index(domain, "."); i != -1 {
+		names["*"+domain[i:]] = true
+	}
+	for i := range certs {
+		if !names[strings.ToLower(certs[i].Domain)] {
+			continue
+		}
+		// Prefer the certificate that lasts longest
+		if c.Certificate == nil || certs[i].NotAfter.After(c.Certificate.NotAfter) {
+			c.Certificate = &certs[i]
+		}
+	}
+
+	if !c.Required {
+		return
+	}
+	switch {
+	case c.Certificate == nil:
+		d.Problems = append(d.Problems, "no certificate issued yet for "+d.Domain+" (check the ACME resolver and that the domain reaches Traefik)")
+	case c.Certificate.Status == "expired":
+		d.Problems = append(d.Problems, "certificate for "+c.Certificate.Domain+" has expired")
+	}
+}
@@ -0,0 +1,157 @@
+package domains
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"api/internal/adguard"
+	"api/internal/database"
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/scheduler"
+)
+
+// dnsReconcileJob is the scheduler job name for the orphaned rewrite cleanup
+const dnsReconcileJob = "domains-dns-reconcile"
+
+// dnsReconcileStartupDelay gives AdGuard time to come up before the first run
+const dnsReconcileStartupDelay = time.Minute
+
+// DNSReconcileResult reports the rewrites answering with the VPN IP that no
+// route (or the panel itself) accounts for
+type DNSReconcileResult struct {
+	DryRun  bool              `json:"dryRun"`
+	VPNIP   string            `json:"vpnIp"`
+	Checked int               `json:"checked"` // rewrites answering with the VPN IP
+	Orphans []adguard.Rewrite `json:"orphans"`
+	Removed int               `json:"removed"`
+	Errors  []string          `json:"errors"`
+}
+
+// scheduleDNSReconcile runs the reconciliation shortly after startup and then
+// every DNS_RECONCILE_INTERVAL minutes. It is opt-in (default 0): the cleanup
+// removes every rewrite to the VPN IP without a route, including ones the
+// operator added in AdGuard by hand.
+func (s *Service) scheduleDNSReconcile() {
+	interval := helper.GetEnvIntOptional("DNS_RECONCILE_INTERVAL", 0)
+	if interval <= 0 {
+		return
+	}
+	run := func() error {
+		result, err := reconcileDNS(s.vpnIP, false)
+		if err != nil {
+			return err
+		}
+		if result.Removed > 0 {
+			log.Printf("DNS reconcile: removed %d orphaned AdGuard rewrite(s)", result.Removed)
+		}
+		return nil
+	}
+	time.AfterFunc(dnsReconcileStartupDelay, func() {
+		if err := run(); err != nil {
+			log.Printf("DNS reconcile: startup run failed: %v", err)
+		}
+	})
+	scheduler.Every(dnsReconcileJob, "Remove AdGuard rewrites for deleted domain routes",
+		time.Duration(interval)*time.Minute, func(ctx context.Context) error {
+			return run()
+		})
+}
+
+// managedRewriteDomains returns every domain allowed to rewrite to the VPN IP:
+// all routes (regardless of state, so toggling doesn't race this), the apex of
+// wildcard routes, and the panel's own domain
+func managedRewriteDomains() (map[string]bool, error) {
+	db, err := database.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT domain FROM domain_routes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	managed := make(map[string]bool)
+	for rows.Next() {
+		var domain string
+		if rows.Scan(&domain) != nil {
+			continue
+		}
+		managed[strings.ToLower(domain)] = true
+		if helper.IsWildcardDomain(domain) {
+			if base := helper.WildcardBaseDomain(domain); base != "" {
+				managed[strings.ToLower(base)] = true
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, env := range []string{"SSL_DOMAIN", "ADMIN_DOMAIN"} {
+		if domain := strings.TrimSpace(helper.GetEnvOptional(env, "")); domain != "" {
+			managed[strings.ToLower(domain)] = true
+		}
+	}
+	return managed, nil
+}
+
+// reconcileDNS finds AdGuard rewrites answering with vpnIP that match no
+// managed domain and, unless dryRun, deletes them. VPN client hostnames under
+// HEADSCALE_BASE_DOMAIN are never touched.
+func reconcileDNS(vpnIP string, dryRun bool) (*DNSReconcileResult, error) {
+	managed, err := managedRewriteDomains()
+	if err != nil {
+		return nil, err
+	}
+	rewrites, err := adguard.GetRewrites()
+	if err != nil {
+		return nil, err
+	}
+
+	clientSuffix := ""
+	if base := helper.GetEnvOptional("HEADSCALE_BASE_DOMAIN", ""); base != "" {
+		clientSuffix = "." + strings.ToLower(base)
+	}
+
+	result := &DNSReconcileResult{DryRun: dryRun, VPNIP: vpnIP, Orphans: []adguard.Rewrite{}, Errors: []string{}}
+	for _, rw := range rewrites {
+		if rw.Answer != vpnIP {
+			continue
+		}
+		result.Checked++
+		domain := strings.ToLower(rw.Domain)
+		if managed[domain] || (clientSuffix != "" && strings.HasSuffix(domain, clientSuffix)) {
+			continue
+		}
+		result.Orphans = append(result.Orphans, rw)
+		if dryRun {
+			continue
+		}
+		if err := adguard.DeleteRewrite(rw.Domain, rw.Answer); err != nil {
+			result.Errors = append(result.Errors, rw.Domain+": "+err.Error())
+			continue
+		}
+		result.Removed++
+	}
+	return result, nil
+}
+
+// handleReconcileDNS removes orphaned AdGuard rewrites pointing at the VPN IP.
+// ?dryRun=true only reports them.
+func (s *Service) handleReconcileDNS(w http.ResponseWriter, r *http.Request) {
+	dryRun := router.QueryParam(r, "dryRun", "") == "true"
+	result, err := reconcileDNS(s.vpnIP, dryRun)
+	if err != nil {
+		router.JSONError(w, "DNS reconcile failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	if result.Removed > 0 {
+		log.Printf("DNS reconcile: removed %d orphaned AdGuard rewrite(s)", result.Removed)
+	}
+	router.JSON(w, result)
+}
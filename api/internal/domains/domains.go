@@ -7,6 +7,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -28,6 +29,49 @@ func ensureVPNMiddleware(middlewares []string) []string {
 	return append(middlewares, traefik.MiddlewareSentinelVPNFile)
 }
 
+// normalizeMiddlewares validates route middlewares against those defined in the
+// Traefik dynamic config. Bare names resolve to the file provider ("rate-limit"
+// -> "rate-limit@file"); duplicates are dropped. Skipped when the Traefik service
+// is disabled or its config can't be read, so routes stay editable.
+func normalizeMiddlewares(middlewares []string) ([]string, error) {
+	tsvc := traefik.GetService()
+	if tsvc == nil || len(middlewares) == 0 {
+		return middlewares, nil
+	}
+	known, err := tsvc.ListMiddlewares()
+	if err != nil {
+		log.Printf("Warning: skipping middleware validation: %v", err)
+		return middlewares, nil
+	}
+	valid := make(map[string]bool, len(known))
+	for _, name := range known {
+		valid[name] = true
+	}
+
+	result := make([]string, 0, len(middlewares))
+	seen := make(map[string]bool)
+	for _, mw := range middlewares {
+		mw = strings.TrimSpace(mw)
+		if mw == "" {
+			continue
+		}
+		if !strings.Contains(mw, "@") {
+			mw += "@file"
+		}
+		if !valid[mw] {
+			return nil, fmt.Errorf("unknown middleware %q (valid: %s)", mw, strings.Join(known, ", "))
+		}
+		if !seen[mw] {
+			seen[mw] = true
+			result = append(result, mw)
+		}
+	}
+	return result, nil
+}
+
+// maxStaticBodySize caps the static response, which is inlined into the Traefik config
+const maxStaticBodySize = 64 * 1024
+
 // validateSentinelConfig validates sentinel config fields
 func validateSentinelConfig(sc *traefik.SentinelConfig) error {
 	if sc == nil {
@@ -39,14 +83,76 @@ func validateSentinelConfig(sc *traefik.SentinelConfig) error {
 		return fmt.Errorf("invalid IP filter: %w", err)
 	}
 
+	// Validate ASNs ("AS13335" or "13335")
+	asnRegex := regexp.MustCompile(`^(?i)(AS)?[0-9]{1,10}$`)
+	for _, asn := range sc.IPFilter.AllowASN {
+		if !asnRegex.MatchString(strings.TrimSpace(asn)) {
+			return fmt.Errorf("invalid ASN: %s (expected AS<number>)", asn)
+		}
+	}
+
+	// Validate maintenance bypass ranges
+	if sc.Maintenance != nil {
+		if err := helper.ValidateIPList(sc.Maintenance.Bypass); err != nil {
+			return fmt.Errorf("invalid maintenance bypass: %w", err)
+		}
+		if err := traefik.ValidateMaintenancePageFile(sc.Maintenance.PageFile); err != nil {
+			return err
+		}
+	}
+
 	// Validate error mode
 	switch sc.ErrorMode {
-	case "", "403", "404", "503", "silent":
+	case "", "403", "404", "503", "silent", "redirect", "static":
 		// valid
 	default:
 		return fmt.Errorf("invalid errorMode: %s", sc.ErrorMode)
 	}
 
+	// Validate redirect / static response
+	if sc.ErrorMode == "redirect" {
+		u, err := url.Parse(sc.RedirectURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("redirectUrl must be an absolute http(s) URL")
+		}
+	}
+	switch sc.RedirectCode {
+	case 0, 301, 302, 303, 307, 308:
+		// valid
+	default:
+		return fmt.Errorf("invalid redirectCode: %d (expected 301, 302, 303, 307, 308)", sc.RedirectCode)
+	}
+	if len(sc.StaticBody) > maxStaticBodySize {
+		return fmt.Errorf("staticBody must be at most %d bytes", maxStaticBodySize)
+	}
+	switch sc.StaticContentType {
+	case "", "text/html", "text/plain", "application/json":
+		// valid
+	default:
+		return fmt.Errorf("invalid staticContentType: %s (expected text/html, text/plain, application/json)", sc.StaticContentType)
+	}
+
+	// Validate drop mode
+	switch sc.DropMode {
+	case "", "rst", "close", "tarpit":
+		// valid
+	default:
+		return fmt.Errorf("invalid dropMode: %s (expected rst, close, tarpit)", sc.DropMode)
+	}
+	if sc.TarpitSeconds < 0 || sc.TarpitSeconds > 300 {
+		return fmt.Errorf("tarpitSeconds must be between 0 and 300")
+	}
+
+	if sc.ViolationThreshold != nil {
+		if err := helper.ValidateIPList(sc.ViolationThreshold.TrustedProxies); err != nil {
+			return fmt.Errorf("invalid violationThreshold trustedProxies: %w", err)
+		}
+	}
+
+	if sc.Slowlist != nil && (sc.Slowlist.DelayMs < 0 || sc.Slowlist.DelayMs > 30000) {
+		return fmt.Errorf("slowlist delayMs must be between 0 and 30000")
+	}
+
 	// Validate time range format (HH:MM-HH:MM)
 	timeRangeRegex := regexp.MustCompile(`^([01]?[0-9]|2[0-3]):[0-5][0-9]-([01]?[0-9]|2[0-3]):[0-5][0-9]$`)
 	if sc.TimeAccess != nil {
@@ -95,6 +201,9 @@ func validateSentinelConfig(sc *traefik.SentinelConfig) error {
 	if len(sc.IPFilter.SourceRange) > 100 {
 		return fmt.Errorf("too many IP ranges (max 100)")
 	}
+	if len(sc.IPFilter.AllowASN) > 50 {
+		return fmt.Errorf("too many ASNs (max 50)")
+	}
 	if len(sc.Headers) > 20 {
 		return fmt.Errorf("too many header rules (max 20)")
 	}
@@ -114,27 +223,127 @@ func parseRouteFields(middlewaresJSON string, accessMode sql.NullString, fronten
 	return middlewares,
 		database.StringFromNullNotEmpty(accessMode, "vpn"),
 		database.BoolFromNull(frontendSSL, false),
-		parseSentinelConfig(sentinelConfigJSON)
+		traefik.ParseSentinelConfig(sentinelConfigJSON)
+}
+
+// validateTLSSettings normalizes and validates the per-route TLS fields
+func validateTLSSettings(minVersion, serverName *string) error {
+	if minVersion != nil {
+		*minVersion = strings.TrimSpace(*minVersion)
+		if _, ok := traefik.TLSMinVersions[*minVersion]; *minVersion != "" && !ok {
+			return fmt.Errorf("tlsMinVersion must be one of 1.0, 1.1, 1.2, 1.3")
+		}
+	}
+	if serverName != nil {
+		*serverName = strings.TrimSpace(strings.ToLower(*serverName))
+		if *serverName != "" {
+			if err := helper.ValidateDomain(*serverName); err != nil {
+				return fmt.Errorf("tlsServerName: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// maxRouteBackends limits the additional targets of one route
+const maxRouteBackends = 10
+
+// validateBackends trims and validates additional route targets
+func validateBackends(backends []traefik.Backend) error {
+	if len(backends) > maxRouteBackends {
+		return fmt.Errorf("too many backends (max %d)", maxRouteBackends)
+	}
+	for i := range backends {
+		backends[i].TargetIP = strings.TrimSpace(backends[i].TargetIP)
+		if err := helper.ValidateIP(backends[i].TargetIP); err != nil {
+			return fmt.Errorf("backend %d: %v", i+1, err)
+		}
+		if err := helper.ValidatePort(backends[i].TargetPort); err != nil {
+			return fmt.Errorf("backend %d: %v", i+1, err)
+		}
+		if backends[i].Weight < 0 || backends[i].Weight > 100 {
+			return fmt.Errorf("backend %d: weight must be between 0 and 100", i+1)
+		}
+	}
+	return nil
+}
+
+// marshalBackends serializes backends for the DB (empty string when none)
+func marshalBackends(backends []traefik.Backend) string {
+	if len(backends) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(backends)
+	return string(b)
+}
+
+// parseBackends parses the backends JSON column (DRY helper)
+func parseBackends(jsonStr string) []traefik.Backend {
+	if jsonStr == "" {
+		return nil
+	}
+	var backends []traefik.Backend
+	if err := json.Unmarshal([]byte(jsonStr), &backends); err != nil {
+		log.Printf("Warning: failed to parse route backends: %v", err)
+		return nil
+	}
+	return backends
+}
+
+// marshalAccessLog serializes a route's access log override (empty string when none)
+func marshalAccessLog(cfg *traefik.AccessLogConfig) string {
+	if cfg == nil {
+		return ""
+	}
+	b, _ := json.Marshal(cfg)
+	return string(b)
 }
 
-// parseSentinelConfig parses JSON string to SentinelConfig (DRY helper)
-func parseSentinelConfig(jsonStr string) *traefik.SentinelConfig {
+// parseAccessLog parses the access_log JSON column
+func parseAccessLog(jsonStr string) *traefik.AccessLogConfig {
 	if jsonStr == "" {
 		return nil
 	}
-	var sc traefik.SentinelConfig
-	if err := json.Unmarshal([]byte(jsonStr), &sc); err != nil {
-		log.Printf("Warning: failed to parse sentinel config: %v", err)
+	var cfg traefik.AccessLogConfig
+	if err := json.Unmarshal([]byte(jsonStr), &cfg); err != nil {
+		log.Printf("Warning: failed to parse route access log config: %v", err)
 		return nil
 	}
-	return &sc
+	return &cfg
+}
+
+// marshalRateLimit serializes a route's rate limit (empty string when none)
+func marshalRateLimit(cfg *traefik.RateLimitConfig) string {
+	if cfg == nil {
+		return ""
+	}
+	b, _ := json.Marshal(cfg)
+	return string(b)
+}
+
+// parseRateLimit parses the rate_limit JSON column
+func parseRateLimit(jsonStr string) *traefik.RateLimitConfig {
+	if jsonStr == "" {
+		return nil
+	}
+	var cfg traefik.RateLimitConfig
+	if err := json.Unmarshal([]byte(jsonStr), &cfg); err != nil {
+		log.Printf("Warning: failed to parse route rate limit: %v", err)
+		return nil
+	}
+	return &cfg
 }
 
 // Service handles domain routes
 type Service struct {
 	traefikConfigDir string
 	vpnIP            string // VPN IP for DNS rewrites (e.g., 10.8.0.1)
-	publicIP         string // Public IP for reference
+}
+
+// publicIP returns the server's effective public IP (auto-detected on dynamic-IP
+// hosts), which public mode domains must resolve to
+func (s *Service) publicIP() string {
+	return helper.ServerIP()
 }
 
 // DomainRoute represents a domain to port mapping
@@ -153,6 +362,11 @@ type DomainRoute struct {
 	FrontendSSL     bool                   `json:"frontendSsl"`     // use websecure entrypoint
 	CertResolver    string                 `json:"certResolver,omitempty"` // explicit resolver name; empty = auto (wildcard/public/vpn tree)
 	SentinelConfig  *traefik.SentinelConfig `json:"sentinelConfig,omitempty"`
+	Backends        []traefik.Backend      `json:"backends,omitempty"` // additional load-balanced targets
+	TLSMinVersion   string                 `json:"tlsMinVersion,omitempty"` // "1.2", "1.3", ...; empty = Traefik default
+	TLSServerName   string                 `json:"tlsServerName,omitempty"` // SNI/certificate domain override
+	AccessLog       *traefik.AccessLogConfig `json:"accessLog,omitempty"` // nil = logged with the default fields
+	RateLimit       *traefik.RateLimitConfig `json:"rateLimit,omitempty"` // nil = no per-route rate limit
 	CreatedAt       time.Time              `json:"createdAt"`
 	UpdatedAt       time.Time              `json:"updatedAt"`
 	VPNClientName   string                 `json:"vpnClientName,omitempty"`
@@ -164,9 +378,9 @@ func New() *Service {
 	svc := &Service{
 		traefikConfigDir: helper.GetEnvOptional("TRAEFIK_CONFIG", "/traefik/dynamic"),
 		vpnIP:            helper.GetEnvOptional("WG_SERVER_IP", "10.8.0.1"), // VPN IP for VPN-only domains
-		publicIP:         helper.GetEnvOptional("SERVER_IP", "127.0.0.1"),   // Public IP (for reference)
 	}
 	log.Printf("Domains service initialized, Traefik config: %s, VPN IP: %s", svc.traefikConfigDir, svc.vpnIP)
+	svc.scheduleDNSReconcile()
 	return svc
 }
 
@@ -175,26 +389,63 @@ func ApplyRoutes() error {
 	traefikConfigDir := helper.GetEnvOptional("TRAEFIK_CONFIG", "/traefik/dynamic")
 	vpnIP := helper.GetEnvOptional("WG_SERVER_IP", "10.8.0.1") // VPN IP for DNS rewrites
 
-	db, err := database.GetDB()
+	routes, err := enabledRouteConfigs()
 	if err != nil {
 		return err
 	}
 
+	// Add VPN domains to AdGuard sync list.
+	// For a wildcard route (`*.example.com`), also rewrite the apex (`example.com`)
+	// — AdGuard wildcards match one label to the left, so the apex needs its own entry.
+	vpnDomains := []adguard.DomainRoute{} // Only VPN mode domains for AdGuard
+	for _, rc := range routes {
+		if rc.AccessMode == "vpn" {
+			vpnDomains = append(vpnDomains, adguard.DomainRoute{Domain: rc.Domain})
+			if helper.IsWildcardDomain(rc.Domain) {
+				baseDomain := helper.WildcardBaseDomain(rc.Domain)
+				if baseDomain != "" {
+					vpnDomains = append(vpnDomains, adguard.DomainRoute{Domain: baseDomain})
+				}
+			}
+		}
+	}
+
+	// Generate Traefik config
+	if err := traefik.GenerateDomainRoutes(traefikConfigDir, routes); err != nil {
+		return fmt.Errorf("failed to generate Traefik config: %v", err)
+	}
+
+	// Sync AdGuard DNS only for VPN mode domains (rewrite to VPN IP, not public IP!)
+	dnsErrors := adguard.SyncDomainRewrites(vpnDomains, vpnIP)
+	if len(dnsErrors) > 0 {
+		log.Printf("DNS sync warnings: %v", dnsErrors)
+	}
+
+	log.Printf("Applied %d domain routes (%d VPN mode with DNS)", len(routes), len(vpnDomains))
+	return nil
+}
+
+// enabledRouteConfigs loads the enabled routes as Traefik generates them
+func enabledRouteConfigs() ([]traefik.DomainRouteConfig, error) {
+	db, err := database.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
 	// Get all enabled routes with new columns
 	rows, err := db.Query(`
-		SELECT domain, target_ip, target_port, https_backend, skip_cert_verify, middlewares, access_mode, frontend_ssl, COALESCE(sentinel_config, ''), COALESCE(cert_resolver, '')
+		SELECT domain, target_ip, target_port, https_backend, skip_cert_verify, middlewares, access_mode, frontend_ssl, COALESCE(sentinel_config, ''), COALESCE(cert_resolver, ''), COALESCE(backends, ''),
+			COALESCE(tls_min_version, ''), COALESCE(tls_server_name, ''), COALESCE(access_log, ''), COALESCE(rate_limit, '')
 		FROM domain_routes
 		WHERE enabled = 1
 		ORDER BY domain
 	`)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
 	routes := []traefik.DomainRouteConfig{}
-	vpnDomains := []adguard.DomainRoute{} // Only VPN mode domains for AdGuard
-
 	for rows.Next() {
 		var rc traefik.DomainRouteConfig
 		var middlewaresJSON string
@@ -203,43 +454,20 @@ func ApplyRoutes() error {
 		var sentinelConfigJSON string
 
 		var certResolver sql.NullString
-		if err := rows.Scan(&rc.Domain, &rc.TargetIP, &rc.TargetPort, &rc.HTTPSBackend, &rc.SkipCertVerify, &middlewaresJSON, &accessMode, &frontendSSL, &sentinelConfigJSON, &certResolver); err != nil {
+		var backendsJSON, accessLogJSON, rateLimitJSON string
+		if err := rows.Scan(&rc.Domain, &rc.TargetIP, &rc.TargetPort, &rc.HTTPSBackend, &rc.SkipCertVerify, &middlewaresJSON, &accessMode, &frontendSSL, &sentinelConfigJSON, &certResolver, &backendsJSON, &rc.TLSMinVersion, &rc.TLSServerName, &accessLogJSON, &rateLimitJSON); err != nil {
 			continue
 		}
+		rc.Backends = parseBackends(backendsJSON)
+		rc.AccessLog = parseAccessLog(accessLogJSON)
+		rc.RateLimit = parseRateLimit(rateLimitJSON)
 		rc.Middlewares, rc.AccessMode, rc.FrontendSSL, rc.SentinelConfig = parseRouteFields(middlewaresJSON, accessMode, frontendSSL, sentinelConfigJSON)
 		if certResolver.Valid {
 			rc.CertResolver = certResolver.String
 		}
-
-		// Add VPN domains to AdGuard sync list.
-		// For a wildcard route (`*.example.com`), also rewrite the apex (`example.com`)
-		// — AdGuard wildcards match one label to the left, so the apex needs its own entry.
-		if rc.AccessMode == "vpn" {
-			vpnDomains = append(vpnDomains, adguard.DomainRoute{Domain: rc.Domain})
-			if helper.IsWildcardDomain(rc.Domain) {
-				baseDomain := helper.WildcardBaseDomain(rc.Domain)
-				if baseDomain != "" {
-					vpnDomains = append(vpnDomains, adguard.DomainRoute{Domain: baseDomain})
-				}
-			}
-		}
-
 		routes = append(routes, rc)
 	}
-
-	// Generate Traefik config
-	if err := traefik.GenerateDomainRoutes(traefikConfigDir, routes); err != nil {
-		return fmt.Errorf("failed to generate Traefik config: %v", err)
-	}
-
-	// Sync AdGuard DNS only for VPN mode domains (rewrite to VPN IP, not public IP!)
-	dnsErrors := adguard.SyncDomainRewrites(vpnDomains, vpnIP)
-	if len(dnsErrors) > 0 {
-		log.Printf("DNS sync warnings: %v", dnsErrors)
-	}
-
-	log.Printf("Applied %d domain routes (%d VPN mode with DNS)", len(routes), len(vpnDomains))
-	return nil
+	return routes, rows.Err()
 }
 
 // applyRoutes calls the exported ApplyRoutes function (internal wrapper for service methods)
@@ -273,14 +501,21 @@ func DeleteClientRoutes(clientID int) (int, error) {
 // Handlers returns the handler map for the router
 func (s *Service) Handlers() router.ServiceHandlers {
 	return router.ServiceHandlers{
-		"List":            s.handleList,
-		"Get":             s.handleGet,
-		"Create":          s.handleCreate,
-		"Update":          s.handleUpdate,
-		"Delete":          s.handleDelete,
-		"Toggle":          s.handleToggle,
-		"GetCertificates": s.handleGetCertificates,
-		"GetSystemDomain": s.handleGetSystemDomain,
+		"List":                      s.handleList,
+		"Get":                       s.handleGet,
+		"Create":                    s.handleCreate,
+		"Update":                    s.handleUpdate,
+		"Delete":                    s.handleDelete,
+		"Toggle":                    s.handleToggle,
+		"GetCertificates":           s.handleGetCertificates,
+		"GetSystemDomain":           s.handleGetSystemDomain,
+		"GetMaintenance":            s.handleGetGlobalMaintenance,
+		"SetMaintenance":            s.handleSetGlobalMaintenance,
+		"TestSentinel":              s.handleTestSentinel,
+		"SimulateAccess":            s.handleSimulateAccess,
+		"DiagnoseRoute":             s.handleDiagnoseRoute,
+		"ReconcileDNS":              s.handleReconcileDNS,
+		"GetGeneratedTraefikConfig": s.handleGetGeneratedTraefikConfig,
 	}
 }
 
@@ -295,7 +530,8 @@ func (s *Service) handleList(w http.ResponseWriter, r *http.Request) {
 		SELECT d.id, d.domain, d.target_ip, d.target_port, d.vpn_client_id,
 		       d.enabled, d.https_backend, d.skip_cert_verify, d.middlewares, d.description,
 		       d.access_mode, d.frontend_ssl, COALESCE(d.sentinel_config, ''),
-		       COALESCE(d.cert_resolver, ''),
+		       COALESCE(d.cert_resolver, ''), COALESCE(d.backends, ''),
+		       COALESCE(d.tls_min_version, ''), COALESCE(d.tls_server_name, ''), COALESCE(d.access_log, ''), COALESCE(d.rate_limit, ''),
 		       d.created_at, d.updated_at, COALESCE(v.name, '') as vpn_client_name
 		FROM domain_routes d
 		LEFT JOIN vpn_clients v ON d.vpn_client_id = v.id
@@ -316,11 +552,12 @@ func (s *Service) handleList(w http.ResponseWriter, r *http.Request) {
 		var frontendSSL sql.NullBool
 		var sentinelConfigJSON string
 		var certResolver sql.NullString
+		var backendsJSON, accessLogJSON, rateLimitJSON string
 		if err := rows.Scan(
 			&route.ID, &route.Domain, &route.TargetIP, &route.TargetPort,
 			&vpnClientID, &route.Enabled, &route.HTTPSBackend, &route.SkipCertVerify, &middlewaresJSON,
 			&route.Description, &accessMode, &frontendSSL, &sentinelConfigJSON,
-			&certResolver,
+			&certResolver, &backendsJSON, &route.TLSMinVersion, &route.TLSServerName, &accessLogJSON, &rateLimitJSON,
 			&route.CreatedAt, &route.UpdatedAt, &route.VPNClientName,
 		); err != nil {
 			continue
@@ -333,12 +570,16 @@ func (s *Service) handleList(w http.ResponseWriter, r *http.Request) {
 		if certResolver.Valid {
 			route.CertResolver = certResolver.String
 		}
+		route.Backends = parseBackends(backendsJSON)
+		route.AccessLog = parseAccessLog(accessLogJSON)
+		route.RateLimit = parseRateLimit(rateLimitJSON)
 		routes = append(routes, route)
 	}
 
 	router.JSON(w, map[string]interface{}{
-		"routes": routes,
-		"count":  len(routes),
+		"routes":   routes,
+		"count":    len(routes),
+		"publicIp": s.publicIP(),
 	})
 }
 
@@ -362,11 +603,13 @@ func (s *Service) handleGet(w http.ResponseWriter, r *http.Request) {
 	var frontendSSL sql.NullBool
 	var sentinelConfigJSON string
 	var certResolver sql.NullString
+	var backendsJSON, accessLogJSON, rateLimitJSON string
 	err = db.QueryRow(`
 		SELECT d.id, d.domain, d.target_ip, d.target_port, d.vpn_client_id,
 		       d.enabled, d.https_backend, d.skip_cert_verify, d.middlewares, d.description,
 		       d.access_mode, d.frontend_ssl, COALESCE(d.sentinel_config, ''),
-		       COALESCE(d.cert_resolver, ''),
+		       COALESCE(d.cert_resolver, ''), COALESCE(d.backends, ''),
+		       COALESCE(d.tls_min_version, ''), COALESCE(d.tls_server_name, ''), COALESCE(d.access_log, ''), COALESCE(d.rate_limit, ''),
 		       d.created_at, d.updated_at, COALESCE(v.name, '') as vpn_client_name
 		FROM domain_routes d
 		LEFT JOIN vpn_clients v ON d.vpn_client_id = v.id
@@ -375,7 +618,7 @@ func (s *Service) handleGet(w http.ResponseWriter, r *http.Request) {
 		&route.ID, &route.Domain, &route.TargetIP, &route.TargetPort,
 		&vpnClientID, &route.Enabled, &route.HTTPSBackend, &route.SkipCertVerify, &middlewaresJSON,
 		&route.Description, &accessMode, &frontendSSL, &sentinelConfigJSON,
-		&certResolver,
+		&certResolver, &backendsJSON, &route.TLSMinVersion, &route.TLSServerName, &accessLogJSON, &rateLimitJSON,
 		&route.CreatedAt, &route.UpdatedAt, &route.VPNClientName,
 	)
 	if err == sql.ErrNoRows {
@@ -394,6 +637,9 @@ func (s *Service) handleGet(w http.ResponseWriter, r *http.Request) {
 	if certResolver.Valid {
 		route.CertResolver = certResolver.String
 	}
+	route.Backends = parseBackends(backendsJSON)
+	route.AccessLog = parseAccessLog(accessLogJSON)
+	route.RateLimit = parseRateLimit(rateLimitJSON)
 
 	router.JSON(w, route)
 }
@@ -412,6 +658,11 @@ type CreateRequest struct {
 	FrontendSSL     bool                    `json:"frontendSsl"` // use websecure entrypoint
 	CertResolver    string                  `json:"certResolver,omitempty"` // explicit resolver name; empty = auto
 	SentinelConfig  *traefik.SentinelConfig `json:"sentinelConfig,omitempty"`
+	Backends        []traefik.Backend       `json:"backends,omitempty"` // additional load-balanced targets
+	TLSMinVersion   string                  `json:"tlsMinVersion,omitempty"`
+	TLSServerName   string                  `json:"tlsServerName,omitempty"`
+	AccessLog       *traefik.AccessLogConfig `json:"accessLog,omitempty"`
+	RateLimit       *traefik.RateLimitConfig `json:"rateLimit,omitempty"`
 }
 
 func (s *Service) handleCreate(w http.ResponseWriter, r *http.Request) {
@@ -440,6 +691,30 @@ func (s *Service) handleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validate additional backends
+	if err := validateBackends(req.Backends); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate TLS settings
+	if err := validateTLSSettings(&req.TLSMinVersion, &req.TLSServerName); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate access log fields
+	if err := traefik.ValidateAccessLogConfig(req.AccessLog); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate per-route rate limit
+	if err := traefik.ValidateRateLimitConfig(req.RateLimit); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Validate access_mode (default to "vpn" if empty)
 	if req.AccessMode == "" {
 		req.AccessMode = "vpn"
@@ -449,6 +724,14 @@ func (s *Service) handleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validate middlewares against Traefik config
+	middlewares, err := normalizeMiddlewares(req.Middlewares)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Middlewares = middlewares
+
 	// For VPN mode, ensure a VPN middleware is present
 	if req.AccessMode == "vpn" {
 		req.Middlewares = ensureVPNMiddleware(req.Middlewares)
@@ -482,9 +765,9 @@ func (s *Service) handleCreate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := db.Exec(`
-		INSERT INTO domain_routes (domain, target_ip, target_port, vpn_client_id, https_backend, skip_cert_verify, middlewares, description, access_mode, frontend_ssl, sentinel_config, cert_resolver)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, req.Domain, req.TargetIP, req.TargetPort, req.VPNClientID, req.HTTPSBackend, req.SkipCertVerify, string(middlewaresJSON), req.Description, req.AccessMode, req.FrontendSSL, sentinelConfigJSON, req.CertResolver)
+		INSERT INTO domain_routes (domain, target_ip, target_port, vpn_client_id, https_backend, skip_cert_verify, middlewares, description, access_mode, frontend_ssl, sentinel_config, cert_resolver, backends, tls_min_version, tls_server_name, access_log, rate_limit)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.Domain, req.TargetIP, req.TargetPort, req.VPNClientID, req.HTTPSBackend, req.SkipCertVerify, string(middlewaresJSON), req.Description, req.AccessMode, req.FrontendSSL, sentinelConfigJSON, req.CertResolver, marshalBackends(req.Backends), req.TLSMinVersion, req.TLSServerName, marshalAccessLog(req.AccessLog), marshalRateLimit(req.RateLimit))
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint") {
 			router.JSONError(w, "domain already exists", http.StatusConflict)
@@ -521,6 +804,11 @@ type UpdateRequest struct {
 	FrontendSSL     *bool                   `json:"frontendSsl,omitempty"`
 	CertResolver    *string                 `json:"certResolver,omitempty"` // explicit resolver override; empty string = clear back to auto
 	SentinelConfig  *traefik.SentinelConfig `json:"sentinelConfig"` // No omitempty - null means clear
+	Backends        *[]traefik.Backend      `json:"backends,omitempty"` // replaces the additional targets; [] clears them
+	TLSMinVersion   *string                 `json:"tlsMinVersion,omitempty"` // empty string = Traefik default
+	TLSServerName   *string                 `json:"tlsServerName,omitempty"` // empty string = route domain
+	AccessLog       *traefik.AccessLogConfig `json:"accessLog"` // No omitempty - null means back to default logging
+	RateLimit       *traefik.RateLimitConfig `json:"rateLimit"` // No omitempty - null removes the route's rate limit
 }
 
 func (s *Service) handleUpdate(w http.ResponseWriter, r *http.Request) {
@@ -552,6 +840,8 @@ func (s *Service) handleUpdate(w http.ResponseWriter, r *http.Request) {
 		sentinelConfigPresent = true
 		sentinelConfigNull = string(raw) == "null"
 	}
+	_, accessLogPresent := rawMap["accessLog"]
+	_, rateLimitPresent := rawMap["rateLimit"]
 
 	// Validate fields if provided
 	if req.Domain != nil {
@@ -576,6 +866,24 @@ func (s *Service) handleUpdate(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if req.Backends != nil {
+		if err := validateBackends(*req.Backends); err != nil {
+			router.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := validateTLSSettings(req.TLSMinVersion, req.TLSServerName); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := traefik.ValidateAccessLogConfig(req.AccessLog); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := traefik.ValidateRateLimitConfig(req.RateLimit); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	db, err := database.GetDB()
 	if err != nil {
@@ -656,6 +964,15 @@ func (s *Service) handleUpdate(w http.ResponseWriter, r *http.Request) {
 		effectiveAccessMode = *req.AccessMode
 	}
 
+	if req.Middlewares != nil {
+		middlewares, err := normalizeMiddlewares(*req.Middlewares)
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Middlewares = &middlewares
+	}
+
 	if effectiveAccessMode == "vpn" {
 		if req.Middlewares != nil {
 			// Middlewares provided - ensure VPN middleware
@@ -692,6 +1009,26 @@ func (s *Service) handleUpdate(w http.ResponseWriter, r *http.Request) {
 		updates = append(updates, "cert_resolver = ?")
 		args = append(args, *req.CertResolver)
 	}
+	if req.Backends != nil {
+		updates = append(updates, "backends = ?")
+		args = append(args, marshalBackends(*req.Backends))
+	}
+	if req.TLSMinVersion != nil {
+		updates = append(updates, "tls_min_version = ?")
+		args = append(args, *req.TLSMinVersion)
+	}
+	if req.TLSServerName != nil {
+		updates = append(updates, "tls_server_name = ?")
+		args = append(args, *req.TLSServerName)
+	}
+	if accessLogPresent {
+		updates = append(updates, "access_log = ?")
+		args = append(args, marshalAccessLog(req.AccessLog))
+	}
+	if rateLimitPresent {
+		updates = append(updates, "rate_limit = ?")
+		args = append(args, marshalRateLimit(req.RateLimit))
+	}
 	// SentinelConfig: handle null (clear) vs object (update) vs omitted (no change)
 	if sentinelConfigPresent {
 		if sentinelConfigNull {
@@ -900,3 +1237,211 @@ func (s *Service) handleGetSystemDomain(w http.ResponseWriter, r *http.Request)
 		"certificate": certInfo,
 	})
 }
+
+// MaintenanceRoute describes a route's maintenance state
+type MaintenanceRoute struct {
+	ID       int      `json:"id"`
+	Domain   string   `json:"domain"`
+	Enabled  bool     `json:"enabled"` // route enabled
+	Message  string   `json:"message,omitempty"`
+	Bypass   []string `json:"bypass,omitempty"`
+	PageFile string   `json:"pageFile,omitempty"`
+}
+
+// handleGetGlobalMaintenance reports which routes are currently in maintenance
+func (s *Service) handleGetGlobalMaintenance(w http.ResponseWriter, r *http.Request) {
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := db.Query(`SELECT id, domain, enabled, COALESCE(sentinel_config, '') FROM domain_routes ORDER BY domain`)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	routes := []MaintenanceRoute{}
+	total := 0
+	for rows.Next() {
+		var mr MaintenanceRoute
+		var sentinelConfigJSON string
+		if err := rows.Scan(&mr.ID, &mr.Domain, &mr.Enabled, &sentinelConfigJSON); err != nil {
+			continue
+		}
+		total++
+		sc := traefik.ParseSentinelConfig(sentinelConfigJSON)
+		if sc == nil || !sc.Enabled || sc.Maintenance == nil || !sc.Maintenance.Enabled {
+			continue
+		}
+		mr.Message = sc.Maintenance.Message
+		mr.Bypass = sc.Maintenance.Bypass
+		mr.PageFile = sc.Maintenance.PageFile
+		routes = append(routes, mr)
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"routes": routes,
+		"count":  len(routes),
+		"total":  total,
+	})
+}
+
+// MaintenanceRequest toggles maintenance mode across routes in one go
+type MaintenanceRequest struct {
+	Enabled  bool     `json:"enabled"`
+	RouteIDs []int    `json:"routeIds,omitempty"` // empty = all routes
+	Message  string   `json:"message,omitempty"`
+	Bypass   []string `json:"bypass,omitempty"`   // admin CIDRs; defaults to the VPN allowlist when enabling
+	PageFile string   `json:"pageFile,omitempty"` // file name in traefik.SentinelMaintenancePagesDir; keeps the route's own when empty
+}
+
+// handleSetGlobalMaintenance sets the maintenance flag on the targeted routes in a
+// single transaction and applies routes once. Intended for incident response.
+// Enabling saves each route's sentinel and maintenance settings; disabling
+// restores them.
+func (s *Service) handleSetGlobalMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceRequest
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	if err := helper.ValidateIPList(req.Bypass); err != nil {
+		router.JSONError(w, "invalid bypass: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := traefik.ValidateMaintenancePageFile(req.PageFile); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Keep admins in: fall back to the VPN allowlist used by sentinel_vpn
+	if req.Enabled && len(req.Bypass) == 0 {
+		if tsvc := traefik.GetService(); tsvc != nil {
+			if cfg := tsvc.GetConfig(); cfg != nil {
+				req.Bypass = cfg.IPAllowlist
+			}
+		}
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, domain, COALESCE(sentinel_config, '') FROM domain_routes`
+	args := []interface{}{}
+	if len(req.RouteIDs) > 0 {
+		placeholders := make([]string, len(req.RouteIDs))
+		for i, id := range req.RouteIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += " WHERE id IN (" + strings.Join(placeholders, ",") + ")"
+	}
+
+	type target struct {
+		id     int
+		domain string
+		sc     *traefik.SentinelConfig
+	}
+	var targets []target
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for rows.Next() {
+		var t target
+		var sentinelConfigJSON string
+		if err := rows.Scan(&t.id, &t.domain, &sentinelConfigJSON); err != nil {
+			continue
+		}
+		t.sc = traefik.ParseSentinelConfig(sentinelConfigJSON)
+		targets = append(targets, t)
+	}
+	rows.Close()
+
+	updated := []string{}
+	for _, t := range targets {
+		sc := t.sc
+		if req.Enabled {
+			if sc == nil {
+				sc = &traefik.SentinelConfig{}
+			}
+			if sc.Maintenance == nil {
+				sc.Maintenance = &traefik.MaintenanceConfig{}
+			}
+			// Save the route's own settings once, so re-enabling doesn't overwrite them
+			if sc.Maintenance.Global == nil {
+				sc.Maintenance.Global = &traefik.MaintenanceRestore{
+					SentinelEnabled:    sc.Enabled,
+					MaintenanceEnabled: sc.Maintenance.Enabled,
+					Message:            sc.Maintenance.Message,
+					Bypass:             sc.Maintenance.Bypass,
+					PageFile:           sc.Maintenance.PageFile,
+				}
+			}
+			sc.Enabled = true
+			sc.Maintenance.Enabled = true
+			if req.Message != "" {
+				sc.Maintenance.Message = req.Message
+			}
+			if req.PageFile != "" {
+				sc.Maintenance.PageFile = req.PageFile
+			}
+			sc.Maintenance.Bypass = req.Bypass
+		} else {
+			if sc == nil || sc.Maintenance == nil || !sc.Maintenance.Enabled {
+				continue
+			}
+			if g := sc.Maintenance.Global; g != nil {
+				// Put back what global maintenance overwrote
+				sc.Enabled = g.SentinelEnabled
+				sc.Maintenance.Enabled = g.MaintenanceEnabled
+				sc.Maintenance.Message = g.Message
+				sc.Maintenance.Bypass = g.Bypass
+				sc.Maintenance.PageFile = g.PageFile
+				sc.Maintenance.Global = nil
+			} else {
+				sc.Maintenance.Enabled = false
+			}
+		}
+
+		b, _ := json.Marshal(sc)
+		if _, err := tx.Exec(`UPDATE domain_routes SET sentinel_config = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, string(b), t.id); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		updated = append(updated, t.domain)
+	}
+
+	if err := tx.Commit(); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(updated) > 0 {
+		if err := s.applyRoutes(); err != nil {
+			router.JSONError(w, "maintenance updated but failed to apply routes: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	log.Printf("Maintenance mode %v on %d routes", req.Enabled, len(updated))
+	router.JSON(w, map[string]interface{}{
+		"enabled": req.Enabled,
+		"updated": updated,
+		"count":   len(updated),
+	})
+}
@@ -0,0 +1,114 @@
+package domains
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"api/internal/router"
+	"api/internal/traefik"
+)
+
+// GeneratedConfigFile is one dynamic config file Traefik loads
+type GeneratedConfigFile struct {
+	Name      string                 `json:"name"`
+	Generated bool                   `json:"generated"`        // rendered now from the DB rather than read from disk
+	InSync    *bool                  `json:"inSync,omitempty"` // generated file matches the one on disk
+	Content   string                 `json:"content"`
+	Config    map[string]interface{} `json:"config,omitempty"` // parsed content
+	Error     string                 `json:"error,omitempty"`
+}
+
+// stripGeneratedAt drops the timestamp header line so renders can be compared
+func stripGeneratedAt(content string) string {
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "# Generated at: ") {
+			kept = append(kept, l)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// generatedTraefikConfig renders domains.yml from the enabled routes without
+// writing it and reads the other dynamic files (core.yml and the middleware
+// files the panel edits in place) from the config directory
+func (s *Service) generatedTraefikConfig() ([]GeneratedConfigFile, error) {
+	routes, err := enabledRouteConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	content := traefik.BuildDomainRoutes(routes)
+	domainsFile := GeneratedConfigFile{Name: traefik.DomainsConfigFile, Generated: true, Content: content}
+	inSync := false
+	if onDisk, err := os.ReadFile(filepath.Join(s.traefikConfigDir, traefik.DomainsConfigFile)); err == nil {
+		inSync = stripGeneratedAt(string(onDisk)) == stripGeneratedAt(content)
+	}
+	domainsFile.InSync = &inSync
+	files := []GeneratedConfigFile{domainsFile}
+
+	paths, _ := filepath.Glob(filepath.Join(s.traefikConfigDir, "*.yml"))
+	sort.Strings(paths)
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if name == traefik.DomainsConfigFile {
+			continue
+		}
+		f := GeneratedConfigFile{Name: name}
+		if data, err := os.ReadFile(path); err != nil {
+			f.Error = err.Error()
+		} else {
+			f.Content = string(data)
+		}
+		files = append(files, f)
+	}
+
+	for i := range files {
+		if files[i].Content == "" {
+			continue
+		}
+		if err := yaml.Unmarshal([]byte(files[i].Content), &files[i].Config); err != nil {
+			files[i].Error = "invalid YAML: " + err.Error()
+		}
+	}
+	return files, nil
+}
+
+// handleGetGeneratedTraefikConfig returns the full dynamic config Traefik is
+// given, with domains.yml rendered fresh from the DB. ?format=yaml returns the
+// files as one YAML stream instead of JSON.
+func (s *Service) handleGetGeneratedTraefikConfig(w http.ResponseWriter, r *http.Request) {
+	files, err := s.generatedTraefikConfig()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if router.QueryParam(r, "format", "json") == "yaml" {
+		var sb strings.Builder
+		for i, f := range files {
+			if i > 0 {
+				sb.WriteString("---\n")
+			}
+			sb.WriteString("# File: " + f.Name + "\n")
+			sb.WriteString(f.Content)
+			if !strings.HasSuffix(f.Content, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write([]byte(sb.String()))
+		return
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"configDir": s.traefikConfigDir,
+		"files":     files,
+	})
+}
@@ -0,0 +1,70 @@
+package domains
+
+import (
+	"database/sql"
+	"net"
+	"net/http"
+
+	"api/internal/database"
+	"api/internal/router"
+	"api/internal/traefik"
+)
+
+// SentinelTestRequest is the body of TestSentinel: a config (or a saved
+// route's config via routeId) and the synthetic request to evaluate
+type SentinelTestRequest struct {
+	RouteID *int                        `json:"routeId,omitempty"`
+	Config  *traefik.SentinelConfig     `json:"config,omitempty"`
+	Request traefik.SentinelTestRequest `json:"request"`
+}
+
+// handleTestSentinel evaluates a sample request against a sentinel config and
+// reports which check (if any) would block it, without touching Traefik
+func (s *Service) handleTestSentinel(w http.ResponseWriter, r *http.Request) {
+	var req SentinelTestRequest
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	if net.ParseIP(req.Request.ClientIP) == nil {
+		router.JSONError(w, "request.clientIp must be a valid IP address", http.StatusBadRequest)
+		return
+	}
+
+	config := req.Config
+	if req.RouteID != nil {
+		db, err := database.GetDB()
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var sentinelJSON string
+		err = db.QueryRow(`SELECT COALESCE(sentinel_config, '') FROM domain_routes WHERE id = ?`, *req.RouteID).Scan(&sentinelJSON)
+		if err == sql.ErrNoRows {
+			router.JSONError(w, "route not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if config = traefik.ParseSentinelConfig(sentinelJSON); config == nil {
+			router.JSONError(w, "route has no sentinel config", http.StatusBadRequest)
+			return
+		}
+	}
+	if config == nil {
+		router.JSONError(w, "config or routeId is required", http.StatusBadRequest)
+		return
+	}
+	if err := validateSentinelConfig(config); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := traefik.EvaluateSentinel(config, req.Request, traefik.LocalSentinelASNFile())
+	router.JSON(w, map[string]interface{}{
+		"result":   result,
+		"attached": config.Enabled, // disabled configs aren't generated as middlewares
+	})
+}
@@ -0,0 +1,187 @@
+package domains
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"api/internal/database"
+	"api/internal/router"
+	"api/internal/traefik"
+)
+
+// Simulation step statuses. Middlewares that don't decide on a single request
+// (rate limits, headers) are reported as not simulated.
+const (
+	simPass         = "pass"
+	simFail         = "fail"
+	simDelay        = "delay"
+	simUnknown      = "unknown"
+	simNotSimulated = "not simulated"
+	simNotReached   = "not reached"
+)
+
+// SimulationStep is one middleware of the route's chain, in the order Traefik runs them
+type SimulationStep struct {
+	Middleware string                      `json:"middleware"`
+	Kind       string                      `json:"kind"` // route, vpnAllowlist, sentinel, other
+	Status     string                      `json:"status"`
+	Detail     string                      `json:"detail,omitempty"`
+	Sentinel   *traefik.SentinelTestResult `json:"sentinel,omitempty"`
+}
+
+// AccessSimulation is the end-to-end outcome for a synthetic visitor
+type AccessSimulation struct {
+	RouteID    int              `json:"routeId"`
+	Domain     string           `json:"domain"`
+	AccessMode string           `json:"accessMode"`
+	Allowed    bool             `json:"allowed"`
+	Action     string           `json:"action"`              // allow, block, maintenance or unknown
+	DecidedBy  string           `json:"decidedBy,omitempty"` // middleware (and check) that decided
+	Detail     string           `json:"detail,omitempty"`
+	Response   string           `json:"response,omitempty"` // what a blocked visitor receives
+	DelayMs    int              `json:"delayMs,omitempty"`
+	Chain      []SimulationStep `json:"chain"`
+}
+
+// handleSimulateAccess evaluates a synthetic request (client IP, user-agent,
+// headers, time) against a route's whole middleware chain: the VPN allowlist
+// middlewares and the route's sentinel config, in Traefik's order.
+func (s *Service) handleSimulateAccess(w http.ResponseWriter, r *http.Request) {
+	idStr := router.ExtractPathParam(r, "/api/domains/")
+	id, ok := router.ParseIDOrError(w, idStr)
+	if !ok {
+		return
+	}
+
+	var req traefik.SentinelTestRequest
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if net.ParseIP(req.ClientIP) == nil {
+		router.JSONError(w, "clientIp must be a valid IP address", http.StatusBadRequest)
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var sim AccessSimulation
+	var enabled bool
+	var middlewaresJSON, sentinelConfigJSON string
+	var accessMode sql.NullString
+	var frontendSSL sql.NullBool
+	err = db.QueryRow(`SELECT id, domain, enabled, COALESCE(middlewares, '[]'), access_mode, frontend_ssl,
+		COALESCE(sentinel_config, '') FROM domain_routes WHERE id = ?`, id).Scan(
+		&sim.RouteID, &sim.Domain, &enabled, &middlewaresJSON, &accessMode, &frontendSSL, &sentinelConfigJSON)
+	if err == sql.ErrNoRows {
+		router.JSONError(w, "route not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	middlewares, mode, _, sentinel := parseRouteFields(middlewaresJSON, accessMode, frontendSSL, sentinelConfigJSON)
+	sim.AccessMode = mode
+
+	simulateChain(&sim, enabled, middlewares, sentinel, req)
+	router.JSON(w, sim)
+}
+
+// simulateChain walks the chain GenerateDomainRoutes emits: the route's
+// sentinel_domain-* middleware first, then the route middlewares in order.
+// The first failing step decides; later steps are reported as not reached.
+func simulateChain(sim *AccessSimulation, enabled bool, middlewares []string, sentinel *traefik.SentinelConfig, req traefik.SentinelTestRequest) {
+	sim.Chain = []SimulationStep{}
+	sim.Action = "allow"
+	decided := false
+	decide := func(step *SimulationStep, action, response string) {
+		if decided {
+			return
+		}
+		decided = true
+		sim.Action, sim.DecidedBy, sim.Detail, sim.Response = action, step.Middleware, step.Detail, response
+	}
+
+	if !enabled {
+		step := SimulationStep{Middleware: "router", Kind: "route", Status: simFail,
+			Detail: "route is disabled, so Traefik has no router for it"}
+		sim.Chain = append(sim.Chain, step)
+		decide(&step, "block", "404 page not found")
+	}
+
+	// Global VPN allowlist used by the sentinel_vpn@file middlewares
+	allowlist, allowlistKnown := traefik.VPNAllowlist()
+
+	var chain []string
+	if sentinel != nil && sentinel.Enabled {
+		chain = append(chain, "sentinel_domain")
+	}
+	chain = append(chain, middlewares...)
+
+	for _, mw := range chain {
+		step := SimulationStep{Middleware: mw, Kind: "other", Status: simNotSimulated}
+		if decided {
+			step.Status = simNotReached
+			if mw == "sentinel_domain" {
+				step.Kind = "sentinel"
+			}
+			sim.Chain = append(sim.Chain, step)
+			continue
+		}
+
+		switch {
+		case mw == "sentinel_domain":
+			step.Kind = "sentinel"
+			result := traefik.EvaluateSentinel(sentinel, req, traefik.LocalSentinelASNFile())
+			step.Sentinel = &result
+			step.Status, step.Detail = simPass, result.Detail
+			switch {
+			case result.Action != "allow":
+				step.Status = simFail
+				step.Middleware = "sentinel_domain (" + result.Check + ")"
+				decide(&step, result.Action, result.Response)
+			case result.DelayMs > 0:
+				step.Status = simDelay
+				sim.DelayMs = result.DelayMs
+			}
+
+		case mw == traefik.MiddlewareSentinelVPNFile || mw == traefik.MiddlewareSentinelVPNSilentFile:
+			step.Kind = "vpnAllowlist"
+			switch {
+			case !allowlistKnown:
+				step.Status, step.Detail = simUnknown, "VPN allowlist could not be read"
+			case traefik.InSourceRange(req.ClientIP, allowlist):
+				step.Status, step.Detail = simPass, fmt.Sprintf("%s is in the VPN allowlist", req.ClientIP)
+			default:
+				step.Status, step.Detail = simFail, fmt.Sprintf("%s is not in the VPN allowlist", req.ClientIP)
+				response := "403 error page"
+				if mw == traefik.MiddlewareSentinelVPNSilentFile {
+					response = "connection dropped (rst)"
+				}
+				decide(&step, "block", response)
+			}
+
+		case strings.HasPrefix(mw, "rate-limit"):
+			step.Detail = "rate limits only apply to repeated requests"
+		}
+		sim.Chain = append(sim.Chain, step)
+	}
+
+	// An unreadable allowlist leaves the outcome open unless something else blocked
+	if !decided {
+		for _, step := range sim.Chain {
+			if step.Status == simUnknown {
+				sim.Action, sim.DecidedBy, sim.Detail = "unknown", step.Middleware, step.Detail
+				break
+			}
+		}
+	}
+	sim.Allowed = sim.Action == "allow"
+}
@@ -0,0 +1,166 @@
+package firewall
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"api/internal/router"
+)
+
+// AllowlistEntry is an IP or CIDR that jails and blocklist imports never block.
+// Unlike an allow entry it adds no nftables rule; it only keeps bans from being created.
+type AllowlistEntry struct {
+	ID          int64  `json:"id"`
+	CIDR        string `json:"cidr"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// allowlistNets returns the allowlisted networks (single IPs as /32 or /128)
+func (s *Service) allowlistNets() []*net.IPNet {
+	rows, err := s.db.Query("SELECT cidr FROM firewall_allowlist")
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var nets []*net.IPNet
+	for rows.Next() {
+		var cidr string
+		if rows.Scan(&cidr) == nil {
+			if n := parseNetwork(cidr); n != nil {
+				nets = append(nets, n)
+			}
+		}
+	}
+	return nets
+}
+
+// allowlistMatch returns the allowlisted network overlapping an IP or CIDR
+// value, or nil. A range overlaps when it contains any allowlisted address.
+func allowlistMatch(value string, nets []*net.IPNet) *net.IPNet {
+	n := parseNetwork(value)
+	if n == nil {
+		return nil
+	}
+	for _, allowed := range nets {
+		if allowed.Contains(n.IP) || n.Contains(allowed.IP) {
+			return allowed
+		}
+	}
+	return nil
+}
+
+// handleGetAllowlist lists the permanent allowlist
+func (s *Service) handleGetAllowlist(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.db.Query(`SELECT id, cidr, COALESCE(description, ''), created_at
+		FROM firewall_allowlist ORDER BY cidr`)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	entries := []AllowlistEntry{}
+	for rows.Next() {
+		var e AllowlistEntry
+		if rows.Scan(&e.ID, &e.CIDR, &e.Description, &e.CreatedAt) == nil {
+			entries = append(entries, e)
+		}
+	}
+	router.JSON(w, map[string]interface{}{
+		"allowlist": entries,
+		"count":     len(entries),
+	})
+}
+
+// handleAddAllowlist adds an IP or CIDR to the allowlist and lifts the jail,
+// escalation and sentinel bans it covers
+func (s *Service) handleAddAllowlist(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CIDR        string `json:"cidr"`
+		Description string `json:"description"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	value, _, err := validateIPOrCIDR(req.CIDR)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n := parseNetwork(value)
+	if n == nil {
+		router.JSONError(w, "invalid IP or CIDR", http.StatusBadRequest)
+		return
+	}
+	// A huge allowlisted range would silently switch jails off
+	ones, bits := n.Mask.Size()
+	if minOnes := map[int]int{32: 8, 128: 32}[bits]; ones < minOnes {
+		router.JSONError(w, fmt.Sprintf("allowlist ranges must be /%d or smaller", minOnes), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.db.Exec("INSERT INTO firewall_allowlist (cidr, description) VALUES (?, ?)",
+		value, strings.TrimSpace(req.Description))
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			router.JSONError(w, value+" is already allowlisted", http.StatusConflict)
+			return
+		}
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	// Lift automatic bans the new entry covers; manual blocks stay
+	rows, err := s.db.Query(`SELECT id, value FROM firewall_entries
+		WHERE entry_type IN ('ip', 'range') AND action = 'block' AND essential = 0
+		AND (source LIKE 'jail:%' OR source IN ('escalated', 'sentinel'))`)
+	var ids []int64
+	if err == nil {
+		for rows.Next() {
+			var entryID int64
+			var entryValue string
+			if rows.Scan(&entryID, &entryValue) == nil && allowlistMatch(entryValue, []*net.IPNet{n}) != nil {
+				ids = append(ids, entryID)
+			}
+		}
+		rows.Close()
+	}
+	var unblocked int64
+	for _, entryID := range ids {
+		if res, err := s.db.Exec("DELETE FROM firewall_entries WHERE id = ?", entryID); err == nil {
+			affected, _ := res.RowsAffected()
+			unblocked += affected
+		}
+	}
+	if unblocked > 0 {
+		log.Printf("Allowlisted %s: removed %d automatic bans", value, unblocked)
+		s.RequestApply()
+	}
+
+	router.JSON(w, map[string]interface{}{"id": id, "cidr": value, "unblocked": unblocked})
+}
+
+// handleRemoveAllowlist removes an allowlist entry; jails may ban it again
+func (s *Service) handleRemoveAllowlist(w http.ResponseWriter, r *http.Request) {
+	idStr := router.ExtractPathParam(r, "/api/fw/allowlist/")
+	id, ok := router.ParseIDOrError(w, idStr)
+	if !ok {
+		return
+	}
+	result, err := s.db.Exec("DELETE FROM firewall_allowlist WHERE id = ?", id)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		router.JSONError(w, "allowlist entry not found", http.StatusNotFound)
+		return
+	}
+	router.JSON(w, map[string]interface{}{"status": "deleted", "id": id})
+}
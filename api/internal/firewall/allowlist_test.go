@@ -0,0 +1,62 @@
+package firewall
+
+import (
+	"net"
+	"testing"
+)
+
+func TestAllowlistMatch(t *testing.T) {
+	nets := []*net.IPNet{parseNetwork("203.0.113.0/28"), parseNetwork("198.51.100.7"), parseNetwork("2001:db8::/64")}
+
+	tests := []struct {
+		value string
+		want  string // matching allowlist network, "" for none
+	}{
+		{"203.0.113.5", "203.0.113.0/28"},
+		{"203.0.113.20", ""},
+		{"198.51.100.7", "198.51.100.7/32"},
+		{"198.51.100.8", ""},
+		{"198.51.100.0/24", "198.51.100.7/32"}, // range containing an allowlisted IP
+		{"203.0.113.0/30", "203.0.113.0/28"},   // range inside an allowlisted range
+		{"2001:db8::1", "2001:db8::/64"},
+		{"2001:db8:1::1", ""},
+		{"not-an-ip", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if n := allowlistMatch(tt.value, nets); n != nil {
+			got = n.String()
+		}
+		if got != tt.want {
+			t.Errorf("allowlistMatch(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestImportableEntry(t *testing.T) {
+	s := &Service{config: Config{IgnoreNetworks: []string{"192.0.2.0/24"}}}
+	allowlist := []*net.IPNet{parseNetwork("203.0.113.0/28")}
+	protected := []net.IP{net.ParseIP("198.51.100.1")}
+
+	tests := []struct {
+		entry string
+		want  string // normalized value, "" when skipped
+	}{
+		{" 198.18.0.9 ", "198.18.0.9"},
+		{"198.18.0.0/16", "198.18.0.0/16"},
+		{"", ""},
+		{"10.0.0.1", ""},        // private
+		{"garbage", ""},         // invalid
+		{"203.0.113.5", ""},     // allowlisted IP
+		{"203.0.113.0/24", ""},  // range covering the allowlist
+		{"192.0.2.10", ""},      // ignored network
+		{"198.51.100.1", ""},    // server IP
+		{"198.51.100.0/24", ""}, // range covering the server IP
+	}
+	for _, tt := range tests {
+		got, _, ok := s.importableEntry(tt.entry, allowlist, protected)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("importableEntry(%q) = %q, %v; want %q", tt.entry, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package firewall
+
+import (
+	"fmt"
+	"net/http"
+
+	"api/internal/router"
+)
+
+// heatmapMaxDays bounds the heatmap window
+const heatmapMaxDays = 365
+
+// AttemptHeatmap counts firewall attempts by day of week and hour of day.
+// Counts[d][h] is day d (0 = Sunday) at hour h.
+type AttemptHeatmap struct {
+	Days      int        `json:"days"`
+	TZOffset  int        `json:"tzOffset"` // minutes east of UTC the buckets were shifted by
+	Total     int        `json:"total"`
+	Max       int        `json:"max"` // largest cell, for scaling the colors
+	DayLabels []string   `json:"dayLabels"`
+	Counts    [7][24]int `json:"counts"`
+}
+
+var heatmapDayLabels = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// attemptHeatmap buckets the firewall log (logs_type 'fw') of the last days
+// days. Timestamps are stored in UTC; tzOffset shifts them to the operator's
+// local time before bucketing.
+func (s *Service) attemptHeatmap(days, tzOffset int) (AttemptHeatmap, error) {
+	hm := AttemptHeatmap{Days: days, TZOffset: tzOffset, DayLabels: heatmapDayLabels}
+	shift := fmt.Sprintf("%+d minutes", tzOffset)
+	rows, err := s.db.Query(`
+		SELECT CAST(strftime('%w', logs_timestamp, ?) AS INTEGER),
+		       CAST(strftime('%H', logs_timestamp, ?) AS INTEGER),
+		       COUNT(*)
+		FROM logs
+		WHERE logs_type = 'fw' AND logs_timestamp >= datetime('now', ?)
+		GROUP BY 1, 2`,
+		shift, shift, fmt.Sprintf("-%d days", days))
+	if err != nil {
+		return hm, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day, hour, count int
+		if rows.Scan(&day, &hour, &count) != nil || day < 0 || day > 6 || hour < 0 || hour > 23 {
+			continue
+		}
+		hm.Counts[day][hour] = count
+		hm.Total += count
+		if count > hm.Max {
+			hm.Max = count
+		}
+	}
+	return hm, rows.Err()
+}
+
+// handleGetAttemptHeatmap returns the 7x24 attempt grid.
+// ?days= sets the window (default 30), ?tzOffset= the UTC offset in minutes.
+func (s *Service) handleGetAttemptHeatmap(w http.ResponseWriter, r *http.Request) {
+	days := router.QueryParamInt(r, "days", 30)
+	if days < 1 || days > heatmapMaxDays {
+		router.JSONError(w, fmt.Sprintf("days must be between 1 and %d", heatmapMaxDays), http.StatusBadRequest)
+		return
+	}
+	tzOffset := router.QueryParamInt(r, "tzOffset", 0)
+	if tzOffset < -720 || tzOffset > 840 {
+		router.JSONError(w, "tzOffset must be between -720 and 840 minutes", http.StatusBadRequest)
+		return
+	}
+
+	hm, err := s.attemptHeatmap(days, tzOffset)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, hm)
+}
@@ -0,0 +1,57 @@
+package firewall
+
+import (
+	"errors"
+	"net/http"
+
+	"api/internal/router"
+)
+
+// BlockCategory is a predefined reason for a manual block
+type BlockCategory struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+}
+
+// BlockCategories are the categories selectable when blocking; the label is
+// the default reason when none is given
+var BlockCategories = []BlockCategory{
+	{ID: "brute-force", Label: "Brute force", Description: "Repeated login or authentication attempts"},
+	{ID: "scanning", Label: "Scanning", Description: "Port scans, vulnerability probes or path enumeration"},
+	{ID: "spam", Label: "Spam", Description: "Spam, comment flooding or form abuse"},
+	{ID: "abuse-report", Label: "Abuse report", Description: "Reported by a third party or an abuse feed"},
+	{ID: "manual-review", Label: "Manual review", Description: "Blocked pending investigation"},
+}
+
+// blockCategory returns the category with the given id
+func blockCategory(id string) (BlockCategory, bool) {
+	for _, c := range BlockCategories {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return BlockCategory{}, false
+}
+
+// categoryReason validates a block's category and defaults the reason to its label
+func categoryReason(category, reason string) (string, error) {
+	if category == "" {
+		return reason, nil
+	}
+	c, ok := blockCategory(category)
+	if !ok {
+		return reason, errors.New("invalid category: see GET /api/fw/block-categories")
+	}
+	if reason == "" {
+		reason = c.Label
+	}
+	return reason, nil
+}
+
+// handleGetBlockCategories lists the block categories
+func (s *Service) handleGetBlockCategories(w http.ResponseWriter, r *http.Request) {
+	router.JSON(w, map[string]interface{}{
+		"categories": BlockCategories,
+	})
+}
@@ -1,6 +1,7 @@
 package firewall
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"net"
@@ -8,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"api/internal/database"
 	"api/internal/logs/sources"
 	"api/internal/nftables"
 )
@@ -34,6 +36,11 @@ func (s *Service) blockIP(ip, jailName, reason string, banTime int) {
 
 // blockIPWithOptions blocks an IP with additional options
 func (s *Service) blockIPWithOptions(ip, jailName, reason string, banTime int, isRange bool, source string) {
+	if allowed := allowlistMatch(ip, s.allowlistNets()); allowed != nil {
+		log.Printf("Not blocking %s (jail: %s): allowlisted by %s", ip, jailName, allowed)
+		return
+	}
+
 	var expiresAt interface{}
 	if banTime > 0 {
 		expiresAt = time.Now().Add(time.Duration(banTime) * time.Second)
@@ -57,7 +64,20 @@ func (s *Service) blockIPWithOptions(ip, jailName, reason string, banTime int, i
 
 	if err == nil {
 		log.Printf("Blocked IP %s (jail: %s, reason: %s, isRange: %v)", ip, jailName, reason, isRange)
-		s.RequestApply()
+		if source == "jail:"+jailName {
+			s.db.Exec("UPDATE jails SET ban_count = COALESCE(ban_count, 0) + 1 WHERE name = ?", jailName)
+			s.runJailAction(jailName, ip, reason, banTime)
+		}
+
+		// The upsert keeps action/direction/enabled of an existing row, so sync what is stored
+		var action, direction string
+		var enabled bool
+		if err := s.db.QueryRow(`SELECT action, direction, enabled FROM firewall_entries
+			WHERE entry_type = ? AND value = ? AND protocol = 'both'`, entryType, ip).Scan(&action, &direction, &enabled); err != nil {
+			s.RequestApply()
+		} else if enabled && action == nftables.ActionBlock {
+			s.addBlockToSets(entryType, ip, direction)
+		}
 
 		// Send push notification for block (async)
 		go func() {
@@ -76,7 +96,132 @@ func (s *Service) blockIPWithOptions(ip, jailName, reason string, banTime int, i
 	}
 }
 
-// checkEscalation checks if we should escalate to blocking an entire /24 range
+// recordManualUnblocks credits each jail with its active bans among the entries
+// matched by idClause, before an operator deletes or disables them. Escalated
+// ranges count for the jail that triggered them.
+func (s *Service) recordManualUnblocks(idClause string, args ...interface{}) {
+	_, err := s.db.Exec(`UPDATE jails SET manual_unblocks = COALESCE(manual_unblocks, 0) + (
+		SELECT COUNT(*) FROM firewall_entries f
+		WHERE f.name = jails.name AND f.source IN ('jail:' || jails.name, 'escalated')
+		AND f.entry_type IN ('ip', 'range') AND f.action = 'block' AND f.enabled = 1
+		AND (f.expires_at IS NULL OR f.expires_at > datetime('now'))
+		AND f.id IN (`+idClause+`))`, args...)
+	if err != nil {
+		log.Printf("firewall: failed to record manual unblock: %v", err)
+	}
+}
+
+// blockSetNames returns the live sets holding an ip/range block value for a
+// direction, nil if the value fits none of them
+func blockSetNames(entryType, value, direction string) []string {
+	var ip net.IP
+	base := "blocked_ips"
+	switch entryType {
+	case nftables.EntryTypeIP:
+		ip = net.ParseIP(value)
+	case nftables.EntryTypeRange:
+		ip, _, _ = net.ParseCIDR(value)
+		base = "blocked_ranges"
+	}
+	if ip == nil {
+		return nil
+	}
+	if ip.To4() == nil {
+		base += "6"
+	}
+	switch direction {
+	case "", nftables.DirectionInbound:
+		return []string{base}
+	case nftables.DirectionOutbound:
+		return []string{base + "_out"}
+	case nftables.DirectionBoth:
+		return []string{base, base + "_out"}
+	}
+	return nil
+}
+
+// addBlockToSets adds a single ip/range block to the live sets without a full
+// apply. Anything the fast path can't handle falls back to RequestApply.
+func (s *Service) addBlockToSets(entryType, value, direction string) {
+	sets := blockSetNames(entryType, value, direction)
+	if s.nft == nil || len(sets) == 0 {
+		s.RequestApply()
+		return
+	}
+	for _, set := range sets {
+		if err := s.nft.AddSetElements("inet", "wgadmin_firewall", set, value); err != nil {
+			log.Printf("firewall: fast add of %s to %s failed, full apply: %v", value, set, err)
+			s.RequestApply()
+			return
+		}
+	}
+}
+
+// removeBlockFromSets removes a deleted ip/range block from the live sets,
+// keeping it in any set still required by another enabled block with the same value
+func (s *Service) removeBlockFromSets(entryType, value, direction string) {
+	sets := blockSetNames(entryType, value, direction)
+	if s.nft == nil || len(sets) == 0 {
+		s.RequestApply()
+		return
+	}
+
+	rows, err := s.db.Query(`SELECT direction FROM firewall_entries
+		WHERE entry_type = ? AND value = ? AND action = 'block' AND enabled = 1
+		AND (expires_at IS NULL OR expires_at > datetime('now'))`, entryType, value)
+	if err != nil {
+		s.RequestApply()
+		return
+	}
+	keep := make(map[string]bool)
+	for rows.Next() {
+		var dir string
+		if rows.Scan(&dir) == nil {
+			for _, set := range blockSetNames(entryType, value, dir) {
+				keep[set] = true
+			}
+		}
+	}
+	rows.Close()
+
+	for _, set := range sets {
+		if keep[set] {
+			continue
+		}
+		if err := s.nft.DeleteSetElements("inet", "wgadmin_firewall", set, value); err != nil {
+			log.Printf("firewall: fast delete of %s from %s failed, full apply: %v", value, set, err)
+			s.RequestApply()
+			return
+		}
+	}
+}
+
+// maxSetElementChanges caps element-level updates per batch; past this one
+// full apply is cheaper than an nft call per element
+const maxSetElementChanges = 200
+
+// setBlock is an ip/range block as it sits in the live sets
+type setBlock struct {
+	entryType, value, direction string
+}
+
+// removeBlocksFromSets removes deleted or disabled blocks from the live sets,
+// falling back to a full apply for large batches
+func (s *Service) removeBlocksFromSets(blocks []setBlock) {
+	if len(blocks) == 0 {
+		return
+	}
+	if len(blocks) > maxSetElementChanges {
+		s.RequestApply()
+		return
+	}
+	for _, b := range blocks {
+		s.removeBlockFromSets(b.entryType, b.value, b.direction)
+	}
+}
+
+// checkEscalation checks if we should escalate to blocking an entire /24 (IPv4)
+// or /64 (IPv6) range
 func (s *Service) checkEscalation(ip, jailName string, banTime int) {
 	// Get jail's escalation settings
 	var escalateEnabled bool
@@ -87,30 +232,55 @@ func (s *Service) checkEscalation(ip, jailName string, banTime int) {
 		return
 	}
 
-	// Get the /24 subnet for this IP
-	subnet := getSubnet24(ip)
+	subnet := getSubnetForEscalation(ip)
 	if subnet == "" {
 		return
 	}
+	_, network, _ := net.ParseCIDR(subnet)
+	if allowed := allowlistMatch(subnet, s.allowlistNets()); allowed != nil {
+		log.Printf("Not escalating %s (jail: %s): contains allowlisted %s", subnet, jailName, allowed)
+		return
+	}
 
-	// Count distinct IPs from this subnet blocked within the escalation window
-	var count int
-	err = s.db.QueryRow(`
-		SELECT COUNT(DISTINCT value) FROM firewall_entries
-		WHERE name = ?
-		AND entry_type = 'ip'
-		AND value LIKE ?
-		AND created_at > datetime('now', '-' || ? || ' seconds')
-	`, jailName, strings.TrimSuffix(subnet, ".0/24")+".%", escalateWindow).Scan(&count)
+	// Count distinct IPs from this subnet blocked within the escalation window.
+	// Matched in Go, since IPv6 text forms can't be prefix-matched in SQL.
+	rows, err := s.db.Query(`
+		SELECT id, value, direction, action = 'block' AND enabled = 1,
+			created_at > datetime('now', '-' || ? || ' seconds')
+		FROM firewall_entries
+		WHERE name = ? AND entry_type = 'ip'
+	`, escalateWindow, jailName)
 	if err != nil {
 		log.Printf("Error checking escalation: %v", err)
 		return
 	}
+	var covered []int64
+	var coveredBlocks []setBlock
+	recent := make(map[string]bool)
+	for rows.Next() {
+		var id int64
+		var value, direction string
+		var active, inWindow bool
+		if rows.Scan(&id, &value, &direction, &active, &inWindow) != nil {
+			continue
+		}
+		if parsed := net.ParseIP(value); parsed != nil && network.Contains(parsed) {
+			covered = append(covered, id)
+			if active {
+				coveredBlocks = append(coveredBlocks, setBlock{nftables.EntryTypeIP, value, direction})
+			}
+			if inWindow {
+				recent[parsed.String()] = true
+			}
+		}
+	}
+	rows.Close()
+	count := len(recent)
 
 	log.Printf("Escalation check for %s: %d IPs from %s (threshold: %d)", jailName, count, subnet, escalateThreshold)
 
 	if count >= escalateThreshold {
-		// Block the entire /24 range
+		// Block the entire range
 		log.Printf("Auto-escalating: blocking %s (jail: %s, IPs: %d)", subnet, jailName, count)
 
 		var expiresAt interface{}
@@ -119,7 +289,7 @@ func (s *Service) checkEscalation(ip, jailName string, banTime int) {
 		}
 
 		// Insert the range block
-		_, err := s.db.Exec(`
+		result, err := s.db.Exec(`
 			INSERT INTO firewall_entries (entry_type, value, action, direction, protocol, source, reason, name, expires_at, enabled, hit_count)
 			VALUES ('range', ?, 'block', 'inbound', 'both', 'escalated', ?, ?, ?, 1, ?)
 			ON CONFLICT(entry_type, value, protocol) DO NOTHING
@@ -131,41 +301,134 @@ func (s *Service) checkEscalation(ip, jailName string, banTime int) {
 			log.Printf("Error inserting escalated range: %v", err)
 			return
 		}
+		if inserted, _ := result.RowsAffected(); inserted > 0 {
+			s.addBlockToSets(nftables.EntryTypeRange, subnet, nftables.DirectionInbound)
+		}
 
 		// Remove individual IPs that are now covered by the range
-		result, err := s.db.Exec(`
-			DELETE FROM firewall_entries
-			WHERE name = ?
-			AND entry_type = 'ip'
-			AND value LIKE ?
-		`, jailName, strings.TrimSuffix(subnet, ".0/24")+".%")
-		if err == nil {
-			if deleted, _ := result.RowsAffected(); deleted > 0 {
-				log.Printf("Removed %d individual IPs now covered by range %s", deleted, subnet)
+		var deleted int64
+		for _, id := range covered {
+			if result, err := s.db.Exec("DELETE FROM firewall_entries WHERE id = ?", id); err == nil {
+				n, _ := result.RowsAffected()
+				deleted += n
 			}
 		}
+		if deleted > 0 {
+			log.Printf("Removed %d individual IPs now covered by range %s", deleted, subnet)
+		}
+		s.removeBlocksFromSets(coveredBlocks)
+	}
+}
 
-		s.RequestApply()
+// coversScope reports whether an entry with outer direction/protocol also applies to inner
+func coversScope(outerDir, outerProto, innerDir, innerProto string) bool {
+	return (outerDir == nftables.DirectionBoth || outerDir == innerDir) &&
+		(outerProto == nftables.ProtocolBoth || outerProto == innerProto)
+}
+
+// outlasts reports whether a block expiring at outer stays in place at least as
+// long as one expiring at inner (nil = permanent)
+func outlasts(outer, inner *time.Time) bool {
+	if outer == nil {
+		return true
 	}
+	return inner != nil && !outer.Before(*inner)
+}
+
+// findCoveringRange returns an enabled block range that already covers ip for the
+// given direction/protocol until expiresAt (nil = permanent), or "" if none does
+func (s *Service) findCoveringRange(ip, direction, protocol string, expiresAt *time.Time) string {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return ""
+	}
+
+	rows, err := s.db.Query(`SELECT value, direction, protocol, expires_at FROM firewall_entries
+		WHERE entry_type = 'range' AND action = 'block' AND enabled = 1`)
+	if err != nil {
+		return ""
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cidr, dir, proto string
+		var rangeExpires sql.NullTime
+		if rows.Scan(&cidr, &dir, &proto, &rangeExpires) != nil || !coversScope(dir, proto, direction, protocol) {
+			continue
+		}
+		if !outlasts(database.TimePointerFromNull(rangeExpires), expiresAt) {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(parsedIP) {
+			return cidr
+		}
+	}
+	return ""
+}
+
+// removeCoveredIPs deletes non-essential individual IP blocks made redundant by a
+// block range with the given direction/protocol expiring at expiresAt (nil =
+// permanent), returns the number removed. IPs blocked for longer are kept.
+func (s *Service) removeCoveredIPs(cidr, direction, protocol string, expiresAt *time.Time) int64 {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0
+	}
+
+	rows, err := s.db.Query(`SELECT id, value, direction, protocol, expires_at FROM firewall_entries
+		WHERE entry_type = 'ip' AND action = 'block' AND essential = 0`)
+	if err != nil {
+		return 0
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var value, dir, proto string
+		var ipExpires sql.NullTime
+		if rows.Scan(&id, &value, &dir, &proto, &ipExpires) != nil || !coversScope(direction, protocol, dir, proto) {
+			continue
+		}
+		if !outlasts(expiresAt, database.TimePointerFromNull(ipExpires)) {
+			continue
+		}
+		if ip := net.ParseIP(value); ip != nil && network.Contains(ip) {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	var removed int64
+	for _, id := range ids {
+		if result, err := s.db.Exec("DELETE FROM firewall_entries WHERE id = ?", id); err == nil {
+			n, _ := result.RowsAffected()
+			removed += n
+		}
+	}
+	if removed > 0 {
+		log.Printf("Removed %d individual IPs now covered by range %s", removed, cidr)
+	}
+	return removed
 }
 
 // isIPBlocked checks if an IP is currently blocked (uses cache for performance)
 func (s *Service) isIPBlocked(ip string) bool {
 	s.refreshBlockCacheIfNeeded()
 
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+
 	s.blockCache.mu.RLock()
 	defer s.blockCache.mu.RUnlock()
 
-	// Check direct IP match
-	if s.blockCache.blockedIPs[ip] {
+	// Check direct IP match on the normalized form, so IPv6 spellings and
+	// IPv4-mapped addresses hit the same key as the stored entry
+	if s.blockCache.blockedIPs[parsedIP.String()] {
 		return true
 	}
 
-	// Check CIDR ranges
-	parsedIP := net.ParseIP(ip)
-	if parsedIP == nil {
-		return false
-	}
+	// Check CIDR ranges (net.IPNet.Contains handles both families)
 
 	for _, network := range s.blockCache.ranges {
 		if network.Contains(parsedIP) {
@@ -205,6 +468,9 @@ func (s *Service) refreshBlockCacheIfNeeded() {
 		for rows.Next() {
 			var ip string
 			if rows.Scan(&ip) == nil {
+				if parsed := net.ParseIP(ip); parsed != nil {
+					ip = parsed.String()
+				}
 				blockedIPs[ip] = true
 			}
 		}
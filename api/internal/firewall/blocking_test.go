@@ -0,0 +1,29 @@
+package firewall
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutlasts(t *testing.T) {
+	now := time.Now()
+	earlier, later := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name         string
+		outer, inner *time.Time
+		want         bool
+	}{
+		{"permanent outlasts permanent", nil, nil, true},
+		{"permanent outlasts temporary", nil, &now, true},
+		{"temporary does not outlast permanent", &now, nil, false},
+		{"later expiry outlasts earlier", &later, &earlier, true},
+		{"same expiry outlasts", &now, &now, true},
+		{"earlier expiry does not outlast later", &earlier, &later, false},
+	}
+	for _, tt := range tests {
+		if got := outlasts(tt.outer, tt.inner); got != tt.want {
+			t.Errorf("%s: outlasts = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"api/internal/helper"
+	"api/internal/nftables"
+	"api/internal/router"
+)
+
+// maxBulkBlockEntries bounds a single pasted list; larger lists belong in a blocklist import
+const maxBulkBlockEntries = 10000
+
+// validBlockSource matches the source names stored with entries (manual, feed:abuse, ...)
+var validBlockSource = regexp.MustCompile(`^[a-z0-9][a-z0-9:_.-]{0,31}$`)
+
+// BulkBlockResult reports what happened to one pasted entry
+type BulkBlockResult struct {
+	Input  string `json:"input"`
+	Value  string `json:"value,omitempty"`  // normalized IP or CIDR
+	Status string `json:"status"`           // added, skipped or invalid
+	Reason string `json:"reason,omitempty"` // why it was skipped or invalid
+}
+
+// parseBulkList splits pasted text on newlines, commas and whitespace,
+// dropping "#" comments
+func parseBulkList(text string) []string {
+	var entries []string
+	for _, line := range strings.Split(text, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		entries = append(entries, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\r'
+		})...)
+	}
+	return entries
+}
+
+// protectedIPs returns the addresses a block must never cover: the server's
+// public IP and, when r is set, the requester's IP
+func protectedIPs(r *http.Request) []net.IP {
+	candidates := []string{helper.ServerIP()}
+	if r != nil {
+		candidates = append(candidates, helper.GetClientIP(r))
+	}
+	var protected []net.IP
+	for _, ip := range candidates {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			protected = append(protected, parsed)
+		}
+	}
+	return protected
+}
+
+// bulkBlockSkipReason returns why a normalized value must not be blocked, or ""
+func (s *Service) bulkBlockSkipReason(value string, isRange bool, protected []net.IP) string {
+	if isPrivateRange(value) {
+		return "private or loopback"
+	}
+	if !isRange && s.isIgnoredIP(value) {
+		return "ignored network"
+	}
+	n := parseNetwork(value)
+	if n == nil {
+		return "invalid address"
+	}
+	for _, ip := range protected {
+		if n.Contains(ip) {
+			if isRange {
+				return fmt.Sprintf("range contains protected address %s", ip)
+			}
+			return "protected address"
+		}
+	}
+	return ""
+}
+
+// handleBulkBlock blocks a pasted list of IPs/CIDRs in one transaction and
+// applies rules once. Accepts {"ips": [...]} and/or {"text": "..."} with an
+// optional source (default manual), category, reason and banTime (seconds, 0 = permanent).
+// Existing entries are left untouched and reported as skipped.
+func (s *Service) handleBulkBlock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IPs      []string `json:"ips"`
+		Text     string   `json:"text"`
+		Source   string   `json:"source"`
+		Category string   `json:"category"`
+		Reason   string   `json:"reason"`
+		BanTime  int      `json:"banTime"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	inputs := append(req.IPs, parseBulkList(req.Text)...)
+	if len(inputs) == 0 {
+		router.JSONError(w, "ips or text is required", http.StatusBadRequest)
+		return
+	}
+	if len(inputs) > maxBulkBlockEntries {
+		router.JSONError(w, fmt.Sprintf("too many entries (max %d), use a blocklist import instead", maxBulkBlockEntries), http.StatusBadRequest)
+		return
+	}
+	if req.Source == "" {
+		req.Source = "manual"
+	}
+	req.Source = strings.ToLower(strings.TrimSpace(req.Source))
+	if !validBlockSource.MatchString(req.Source) {
+		router.JSONError(w, "invalid source: use lowercase letters, digits and :_.-", http.StatusBadRequest)
+		return
+	}
+	if req.BanTime < 0 {
+		router.JSONError(w, "banTime must not be negative", http.StatusBadRequest)
+		return
+	}
+	reason, err := categoryReason(req.Category, req.Reason)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Reason = reason
+	if req.Reason == "" {
+		req.Reason = "Bulk block"
+	}
+
+	// Never lock out the server or the operator doing the paste
+	protected := protectedIPs(r)
+
+	var expiresAt interface{}
+	if req.BanTime > 0 {
+		expiresAt = time.Now().Add(time.Duration(req.BanTime) * time.Second)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO firewall_entries
+		(entry_type, value, action, direction, protocol, source, reason, category, expires_at, enabled)
+		VALUES (?, ?, 'block', 'inbound', 'both', ?, ?, ?, ?, 1)`)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer stmt.Close()
+
+	results := make([]BulkBlockResult, 0, len(inputs))
+	seen := make(map[string]bool)
+	counts := map[string]int{"added": 0, "skipped": 0, "invalid": 0}
+	for _, input := range inputs {
+		res := BulkBlockResult{Input: strings.TrimSpace(input)}
+		value, isRange, err := validateIPOrCIDR(input)
+		switch {
+		case err != nil:
+			res.Status, res.Reason = "invalid", err.Error()
+		case seen[value]:
+			res.Value, res.Status, res.Reason = value, "skipped", "duplicate in list"
+		default:
+			seen[value] = true
+			res.Value = value
+			if reason := s.bulkBlockSkipReason(value, isRange, protected); reason != "" {
+				res.Status, res.Reason = "skipped", reason
+				break
+			}
+			entryType := nftables.EntryTypeIP
+			if isRange {
+				entryType = nftables.EntryTypeRange
+			}
+			result, err := stmt.Exec(entryType, value, req.Source, req.Reason, req.Category, expiresAt)
+			if err != nil {
+				router.JSONError(w, "failed to block "+value+": "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if n, _ := result.RowsAffected(); n == 0 {
+				res.Status, res.Reason = "skipped", "already exists"
+				break
+			}
+			res.Status = "added"
+		}
+		counts[res.Status]++
+		results = append(results, res)
+	}
+
+	if err := tx.Commit(); err != nil {
+		router.JSONError(w, "failed to save entries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if counts["added"] > 0 {
+		s.RequestApply()
+	}
+
+	resp := map[string]interface{}{
+		"added":   counts["added"],
+		"skipped": counts["skipped"],
+		"invalid": counts["invalid"],
+		"results": results,
+	}
+	if warning := s.enforcementWarning(); warning != "" {
+		resp["warning"] = warning
+	}
+	router.JSON(w, resp)
+}
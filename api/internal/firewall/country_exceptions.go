@@ -0,0 +1,169 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"api/internal/router"
+)
+
+// CountryException is a range inside a blocked country that stays reachable
+type CountryException struct {
+	ID          int64  `json:"id"`
+	CountryCode string `json:"countryCode"`
+	CIDR        string `json:"cidr"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// countryExceptionNets returns the exception networks of a country
+func (s *Service) countryExceptionNets(code string) []*net.IPNet {
+	rows, err := s.db.Query("SELECT cidr FROM country_exceptions WHERE country_code = ?", code)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var nets []*net.IPNet
+	for rows.Next() {
+		var cidr string
+		if rows.Scan(&cidr) == nil {
+			if n := parseNetwork(cidr); n != nil {
+				nets = append(nets, n)
+			}
+		}
+	}
+	return nets
+}
+
+// handleGetCountryExceptions lists exceptions, optionally for one ?country=
+func (s *Service) handleGetCountryExceptions(w http.ResponseWriter, r *http.Request) {
+	query := `SELECT id, country_code, cidr, COALESCE(description, ''), created_at FROM country_exceptions`
+	var args []interface{}
+	if code := strings.ToUpper(router.QueryParam(r, "country", "")); code != "" {
+		query += " WHERE country_code = ?"
+		args = append(args, code)
+	}
+	rows, err := s.db.Query(query+" ORDER BY country_code, cidr", args...)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	exceptions := []CountryException{}
+	for rows.Next() {
+		var e CountryException
+		if rows.Scan(&e.ID, &e.CountryCode, &e.CIDR, &e.Description, &e.CreatedAt) == nil {
+			exceptions = append(exceptions, e)
+		}
+	}
+	router.JSON(w, map[string]interface{}{
+		"exceptions": exceptions,
+		"count":      len(exceptions),
+	})
+}
+
+// handleAddCountryException exempts an IPv4 range from a country block.
+// Exceptions must not overlap each other, since they share one interval set.
+func (s *Service) handleAddCountryException(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Country     string `json:"country"`
+		CIDR        string `json:"cidr"`
+		Description string `json:"description"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Country))
+	if len(code) != 2 {
+		router.JSONError(w, "country code must be 2 letters (ISO 3166-1 alpha-2)", http.StatusBadRequest)
+		return
+	}
+	var blocked int
+	s.db.QueryRow("SELECT COUNT(*) FROM firewall_entries WHERE entry_type = 'country' AND value = ? AND action = 'block'",
+		code).Scan(&blocked)
+	if blocked == 0 {
+		router.JSONError(w, code+" is not blocked", http.StatusBadRequest)
+		return
+	}
+
+	value, _, err := validateIPOrCIDR(req.CIDR)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n := parseNetwork(value)
+	if n == nil || n.IP.To4() == nil {
+		router.JSONError(w, "only IPv4 exceptions are supported (country sets are IPv4)", http.StatusBadRequest)
+		return
+	}
+
+	// Overlapping elements would make the interval set fail to load
+	rows, err := s.db.Query("SELECT country_code, cidr FROM country_exceptions")
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for rows.Next() {
+		var otherCode, otherCIDR string
+		if rows.Scan(&otherCode, &otherCIDR) != nil {
+			continue
+		}
+		if other := parseNetwork(otherCIDR); other != nil && (other.Contains(n.IP) || n.Contains(other.IP)) {
+			rows.Close()
+			router.JSONError(w, fmt.Sprintf("%s overlaps the existing %s exception %s", value, otherCode, otherCIDR), http.StatusConflict)
+			return
+		}
+	}
+	rows.Close()
+
+	result, err := s.db.Exec("INSERT INTO country_exceptions (country_code, cidr, description) VALUES (?, ?, ?)",
+		code, value, strings.TrimSpace(req.Description))
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	id, _ := result.LastInsertId()
+	s.RequestApply()
+
+	// An exception outside the country's zones is harmless but does nothing
+	resp := map[string]interface{}{"id": id, "country": code, "cidr": value}
+	if s.geo != nil {
+		if cidrs, err := s.geo.CountryCIDRs(code); err == nil && len(cidrs) > 0 {
+			inside := false
+			for _, cidr := range cidrs {
+				if zone := parseNetwork(cidr); zone != nil && (zone.Contains(n.IP) || n.Contains(zone.IP)) {
+					inside = true
+					break
+				}
+			}
+			if !inside {
+				resp["warning"] = fmt.Sprintf("%s is not inside any %s zone, so the exception has no effect", value, code)
+			}
+		}
+	}
+	router.JSON(w, resp)
+}
+
+// handleDeleteCountryException removes an exception, blocking its range again
+func (s *Service) handleDeleteCountryException(w http.ResponseWriter, r *http.Request) {
+	idStr := router.ExtractPathParam(r, "/api/fw/country-exceptions/")
+	id, ok := router.ParseIDOrError(w, idStr)
+	if !ok {
+		return
+	}
+	result, err := s.db.Exec("DELETE FROM country_exceptions WHERE id = ?", id)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		router.JSONError(w, "exception not found", http.StatusNotFound)
+		return
+	}
+	s.RequestApply()
+	router.JSON(w, map[string]interface{}{"status": "deleted", "id": id})
+}
@@ -0,0 +1,89 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"api/internal/helper"
+	"api/internal/nftables"
+)
+
+// SelfBlockWarning is a protected address that a country block would drop
+type SelfBlockWarning struct {
+	IP      string `json:"ip"`
+	Role    string `json:"role"`  // "server" or "requester"
+	Range   string `json:"range"` // zone range containing the IP
+	Message string `json:"message"`
+}
+
+// countrySelfBlockWarnings reports whether blocking a country inbound would drop
+// the server's public IP or the requester's IP. Addresses already covered by an
+// inbound allow entry or a country exception are skipped, since both bypass
+// country drops.
+// Returns an error when the zones can't be loaded to check.
+func (s *Service) countrySelfBlockWarnings(code, direction string, r *http.Request) ([]SelfBlockWarning, error) {
+	if direction == nftables.DirectionOutbound {
+		return nil, nil
+	}
+
+	candidates := []struct{ ip, role string }{
+		{helper.ServerIP(), "server"},
+		{helper.GetClientIP(r), "requester"},
+	}
+	var protected []struct{ ip, role string }
+	for _, c := range candidates {
+		if c.ip != "" && net.ParseIP(c.ip) != nil && !isPrivateRange(c.ip) {
+			protected = append(protected, c)
+		}
+	}
+	if len(protected) == 0 {
+		return nil, nil
+	}
+
+	if s.geo == nil {
+		return nil, fmt.Errorf("geolocation service not available")
+	}
+	cidrs, err := s.geo.CountryCIDRs(code)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowNets []*net.IPNet
+	rows, err := s.db.Query(`SELECT value FROM firewall_entries
+		WHERE action = 'allow' AND entry_type IN ('ip', 'range') AND enabled = 1
+		AND direction IN ('inbound', 'both')`)
+	if err == nil {
+		for rows.Next() {
+			var value string
+			if rows.Scan(&value) == nil {
+				if n := parseNetwork(value); n != nil {
+					allowNets = append(allowNets, n)
+				}
+			}
+		}
+		rows.Close()
+	}
+	allowNets = append(allowNets, s.countryExceptionNets(code)...)
+
+	var warnings []SelfBlockWarning
+	for _, p := range protected {
+		ipNet := parseNetwork(p.ip)
+		if ipNet == nil || networkCovered(ipNet, allowNets) {
+			continue
+		}
+		for _, cidr := range cidrs {
+			if n := parseNetwork(cidr); n != nil && n.Contains(ipNet.IP) {
+				warnings = append(warnings, SelfBlockWarning{
+					IP:    p.ip,
+					Role:  p.role,
+					Range: cidr,
+					Message: fmt.Sprintf("%s IP %s is in %s (%s); blocking %s inbound would drop its new connections. Add an allow entry for it first.",
+						p.role, p.ip, code, cidr, code),
+				})
+				break
+			}
+		}
+	}
+	return warnings, nil
+}
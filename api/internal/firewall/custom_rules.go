@@ -0,0 +1,83 @@
+package firewall
+
+import (
+	"net/http"
+	"strings"
+
+	"api/internal/nftables"
+	"api/internal/router"
+	"api/internal/settings"
+)
+
+// handleGetCustomRules returns the operator's custom nftables rules
+func (s *Service) handleGetCustomRules(w http.ResponseWriter, r *http.Request) {
+	router.JSON(w, nftables.LoadCustomRules(s.db))
+}
+
+// checkCustomRules builds and syntax-checks a snippet, returning the error message if invalid
+func (s *Service) checkCustomRules(c nftables.CustomRules) string {
+	script, err := nftables.BuildCustomRulesScript(c)
+	if err != nil {
+		return err.Error()
+	}
+	if s.nft == nil {
+		return "nftables service not available"
+	}
+	// Declare-then-delete so the check runs against a clean table, like the real apply
+	if err := s.nft.CheckScript("table inet wgadmin_custom\ndelete table inet wgadmin_custom\n" + script); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func decodeCustomRules(w http.ResponseWriter, r *http.Request) (nftables.CustomRules, bool) {
+	var c nftables.CustomRules
+	if !router.DecodeJSONOrError(w, r, &c) {
+		return c, false
+	}
+	c.Hook = strings.ToLower(strings.TrimSpace(c.Hook))
+	if c.Hook == "" {
+		c.Hook = "input"
+	}
+	return c, true
+}
+
+// handleValidateCustomRules checks a snippet with nft check mode without saving it
+func (s *Service) handleValidateCustomRules(w http.ResponseWriter, r *http.Request) {
+	c, ok := decodeCustomRules(w, r)
+	if !ok {
+		return
+	}
+
+	if msg := s.checkCustomRules(c); msg != "" {
+		router.JSON(w, map[string]interface{}{"valid": false, "error": msg})
+		return
+	}
+	router.JSON(w, map[string]interface{}{"valid": true})
+}
+
+// handleSetCustomRules validates, stores and applies the custom rules.
+// Snippets failing the check are rejected so they can't break the ruleset.
+func (s *Service) handleSetCustomRules(w http.ResponseWriter, r *http.Request) {
+	c, ok := decodeCustomRules(w, r)
+	if !ok {
+		return
+	}
+
+	if msg := s.checkCustomRules(c); msg != "" {
+		router.JSONError(w, "invalid rules: "+msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := settings.SetSetting(nftables.CustomRulesSetting, c.Rules); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := settings.SetSetting(nftables.CustomHookSetting, c.Hook); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.RequestApply()
+	router.JSON(w, map[string]interface{}{"status": "saved", "hook": c.Hook, "rules": c.Rules})
+}
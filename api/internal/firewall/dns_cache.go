@@ -3,9 +3,11 @@ package firewall
 import (
 	"container/list"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Default cache parameters, overridable at runtime via SetDNSConfig
 const (
 	dnsCacheMaxSize = 10000
 	dnsCacheTTL     = 1 * time.Hour
@@ -20,11 +22,25 @@ type dnsEntry struct {
 
 // lruDNSCache is an LRU cache for reverse DNS lookups (IP → domain)
 type lruDNSCache struct {
-	items   map[string]*list.Element
-	order   *list.List
-	maxSize int
-	ttl     time.Duration
-	mu      sync.RWMutex
+	items     map[string]*list.Element
+	order     *list.List
+	maxSize   int
+	ttl       time.Duration
+	mu        sync.RWMutex
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// DNSCacheStats reports the reverse DNS cache fill and effectiveness.
+// Evictions growing along with misses means the cache is too small.
+type DNSCacheStats struct {
+	Entries   int     `json:"entries"`
+	MaxSize   int     `json:"maxSize"`
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"` // LRU entries dropped to make room
+	HitRate   float64 `json:"hitRate"`   // hits / lookups, 0 before the first lookup
 }
 
 func newLRUDNSCache(maxSize int, ttl time.Duration) *lruDNSCache {
@@ -41,14 +57,17 @@ func (c *lruDNSCache) get(ip string) (string, bool) {
 	elem, exists := c.items[ip]
 	if !exists {
 		c.mu.RUnlock()
+		c.misses.Add(1)
 		return "", false
 	}
 	entry := elem.Value.(*dnsEntry)
 	if time.Since(entry.timestamp) >= c.ttl {
 		c.mu.RUnlock()
+		c.misses.Add(1)
 		return "", false
 	}
 	c.mu.RUnlock()
+	c.hits.Add(1)
 
 	// Move to front (most recently used)
 	c.mu.Lock()
@@ -78,6 +97,7 @@ func (c *lruDNSCache) set(ip, domain string) {
 			entry := oldest.Value.(*dnsEntry)
 			delete(c.items, entry.ip)
 			c.order.Remove(oldest)
+			c.evictions.Add(1)
 		}
 	}
 
@@ -86,3 +106,19 @@ func (c *lruDNSCache) set(ip, domain string) {
 	elem := c.order.PushFront(entry)
 	c.items[ip] = elem
 }
+
+func (c *lruDNSCache) stats() DNSCacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stats := DNSCacheStats{
+		Entries:   c.order.Len(),
+		MaxSize:   c.maxSize,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
@@ -0,0 +1,111 @@
+package firewall
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"api/internal/router"
+	"api/internal/settings"
+)
+
+// dnsConfigSetting stores the reverse DNS tuning as JSON
+const dnsConfigSetting = "firewall_dns_config"
+
+// DNSConfig tunes the reverse DNS lookups used to annotate traffic and blocks
+type DNSConfig struct {
+	LookupTimeoutSec int `json:"lookupTimeoutSec"`
+	CacheSize        int `json:"cacheSize"`
+	CacheTTLMinutes  int `json:"cacheTtlMinutes"`
+}
+
+// validate checks the config against sane bounds
+func (c DNSConfig) validate() string {
+	switch {
+	case c.LookupTimeoutSec < 1 || c.LookupTimeoutSec > 30:
+		return "lookupTimeoutSec must be between 1 and 30"
+	case c.CacheSize < 100 || c.CacheSize > 1000000:
+		return "cacheSize must be between 100 and 1000000"
+	case c.CacheTTLMinutes < 1 || c.CacheTTLMinutes > 10080:
+		return "cacheTtlMinutes must be between 1 and 10080 (7 days)"
+	}
+	return ""
+}
+
+// loadDNSConfig returns the saved config, falling back to the app config
+// timeout and the package cache defaults
+func loadDNSConfig(defaultTimeout int) DNSConfig {
+	cfg := DNSConfig{
+		LookupTimeoutSec: defaultTimeout,
+		CacheSize:        dnsCacheMaxSize,
+		CacheTTLMinutes:  int(dnsCacheTTL / time.Minute),
+	}
+	raw, err := settings.GetSetting(dnsConfigSetting)
+	if err != nil || raw == "" {
+		return cfg
+	}
+	saved := cfg
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil || saved.validate() != "" {
+		log.Printf("firewall: invalid %s setting (using defaults)", dnsConfigSetting)
+		return cfg
+	}
+	return saved
+}
+
+// currentDNSConfig reads the live timeout and cache parameters
+func (s *Service) currentDNSConfig() (DNSConfig, DNSCacheStats) {
+	s.dnsMutex.RLock()
+	defer s.dnsMutex.RUnlock()
+	return DNSConfig{
+		LookupTimeoutSec: s.dnsTimeout,
+		CacheSize:        s.dnsCache.maxSize,
+		CacheTTLMinutes:  int(s.dnsCache.ttl / time.Minute),
+	}, s.dnsCache.stats()
+}
+
+// applyDNSConfig sets the lookup timeout and, when the size or TTL changed,
+// replaces the cache (dropping its entries and stats)
+func (s *Service) applyDNSConfig(cfg DNSConfig) {
+	ttl := time.Duration(cfg.CacheTTLMinutes) * time.Minute
+	s.dnsMutex.Lock()
+	defer s.dnsMutex.Unlock()
+	s.dnsTimeout = cfg.LookupTimeoutSec
+	if s.dnsCache == nil || s.dnsCache.maxSize != cfg.CacheSize || s.dnsCache.ttl != ttl {
+		s.dnsCache = newLRUDNSCache(cfg.CacheSize, ttl)
+	}
+}
+
+// handleGetDNSConfig returns the reverse DNS config and cache stats
+func (s *Service) handleGetDNSConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, stats := s.currentDNSConfig()
+	router.JSON(w, map[string]interface{}{
+		"config": cfg,
+		"cache":  stats,
+	})
+}
+
+// handleGetDNSCacheStats returns the cache counters on their own, for polling
+func (s *Service) handleGetDNSCacheStats(w http.ResponseWriter, r *http.Request) {
+	_, stats := s.currentDNSConfig()
+	router.JSON(w, stats)
+}
+
+// handleSetDNSConfig saves and applies the reverse DNS config
+func (s *Service) handleSetDNSConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, _ := s.currentDNSConfig()
+	if !router.DecodeJSONOrError(w, r, &cfg) {
+		return
+	}
+	if msg := cfg.validate(); msg != "" {
+		router.JSONError(w, msg, http.StatusBadRequest)
+		return
+	}
+	data, _ := json.Marshal(cfg)
+	if err := settings.SetSetting(dnsConfigSetting, string(data)); err != nil {
+		router.JSONError(w, "failed to save DNS config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.applyDNSConfig(cfg)
+	s.handleGetDNSConfig(w, r)
+}
@@ -0,0 +1,137 @@
+package firewall
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"api/internal/helper"
+)
+
+// dockerEventDebounce groups bursts of container events (compose up/down)
+// into a single port sync
+const dockerEventDebounce = 5 * time.Second
+
+// Reconnect backoff for the Docker event stream
+const (
+	dockerEventBackoffMin = time.Second
+	dockerEventBackoffMax = time.Minute
+)
+
+// dockerEventsEnabled reports whether container start/stop should re-sync
+// Docker ports. Opt-in via DOCKER_EVENT_SYNC=true, and off whenever
+// AUTO_DISCOVER_DOCKER_PORTS=false disables discovery altogether.
+func dockerEventsEnabled() bool {
+	if strings.EqualFold(os.Getenv("AUTO_DISCOVER_DOCKER_PORTS"), "false") {
+		return false
+	}
+	return strings.EqualFold(os.Getenv("DOCKER_EVENT_SYNC"), "true")
+}
+
+// watchDockerEvents follows the Docker event stream until the service stops,
+// reconnecting with exponential backoff when the stream drops
+func (s *Service) watchDockerEvents() {
+	var (
+		mu    sync.Mutex
+		timer *time.Timer
+	)
+	trigger := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(dockerEventDebounce, s.syncDockerPortsAndApply)
+	}
+
+	backoff := dockerEventBackoffMin
+	for {
+		connected, err := s.streamDockerEvents(trigger)
+		if s.ctx.Err() != nil {
+			return
+		}
+		if connected {
+			// The stream worked for a while; start over with a short wait, and
+			// resync in case containers changed while disconnected
+			backoff = dockerEventBackoffMin
+			trigger()
+		}
+		log.Printf("firewall: Docker event stream lost (%v), reconnecting in %s", err, backoff)
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > dockerEventBackoffMax {
+			backoff = dockerEventBackoffMax
+		}
+	}
+}
+
+// streamDockerEvents reads container start/die events and calls onEvent for
+// each. connected is true once the stream was opened, so the caller can tell
+// a dropped stream from a Docker API that can't be reached at all.
+func (s *Service) streamDockerEvents(onEvent func()) (connected bool, err error) {
+	filters := url.QueryEscape(`{"type":["container"],"event":["start","die"]}`)
+	req, err := http.NewRequestWithContext(s.ctx, "GET", "http://docker/v1.44/events?filters="+filters, nil)
+	if err != nil {
+		return false, err
+	}
+
+	// No client timeout: the stream stays open for as long as Docker runs
+	resp, err := helper.NewDockerHTTPClientWithTimeout(0).Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var ev struct {
+			Action string
+		}
+		if json.Unmarshal(scanner.Bytes(), &ev) == nil && ev.Action != "" {
+			onEvent()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+	return true, fmt.Errorf("stream closed")
+}
+
+// syncDockerPortsAndApply re-syncs published container ports and queues an
+// apply only when the docker-source rows actually changed
+func (s *Service) syncDockerPortsAndApply() {
+	if s.ctx.Err() != nil {
+		return
+	}
+	before := s.dockerPortRows()
+	if _, err := s.SyncDockerPortsToDB(); err != nil {
+		log.Printf("firewall: Docker event port sync failed: %v", err)
+		return
+	}
+	if s.dockerPortRows() != before {
+		log.Printf("firewall: Docker container ports changed, re-applying rules")
+		s.RequestApply()
+	}
+}
+
+// dockerPortRows returns the docker-source port rows as a comparable string
+func (s *Service) dockerPortRows() string {
+	var rows string
+	s.db.QueryRow(`SELECT COALESCE(group_concat(value || '/' || protocol, ','), '') FROM
+		(SELECT value, protocol FROM firewall_entries WHERE entry_type = 'port' AND source = 'docker' ORDER BY value, protocol)`).Scan(&rows)
+	return rows
+}
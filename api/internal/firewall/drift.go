@@ -0,0 +1,167 @@
+package firewall
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"api/internal/helper"
+	"api/internal/nftables"
+	"api/internal/router"
+	"api/internal/scheduler"
+	"api/internal/ws"
+)
+
+// driftCheckJob is the scheduler job name for the nftables drift check
+const driftCheckJob = "firewall-drift-check"
+
+// driftAlertState avoids re-alerting on every check while the drift persists
+type driftAlertState struct {
+	mu      sync.Mutex
+	alerted bool
+}
+
+// checkDrift compares the live ruleset with the last apply and alerts once
+// when it starts differing (log, WebSocket and FIREWALL_DRIFT_WEBHOOK_URL)
+func (s *Service) checkDrift() nftables.DriftStatus {
+	status := s.nft.CheckDrift()
+	if status.Skipped != "" {
+		return status
+	}
+
+	s.drift.mu.Lock()
+	wasAlerted := s.drift.alerted
+	s.drift.alerted = status.Drifted
+	s.drift.mu.Unlock()
+
+	if !status.Drifted {
+		if wasAlerted {
+			log.Printf("nftables drift resolved")
+		}
+		return status
+	}
+	if wasAlerted {
+		return status
+	}
+
+	message := "nftables ruleset changed outside the panel: " + driftSummary(status)
+	log.Printf("Warning: %s", message)
+
+	ws.Broadcast("general_info", map[string]interface{}{
+		"event":   "firewall:drift",
+		"status":  "warning",
+		"message": message,
+		"data":    status,
+	})
+
+	if url := helper.GetEnvOptional("FIREWALL_DRIFT_WEBHOOK_URL", ""); url != "" {
+		if err := sendDriftWebhook(url, message, status); err != nil {
+			log.Printf("Warning: drift webhook failed: %v", err)
+		}
+	}
+	return status
+}
+
+// driftSummary lists the drifted tables, e.g. "inet firewall (changed)"
+func driftSummary(status nftables.DriftStatus) string {
+	var changed []string
+	for _, t := range status.Tables {
+		if t.Drifted {
+			changed = append(changed, fmt.Sprintf("%s %s (%s)", t.Family, t.Name, t.Reason))
+		}
+	}
+	return strings.Join(changed, ", ")
+}
+
+// reconcile regenerates every table from the DB and re-applies it, logging
+// the tables whose live ruleset had diverged
+func (s *Service) reconcile() (nftables.DriftStatus, error) {
+	if s.nft == nil {
+		return nftables.DriftStatus{}, fmt.Errorf("nftables service not initialized")
+	}
+	status, err := s.nft.Reconcile()
+	if err != nil {
+		return status, err
+	}
+	if status.Drifted {
+		log.Printf("nftables reconcile: corrected drift in %s", driftSummary(status))
+	}
+
+	// The ruleset matches the DB again, so the next drift may alert
+	s.drift.mu.Lock()
+	s.drift.alerted = false
+	s.drift.mu.Unlock()
+	return status, nil
+}
+
+// handleReconcileFirewall re-applies the firewall from the DB and reports
+// whether the live ruleset was out of sync
+func (s *Service) handleReconcileFirewall(w http.ResponseWriter, r *http.Request) {
+	status, err := s.reconcile()
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, nftables.ErrUnavailable) {
+			code = http.StatusServiceUnavailable
+		}
+		router.JSONError(w, "reconcile failed: "+err.Error(), code)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"outOfSync": status.Drifted,
+		"drift":     status,
+	})
+}
+
+// sendDriftWebhook POSTs the drift status; "text" carries a one-line summary for chat webhooks
+func sendDriftWebhook(url, message string, status nftables.DriftStatus) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"text":  message,
+		"drift": status,
+	})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: helper.HTTPClientTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// scheduleDriftCheck registers the periodic drift check
+func (s *Service) scheduleDriftCheck(interval time.Duration) {
+	scheduler.Every(driftCheckJob, "Detect out-of-band nftables changes", interval, func(ctx context.Context) error {
+		s.checkDrift()
+		return nil
+	})
+}
+
+// handleGetDriftStatus returns the last drift check, or runs one with ?refresh=true
+func (s *Service) handleGetDriftStatus(w http.ResponseWriter, r *http.Request) {
+	if s.nft == nil {
+		router.JSONError(w, "nftables service not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.URL.Query().Get("refresh") != "true" {
+		if last := s.nft.LastDrift(); last != nil {
+			router.JSON(w, last)
+			return
+		}
+	}
+	router.JSON(w, s.checkDrift())
+}
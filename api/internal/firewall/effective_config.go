@@ -0,0 +1,47 @@
+package firewall
+
+import (
+	"api/internal/config"
+	"api/internal/settings"
+)
+
+// effectiveConfig reports the firewall's runtime config and the source of
+// each value, for the settings GetEffectiveConfig endpoint
+func (s *Service) effectiveConfig() []settings.EffectiveValue {
+	var file config.FirewallAppConfig
+	if app := config.GetApp(); app != nil {
+		file = app.Firewall
+	}
+	eff := config.GetFirewallConfig()
+	dns, _ := s.currentDNSConfig()
+
+	// The DNS timeout comes from the config file unless SetDNSConfig saved one
+	dnsTimeout := settings.StoredValue("dnsLookupTimeoutSec", dnsConfigSetting, dns.LookupTimeoutSec)
+	if dnsTimeout.Source == settings.SourceDefault {
+		dnsTimeout = settings.ConfigIntValue("dnsLookupTimeoutSec", "app.firewall.dnsLookupTimeoutSec",
+			file.DNSLookupTimeoutSec, dns.LookupTimeoutSec)
+	}
+
+	return []settings.EffectiveValue{
+		settings.ConfigIntValue("maxAttempts", "app.firewall.maxAttempts", file.MaxAttempts, eff.MaxAttempts),
+		settings.ConfigIntValue("jailCheckIntervalSec", "app.firewall.jailCheckIntervalSec", file.JailCheckIntervalSec, eff.JailCheckIntervalSec),
+		settings.ConfigIntValue("cleanupIntervalMin", "app.firewall.cleanupIntervalMin", file.CleanupIntervalMin, eff.CleanupIntervalMin),
+		settings.ConfigIntValue("driftCheckIntervalMin", "app.firewall.driftCheckIntervalMin", file.DriftCheckIntervalMin, eff.DriftCheckIntervalMin),
+		settings.ConfigIntValue("maxJailMonitors", "app.firewall.maxJailMonitors", file.MaxJailMonitors, eff.MaxJailMonitors),
+		dnsTimeout,
+		settings.StoredValue("dnsCacheSize", dnsConfigSetting, dns.CacheSize),
+		settings.StoredValue("dnsCacheTtlMinutes", dnsConfigSetting, dns.CacheTTLMinutes),
+		settings.StoredValue("essentialPorts", essentialPortsSetting, loadEssentialPorts()),
+		settings.StoredValue("jailDefaults", jailDefaultsSetting, loadJailDefaults()),
+		settings.StoredValue("slowlist", slowlistSetting, loadSlowlistConfig()),
+		settings.StoredValue("multiJail", multiJailSetting, loadMultiJailConfig()),
+		settings.StoredValue("portStats", portStatsSetting, loadPortStatsConfig()),
+		settings.EnvValue("ignoreNetworks", "IGNORE_NETWORKS", nil),
+		settings.EnvValue("wgPort", "WG_PORT", nil),
+		settings.EnvValue("wgIPRange", "WG_IP_RANGE", nil),
+		settings.EnvValue("headscaleIPRange", "HEADSCALE_IP_RANGE", nil),
+		settings.EnvValue("autoDiscoverDockerPorts", "AUTO_DISCOVER_DOCKER_PORTS", "true"),
+		settings.EnvValue("dockerEventSync", "DOCKER_EVENT_SYNC", "false"),
+		settings.SecretEnvValue("driftWebhookURL", "FIREWALL_DRIFT_WEBHOOK_URL"),
+	}
+}
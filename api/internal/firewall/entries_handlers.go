@@ -3,6 +3,7 @@ package firewall
 import (
 	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -21,6 +22,7 @@ func (s *Service) handleGetEntries(w http.ResponseWriter, r *http.Request) {
 	typeFilter := r.URL.Query().Get("type")     // ip, range, country, port
 	sourceFilter := r.URL.Query().Get("source") // manual, fail2ban, blocklist, etc.
 	actionFilter := r.URL.Query().Get("action") // block, allow
+	categoryFilter := r.URL.Query().Get("category")
 
 	where := "(expires_at IS NULL OR expires_at > datetime('now'))"
 	args := []interface{}{}
@@ -40,24 +42,47 @@ func (s *Service) handleGetEntries(w http.ResponseWriter, r *http.Request) {
 		args = append(args, actionFilter)
 	}
 
+	if categoryFilter != "" {
+		where += " AND category = ?"
+		args = append(args, categoryFilter)
+	}
+
 	if search != "" {
 		where += " AND (value LIKE ? ESCAPE '\\' OR source LIKE ? ESCAPE '\\' OR reason LIKE ? ESCAPE '\\' OR name LIKE ? ESCAPE '\\')"
 		searchPattern := "%" + database.EscapeLikePattern(search) + "%"
 		args = append(args, searchPattern, searchPattern, searchPattern, searchPattern)
 	}
 
+	// ?containsIp= keeps the IP's own entry and the ranges containing it. The
+	// SQL prefilter is coarse; containment is checked below and paginated in Go.
+	var containsIP net.IP
+	if v := r.URL.Query().Get("containsIp"); v != "" {
+		if containsIP = net.ParseIP(strings.TrimSpace(v)); containsIP == nil {
+			router.JSONError(w, "containsIp must be an IP address", http.StatusBadRequest)
+			return
+		}
+		prefilter, prefilterArgs := containsIPPrefilter(containsIP)
+		where += " AND " + prefilter
+		args = append(args, prefilterArgs...)
+	}
+
 	var total int
-	countQuery := "SELECT COUNT(*) FROM firewall_entries WHERE " + where
-	_ = s.db.QueryRow(countQuery, args...).Scan(&total)
+	if containsIP == nil {
+		countQuery := "SELECT COUNT(*) FROM firewall_entries WHERE " + where
+		_ = s.db.QueryRow(countQuery, args...).Scan(&total)
+	}
 
 	// Get filter options (types are known constants, only sources need DB query)
 	types := []string{"ip", "range", "country", "port"}
 	sources := s.getDistinctValues("firewall_entries", "source")
 
 	query := fmt.Sprintf(`SELECT id, entry_type, value, action, direction, protocol, source,
-		COALESCE(reason, ''), COALESCE(name, ''), essential, expires_at, enabled, hit_count, created_at
-		FROM firewall_entries WHERE %s ORDER BY created_at DESC LIMIT ? OFFSET ?`, where)
-	args = append(args, p.Limit, p.Offset)
+		COALESCE(reason, ''), COALESCE(category, ''), COALESCE(name, ''), essential, expires_at, enabled, hit_count, created_at
+		FROM firewall_entries WHERE %s ORDER BY created_at DESC`, where)
+	if containsIP == nil {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, p.Limit, p.Offset)
+	}
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -71,21 +96,32 @@ func (s *Service) handleGetEntries(w http.ResponseWriter, r *http.Request) {
 		var e nftables.FirewallEntry
 		var expiresAt sql.NullTime
 		if err := rows.Scan(&e.ID, &e.EntryType, &e.Value, &e.Action, &e.Direction, &e.Protocol,
-			&e.Source, &e.Reason, &e.Name, &e.Essential, &expiresAt, &e.Enabled,
+			&e.Source, &e.Reason, &e.Category, &e.Name, &e.Essential, &expiresAt, &e.Enabled,
 			&e.HitCount, &e.CreatedAt); err != nil {
 			continue
 		}
+		if containsIP != nil {
+			if n := parseNetwork(e.Value); n == nil || !n.Contains(containsIP) {
+				continue
+			}
+		}
 		e.ExpiresAt = database.TimePointerFromNull(expiresAt)
 		entries = append(entries, e)
 	}
 
+	if containsIP != nil {
+		total = len(entries)
+		entries = entries[min(p.Offset, total):min(p.Offset+p.Limit, total)]
+	}
+
 	router.JSON(w, map[string]interface{}{
-		"entries": entries,
-		"total":   total,
-		"limit":   p.Limit,
-		"offset":  p.Offset,
-		"types":   types,
-		"sources": sources,
+		"entries":    entries,
+		"total":      total,
+		"limit":      p.Limit,
+		"offset":     p.Offset,
+		"types":      types,
+		"sources":    sources,
+		"categories": BlockCategories,
 	})
 }
 
@@ -98,8 +134,10 @@ func (s *Service) handleCreateEntry(w http.ResponseWriter, r *http.Request) {
 		Direction string `json:"direction"` // inbound, outbound, both
 		Protocol  string `json:"protocol"`  // tcp, udp, both
 		Reason    string `json:"reason"`
-		Name      string `json:"name"`    // country name or port service name
-		BanTime   int    `json:"banTime"` // seconds, 0 = permanent
+		Category  string `json:"category"` // see BlockCategories; default reason is its label
+		Name      string `json:"name"`     // country name or port service name
+		BanTime   int    `json:"banTime"`  // seconds, 0 = permanent
+		Force     bool   `json:"force"`    // block a country even if it contains the server/requester IP
 	}
 	if !router.DecodeJSONOrError(w, r, &req) {
 		return
@@ -131,9 +169,20 @@ func (s *Service) handleCreateEntry(w http.ResponseWriter, r *http.Request) {
 	if req.Protocol == "" {
 		req.Protocol = nftables.ProtocolBoth
 	}
+	if req.Category != "" && req.Action != nftables.ActionBlock {
+		router.JSONError(w, "category only applies to block entries", http.StatusBadRequest)
+		return
+	}
+	reason, err := categoryReason(req.Category, req.Reason)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Reason = reason
 
 	// Validate and normalize value based on type
 	var normalizedValue string
+	var selfBlockWarnings []SelfBlockWarning // forced country blocks covering protected IPs
 	switch req.Type {
 	case nftables.EntryTypeIP:
 		normalized, isRange, err := validateIPOrCIDR(req.Value)
@@ -160,35 +209,85 @@ func (s *Service) handleCreateEntry(w http.ResponseWriter, r *http.Request) {
 		}
 		normalizedValue = normalized
 
+		if req.Action == nftables.ActionBlock {
+			if reason := s.bulkBlockSkipReason(normalizedValue, true, protectedIPs(r)); reason != "" {
+				router.JSONError(w, "cannot block "+normalizedValue+": "+reason, http.StatusForbidden)
+				return
+			}
+		}
+
 	case nftables.EntryTypeCountry:
 		code := strings.ToUpper(strings.TrimSpace(req.Value))
 		if len(code) != 2 {
 			router.JSONError(w, "country code must be 2 letters (ISO 3166-1 alpha-2)", http.StatusBadRequest)
 			return
 		}
+		if req.Action != nftables.ActionBlock {
+			// Country zones only feed the block sets; use country exceptions to allow ranges
+			router.JSONError(w, "country entries only support action block", http.StatusBadRequest)
+			return
+		}
 		normalizedValue = code
 
+		if req.Action == nftables.ActionBlock {
+			warnings, err := s.countrySelfBlockWarnings(code, req.Direction, r)
+			if err != nil && !req.Force {
+				// Fail closed: without the zones we can't tell whether this locks us out
+				router.JSONError(w, "could not check "+code+" zones for protected addresses: "+err.Error()+"; resend with force to block anyway", http.StatusServiceUnavailable)
+				return
+			}
+			if len(warnings) > 0 && !req.Force {
+				router.JSONWithStatus(w, map[string]interface{}{
+					"error":    "blocking " + code + " would block protected addresses; add allow entries or resend with force",
+					"warnings": warnings,
+				}, http.StatusConflict)
+				return
+			}
+			selfBlockWarnings = warnings
+		}
+
 	case nftables.EntryTypePort:
-		port, err := strconv.Atoi(req.Value)
-		if err != nil || port < 1 || port > 65535 {
-			router.JSONError(w, "invalid port number (must be 1-65535)", http.StatusBadRequest)
+		// Single port ("443") or inclusive range ("30000-30100")
+		start, end, err := helper.ParsePortRange(req.Value)
+		if err != nil {
+			router.JSONError(w, "invalid port or port range (must be 1-65535, start-end)", http.StatusBadRequest)
 			return
 		}
-		normalizedValue = req.Value
+		normalizedValue = helper.FormatPortRange(start, end)
 	}
 
-	var expiresAt interface{}
+	var expiresAt *time.Time
 	if req.BanTime > 0 {
-		expiresAt = time.Now().Add(time.Duration(req.BanTime) * time.Second)
+		t := time.Now().Add(time.Duration(req.BanTime) * time.Second)
+		expiresAt = &t
+	}
+
+	// Skip IP blocks already covered by an existing range block
+	if req.Type == nftables.EntryTypeIP && req.Action == nftables.ActionBlock {
+		if cidr := s.findCoveringRange(normalizedValue, req.Direction, req.Protocol, expiresAt); cidr != "" {
+			router.JSON(w, map[string]interface{}{
+				"status":    "covered",
+				"type":      req.Type,
+				"value":     normalizedValue,
+				"coveredBy": cidr,
+				"message":   fmt.Sprintf("%s is already blocked by range %s", normalizedValue, cidr),
+			})
+			return
+		}
 	}
 
+	// The upsert may rewrite an existing row's action/direction, which needs a full apply
+	var existing int
+	s.db.QueryRow("SELECT COUNT(*) FROM firewall_entries WHERE entry_type = ? AND value = ? AND protocol = ?",
+		req.Type, normalizedValue, req.Protocol).Scan(&existing)
+
 	result, err := s.db.Exec(`INSERT INTO firewall_entries
-		(entry_type, value, action, direction, protocol, source, reason, name, expires_at, enabled)
-		VALUES (?, ?, ?, ?, ?, 'manual', ?, ?, ?, 1)
+		(entry_type, value, action, direction, protocol, source, reason, category, name, expires_at, enabled)
+		VALUES (?, ?, ?, ?, ?, 'manual', ?, ?, ?, ?, 1)
 		ON CONFLICT(entry_type, value, protocol) DO UPDATE SET
 		action = excluded.action, direction = excluded.direction, reason = excluded.reason,
-		name = excluded.name, expires_at = excluded.expires_at, enabled = 1`,
-		req.Type, normalizedValue, req.Action, req.Direction, req.Protocol, req.Reason, req.Name, expiresAt)
+		category = excluded.category, name = excluded.name, expires_at = excluded.expires_at, enabled = 1`,
+		req.Type, normalizedValue, req.Action, req.Direction, req.Protocol, req.Reason, req.Category, req.Name, expiresAt)
 	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -198,25 +297,48 @@ func (s *Service) handleCreateEntry(w http.ResponseWriter, r *http.Request) {
 
 	// For country entries, fetch zones async
 	if req.Type == nftables.EntryTypeCountry {
-		router.JSON(w, map[string]interface{}{
+		resp := map[string]interface{}{
 			"status": "queued",
 			"id":     id,
 			"type":   req.Type,
 			"value":  normalizedValue,
 			"action": req.Action,
-		})
+		}
+		if len(selfBlockWarnings) > 0 {
+			resp["warnings"] = selfBlockWarnings
+		}
+		if warning := s.enforcementWarning(); warning != "" {
+			resp["warning"] = warning
+		}
+		router.JSON(w, resp)
 		s.FetchCountryZonesAsync([]string{normalizedValue})
 		return
 	}
 
-	s.RequestApply()
-	router.JSON(w, map[string]interface{}{
+	// A new range block makes individual IP blocks inside it redundant
+	var merged int64
+	if req.Type == nftables.EntryTypeRange && req.Action == nftables.ActionBlock {
+		merged = s.removeCoveredIPs(normalizedValue, req.Direction, req.Protocol, expiresAt)
+	}
+
+	if existing == 0 && merged == 0 && req.Action == nftables.ActionBlock &&
+		(req.Type == nftables.EntryTypeIP || req.Type == nftables.EntryTypeRange) {
+		s.addBlockToSets(req.Type, normalizedValue, req.Direction)
+	} else {
+		s.RequestApply()
+	}
+	resp := map[string]interface{}{
 		"status": "created",
 		"id":     id,
 		"type":   req.Type,
 		"value":  normalizedValue,
 		"action": req.Action,
-	})
+		"merged": merged,
+	}
+	if warning := s.enforcementWarning(); warning != "" {
+		resp["warning"] = warning
+	}
+	router.JSON(w, resp)
 }
 
 // handleDeleteEntry deletes a firewall entry by ID
@@ -229,8 +351,10 @@ func (s *Service) handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if essential
-	var essential bool
-	err = s.db.QueryRow("SELECT essential FROM firewall_entries WHERE id = ?", id).Scan(&essential)
+	var essential, enabled bool
+	var entryType, value, action, direction string
+	err = s.db.QueryRow("SELECT essential, enabled, entry_type, value, action, direction FROM firewall_entries WHERE id = ?", id).
+		Scan(&essential, &enabled, &entryType, &value, &action, &direction)
 	if err == sql.ErrNoRows {
 		router.JSONError(w, "entry not found", http.StatusNotFound)
 		return
@@ -240,13 +364,21 @@ func (s *Service) handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordManualUnblocks("?", id)
 	_, err = s.db.Exec("DELETE FROM firewall_entries WHERE id = ? AND essential = 0", id)
 	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	s.RequestApply()
+	switch {
+	case !enabled:
+		// Disabled entries aren't in the ruleset
+	case action == nftables.ActionBlock && (entryType == nftables.EntryTypeIP || entryType == nftables.EntryTypeRange):
+		s.removeBlockFromSets(entryType, value, direction)
+	default:
+		s.RequestApply()
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -262,6 +394,7 @@ func (s *Service) handleBulkEntries(w http.ResponseWriter, r *http.Request) {
 			Direction string `json:"direction"`
 			Name      string `json:"name"`
 		} `json:"entries"`
+		Force bool `json:"force"` // create country blocks even if they cover the server/requester IP
 	}
 	if !router.DecodeJSONOrError(w, r, &req) {
 		return
@@ -276,6 +409,8 @@ func (s *Service) handleBulkEntries(w http.ResponseWriter, r *http.Request) {
 
 		created := 0
 		var countryEntries []string
+		skipped := []BulkBlockResult{}
+		protected := protectedIPs(r)
 
 		for _, e := range req.Entries {
 			action := e.Action
@@ -286,6 +421,36 @@ func (s *Service) handleBulkEntries(w http.ResponseWriter, r *http.Request) {
 			if direction == "" {
 				direction = nftables.DirectionInbound
 			}
+			if e.Type == nftables.EntryTypeCountry {
+				// Same rules as a single create: upper-case code, block only
+				e.Value = strings.ToUpper(strings.TrimSpace(e.Value))
+				if len(e.Value) != 2 || action != nftables.ActionBlock {
+					continue
+				}
+				if !req.Force {
+					warnings, err := s.countrySelfBlockWarnings(e.Value, direction, r)
+					switch {
+					case err != nil:
+						skipped = append(skipped, BulkBlockResult{Input: e.Value, Status: "skipped", Reason: "could not check zones for protected addresses: " + err.Error()})
+						continue
+					case len(warnings) > 0:
+						skipped = append(skipped, BulkBlockResult{Input: e.Value, Status: "skipped", Reason: warnings[0].Message})
+						continue
+					}
+				}
+			}
+			if (e.Type == nftables.EntryTypeIP || e.Type == nftables.EntryTypeRange) && action == nftables.ActionBlock {
+				value, isRange, err := validateIPOrCIDR(e.Value)
+				if err != nil {
+					skipped = append(skipped, BulkBlockResult{Input: e.Value, Status: "invalid", Reason: err.Error()})
+					continue
+				}
+				if reason := s.bulkBlockSkipReason(value, isRange, protected); reason != "" {
+					skipped = append(skipped, BulkBlockResult{Input: e.Value, Value: value, Status: "skipped", Reason: reason})
+					continue
+				}
+				e.Value = value
+			}
 
 			// Insert entry immediately (zones will be fetched async for countries)
 			_, err := s.db.Exec(`INSERT OR IGNORE INTO firewall_entries
@@ -306,6 +471,7 @@ func (s *Service) handleBulkEntries(w http.ResponseWriter, r *http.Request) {
 			"status":   "queued",
 			"created":  created,
 			"fetching": len(countryEntries),
+			"skipped":  skipped,
 		})
 
 		// Async: fetch country zones and apply rules
@@ -347,6 +513,10 @@ func (s *Service) handleBulkEntries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Action == "delete" || req.Action == "disable" {
+		s.recordManualUnblocks(inClause, args...)
+	}
+
 	result, err := s.db.Exec(query, args...)
 	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
@@ -364,7 +534,8 @@ func (s *Service) handleBulkEntries(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleImportEntries imports entries from a blocklist source
+// handleImportEntries validates the source and starts a background import job.
+// Progress is available via handleGetImportProgress and WS "firewall:import:*" events.
 func (s *Service) handleImportEntries(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Source string `json:"source"` // blocklist source ID
@@ -374,7 +545,7 @@ func (s *Service) handleImportEntries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var entries []string
+	var fetch func() ([]string, error)
 	var sourceName string
 
 	if req.Source != "" {
@@ -386,14 +557,9 @@ func (s *Service) handleImportEntries(w http.ResponseWriter, r *http.Request) {
 		sourceName = req.Source
 
 		if src.Type == "static" {
-			entries = src.Ranges
+			fetch = func() ([]string, error) { return src.Ranges, nil }
 		} else {
-			var err error
-			entries, err = s.fetchBlocklist(src.URL, src.MinScore)
-			if err != nil {
-				router.JSONError(w, "failed to fetch blocklist: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
+			fetch = func() ([]string, error) { return s.fetchBlocklist(src.URL, src.MinScore) }
 		}
 	} else if req.URL != "" {
 		if err := helper.ValidateBlocklistURL(req.URL); err != nil {
@@ -401,64 +567,31 @@ func (s *Service) handleImportEntries(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		sourceName = "custom"
-		var err error
-		entries, err = s.fetchBlocklist(req.URL, 0)
-		if err != nil {
-			router.JSONError(w, "failed to fetch blocklist: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+		url := req.URL
+		fetch = func() ([]string, error) { return s.fetchBlocklist(url, 0) }
 	} else {
 		router.JSONError(w, "source or url required", http.StatusBadRequest)
 		return
 	}
 
-	added := 0
-	skipped := 0
-	for _, entry := range entries {
-		entry = strings.TrimSpace(entry)
-		if entry == "" || isPrivateRange(entry) {
-			skipped++
-			continue
-		}
-
-		normalizedIP, isRange, err := validateIPOrCIDR(entry)
-		if err != nil {
-			skipped++
-			continue
-		}
-
-		entryType := nftables.EntryTypeIP
-		if isRange {
-			entryType = nftables.EntryTypeRange
-		}
+	job := s.startImport(sourceName, fetch)
 
-		result, err := s.db.Exec(`INSERT OR IGNORE INTO firewall_entries
-			(entry_type, value, action, direction, protocol, source, reason, enabled)
-			VALUES (?, ?, 'block', 'inbound', 'both', ?, ?, 1)`,
-			entryType, normalizedIP, sourceName, fmt.Sprintf("Imported from %s", sourceName))
-		if err != nil {
-			skipped++
-			continue
-		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected > 0 {
-			added++
-		} else {
-			skipped++
-		}
-	}
+	router.JSONWithStatus(w, map[string]interface{}{
+		"status": "queued",
+		"jobId":  job.ID,
+		"source": sourceName,
+	}, http.StatusAccepted)
+}
 
-	if added > 0 {
-		s.RequestApply()
+// handleGetImportProgress returns the progress of an import job
+func (s *Service) handleGetImportProgress(w http.ResponseWriter, r *http.Request) {
+	id := router.ExtractPathParam(r, "/api/fw/entries/import/")
+	job, ok := s.getImport(id)
+	if !ok {
+		router.JSONError(w, "import job not found", http.StatusNotFound)
+		return
 	}
-
-	router.JSON(w, map[string]interface{}{
-		"status":  "imported",
-		"source":  sourceName,
-		"added":   added,
-		"skipped": skipped,
-		"total":   len(entries),
-	})
+	router.JSON(w, job)
 }
 
 // handleDeleteBySource deletes all entries from a source
@@ -506,6 +639,162 @@ func (s *Service) handleDeleteAll(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// PurgedEntry describes a block entry removed by handlePurgeInvalidBlocks
+type PurgedEntry struct {
+	ID     int64  `json:"id"`
+	Type   string `json:"type"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// handlePurgeInvalidBlocks removes IP/range blocks that are private, in the ignore
+// networks, the server's own IP, or covered by an allow entry (?dryRun=true to preview)
+func (s *Service) handlePurgeInvalidBlocks(w http.ResponseWriter, r *http.Request) {
+	dryRun := router.QueryParam(r, "dryRun", "false") == "true"
+
+	// Allow entries act as an allowlist for blocks
+	var allowNets []*net.IPNet
+	rows, err := s.db.Query(`SELECT value FROM firewall_entries
+		WHERE action = 'allow' AND entry_type IN ('ip', 'range') AND enabled = 1`)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for rows.Next() {
+		var value string
+		if rows.Scan(&value) == nil {
+			if n := parseNetwork(value); n != nil {
+				allowNets = append(allowNets, n)
+			}
+		}
+	}
+	rows.Close()
+
+	var ignoreNets []*net.IPNet
+	for _, network := range s.config.IgnoreNetworks {
+		if n := parseNetwork(network); n != nil {
+			ignoreNets = append(ignoreNets, n)
+		}
+	}
+
+	rows, err = s.db.Query(`SELECT id, entry_type, value, source FROM firewall_entries
+		WHERE action = 'block' AND entry_type IN ('ip', 'range') AND essential = 0`)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	purged := []PurgedEntry{}
+	for rows.Next() {
+		var e PurgedEntry
+		if err := rows.Scan(&e.ID, &e.Type, &e.Value, &e.Source); err != nil {
+			continue
+		}
+		if e.Reason = s.invalidBlockReason(e.Type, e.Value, ignoreNets, allowNets); e.Reason != "" {
+			purged = append(purged, e)
+		}
+	}
+	rows.Close()
+
+	if !dryRun && len(purged) > 0 {
+		tx, err := s.db.Begin()
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		for _, e := range purged {
+			if _, err := tx.Exec("DELETE FROM firewall_entries WHERE id = ? AND essential = 0", e.ID); err != nil {
+				router.JSONError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.RequestApply()
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"dryRun":  dryRun,
+		"purged":  len(purged),
+		"entries": purged,
+	})
+}
+
+// invalidBlockReason returns why a block entry shouldn't exist, or "" if it is valid
+func (s *Service) invalidBlockReason(entryType, value string, ignoreNets, allowNets []*net.IPNet) string {
+	if isPrivateRange(value) {
+		return "private or loopback"
+	}
+
+	if entryType == nftables.EntryTypeIP {
+		if serverIP := helper.ServerIP(); serverIP != "" && value == serverIP {
+			return "server IP"
+		}
+		if s.isIgnoredIP(value) {
+			return "ignored network"
+		}
+	}
+
+	n := parseNetwork(value)
+	if n == nil {
+		return "invalid address"
+	}
+	if networkCovered(n, ignoreNets) {
+		return "ignored network"
+	}
+	if networkCovered(n, allowNets) {
+		return "allowlisted"
+	}
+	return ""
+}
+
+// containsIPPrefilter narrows entries to those that can contain ip: the exact
+// IP entry, and ranges of its family. IPv4 ranges of /8 or longer share the
+// IP's first octet; the single-digit prefixes (/0-/9) are kept regardless.
+func containsIPPrefilter(ip net.IP) (string, []interface{}) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return `((entry_type = 'ip' AND value = ?) OR (entry_type = 'range' AND value NOT LIKE '%:%'
+			AND (value LIKE ? OR value LIKE '%/_')))`, []interface{}{ip4.String(), fmt.Sprintf("%d.%%", ip4[0])}
+	}
+	return `((entry_type = 'ip' AND value = ?) OR (entry_type = 'range' AND value LIKE '%:%'))`, []interface{}{ip.String()}
+}
+
+// parseNetwork parses an IP or CIDR into a network (single IPs become /32 or /128)
+func parseNetwork(value string) *net.IPNet {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		_, n, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil
+		}
+		return n
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// networkCovered reports whether n lies entirely within one of the networks
+func networkCovered(n *net.IPNet, networks []*net.IPNet) bool {
+	ones, bits := n.Mask.Size()
+	for _, outer := range networks {
+		outerOnes, outerBits := outer.Mask.Size()
+		if outerBits == bits && outerOnes <= ones && outer.Contains(n.IP) {
+			return true
+		}
+	}
+	return false
+}
+
 // handleToggleEntry enables/disables an entry or changes direction
 func (s *Service) handleToggleEntry(w http.ResponseWriter, r *http.Request) {
 	idStr := router.ExtractPathParam(r, "/api/fw/entries/")
@@ -529,7 +818,9 @@ func (s *Service) handleToggleEntry(w http.ResponseWriter, r *http.Request) {
 	// Check if entry exists and get current values
 	var essential bool
 	var currentEnabled bool
-	err = s.db.QueryRow("SELECT essential, enabled FROM firewall_entries WHERE id = ?", id).Scan(&essential, &currentEnabled)
+	var entryType, value, action, currentDirection string
+	err = s.db.QueryRow("SELECT essential, enabled, entry_type, value, action, direction FROM firewall_entries WHERE id = ?", id).
+		Scan(&essential, &currentEnabled, &entryType, &value, &action, &currentDirection)
 	if err == sql.ErrNoRows {
 		router.JSONError(w, "entry not found", http.StatusNotFound)
 		return
@@ -543,6 +834,9 @@ func (s *Service) handleToggleEntry(w http.ResponseWriter, r *http.Request) {
 			router.JSONError(w, "cannot disable essential entry", http.StatusForbidden)
 			return
 		}
+		if !*req.Enabled {
+			s.recordManualUnblocks("?", id)
+		}
 		_, err = s.db.Exec("UPDATE firewall_entries SET enabled = ? WHERE id = ?", *req.Enabled, id)
 		if err != nil {
 			router.JSONError(w, err.Error(), http.StatusInternalServerError)
@@ -570,7 +864,24 @@ func (s *Service) handleToggleEntry(w http.ResponseWriter, r *http.Request) {
 		response["direction"] = req.Direction
 	}
 
-	s.RequestApply()
+	// ip/range blocks move in the live sets: out of the old state, into the new
+	if action == nftables.ActionBlock && (entryType == nftables.EntryTypeIP || entryType == nftables.EntryTypeRange) {
+		enabled, direction := currentEnabled, currentDirection
+		if req.Enabled != nil {
+			enabled = *req.Enabled
+		}
+		if req.Direction != "" {
+			direction = req.Direction
+		}
+		if currentEnabled {
+			s.removeBlockFromSets(entryType, value, currentDirection)
+		}
+		if enabled {
+			s.addBlockToSets(entryType, value, direction)
+		}
+	} else {
+		s.RequestApply()
+	}
 	router.JSON(w, response)
 }
 
@@ -588,7 +899,7 @@ func (s *Service) validateIPNotProtected(ip string, r *http.Request) error {
 	}
 
 	// Don't block server's IP
-	if s.config.ServerIP != "" && ip == s.config.ServerIP {
+	if serverIP := helper.ServerIP(); serverIP != "" && ip == serverIP {
 		return fmt.Errorf("cannot block the server's IP address")
 	}
 
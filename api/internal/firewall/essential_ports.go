@@ -0,0 +1,150 @@
+package firewall
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/settings"
+)
+
+// essentialPortsSetting stores the operator-defined essential ports as JSON.
+// When unset, helper.BuildEssentialPorts (SSH + essential-ports.json) is the seed.
+const essentialPortsSetting = "firewall_essential_ports"
+
+// essentialPortJSON is the API/settings shape of an essential port
+type essentialPortJSON struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Service  string `json:"service"`
+}
+
+// loadEssentialPorts returns the configured essential ports, falling back to the
+// built-in defaults. The detected SSH port is always included to avoid lock-out.
+func loadEssentialPorts() []helper.EssentialPort {
+	raw, err := settings.GetSetting(essentialPortsSetting)
+	if err != nil || raw == "" {
+		return helper.BuildEssentialPorts()
+	}
+
+	var entries []essentialPortJSON
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		log.Printf("essential-ports: invalid %s setting: %v (using defaults)", essentialPortsSetting, err)
+		return helper.BuildEssentialPorts()
+	}
+
+	ports := []helper.EssentialPort{}
+	if sshPort := helper.GetSSHPort(); sshPort > 0 {
+		ports = append(ports, helper.EssentialPort{Port: sshPort, Protocol: "tcp", Service: "SSH"})
+	}
+	for _, e := range entries {
+		if !containsEssentialPort(ports, e.Port, e.Protocol) {
+			ports = append(ports, helper.EssentialPort{Port: e.Port, Protocol: e.Protocol, Service: e.Service})
+		}
+	}
+	return ports
+}
+
+func containsEssentialPort(ports []helper.EssentialPort, port int, protocol string) bool {
+	for _, p := range ports {
+		if p.Port == port && p.Protocol == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// isEssentialPort reports whether port/protocol is essential ("" matches any protocol)
+func (s *Service) isEssentialPort(port int, protocol string) (helper.EssentialPort, bool) {
+	for _, ep := range s.config.EssentialPorts {
+		if ep.Port == port && (protocol == "" || ep.Protocol == protocol || ep.Protocol == "both") {
+			return ep, true
+		}
+	}
+	return helper.EssentialPort{}, false
+}
+
+// syncEssentialFlags re-derives the essential flag of port entries from the config.
+// Docker-discovered rows keep their flag; they are managed by SyncDockerPortsToDB.
+func (s *Service) syncEssentialFlags() error {
+	if _, err := s.db.Exec(`UPDATE firewall_entries SET essential = 0
+		WHERE entry_type = 'port' AND source != 'docker'`); err != nil {
+		return fmt.Errorf("failed to reset essential flags: %v", err)
+	}
+	return s.ensureEssentialPorts()
+}
+
+// handleGetEssentialPorts returns the current essential ports
+func (s *Service) handleGetEssentialPorts(w http.ResponseWriter, r *http.Request) {
+	ports := make([]essentialPortJSON, 0, len(s.config.EssentialPorts))
+	for _, ep := range s.config.EssentialPorts {
+		ports = append(ports, essentialPortJSON{Port: ep.Port, Protocol: ep.Protocol, Service: ep.Service})
+	}
+
+	raw, _ := settings.GetSetting(essentialPortsSetting)
+	router.JSON(w, map[string]interface{}{
+		"ports":   ports,
+		"custom":  raw != "",
+		"sshPort": helper.GetSSHPort(),
+	})
+}
+
+// handleSetEssentialPorts replaces the essential ports list ({"ports": [...]}),
+// or restores the defaults with {"reset": true}
+func (s *Service) handleSetEssentialPorts(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Ports []essentialPortJSON `json:"ports"`
+		Reset bool                `json:"reset"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	if req.Reset {
+		if err := settings.DeleteSetting(essentialPortsSetting); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		for i := range req.Ports {
+			p := &req.Ports[i]
+			if err := helper.ValidatePort(p.Port); err != nil {
+				router.JSONError(w, "invalid port "+strconv.Itoa(p.Port)+": must be 1-65535", http.StatusBadRequest)
+				return
+			}
+			p.Protocol = strings.ToLower(strings.TrimSpace(p.Protocol))
+			if p.Protocol == "" {
+				p.Protocol = "tcp"
+			}
+			if p.Protocol != "tcp" && p.Protocol != "udp" && p.Protocol != "both" {
+				router.JSONError(w, "invalid protocol for port "+strconv.Itoa(p.Port)+": must be tcp, udp or both", http.StatusBadRequest)
+				return
+			}
+			p.Service = strings.TrimSpace(p.Service)
+		}
+
+		data, err := json.Marshal(req.Ports)
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := settings.SetSetting(essentialPortsSetting, string(data)); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.config.EssentialPorts = loadEssentialPorts()
+	if err := s.syncEssentialFlags(); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.RequestApply()
+
+	s.handleGetEssentialPorts(w, r)
+}
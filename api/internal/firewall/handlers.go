@@ -1,11 +1,16 @@
 package firewall
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strings"
 
 	"api/internal/helper"
+	"api/internal/nftables"
 	"api/internal/router"
 )
 
@@ -58,7 +63,11 @@ func (s *Service) handleStatus(w http.ResponseWriter, r *http.Request) {
 		countryBlockingEnabled = s.geo.IsBlockingEnabled()
 	}
 
+	enforcement := s.enforcement()
+
 	router.JSON(w, map[string]interface{}{
+		"enforcing":              enforcement.Available,
+		"enforcementError":       enforcement.Error,
 		"blockedIPCount":         blockedCount,
 		"allowedPorts":           portsCount,
 		"blockedCountries":       countryCount,
@@ -85,7 +94,11 @@ func (s *Service) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 // handleApplyRules manually applies firewall rules
 func (s *Service) handleApplyRules(w http.ResponseWriter, r *http.Request) {
 	if err := s.ApplyRules(); err != nil {
-		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, nftables.ErrUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		router.JSONError(w, err.Error(), status)
 		return
 	}
 	router.JSON(w, map[string]string{"status": "applied"})
@@ -95,36 +108,29 @@ func (s *Service) handleApplyRules(w http.ResponseWriter, r *http.Request) {
 func (s *Service) handleSyncStatus(w http.ResponseWriter, r *http.Request) {
 	nftStatus := s.GetSyncStatus()
 
-	// Get DB counts - inbound (includes 'both')
-	var dbBlockedIPsIn, dbBlockedRangesIn int
-	_ = s.db.QueryRow(`SELECT COUNT(*) FROM firewall_entries
-		WHERE entry_type = 'ip' AND action = 'block' AND enabled = 1
-		AND direction IN ('inbound', 'both')
-		AND (expires_at IS NULL OR expires_at > datetime('now'))`).Scan(&dbBlockedIPsIn)
-	_ = s.db.QueryRow(`SELECT COUNT(*) FROM firewall_entries
-		WHERE entry_type = 'range' AND action = 'block' AND enabled = 1
-		AND direction IN ('inbound', 'both')
-		AND (expires_at IS NULL OR expires_at > datetime('now'))`).Scan(&dbBlockedRangesIn)
-
-	// Get DB counts - outbound (includes 'both')
-	var dbBlockedIPsOut, dbBlockedRangesOut int
-	_ = s.db.QueryRow(`SELECT COUNT(*) FROM firewall_entries
-		WHERE entry_type = 'ip' AND action = 'block' AND enabled = 1
-		AND direction IN ('outbound', 'both')
-		AND (expires_at IS NULL OR expires_at > datetime('now'))`).Scan(&dbBlockedIPsOut)
-	_ = s.db.QueryRow(`SELECT COUNT(*) FROM firewall_entries
-		WHERE entry_type = 'range' AND action = 'block' AND enabled = 1
-		AND direction IN ('outbound', 'both')
-		AND (expires_at IS NULL OR expires_at > datetime('now'))`).Scan(&dbBlockedRangesOut)
+	// Get DB counts per direction (each includes 'both'), split by address
+	// family since IPv6 entries live in their own sets
+	var dbBlockedIPsIn, dbBlockedRangesIn, dbBlockedIPs6In, dbBlockedRanges6In int
+	var dbBlockedIPsOut, dbBlockedRangesOut, dbBlockedIPs6Out, dbBlockedRanges6Out int
+	countBlocks := `SELECT
+		COALESCE(SUM(CASE WHEN entry_type = 'ip' AND instr(value, ':') = 0 THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN entry_type = 'range' AND instr(value, ':') = 0 THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN entry_type = 'ip' AND instr(value, ':') > 0 THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN entry_type = 'range' AND instr(value, ':') > 0 THEN 1 ELSE 0 END), 0)
+		FROM firewall_entries
+		WHERE action = 'block' AND enabled = 1 AND direction IN (?, 'both')
+		AND (expires_at IS NULL OR expires_at > datetime('now'))`
+	_ = s.db.QueryRow(countBlocks, "inbound").Scan(&dbBlockedIPsIn, &dbBlockedRangesIn, &dbBlockedIPs6In, &dbBlockedRanges6In)
+	_ = s.db.QueryRow(countBlocks, "outbound").Scan(&dbBlockedIPsOut, &dbBlockedRangesOut, &dbBlockedIPs6Out, &dbBlockedRanges6Out)
 
 	// Get DB counts - ports and countries
 	var dbAllowedTCPPorts, dbAllowedUDPPorts, dbCountries int
 	_ = s.db.QueryRow(`SELECT COUNT(*) FROM firewall_entries
 		WHERE entry_type = 'port' AND action = 'allow' AND enabled = 1
-		AND protocol IN ('tcp', 'both')`).Scan(&dbAllowedTCPPorts)
+		AND protocol IN ('tcp', 'both') AND direction IN ('inbound', 'both')`).Scan(&dbAllowedTCPPorts)
 	_ = s.db.QueryRow(`SELECT COUNT(*) FROM firewall_entries
 		WHERE entry_type = 'port' AND action = 'allow' AND enabled = 1
-		AND protocol IN ('udp', 'both')`).Scan(&dbAllowedUDPPorts)
+		AND protocol IN ('udp', 'both') AND direction IN ('inbound', 'both')`).Scan(&dbAllowedUDPPorts)
 	_ = s.db.QueryRow(`SELECT COUNT(*) FROM firewall_entries
 		WHERE entry_type = 'country' AND enabled = 1`).Scan(&dbCountries)
 
@@ -137,6 +143,10 @@ func (s *Service) handleSyncStatus(w http.ResponseWriter, r *http.Request) {
 		nftCounts["blocked_ranges"] == dbBlockedRangesIn &&
 		nftCounts["blocked_ips_out"] == dbBlockedIPsOut &&
 		nftCounts["blocked_ranges_out"] == dbBlockedRangesOut &&
+		nftCounts["blocked_ips6"] == dbBlockedIPs6In &&
+		nftCounts["blocked_ranges6"] == dbBlockedRanges6In &&
+		nftCounts["blocked_ips6_out"] == dbBlockedIPs6Out &&
+		nftCounts["blocked_ranges6_out"] == dbBlockedRanges6Out &&
 		nftCounts["allowed_tcp_ports"] == dbAllowedTCPPorts &&
 		nftCounts["allowed_udp_ports"] == dbAllowedUDPPorts
 
@@ -146,12 +156,12 @@ func (s *Service) handleSyncStatus(w http.ResponseWriter, r *http.Request) {
 		"lastApplyAt":      nftStatus.LastApplyAt,
 		"lastApplyError":   nftStatus.LastApplyError,
 		"tables":           nftStatus.Tables,
-		"dbBlockedIPs":     dbBlockedIPsIn,
-		"dbBlockedRanges":  dbBlockedRangesIn,
+		"dbBlockedIPs":     dbBlockedIPsIn + dbBlockedIPs6In,
+		"dbBlockedRanges":  dbBlockedRangesIn + dbBlockedRanges6In,
 		"dbAllowedPorts":   dbAllowedTCPPorts + dbAllowedUDPPorts,
 		"dbCountryRanges":  dbCountries,
-		"nftBlockedIPs":    nftCounts["blocked_ips"],
-		"nftBlockedRanges": nftCounts["blocked_ranges"],
+		"nftBlockedIPs":    nftCounts["blocked_ips"] + nftCounts["blocked_ips6"],
+		"nftBlockedRanges": nftCounts["blocked_ranges"] + nftCounts["blocked_ranges6"],
 		"nftAllowedPorts":  nftCounts["allowed_tcp_ports"] + nftCounts["allowed_udp_ports"],
 	})
 }
@@ -182,3 +192,88 @@ func (s *Service) getDistinctValues(table, column string) []string {
 	}
 	return values
 }
+
+// handleGetSets returns the element count of every loaded firewall set
+func (s *Service) handleGetSets(w http.ResponseWriter, r *http.Request) {
+	if s.nft == nil {
+		router.JSONError(w, "nftables service not available", http.StatusServiceUnavailable)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"table": "inet wgadmin_firewall",
+		"sets":  s.nft.GetFirewallSetCounts(),
+	})
+}
+
+// handleGetSetMembers returns the elements currently loaded in a firewall set.
+// ?search= filters by substring; an IP also matches ranges/countries containing it.
+func (s *Service) handleGetSetMembers(w http.ResponseWriter, r *http.Request) {
+	name := router.ExtractPathParam(r, "/api/fw/sets/")
+	if !nftables.IsFirewallSet(name) {
+		router.JSONError(w, "unknown set", http.StatusNotFound)
+		return
+	}
+	if s.nft == nil {
+		router.JSONError(w, "nftables service not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Country sets don't exist while country blocking is off - report them as empty
+	if nftables.IsCountrySet(name) && (s.geo == nil || !s.geo.IsBlockingEnabled()) {
+		p := router.ParsePagination(r, helper.DefaultPaginationLimit)
+		router.JSON(w, map[string]interface{}{
+			"set":             name,
+			"elements":        []string{},
+			"total":           0,
+			"limit":           p.Limit,
+			"offset":          p.Offset,
+			"countryBlocking": false,
+		})
+		return
+	}
+
+	elements, err := s.nft.ListSetElements("inet", "wgadmin_firewall", name)
+	if err != nil {
+		router.JSONError(w, "failed to list set: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if search := strings.TrimSpace(r.URL.Query().Get("search")); search != "" {
+		searchIP := net.ParseIP(search)
+		filtered := []string{}
+		for _, e := range elements {
+			if strings.Contains(e, search) || (searchIP != nil && elementContainsIP(e, searchIP)) {
+				filtered = append(filtered, e)
+			}
+		}
+		elements = filtered
+	}
+
+	p := router.ParsePagination(r, helper.DefaultPaginationLimit)
+	total := len(elements)
+	start := min(p.Offset, total)
+	end := min(start+p.Limit, total)
+
+	router.JSON(w, map[string]interface{}{
+		"set":      name,
+		"elements": elements[start:end],
+		"total":    total,
+		"limit":    p.Limit,
+		"offset":   p.Offset,
+	})
+}
+
+// elementContainsIP reports whether a set element (IP, CIDR or "a-b" interval) contains ip
+func elementContainsIP(element string, ip net.IP) bool {
+	if _, network, err := net.ParseCIDR(element); err == nil {
+		return network.Contains(ip)
+	}
+	if from, to, ok := strings.Cut(element, "-"); ok {
+		lo, hi := net.ParseIP(strings.TrimSpace(from)), net.ParseIP(strings.TrimSpace(to))
+		if lo == nil || hi == nil || ip.To4() == nil {
+			return false
+		}
+		return bytes.Compare(ip.To4(), lo.To4()) >= 0 && bytes.Compare(ip.To4(), hi.To4()) <= 0
+	}
+	return ip.Equal(net.ParseIP(element))
+}
@@ -0,0 +1,221 @@
+package firewall
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"api/internal/nftables"
+	"api/internal/ws"
+)
+
+const (
+	importProgressEvery = 1000          // broadcast progress every N entries
+	importJobRetention  = 1 * time.Hour // finished jobs are kept this long for polling
+)
+
+// ImportJob tracks a background blocklist import
+type ImportJob struct {
+	ID         string     `json:"id"`
+	Source     string     `json:"source"`
+	Status     string     `json:"status"` // fetching, importing, completed, failed
+	Added      int        `json:"added"`
+	Skipped    int        `json:"skipped"`
+	Processed  int        `json:"processed"`
+	Total      int        `json:"total"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// importTracker holds running and recently finished import jobs
+type importTracker struct {
+	mu   sync.Mutex
+	jobs map[string]*ImportJob
+}
+
+// startImport registers a job and runs fetch + insert in the background
+func (s *Service) startImport(source string, fetch func() ([]string, error)) ImportJob {
+	job := &ImportJob{
+		ID:        newImportID(),
+		Source:    source,
+		Status:    "fetching",
+		StartedAt: time.Now(),
+	}
+
+	s.imports.mu.Lock()
+	if s.imports.jobs == nil {
+		s.imports.jobs = make(map[string]*ImportJob)
+	}
+	for id, j := range s.imports.jobs {
+		if j.FinishedAt != nil && time.Since(*j.FinishedAt) > importJobRetention {
+			delete(s.imports.jobs, id)
+		}
+	}
+	s.imports.jobs[job.ID] = job
+	snapshot := *job
+	s.imports.mu.Unlock()
+
+	go s.runImport(job, fetch)
+	return snapshot
+}
+
+// getImport returns a copy of an import job's current state
+func (s *Service) getImport(id string) (ImportJob, bool) {
+	s.imports.mu.Lock()
+	defer s.imports.mu.Unlock()
+	job, ok := s.imports.jobs[id]
+	if !ok {
+		return ImportJob{}, false
+	}
+	return *job, true
+}
+
+// updateImport mutates a job under lock and broadcasts the new state
+func (s *Service) updateImport(job *ImportJob, event string, fn func(j *ImportJob)) {
+	s.imports.mu.Lock()
+	fn(job)
+	snapshot := *job
+	s.imports.mu.Unlock()
+
+	ws.Broadcast("general_info", map[string]interface{}{
+		"event":     event,
+		"jobId":     snapshot.ID,
+		"source":    snapshot.Source,
+		"status":    snapshot.Status,
+		"added":     snapshot.Added,
+		"skipped":   snapshot.Skipped,
+		"processed": snapshot.Processed,
+		"total":     snapshot.Total,
+		"error":     snapshot.Error,
+	})
+}
+
+func (s *Service) runImport(job *ImportJob, fetch func() ([]string, error)) {
+	finish := func(err error) {
+		s.updateImport(job, "firewall:import:done", func(j *ImportJob) {
+			now := time.Now()
+			j.FinishedAt = &now
+			if err != nil {
+				j.Status = "failed"
+				j.Error = err.Error()
+			} else {
+				j.Status = "completed"
+			}
+		})
+	}
+
+	entries, err := fetch()
+	if err != nil {
+		finish(fmt.Errorf("failed to fetch blocklist: %v", err))
+		return
+	}
+
+	s.updateImport(job, "firewall:import:start", func(j *ImportJob) {
+		j.Status = "importing"
+		j.Total = len(entries)
+	})
+
+	added, err := s.importBlocklistEntries(job, entries)
+	if added > 0 {
+		s.RequestApply()
+	}
+	if err != nil {
+		log.Printf("Warning: blocklist import from %s failed: %v", job.Source, err)
+	}
+	finish(err)
+}
+
+// importableEntry normalizes a blocklist line, rejecting invalid, private,
+// allowlisted and protected values
+func (s *Service) importableEntry(entry string, allowlist []*net.IPNet, protected []net.IP) (string, bool, bool) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || isPrivateRange(entry) {
+		return "", false, false
+	}
+	value, isRange, err := validateIPOrCIDR(entry)
+	if err != nil || allowlistMatch(value, allowlist) != nil ||
+		s.bulkBlockSkipReason(value, isRange, protected) != "" {
+		return "", false, false
+	}
+	return value, isRange, true
+}
+
+// importBlocklistEntries inserts entries in a single transaction with a prepared
+// statement; large lists (50k+ entries) would otherwise commit one implicit
+// transaction per row. Duplicates are skipped by the unique index on
+// (entry_type, value, protocol). Returns the number of committed rows.
+func (s *Service) importBlocklistEntries(job *ImportJob, entries []string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("database error: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO firewall_entries
+		(entry_type, value, action, direction, protocol, source, reason, enabled)
+		VALUES (?, ?, 'block', 'inbound', 'both', ?, ?, 1)`)
+	if err != nil {
+		return 0, fmt.Errorf("database error: %v", err)
+	}
+	defer stmt.Close()
+
+	reason := fmt.Sprintf("Imported from %s", job.Source)
+	allowlist := s.allowlistNets()
+	protected := protectedIPs(nil)
+	added := 0
+	skipped := 0
+	for i, entry := range entries {
+		if added+skipped > 0 && i%importProgressEvery == 0 {
+			a, sk, n := added, skipped, i
+			s.updateImport(job, "firewall:import:progress", func(j *ImportJob) {
+				j.Added, j.Skipped, j.Processed = a, sk, n
+			})
+		}
+
+		normalizedIP, isRange, ok := s.importableEntry(entry, allowlist, protected)
+		if !ok {
+			skipped++
+			continue
+		}
+
+		entryType := nftables.EntryTypeIP
+		if isRange {
+			entryType = nftables.EntryTypeRange
+		}
+
+		result, err := stmt.Exec(entryType, normalizedIP, job.Source, reason)
+		if err != nil {
+			skipped++
+			continue
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected > 0 {
+			added++
+		} else {
+			skipped++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to save entries: %v", err)
+	}
+
+	s.imports.mu.Lock()
+	job.Added, job.Skipped, job.Processed = added, skipped, len(entries)
+	s.imports.mu.Unlock()
+	return added, nil
+}
+
+func newImportID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"api/internal/helper"
+	"api/internal/scheduler"
 )
 
 // runJailMonitors starts monitors for all enabled jails
@@ -33,27 +34,57 @@ func (s *Service) runJailMonitors() {
 		jails = append(jails, j)
 	}
 
+	started := 0
 	for _, jail := range jails {
-		s.startJailMonitor(jail.ID, jail.Name, jail.LogFile, jail.FilterRegex, jail.MaxRetry, jail.FindTime, jail.BanTime, jail.LastLogPos)
+		if s.startJailMonitor(jail.ID, jail.Name, jail.LogFile, jail.FilterRegex, jail.MaxRetry, jail.FindTime, jail.BanTime, jail.LastLogPos) == nil {
+			started++
+		}
 	}
 
-	log.Printf("Started %d jail monitors", len(jails))
+	log.Printf("Started %d of %d jail monitors", started, len(jails))
 }
 
-// startJailMonitor starts a jail monitor with its own cancellable context
-func (s *Service) startJailMonitor(jailID int64, name, logFile, filterRegex string, maxRetry, findTime, banTime int, lastLogPos int64) {
+// startJailMonitor starts a jail monitor with its own cancellable context.
+// It refuses to start beyond the MaxJailMonitors cap.
+func (s *Service) startJailMonitor(jailID int64, name, logFile, filterRegex string, maxRetry, findTime, banTime int, lastLogPos int64) error {
 	s.stopJailMonitor(jailID)
 
-	ctx, cancel := context.WithCancel(s.ctx)
-
 	s.jailMutex.Lock()
-	s.jailMonitors[jailID] = &jailMonitor{
-		cancel: cancel,
-		name:   name,
+	if s.config.MaxJailMonitors > 0 && len(s.jailMonitors) >= s.config.MaxJailMonitors {
+		s.jailMutex.Unlock()
+		log.Printf("Jail %s: not started, %d jail monitors already running (maxJailMonitors)", name, s.config.MaxJailMonitors)
+		return fmt.Errorf("jail monitor limit reached (%d)", s.config.MaxJailMonitors)
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	monitor := &jailMonitor{
+		cancel:    cancel,
+		name:      name,
+		logFile:   logFile,
+		startedAt: time.Now(),
 	}
+	s.jailMonitors[jailID] = monitor
 	s.jailMutex.Unlock()
 
-	go s.monitorJailWithContext(ctx, jailID, name, logFile, filterRegex, maxRetry, findTime, banTime, lastLogPos)
+	task := scheduler.RegisterTask("jail:"+name, "Jail monitor for "+logFile,
+		time.Duration(s.config.JailCheckInterval)*time.Second)
+	go func() {
+		// A monitor that exits on its own (bad path, panic) frees its slot
+		defer s.releaseJailMonitor(jailID, monitor)
+		defer task.Done(ctx)
+		s.monitorJailWithContext(ctx, task, jailID, name, logFile, filterRegex, maxRetry, findTime, banTime, lastLogPos)
+	}()
+	return nil
+}
+
+// releaseJailMonitor removes a monitor from the registry unless it was
+// already replaced by a newer monitor for the same jail
+func (s *Service) releaseJailMonitor(jailID int64, monitor *jailMonitor) {
+	s.jailMutex.Lock()
+	defer s.jailMutex.Unlock()
+	if s.jailMonitors[jailID] == monitor {
+		monitor.cancel()
+		delete(s.jailMonitors, jailID)
+	}
 }
 
 // stopJailMonitor stops a running jail monitor
@@ -82,13 +113,18 @@ func (s *Service) restartJailMonitor(jailID int64) {
 	s.stopJailMonitor(jailID)
 
 	if j.Enabled {
-		s.startJailMonitor(j.ID, j.Name, j.LogFile, j.FilterRegex, j.MaxRetry, j.FindTime, j.BanTime, j.LastLogPos)
-		log.Printf("Restarted jail monitor: %s", j.Name)
+		if s.startJailMonitor(j.ID, j.Name, j.LogFile, j.FilterRegex, j.MaxRetry, j.FindTime, j.BanTime, j.LastLogPos) == nil {
+			log.Printf("Restarted jail monitor: %s", j.Name)
+		}
 	}
 }
 
-// monitorJailWithContext monitors a log file for the jail with a cancellable context
-func (s *Service) monitorJailWithContext(ctx context.Context, jailID int64, name, logFile, filterRegex string, maxRetry, findTime, banTime int, lastLogPos int64) {
+// monitorJailWithContext monitors a log file for the jail with a cancellable context.
+// Jail monitors stay outside the scheduler's jobs: each one is started and
+// stopped with its jail, and the tail offset and attempt window live in this
+// goroutine, so the stale-IP cleanup runs on the same loop instead of as a
+// separate job that would need to lock them. Liveness is reported via task.
+func (s *Service) monitorJailWithContext(ctx context.Context, task *scheduler.Task, jailID int64, name, logFile, filterRegex string, maxRetry, findTime, banTime int, lastLogPos int64) {
 	// Validate log file path to prevent path injection
 	if err := helper.ValidateLogFilePath(logFile); err != nil {
 		log.Printf("Jail %s: invalid log file path %s: %v", name, logFile, err)
@@ -106,12 +142,14 @@ func (s *Service) monitorJailWithContext(ctx context.Context, jailID int64, name
 	ticker := time.NewTicker(time.Duration(s.config.JailCheckInterval) * time.Second)
 	defer ticker.Stop()
 
-	// Cleanup ticker removes stale IPs from memory every 5 minutes
-	cleanupTicker := time.NewTicker(5 * time.Minute)
-	defer cleanupTicker.Stop()
+	// Stale IPs are removed from memory every jailAttemptsCleanupInterval
+	lastCleanup := time.Now()
 
 	regex := regexp.MustCompile(filterRegex)
-	ipAttempts := make(map[string][]time.Time)
+	ipAttempts := s.loadRecentAttempts(name, findTime, maxRetry)
+	if len(ipAttempts) > 0 {
+		log.Printf("Jail %s: restored attempts of %d IPs within findTime", name, len(ipAttempts))
+	}
 
 	if lastLogPos == 0 {
 		if stat, err := os.Stat(logFile); err == nil {
@@ -127,24 +165,108 @@ func (s *Service) monitorJailWithContext(ctx context.Context, jailID int64, name
 			return
 		case <-ticker.C:
 			lastLogPos = s.processJailLogFile(name, logFile, regex, ipAttempts, lastLogPos, jailID, maxRetry, findTime, banTime)
-		case <-cleanupTicker.C:
-			// Remove IPs with no recent timestamps to prevent memory leak
-			cutoff := time.Now().Add(-time.Duration(findTime) * time.Second)
-			for ip, timestamps := range ipAttempts {
-				var recent []time.Time
-				for _, t := range timestamps {
-					if t.After(cutoff) {
-						recent = append(recent, t)
-					}
-				}
-				if len(recent) == 0 {
-					delete(ipAttempts, ip)
-				} else {
-					ipAttempts[ip] = recent
-				}
+			if time.Since(lastCleanup) >= jailAttemptsCleanupInterval {
+				pruneStaleAttempts(ipAttempts, findTime)
+				lastCleanup = time.Now()
+			}
+			task.Tick()
+		}
+	}
+}
+
+// jailAttemptsCleanupInterval is how often a monitor drops IPs whose attempts
+// all fell out of findTime
+const jailAttemptsCleanupInterval = 5 * time.Minute
+
+// pruneStaleAttempts removes IPs with no recent timestamps to prevent memory leak
+func pruneStaleAttempts(ipAttempts map[string][]time.Time, findTime int) {
+	cutoff := time.Now().Add(-time.Duration(findTime) * time.Second)
+	for ip, timestamps := range ipAttempts {
+		var recent []time.Time
+		for _, t := range timestamps {
+			if t.After(cutoff) {
+				recent = append(recent, t)
 			}
 		}
+		if len(recent) == 0 {
+			delete(ipAttempts, ip)
+		} else {
+			ipAttempts[ip] = recent
+		}
+	}
+}
+
+// maxRestoredAttempts bounds the fw log rows read when a monitor starts
+const maxRestoredAttempts = 50000
+
+// jailBanStatus marks the fw log row of the attempt that got an IP banned,
+// other attempts are logged as "blocked"
+const jailBanStatus = "banned"
+
+// loggedAttempt is one jail attempt read back from the fw logs
+type loggedAttempt struct {
+	ip     string
+	at     time.Time
+	banned bool // this attempt triggered a ban
+}
+
+// loadRecentAttempts rebuilds a jail's attempt window from the fw logs that
+// recordAttempt writes, so a restart doesn't reset IPs that were close to
+// maxRetry. Blocked IPs are left out.
+func (s *Service) loadRecentAttempts(name string, findTime, maxRetry int) map[string][]time.Time {
+	rows, err := s.db.Query(`
+		SELECT logs_src_ip, CAST(strftime('%s', logs_timestamp) AS INTEGER), COALESCE(logs_status, '') FROM logs
+		WHERE logs_type = 'fw' AND logs_service = ? AND logs_timestamp >= datetime('now', ?)
+		ORDER BY logs_timestamp DESC, logs_id DESC LIMIT ?`,
+		name, fmt.Sprintf("-%d seconds", findTime), maxRestoredAttempts)
+	if err != nil {
+		log.Printf("Jail %s: failed to restore attempts: %v", name, err)
+		return make(map[string][]time.Time)
+	}
+	defer rows.Close()
+
+	var logged []loggedAttempt
+	for rows.Next() {
+		var a loggedAttempt
+		var unix int64
+		var status string
+		if rows.Scan(&a.ip, &unix, &status) != nil {
+			continue
+		}
+		a.at, a.banned = time.Unix(unix, 0), status == jailBanStatus
+		logged = append(logged, a)
+	}
+
+	ipAttempts := attemptWindows(logged, maxRetry)
+	for ip := range ipAttempts {
+		if s.isIPBlocked(ip) {
+			delete(ipAttempts, ip)
+		}
+	}
+	return ipAttempts
+}
+
+// attemptWindows groups attempts (newest first) by IP, oldest first as the
+// monitor keeps them. Only attempts after an IP's last ban count, since the
+// ban consumed the earlier ones, and only the last maxRetry are kept.
+func attemptWindows(logged []loggedAttempt, maxRetry int) map[string][]time.Time {
+	ipAttempts := make(map[string][]time.Time)
+	banned := make(map[string]bool)
+	for _, a := range logged {
+		if a.banned {
+			banned[a.ip] = true
+		}
+		if banned[a.ip] || len(ipAttempts[a.ip]) >= maxRetry {
+			continue
+		}
+		ipAttempts[a.ip] = append(ipAttempts[a.ip], a.at)
 	}
+	for _, times := range ipAttempts {
+		for i, j := 0, len(times)-1; i < j; i, j = i+1, j-1 {
+			times[i], times[j] = times[j], times[i]
+		}
+	}
+	return ipAttempts
 }
 
 // processJailLogFile processes a jail log file and returns the new position
@@ -158,9 +280,17 @@ func (s *Service) processJailLogFile(name, logFile string, regex *regexp.Regexp,
 	if err != nil {
 		return lastLogPos
 	}
-	defer file.Close()
-
-	stat, _ := file.Stat()
+	// Deferred so the file is closed on every return and on panics
+	s.jailFiles.Add(1)
+	defer func() {
+		file.Close()
+		s.jailFiles.Add(-1)
+	}()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return lastLogPos
+	}
 	currentSize := stat.Size()
 
 	// File rotated?
@@ -170,6 +300,13 @@ func (s *Service) processJailLogFile(name, logFile string, regex *regexp.Regexp,
 
 	file.Seek(lastLogPos, 0)
 
+	// Port tally for the portscan jail, counted whether or not the IP gets banned
+	var probes map[int]int
+	if name == "portscan" && s.portStats.Load() {
+		probes = make(map[int]int)
+	}
+
+	allowlist := s.allowlistNets()
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -180,7 +317,13 @@ func (s *Service) processJailLogFile(name, logFile string, regex *regexp.Regexp,
 
 		srcIP := matches[1]
 
-		if s.isIgnoredIP(srcIP) || s.isIPBlocked(srcIP) {
+		if probes != nil && len(matches) >= 3 && !s.isIgnoredIP(srcIP) {
+			if port, err := strconv.Atoi(matches[2]); err == nil {
+				probes[port]++
+			}
+		}
+
+		if s.isIgnoredIP(srcIP) || allowlistMatch(srcIP, allowlist) != nil || s.isIPBlocked(srcIP) {
 			continue
 		}
 
@@ -195,12 +338,6 @@ func (s *Service) processJailLogFile(name, logFile string, regex *regexp.Regexp,
 		now := time.Now()
 		ipAttempts[srcIP] = append(ipAttempts[srcIP], now)
 
-		destPort := 0
-		if len(matches) >= 3 {
-			destPort, _ = strconv.Atoi(matches[2])
-		}
-		s.recordAttempt(srcIP, destPort, "tcp", name, "blocked")
-
 		// Clean old attempts outside findTime window
 		cutoff := now.Add(-time.Duration(findTime) * time.Second)
 		var recent []time.Time
@@ -211,17 +348,30 @@ func (s *Service) processJailLogFile(name, logFile string, regex *regexp.Regexp,
 		}
 		ipAttempts[srcIP] = recent
 
-		if len(recent) >= maxRetry {
+		destPort := 0
+		if len(matches) >= 3 {
+			destPort, _ = strconv.Atoi(matches[2])
+		}
+		ban := len(recent) >= maxRetry
+		status := "blocked"
+		if ban {
+			status = jailBanStatus
+		}
+		s.recordAttempt(srcIP, destPort, "tcp", name, status)
+
+		if ban {
 			s.blockIP(srcIP, name, fmt.Sprintf("Auto-blocked: %d attempts in %ds", len(recent), findTime), banTime)
 			delete(ipAttempts, srcIP)
 		}
 	}
 
+	s.recordPortProbes(probes)
 	s.db.Exec("UPDATE jails SET last_log_pos = ? WHERE id = ?", currentSize, jailID)
 	return currentSize
 }
 
-// ensureDefaultJails inserts default jails and ports if they don't exist
+// ensureDefaultJails inserts default jails if they don't exist and marks them as default.
+// Existing rows are never re-enabled, so an operator's disable choice survives restarts.
 func (s *Service) ensureDefaultJails() error {
 	sshPort := strconv.Itoa(helper.GetSSHPort())
 
@@ -235,9 +385,10 @@ func (s *Service) ensureDefaultJails() error {
 	}
 
 	for _, jail := range defaultJails {
-		s.db.Exec(`INSERT OR IGNORE INTO jails (name, enabled, log_file, filter_regex, max_retry, find_time, ban_time, port, action)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.db.Exec(`INSERT OR IGNORE INTO jails (name, enabled, log_file, filter_regex, max_retry, find_time, ban_time, port, action, is_default)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 1)`,
 			jail.Name, jail.Enabled, jail.LogFile, jail.FilterRegex, jail.MaxRetry, jail.FindTime, jail.BanTime, jail.Port, jail.Action)
+		s.db.Exec(`UPDATE jails SET is_default = 1 WHERE name = ?`, jail.Name)
 	}
 
 	s.db.Exec(`UPDATE jails SET port = ? WHERE name = 'sshd'`, sshPort)
@@ -0,0 +1,100 @@
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jailActionTimeout bounds a single run of a jail's action command
+const jailActionTimeout = 30 * time.Second
+
+// jailActionOutputLimit caps how much command output is logged
+const jailActionOutputLimit = 2048
+
+// jailActionPlaceholder matches {name} tokens in an action command template
+var jailActionPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// jailActionVars are the placeholders substituted when a jail bans an IP
+var jailActionVars = map[string]bool{
+	"{ip}":      true,
+	"{jail}":    true,
+	"{reason}":  true,
+	"{bantime}": true,
+}
+
+// parseActionCommand splits a template into the executable and its arguments.
+// The command is run directly, never through a shell, and placeholders are
+// substituted per argument after splitting, so a value containing spaces or
+// shell syntax stays a single literal argument.
+func parseActionCommand(template string) ([]string, error) {
+	args := strings.Fields(template)
+	if len(args) == 0 {
+		return nil, nil
+	}
+	if !filepath.IsAbs(args[0]) {
+		return nil, fmt.Errorf("actionCommand must start with an absolute path to the executable")
+	}
+	if jailActionPlaceholder.MatchString(args[0]) {
+		return nil, fmt.Errorf("actionCommand executable path can't contain placeholders")
+	}
+	for _, arg := range args[1:] {
+		for _, p := range jailActionPlaceholder.FindAllString(arg, -1) {
+			if !jailActionVars[p] {
+				return nil, fmt.Errorf("unknown actionCommand placeholder %s (use {ip}, {jail}, {reason} or {bantime})", p)
+			}
+		}
+	}
+	return args, nil
+}
+
+// validateActionCommand checks an action command template; empty is valid (no action)
+func validateActionCommand(template string) error {
+	_, err := parseActionCommand(template)
+	return err
+}
+
+// runJailAction runs the jail's action command for a ban in the background,
+// in addition to the nftables block. Output and failures only go to the log,
+// so a slow or broken command never holds up the jail monitor.
+func (s *Service) runJailAction(jailName, ip, reason string, banTime int) {
+	go func() {
+		var template string
+		if err := s.db.QueryRow(`SELECT COALESCE(action_command, '') FROM jails WHERE name = ?`, jailName).
+			Scan(&template); err != nil || template == "" {
+			return
+		}
+		args, err := parseActionCommand(template)
+		if err != nil {
+			log.Printf("Jail %s: action command skipped: %v", jailName, err)
+			return
+		}
+
+		vars := strings.NewReplacer("{ip}", ip, "{jail}", jailName, "{reason}", reason, "{bantime}", strconv.Itoa(banTime))
+		for i := 1; i < len(args); i++ {
+			args[i] = vars.Replace(args[i])
+		}
+
+		ctx, cancel := context.WithTimeout(s.ctx, jailActionTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		output := strings.TrimSpace(string(out))
+		if len(output) > jailActionOutputLimit {
+			output = output[:jailActionOutputLimit] + "..."
+		}
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			log.Printf("Jail %s: action command for %s timed out after %s", jailName, ip, jailActionTimeout)
+		case err != nil:
+			log.Printf("Jail %s: action command for %s failed: %v: %s", jailName, ip, err, output)
+		default:
+			log.Printf("Jail %s: action command for %s done: %s", jailName, ip, output)
+		}
+	}()
+}
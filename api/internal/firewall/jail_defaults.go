@@ -0,0 +1,148 @@
+package firewall
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"api/internal/router"
+	"api/internal/settings"
+)
+
+// jailDefaultsSetting stores the operator-defined defaults for new jails as JSON
+const jailDefaultsSetting = "firewall_jail_defaults"
+
+// JailDefaults are applied to fields a create request leaves empty (zero)
+type JailDefaults struct {
+	MaxRetry          int    `json:"maxRetry"`
+	FindTime          int    `json:"findTime"` // seconds
+	BanTime           int    `json:"banTime"`  // seconds
+	Action            string `json:"action"`
+	EscalateThreshold int    `json:"escalateThreshold"` // bans within the window before escalating
+	EscalateWindow    int    `json:"escalateWindow"`    // seconds
+}
+
+// builtinJailDefaults match the jails table column defaults
+var builtinJailDefaults = JailDefaults{
+	MaxRetry:          5,
+	FindTime:          600,
+	BanTime:           2592000,
+	Action:            "drop",
+	EscalateThreshold: 3,
+	EscalateWindow:    3600,
+}
+
+// loadJailDefaults returns the configured jail defaults; fields missing from
+// the setting keep their built-in value
+func loadJailDefaults() JailDefaults {
+	defaults := builtinJailDefaults
+	raw, err := settings.GetSetting(jailDefaultsSetting)
+	if err != nil || raw == "" {
+		return defaults
+	}
+
+	var stored JailDefaults
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+		log.Printf("jail-defaults: invalid %s setting: %v (using built-in defaults)", jailDefaultsSetting, err)
+		return defaults
+	}
+	defaults.merge(stored)
+	return defaults
+}
+
+// merge overrides d with the non-zero fields of o
+func (d *JailDefaults) merge(o JailDefaults) {
+	if o.MaxRetry > 0 {
+		d.MaxRetry = o.MaxRetry
+	}
+	if o.FindTime > 0 {
+		d.FindTime = o.FindTime
+	}
+	if o.BanTime > 0 {
+		d.BanTime = o.BanTime
+	}
+	if o.Action != "" {
+		d.Action = o.Action
+	}
+	if o.EscalateThreshold > 0 {
+		d.EscalateThreshold = o.EscalateThreshold
+	}
+	if o.EscalateWindow > 0 {
+		d.EscalateWindow = o.EscalateWindow
+	}
+}
+
+// applyJailDefaults fills the fields a new jail leaves empty
+func applyJailDefaults(jail *Jail, d JailDefaults) {
+	if jail.MaxRetry == 0 {
+		jail.MaxRetry = d.MaxRetry
+	}
+	if jail.FindTime == 0 {
+		jail.FindTime = d.FindTime
+	}
+	if jail.BanTime == 0 {
+		jail.BanTime = d.BanTime
+	}
+	if jail.Action == "" {
+		jail.Action = d.Action
+	}
+	if jail.EscalateThreshold == 0 {
+		jail.EscalateThreshold = d.EscalateThreshold
+	}
+	if jail.EscalateWindow == 0 {
+		jail.EscalateWindow = d.EscalateWindow
+	}
+}
+
+// handleGetJailDefaults returns the defaults applied to new jails
+func (s *Service) handleGetJailDefaults(w http.ResponseWriter, r *http.Request) {
+	raw, _ := settings.GetSetting(jailDefaultsSetting)
+	router.JSON(w, map[string]interface{}{
+		"defaults": loadJailDefaults(),
+		"builtin":  builtinJailDefaults,
+		"custom":   raw != "",
+	})
+}
+
+// handleSetJailDefaults stores the jail defaults; omitted or zero fields keep
+// the built-in value. {"reset": true} restores the built-in defaults.
+func (s *Service) handleSetJailDefaults(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		JailDefaults
+		Reset bool `json:"reset"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	if req.Reset {
+		if err := settings.DeleteSetting(jailDefaultsSetting); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.handleGetJailDefaults(w, r)
+		return
+	}
+
+	d := req.JailDefaults
+	if d.MaxRetry < 0 || d.FindTime < 0 || d.BanTime < 0 || d.EscalateThreshold < 0 || d.EscalateWindow < 0 {
+		router.JSONError(w, "jail defaults must not be negative", http.StatusBadRequest)
+		return
+	}
+	if d.Action != "" && d.Action != "drop" {
+		router.JSONError(w, "invalid action: only drop is supported", http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := settings.SetSetting(jailDefaultsSetting, string(data)); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.handleGetJailDefaults(w, r)
+}
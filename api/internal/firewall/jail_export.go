@@ -0,0 +1,212 @@
+package firewall
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"api/internal/helper"
+	"api/internal/router"
+)
+
+// jailExportVersion is bumped when the export format changes incompatibly
+const jailExportVersion = 1
+
+// JailDefinition is the portable part of a jail: its configuration without
+// IDs, counters or ban state
+type JailDefinition struct {
+	Name              string `json:"name"`
+	Enabled           bool   `json:"enabled"`
+	LogFile           string `json:"logFile"`
+	FilterRegex       string `json:"filterRegex"`
+	MaxRetry          int    `json:"maxRetry"`
+	FindTime          int    `json:"findTime"`
+	BanTime           int    `json:"banTime"`
+	Port              string `json:"port"`
+	Action            string `json:"action"`
+	EscalateEnabled   bool   `json:"escalateEnabled"`
+	EscalateThreshold int    `json:"escalateThreshold"`
+	EscalateWindow    int    `json:"escalateWindow"`
+}
+
+// JailExport is the document produced by ExportJails and read by ImportJails
+type JailExport struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exportedAt"`
+	Jails      []JailDefinition `json:"jails"`
+}
+
+// JailImportResult reports what an import did per jail
+type JailImportResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // created, updated or error
+	Error  string `json:"error,omitempty"`
+}
+
+// validJailName matches names that are safe as nftables comments and path segments
+var validJailName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// validate checks a definition before it is written, filling omitted fields
+// from the jail defaults
+func (d *JailDefinition) validate() error {
+	d.Name = strings.TrimSpace(d.Name)
+	if !validJailName.MatchString(d.Name) {
+		return fmt.Errorf("invalid name")
+	}
+	if d.FilterRegex == "" {
+		return fmt.Errorf("filterRegex is required")
+	}
+	if _, err := checkJailRegex(d.FilterRegex); err != nil {
+		return err
+	}
+	if d.LogFile == "" {
+		return fmt.Errorf("logFile is required")
+	}
+	if err := helper.ValidateLogFilePath(d.LogFile); err != nil {
+		return err
+	}
+	if d.MaxRetry < 0 || d.FindTime < 0 || d.BanTime < 0 || d.EscalateThreshold < 0 || d.EscalateWindow < 0 {
+		return fmt.Errorf("timings must not be negative")
+	}
+	if d.Action != "" && d.Action != "drop" {
+		return fmt.Errorf("invalid action: only drop is supported")
+	}
+
+	jail := Jail{MaxRetry: d.MaxRetry, FindTime: d.FindTime, BanTime: d.BanTime, Action: d.Action,
+		EscalateThreshold: d.EscalateThreshold, EscalateWindow: d.EscalateWindow}
+	applyJailDefaults(&jail, loadJailDefaults())
+	d.MaxRetry, d.FindTime, d.BanTime, d.Action = jail.MaxRetry, jail.FindTime, jail.BanTime, jail.Action
+	d.EscalateThreshold, d.EscalateWindow = jail.EscalateThreshold, jail.EscalateWindow
+	if d.Port == "" {
+		d.Port = "all"
+	}
+	return nil
+}
+
+// handleExportJails returns every jail definition as a downloadable JSON document
+func (s *Service) handleExportJails(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.db.Query(`SELECT name, enabled, log_file, filter_regex, max_retry, find_time, ban_time,
+		COALESCE(port, 'all'), COALESCE(action, 'drop'), COALESCE(escalate_enabled, 0),
+		COALESCE(escalate_threshold, 3), COALESCE(escalate_window, 3600)
+		FROM jails ORDER BY name`)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	export := JailExport{Version: jailExportVersion, ExportedAt: time.Now().UTC(), Jails: []JailDefinition{}}
+	for rows.Next() {
+		var d JailDefinition
+		if err := rows.Scan(&d.Name, &d.Enabled, &d.LogFile, &d.FilterRegex, &d.MaxRetry, &d.FindTime, &d.BanTime,
+			&d.Port, &d.Action, &d.EscalateEnabled, &d.EscalateThreshold, &d.EscalateWindow); err != nil {
+			continue
+		}
+		export.Jails = append(export.Jails, d)
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"jails-%s.json\"", time.Now().Format("20060102")))
+	router.JSON(w, export)
+}
+
+// handleImportJails upserts jail definitions by name. Every definition is
+// validated first; with any invalid entry nothing is written unless
+// ?partial=true, which imports the valid ones and reports the rest.
+func (s *Service) handleImportJails(w http.ResponseWriter, r *http.Request) {
+	var doc JailExport
+	if !router.DecodeJSONOrError(w, r, &doc) {
+		return
+	}
+	if doc.Version > jailExportVersion {
+		router.JSONError(w, fmt.Sprintf("unsupported export version %d", doc.Version), http.StatusBadRequest)
+		return
+	}
+	if len(doc.Jails) == 0 {
+		router.JSONError(w, "no jails to import", http.StatusBadRequest)
+		return
+	}
+	partial := router.QueryParam(r, "partial", "") == "true"
+
+	results := make([]JailImportResult, len(doc.Jails))
+	seen := make(map[string]bool)
+	invalid := 0
+	for i := range doc.Jails {
+		d := &doc.Jails[i]
+		results[i].Name = d.Name
+		err := d.validate()
+		if err == nil && seen[d.Name] {
+			err = fmt.Errorf("duplicate jail name")
+		}
+		if err != nil {
+			results[i].Status, results[i].Error = "error", err.Error()
+			invalid++
+			continue
+		}
+		seen[d.Name] = true
+	}
+	if invalid > 0 && !partial {
+		router.JSONWithStatus(w, map[string]interface{}{
+			"error":   fmt.Sprintf("%d invalid jail definition(s), nothing imported", invalid),
+			"results": results,
+		}, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	for i, d := range doc.Jails {
+		if results[i].Status == "error" {
+			continue
+		}
+		var existing int
+		tx.QueryRow("SELECT COUNT(*) FROM jails WHERE name = ?", d.Name).Scan(&existing)
+		if _, err := tx.Exec(`INSERT INTO jails (name, enabled, log_file, filter_regex, max_retry, find_time, ban_time, port, action,
+			escalate_enabled, escalate_threshold, escalate_window)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET enabled = excluded.enabled, log_file = excluded.log_file,
+			filter_regex = excluded.filter_regex, max_retry = excluded.max_retry, find_time = excluded.find_time,
+			ban_time = excluded.ban_time, port = excluded.port, action = excluded.action,
+			escalate_enabled = excluded.escalate_enabled, escalate_threshold = excluded.escalate_threshold,
+			escalate_window = excluded.escalate_window`,
+			d.Name, d.Enabled, d.LogFile, d.FilterRegex, d.MaxRetry, d.FindTime, d.BanTime, d.Port, d.Action,
+			d.EscalateEnabled, d.EscalateThreshold, d.EscalateWindow); err != nil {
+			router.JSONError(w, "failed to import jail "+d.Name+": "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if existing > 0 {
+			results[i].Status = "updated"
+		} else {
+			results[i].Status = "created"
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Restart monitors so the imported regexes and timings take effect
+	imported := 0
+	for i, d := range doc.Jails {
+		if results[i].Status == "error" {
+			continue
+		}
+		imported++
+		var jailID int64
+		if s.db.QueryRow("SELECT id FROM jails WHERE name = ?", d.Name).Scan(&jailID) == nil {
+			s.restartJailMonitor(jailID)
+		}
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"imported": imported,
+		"failed":   invalid,
+		"results":  results,
+	})
+}
@@ -0,0 +1,167 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"api/internal/router"
+)
+
+// jailRegexBudget is how long a filter may spend on all adversarial samples.
+// Go's RE2 engine can't backtrack catastrophically, but a huge pattern run
+// against a long line still costs time on every line the monitor reads.
+const jailRegexBudget = 250 * time.Millisecond
+
+// jailRegexSamples are worst-case log lines, each as long as the longest line
+// the jail monitor's scanner accepts
+var jailRegexSamples = func() []string {
+	fill := func(unit string) string {
+		return strings.Repeat(unit, bufio.MaxScanTokenSize/len(unit)+1)[:bufio.MaxScanTokenSize-1]
+	}
+	return []string{
+		fill("a"),
+		fill(" "),
+		fill("1."),
+		fill("9.9.9.9 "),
+		fill("SRC=10.0.0.1 DPT=22 "),
+		fill("Failed password for root from 203.0.113.7 port 22 ssh2 "),
+	}
+}()
+
+// checkJailRegex compiles a jail filter and runs it over the adversarial
+// samples in a goroutine, rejecting it if it overruns the time budget
+func checkJailRegex(pattern string) (time.Duration, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return 0, fmt.Errorf("invalid regex pattern: %v", err)
+	}
+
+	done := make(chan time.Duration, 1)
+	go func() {
+		start := time.Now()
+		for _, sample := range jailRegexSamples {
+			re.FindStringSubmatch(sample)
+		}
+		done <- time.Since(start)
+	}()
+
+	select {
+	case elapsed := <-done:
+		if elapsed > jailRegexBudget {
+			return elapsed, fmt.Errorf("regex pattern too slow: %s on worst-case lines (limit %s)",
+				elapsed.Round(time.Millisecond), jailRegexBudget)
+		}
+		return elapsed, nil
+	case <-time.After(jailRegexBudget):
+		// The goroutine finishes on its own: RE2 matching always terminates
+		return jailRegexBudget, fmt.Errorf("regex pattern too slow: exceeded %s on worst-case lines", jailRegexBudget)
+	}
+}
+
+// handleValidateJailRegex checks a filter regex without saving a jail,
+// optionally reporting what it captures from a sample log line
+func (s *Service) handleValidateJailRegex(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FilterRegex string `json:"filterRegex"`
+		Sample      string `json:"sample,omitempty"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if req.FilterRegex == "" {
+		router.JSONError(w, "filterRegex is required", http.StatusBadRequest)
+		return
+	}
+
+	elapsed, err := checkJailRegex(req.FilterRegex)
+	result := map[string]interface{}{
+		"valid":      err == nil,
+		"durationMs": elapsed.Milliseconds(),
+		"budgetMs":   jailRegexBudget.Milliseconds(),
+	}
+	if err != nil {
+		result["error"] = err.Error()
+		router.JSON(w, result)
+		return
+	}
+
+	if req.Sample != "" {
+		// Jails ban the IP in the first capture group
+		matches := regexp.MustCompile(req.FilterRegex).FindStringSubmatch(req.Sample)
+		result["matches"] = matches != nil
+		if len(matches) >= 2 {
+			result["ip"] = matches[1]
+		}
+	}
+	router.JSON(w, result)
+}
+
+// maxJailRegexTestLines caps the sample lines one regex test request may run
+const maxJailRegexTestLines = 500
+
+// handleTestJailRegex runs a filter regex over sample log lines the way the
+// jail monitor does, reporting per line what it matched and captured
+func (s *Service) handleTestJailRegex(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Regex       string   `json:"regex"`
+		SampleLines []string `json:"sampleLines"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if req.Regex == "" {
+		router.JSONError(w, "regex is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.SampleLines) > maxJailRegexTestLines {
+		router.JSONError(w, fmt.Sprintf("at most %d sample lines allowed", maxJailRegexTestLines), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := checkJailRegex(req.Regex); err != nil {
+		router.JSON(w, map[string]interface{}{"valid": false, "error": err.Error()})
+		return
+	}
+	regex := regexp.MustCompile(req.Regex)
+
+	type lineResult struct {
+		Line    string   `json:"line"`
+		Matched bool     `json:"matched"`
+		Groups  []string `json:"groups,omitempty"`
+		IP      string   `json:"ip,omitempty"`
+		Port    string   `json:"port,omitempty"`
+	}
+	results := make([]lineResult, 0, len(req.SampleLines))
+	matched := 0
+	for _, line := range req.SampleLines {
+		res := lineResult{Line: line}
+		// Same capture layout as monitorJailWithContext: group 1 is the IP, group 2 the port
+		if matches := regex.FindStringSubmatch(line); matches != nil {
+			res.Matched = true
+			res.Groups = matches[1:]
+			if len(matches) >= 2 {
+				res.IP = matches[1]
+			}
+			if len(matches) >= 3 {
+				res.Port = matches[2]
+			}
+			matched++
+		}
+		results = append(results, res)
+	}
+
+	result := map[string]interface{}{
+		"valid":   true,
+		"results": results,
+		"matched": matched,
+		"total":   len(results),
+	}
+	if regex.NumSubexp() == 0 {
+		result["warning"] = "regex has no capture group; the jail monitor skips lines without an IP in group 1"
+	}
+	router.JSON(w, result)
+}
@@ -0,0 +1,45 @@
+package firewall
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttemptWindows(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	// Newest first, as loadRecentAttempts reads them
+	logged := []loggedAttempt{
+		{ip: "203.0.113.1", at: at(50)},
+		{ip: "198.51.100.2", at: at(45)},
+		{ip: "203.0.113.1", at: at(40)},
+		{ip: "203.0.113.1", at: at(30), banned: true}, // ban consumed this and older attempts
+		{ip: "198.51.100.2", at: at(25)},
+		{ip: "203.0.113.1", at: at(20)},
+		{ip: "198.51.100.2", at: at(15)},
+		{ip: "198.51.100.2", at: at(10)},
+		{ip: "192.0.2.3", at: at(5), banned: true},
+	}
+	got := attemptWindows(logged, 3)
+
+	want := map[string][]time.Time{
+		"203.0.113.1":  {at(40), at(50)},
+		"198.51.100.2": {at(15), at(25), at(45)}, // capped at maxRetry, oldest dropped
+	}
+	if len(got) != len(want) {
+		t.Fatalf("restored IPs = %v, want %v", got, want)
+	}
+	for ip, times := range want {
+		if len(got[ip]) != len(times) {
+			t.Errorf("%s: attempts = %v, want %v", ip, got[ip], times)
+			continue
+		}
+		for i := range times {
+			if !got[ip][i].Equal(times[i]) {
+				t.Errorf("%s: attempts = %v, want %v", ip, got[ip], times)
+				break
+			}
+		}
+	}
+}
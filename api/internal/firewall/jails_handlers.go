@@ -2,7 +2,8 @@ package firewall
 
 import (
 	"net/http"
-	"regexp"
+	"sort"
+	"time"
 
 	"api/internal/helper"
 	"api/internal/router"
@@ -13,13 +14,28 @@ const jailQueryBase = `
 	SELECT j.id, j.name, j.enabled, j.log_file, j.filter_regex, j.max_retry, j.find_time, j.ban_time, j.port, j.action,
 		COUNT(CASE WHEN f.id IS NOT NULL AND f.enabled = 1 AND (f.expires_at IS NULL OR f.expires_at > datetime('now')) THEN 1 END) as currently_banned,
 		COUNT(f.id) as total_banned,
-		COALESCE(j.escalate_enabled, 0), COALESCE(j.escalate_threshold, 3), COALESCE(j.escalate_window, 3600)
+		COALESCE(j.escalate_enabled, 0), COALESCE(j.escalate_threshold, 3), COALESCE(j.escalate_window, 3600),
+		COALESCE(j.is_default, 0), COALESCE(j.ban_count, 0), COALESCE(j.manual_unblocks, 0), COALESCE(j.action_command, '')
 	FROM jails j
 	LEFT JOIN firewall_entries f ON j.name = f.name AND f.entry_type IN ('ip', 'range') AND f.action = 'block'`
 
 const jailGroupBy = `
 	GROUP BY j.id, j.name, j.enabled, j.log_file, j.filter_regex, j.max_retry, j.find_time, j.ban_time, j.port, j.action,
-		j.escalate_enabled, j.escalate_threshold, j.escalate_window`
+		j.escalate_enabled, j.escalate_threshold, j.escalate_window, j.is_default, j.ban_count, j.manual_unblocks, j.action_command`
+
+// A jail is flagged too aggressive once enough of its bans get manually unblocked
+const (
+	jailAggressiveMinBans = 10
+	jailAggressiveRatio   = 0.2
+)
+
+// setEffectiveness derives the unblock ratio and aggressiveness flag from the counters
+func (j *Jail) setEffectiveness() {
+	if j.BanCount > 0 {
+		j.UnblockRatio = float64(j.ManualUnblocks) / float64(j.BanCount)
+	}
+	j.TooAggressive = j.BanCount >= jailAggressiveMinBans && j.UnblockRatio >= jailAggressiveRatio
+}
 
 // handleGetJails returns all jails
 func (s *Service) handleGetJails(w http.ResponseWriter, r *http.Request) {
@@ -34,9 +50,11 @@ func (s *Service) handleGetJails(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		var j Jail
 		if err := rows.Scan(&j.ID, &j.Name, &j.Enabled, &j.LogFile, &j.FilterRegex, &j.MaxRetry, &j.FindTime, &j.BanTime, &j.Port, &j.Action,
-			&j.CurrentlyBanned, &j.TotalBanned, &j.EscalateEnabled, &j.EscalateThreshold, &j.EscalateWindow); err != nil {
+			&j.CurrentlyBanned, &j.TotalBanned, &j.EscalateEnabled, &j.EscalateThreshold, &j.EscalateWindow, &j.IsDefault,
+			&j.BanCount, &j.ManualUnblocks, &j.ActionCommand); err != nil {
 			continue
 		}
+		j.setEffectiveness()
 		jails = append(jails, j)
 	}
 	router.JSON(w, jails)
@@ -50,8 +68,8 @@ func (s *Service) handleCreateJail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if jail.FilterRegex != "" {
-		if _, err := regexp.Compile(jail.FilterRegex); err != nil {
-			router.JSONError(w, "invalid regex pattern: "+err.Error(), http.StatusBadRequest)
+		if _, err := checkJailRegex(jail.FilterRegex); err != nil {
+			router.JSONError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 	}
@@ -64,18 +82,19 @@ func (s *Service) handleCreateJail(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if jail.EscalateThreshold == 0 {
-		jail.EscalateThreshold = 3
-	}
-	if jail.EscalateWindow == 0 {
-		jail.EscalateWindow = 3600
+	if err := validateActionCommand(jail.ActionCommand); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
+	// Omitted fields take the configured jail defaults; the response has the effective values
+	applyJailDefaults(&jail, loadJailDefaults())
+
 	result, err := s.db.Exec(`INSERT INTO jails (name, enabled, log_file, filter_regex, max_retry, find_time, ban_time, port, action,
-		escalate_enabled, escalate_threshold, escalate_window)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		escalate_enabled, escalate_threshold, escalate_window, action_command)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		jail.Name, jail.Enabled, jail.LogFile, jail.FilterRegex, jail.MaxRetry, jail.FindTime, jail.BanTime, jail.Port, jail.Action,
-		jail.EscalateEnabled, jail.EscalateThreshold, jail.EscalateWindow)
+		jail.EscalateEnabled, jail.EscalateThreshold, jail.EscalateWindow, jail.ActionCommand)
 	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -84,7 +103,13 @@ func (s *Service) handleCreateJail(w http.ResponseWriter, r *http.Request) {
 	jail.ID, _ = result.LastInsertId()
 
 	if jail.Enabled {
-		s.startJailMonitor(jail.ID, jail.Name, jail.LogFile, jail.FilterRegex, jail.MaxRetry, jail.FindTime, jail.BanTime, 0)
+		if err := s.startJailMonitor(jail.ID, jail.Name, jail.LogFile, jail.FilterRegex, jail.MaxRetry, jail.FindTime, jail.BanTime, 0); err != nil {
+			router.JSON(w, map[string]interface{}{
+				"jail":    jail,
+				"warning": "jail saved but not monitored: " + err.Error(),
+			})
+			return
+		}
 	}
 
 	router.JSON(w, jail)
@@ -98,11 +123,13 @@ func (s *Service) handleGetJail(w http.ResponseWriter, r *http.Request) {
 		&jail.ID, &jail.Name, &jail.Enabled, &jail.LogFile, &jail.FilterRegex,
 		&jail.MaxRetry, &jail.FindTime, &jail.BanTime, &jail.Port, &jail.Action,
 		&jail.CurrentlyBanned, &jail.TotalBanned,
-		&jail.EscalateEnabled, &jail.EscalateThreshold, &jail.EscalateWindow)
+		&jail.EscalateEnabled, &jail.EscalateThreshold, &jail.EscalateWindow, &jail.IsDefault,
+		&jail.BanCount, &jail.ManualUnblocks, &jail.ActionCommand)
 	if err != nil {
 		router.JSONError(w, "jail not found", http.StatusNotFound)
 		return
 	}
+	jail.setEffectiveness()
 	router.JSON(w, jail)
 }
 
@@ -115,8 +142,8 @@ func (s *Service) handleUpdateJail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if jail.FilterRegex != "" {
-		if _, err := regexp.Compile(jail.FilterRegex); err != nil {
-			router.JSONError(w, "invalid regex pattern: "+err.Error(), http.StatusBadRequest)
+		if _, err := checkJailRegex(jail.FilterRegex); err != nil {
+			router.JSONError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 	}
@@ -129,14 +156,19 @@ func (s *Service) handleUpdateJail(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := validateActionCommand(jail.ActionCommand); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var jailID int64
 	_ = s.db.QueryRow("SELECT id FROM jails WHERE name = ?", name).Scan(&jailID)
 
 	_, err := s.db.Exec(`UPDATE jails SET enabled = ?, log_file = ?, filter_regex = ?, max_retry = ?,
 		find_time = ?, ban_time = ?, port = ?, action = ?,
-		escalate_enabled = ?, escalate_threshold = ?, escalate_window = ? WHERE name = ?`,
+		escalate_enabled = ?, escalate_threshold = ?, escalate_window = ?, action_command = ? WHERE name = ?`,
 		jail.Enabled, jail.LogFile, jail.FilterRegex, jail.MaxRetry, jail.FindTime, jail.BanTime, jail.Port, jail.Action,
-		jail.EscalateEnabled, jail.EscalateThreshold, jail.EscalateWindow, name)
+		jail.EscalateEnabled, jail.EscalateThreshold, jail.EscalateWindow, jail.ActionCommand, name)
 	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -155,7 +187,15 @@ func (s *Service) handleDeleteJail(w http.ResponseWriter, r *http.Request) {
 	name := router.ExtractPathParam(r, "/api/fw/jails/")
 
 	var jailID int64
-	_ = s.db.QueryRow("SELECT id FROM jails WHERE name = ?", name).Scan(&jailID)
+	var isDefault bool
+	_ = s.db.QueryRow("SELECT id, COALESCE(is_default, 0) FROM jails WHERE name = ?", name).Scan(&jailID, &isDefault)
+
+	// Default jails would be recreated on next startup, so only disabling is allowed
+	if isDefault {
+		router.JSONError(w, "default jail cannot be deleted, disable it instead", http.StatusConflict)
+		return
+	}
+
 	if jailID > 0 {
 		s.stopJailMonitor(jailID)
 	}
@@ -165,3 +205,30 @@ func (s *Service) handleDeleteJail(w http.ResponseWriter, r *http.Request) {
 	s.RequestApply()
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// JailMonitorInfo describes a running jail monitor
+type JailMonitorInfo struct {
+	JailID    int64     `json:"jailId"`
+	Name      string    `json:"name"`
+	LogFile   string    `json:"logFile"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// handleGetMonitorStats reports the running jail monitors against the cap
+// and the number of jail log files currently open
+func (s *Service) handleGetMonitorStats(w http.ResponseWriter, r *http.Request) {
+	s.jailMutex.RLock()
+	monitors := make([]JailMonitorInfo, 0, len(s.jailMonitors))
+	for id, m := range s.jailMonitors {
+		monitors = append(monitors, JailMonitorInfo{JailID: id, Name: m.name, LogFile: m.logFile, StartedAt: m.startedAt})
+	}
+	s.jailMutex.RUnlock()
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i].Name < monitors[j].Name })
+
+	router.JSON(w, map[string]interface{}{
+		"active":    len(monitors),
+		"max":       s.config.MaxJailMonitors,
+		"openFiles": s.jailFiles.Load(),
+		"monitors":  monitors,
+	})
+}
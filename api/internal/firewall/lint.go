@@ -0,0 +1,264 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+
+	"api/internal/helper"
+	"api/internal/nftables"
+	"api/internal/router"
+)
+
+// Lint issue kinds
+const (
+	LintConflict  = "conflict"  // allow and block overlap
+	LintDuplicate = "duplicate" // same network/port twice with the same action
+	LintShadowed  = "shadowed"  // fully covered by a broader entry with the same action
+)
+
+// maxLintIssues caps the issues returned by LintRules
+const maxLintIssues = 1000
+
+// LintEntry identifies a firewall entry in a lint issue
+type LintEntry struct {
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Action    string `json:"action"`
+	Direction string `json:"direction"`
+	Protocol  string `json:"protocol"`
+	Source    string `json:"source"`
+	Essential bool   `json:"essential"`
+}
+
+// LintIssue is a problem between two entries and how to resolve it
+type LintIssue struct {
+	Kind       string    `json:"kind"`
+	Entry      LintEntry `json:"entry"`   // the entry the suggestion acts on
+	Related    LintEntry `json:"related"` // the entry it conflicts with or is covered by
+	Message    string    `json:"message"`
+	Suggestion string    `json:"suggestion"`
+}
+
+// LintReport is the result of LintRules
+type LintReport struct {
+	Checked    int         `json:"checked"`
+	Conflicts  int         `json:"conflicts"`
+	Duplicates int         `json:"duplicates"`
+	Shadowed   int         `json:"shadowed"`
+	Truncated  bool        `json:"truncated"`
+	Issues     []LintIssue `json:"issues"`
+}
+
+func (r *LintReport) add(issue LintIssue) {
+	switch issue.Kind {
+	case LintConflict:
+		r.Conflicts++
+	case LintDuplicate:
+		r.Duplicates++
+	case LintShadowed:
+		r.Shadowed++
+	}
+	if len(r.Issues) >= maxLintIssues {
+		r.Truncated = true
+		return
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// lintAddr is an ip/range entry with its parsed network
+type lintAddr struct {
+	LintEntry
+	network *net.IPNet
+	ones    int
+}
+
+// lintPort is a port entry with its parsed range
+type lintPort struct {
+	LintEntry
+	start, end int
+}
+
+// LintRules analyzes the active (enabled, unexpired) entries for allow/block
+// conflicts, duplicates and entries shadowed by a broader one. Allow entries
+// win over address blocks, while port blocks win over port allows, matching
+// the order of the generated nftables rules.
+func (s *Service) LintRules() (LintReport, error) {
+	report := LintReport{Issues: []LintIssue{}}
+
+	rows, err := s.db.Query(`SELECT id, entry_type, value, action, direction, protocol, source, essential
+		FROM firewall_entries
+		WHERE enabled = 1 AND entry_type IN ('ip', 'range', 'port')
+		AND (expires_at IS NULL OR expires_at > datetime('now'))
+		ORDER BY id`)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	var addrs []lintAddr
+	var ports []lintPort
+	for rows.Next() {
+		var e LintEntry
+		if rows.Scan(&e.ID, &e.Type, &e.Value, &e.Action, &e.Direction, &e.Protocol, &e.Source, &e.Essential) != nil {
+			continue
+		}
+		report.Checked++
+		if e.Type == nftables.EntryTypePort {
+			start, end, err := helper.ParsePortRange(e.Value)
+			if err == nil {
+				ports = append(ports, lintPort{LintEntry: e, start: start, end: end})
+			}
+			continue
+		}
+		if n := parseNetwork(e.Value); n != nil {
+			ones, _ := n.Mask.Size()
+			addrs = append(addrs, lintAddr{LintEntry: e, network: n, ones: ones})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	lintAddresses(&report, addrs)
+	lintPorts(&report, ports)
+	return report, nil
+}
+
+// lintAddresses checks every address against the entries on its own and each
+// broader prefix, using a network index instead of comparing all pairs
+func lintAddresses(report *LintReport, addrs []lintAddr) {
+	index := make(map[string][]*lintAddr)
+	for i := range addrs {
+		key := addrs[i].network.String()
+		index[key] = append(index[key], &addrs[i])
+	}
+
+	for i := range addrs {
+		a := &addrs[i]
+		_, bits := a.network.Mask.Size()
+		shadowed := false
+
+		for ones := a.ones; ones >= 0; ones-- {
+			mask := net.CIDRMask(ones, bits)
+			key := (&net.IPNet{IP: a.network.IP.Mask(mask), Mask: mask}).String()
+
+			for _, b := range index[key] {
+				if b.ID == a.ID {
+					continue
+				}
+				sameNetwork := ones == a.ones
+
+				switch {
+				case sameNetwork && b.Action == a.Action && directionCovers(b.Direction, a.Direction) &&
+					(a.Direction != b.Direction || a.ID > b.ID):
+					// Reported on the narrower entry, or the newer one when they are identical
+					report.add(LintIssue{
+						Kind: LintDuplicate, Entry: a.LintEntry, Related: b.LintEntry,
+						Message:    fmt.Sprintf("%s %s is also configured by entry #%d (%s)", a.Action, a.Value, b.ID, b.Value),
+						Suggestion: deleteSuggestion(a.LintEntry, fmt.Sprintf("entry #%d already covers it", b.ID)),
+					})
+				case a.Action == nftables.ActionBlock && b.Action == nftables.ActionAllow && directionsOverlap(a.Direction, b.Direction):
+					// Allow entries are excluded from every address drop
+					where := "the same address"
+					if !sameNetwork {
+						where = "allowed range " + b.Value
+					}
+					report.add(LintIssue{
+						Kind: LintConflict, Entry: a.LintEntry, Related: b.LintEntry,
+						Message:    fmt.Sprintf("block %s never applies: entry #%d allows %s", a.Value, b.ID, where),
+						Suggestion: fmt.Sprintf("Delete block #%d, or delete or narrow allow #%d if the block is intended", a.ID, b.ID),
+					})
+				case !sameNetwork && !shadowed && b.Action == a.Action && directionCovers(b.Direction, a.Direction):
+					report.add(LintIssue{
+						Kind: LintShadowed, Entry: a.LintEntry, Related: b.LintEntry,
+						Message:    fmt.Sprintf("%s %s is inside %s (entry #%d)", a.Action, a.Value, b.Value, b.ID),
+						Suggestion: deleteSuggestion(a.LintEntry, fmt.Sprintf("%s already covers it", b.Value)),
+					})
+					shadowed = true
+				}
+			}
+		}
+	}
+}
+
+// lintPorts compares port entries pairwise; there are few enough of them
+func lintPorts(report *LintReport, ports []lintPort) {
+	sort.SliceStable(ports, func(i, j int) bool { return ports[i].start < ports[j].start })
+
+	for i := range ports {
+		a := &ports[i]
+		shadowed := false
+		for j := range ports {
+			b := &ports[j]
+			if a.ID == b.ID || b.end < a.start || b.start > a.end || !protocolsOverlap(a.Protocol, b.Protocol) {
+				continue
+			}
+			sameRange := a.start == b.start && a.end == b.end
+			covers := b.start <= a.start && b.end >= a.end
+
+			switch {
+			case a.Action != b.Action:
+				// Port blocks are dropped before allowed ports are accepted; report once, on the allow
+				if a.Action == nftables.ActionAllow && directionsOverlap(a.Direction, b.Direction) {
+					report.add(LintIssue{
+						Kind: LintConflict, Entry: a.LintEntry, Related: b.LintEntry,
+						Message:    fmt.Sprintf("allow %s/%s is overridden by block #%d (%s/%s)", a.Value, a.Protocol, b.ID, b.Value, b.Protocol),
+						Suggestion: fmt.Sprintf("Delete block #%d to open the port, or delete allow #%d", b.ID, a.ID),
+					})
+				}
+			case sameRange && protocolCovers(b.Protocol, a.Protocol) && directionCovers(b.Direction, a.Direction) &&
+				(a.Protocol != b.Protocol || a.Direction != b.Direction || a.ID > b.ID):
+				report.add(LintIssue{
+					Kind: LintDuplicate, Entry: a.LintEntry, Related: b.LintEntry,
+					Message:    fmt.Sprintf("%s %s/%s is also configured by entry #%d (%s/%s)", a.Action, a.Value, a.Protocol, b.ID, b.Value, b.Protocol),
+					Suggestion: deleteSuggestion(a.LintEntry, fmt.Sprintf("entry #%d already covers it", b.ID)),
+				})
+			case !sameRange && !shadowed && covers && protocolCovers(b.Protocol, a.Protocol) && directionCovers(b.Direction, a.Direction):
+				report.add(LintIssue{
+					Kind: LintShadowed, Entry: a.LintEntry, Related: b.LintEntry,
+					Message:    fmt.Sprintf("%s %s/%s is inside %s/%s (entry #%d)", a.Action, a.Value, a.Protocol, b.Value, b.Protocol, b.ID),
+					Suggestion: deleteSuggestion(a.LintEntry, fmt.Sprintf("%s/%s already covers it", b.Value, b.Protocol)),
+				})
+				shadowed = true
+			}
+		}
+	}
+}
+
+// deleteSuggestion proposes removing a redundant entry, unless it is essential
+func deleteSuggestion(e LintEntry, why string) string {
+	if e.Essential {
+		return fmt.Sprintf("Keep essential entry #%d; %s, so the overlap is harmless", e.ID, why)
+	}
+	return fmt.Sprintf("Delete entry #%d; %s", e.ID, why)
+}
+
+// directionCovers reports whether outer applies to all traffic inner applies to
+func directionCovers(outer, inner string) bool {
+	return outer == nftables.DirectionBoth || outer == inner
+}
+
+func directionsOverlap(a, b string) bool {
+	return a == b || a == nftables.DirectionBoth || b == nftables.DirectionBoth
+}
+
+func protocolCovers(outer, inner string) bool {
+	return outer == nftables.ProtocolBoth || outer == inner
+}
+
+func protocolsOverlap(a, b string) bool {
+	return a == b || a == nftables.ProtocolBoth || b == nftables.ProtocolBoth
+}
+
+// handleLintRules reports conflicting, duplicate and shadowed firewall entries
+func (s *Service) handleLintRules(w http.ResponseWriter, r *http.Request) {
+	report, err := s.LintRules()
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, report)
+}
@@ -0,0 +1,174 @@
+package firewall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"api/internal/router"
+	"api/internal/scheduler"
+	"api/internal/settings"
+)
+
+// multiJailSetting stores the cross-jail aggregation config as JSON
+const multiJailSetting = "firewall_multi_jail"
+
+// multiJailJob is the scheduler job name for cross-jail aggregation
+const multiJailJob = "firewall-multi-jail"
+
+// multiJailName is the synthetic jail aggregated bans are recorded under
+const multiJailName = "multi"
+
+// MultiJailConfig bans IPs whose attempts across all jails add up to more than
+// Threshold within the window, even though no single jail banned them.
+// MinJails requires the attempts to be spread over that many distinct jails.
+type MultiJailConfig struct {
+	Enabled       bool `json:"enabled"`
+	Threshold     int  `json:"threshold"`
+	WindowMinutes int  `json:"windowMinutes"`
+	MinJails      int  `json:"minJails"`
+	BanTime       int  `json:"banTime"` // seconds, 0 = permanent
+}
+
+var defaultMultiJailConfig = MultiJailConfig{Threshold: 10, WindowMinutes: 60, MinJails: 2, BanTime: 86400}
+
+// MultiJailOffender is an IP over the aggregated threshold
+type MultiJailOffender struct {
+	IP       string `json:"ip"`
+	Attempts int    `json:"attempts"`
+	Jails    int    `json:"jails"`
+}
+
+func loadMultiJailConfig() MultiJailConfig {
+	cfg := defaultMultiJailConfig
+	raw, err := settings.GetSetting(multiJailSetting)
+	if err != nil || raw == "" {
+		return cfg
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		log.Printf("multi jail: invalid %s setting: %v (using defaults)", multiJailSetting, err)
+		return defaultMultiJailConfig
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = defaultMultiJailConfig.Threshold
+	}
+	if cfg.WindowMinutes <= 0 {
+		cfg.WindowMinutes = defaultMultiJailConfig.WindowMinutes
+	}
+	if cfg.MinJails <= 0 {
+		cfg.MinJails = defaultMultiJailConfig.MinJails
+	}
+	return cfg
+}
+
+// scheduleMultiJail checks for aggregated offenders every minute
+func (s *Service) scheduleMultiJail() {
+	scheduler.Every(multiJailJob, "Ban IPs over the attempt threshold across all jails", time.Minute,
+		func(ctx context.Context) error {
+			_, err := s.banMultiJailOffenders()
+			return err
+		})
+}
+
+// multiJailOffenders returns IPs with more than Threshold jail attempts in the
+// window, spread over at least MinJails jails, that aren't banned or ignored.
+// Jail attempts are the fw rows of the logs table, keyed by jail in logs_service.
+func (s *Service) multiJailOffenders(cfg MultiJailConfig) ([]MultiJailOffender, error) {
+	rows, err := s.db.Query(`
+		SELECT logs_src_ip, COUNT(*), COUNT(DISTINCT logs_service)
+		FROM logs
+		WHERE logs_type = 'fw' AND logs_timestamp >= datetime('now', ?)
+			AND COALESCE(logs_service, '') NOT IN ('', ?)
+		GROUP BY logs_src_ip
+		HAVING COUNT(*) > ? AND COUNT(DISTINCT logs_service) >= ?
+		ORDER BY COUNT(*) DESC`,
+		fmt.Sprintf("-%d minutes", cfg.WindowMinutes), multiJailName, cfg.Threshold, cfg.MinJails)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offenders := []MultiJailOffender{}
+	for rows.Next() {
+		var o MultiJailOffender
+		if rows.Scan(&o.IP, &o.Attempts, &o.Jails) != nil {
+			continue
+		}
+		if s.isIgnoredIP(o.IP) || s.isIPBlocked(o.IP) {
+			continue
+		}
+		offenders = append(offenders, o)
+	}
+	return offenders, rows.Err()
+}
+
+// banMultiJailOffenders bans the current offenders under the multi jail
+func (s *Service) banMultiJailOffenders() ([]MultiJailOffender, error) {
+	cfg := loadMultiJailConfig()
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	offenders, err := s.multiJailOffenders(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range offenders {
+		s.blockIP(o.IP, multiJailName, fmt.Sprintf("Auto-blocked: %d attempts across %d jails in %dm",
+			o.Attempts, o.Jails, cfg.WindowMinutes), cfg.BanTime)
+	}
+	return offenders, nil
+}
+
+// handleGetMultiJail returns the aggregation config and the IPs it would ban now
+func (s *Service) handleGetMultiJail(w http.ResponseWriter, r *http.Request) {
+	cfg := loadMultiJailConfig()
+	offenders, err := s.multiJailOffenders(cfg)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"config":    cfg,
+		"offenders": offenders,
+		"count":     len(offenders),
+	})
+}
+
+// handleSetMultiJail stores the aggregation config and runs a check right away
+func (s *Service) handleSetMultiJail(w http.ResponseWriter, r *http.Request) {
+	var cfg MultiJailConfig
+	if !router.DecodeJSONOrError(w, r, &cfg) {
+		return
+	}
+	if cfg.Threshold < 0 || cfg.MinJails < 0 || cfg.BanTime < 0 || cfg.WindowMinutes < 0 || cfg.WindowMinutes > 10080 {
+		router.JSONError(w, "threshold, minJails and banTime must not be negative and windowMinutes must be between 0 and 10080", http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := settings.SetSetting(multiJailSetting, string(data)); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	banned, err := s.banMultiJailOffenders()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if banned == nil {
+		banned = []MultiJailOffender{}
+	}
+	router.JSON(w, map[string]interface{}{
+		"config": loadMultiJailConfig(),
+		"banned": banned,
+		"count":  len(banned),
+	})
+}
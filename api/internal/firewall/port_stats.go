@@ -0,0 +1,156 @@
+package firewall
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"api/internal/router"
+	"api/internal/settings"
+)
+
+// portStatsSetting stores the port probe tally config as JSON
+const portStatsSetting = "firewall_port_stats"
+
+// PortStatsConfig controls the per-port tally of dropped packets. Drops are
+// read from the portscan jail's log using its DPT capture, so the tally covers
+// every probe, not only those from IPs that ended up banned.
+type PortStatsConfig struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retentionDays"`
+}
+
+var defaultPortStatsConfig = PortStatsConfig{RetentionDays: 30}
+
+// PortProbeStat is one destination port's drops over the requested window
+type PortProbeStat struct {
+	Port     int    `json:"port"`
+	Hits     int    `json:"hits"`
+	Days     int    `json:"days"` // days with at least one drop
+	LastSeen string `json:"lastSeen"`
+}
+
+func loadPortStatsConfig() PortStatsConfig {
+	cfg := defaultPortStatsConfig
+	raw, err := settings.GetSetting(portStatsSetting)
+	if err != nil || raw == "" {
+		return cfg
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		log.Printf("port stats: invalid %s setting: %v (using defaults)", portStatsSetting, err)
+		return defaultPortStatsConfig
+	}
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = defaultPortStatsConfig.RetentionDays
+	}
+	return cfg
+}
+
+// recordPortProbes adds one pass's per-port drop counts to today's rows
+func (s *Service) recordPortProbes(probes map[int]int) {
+	if len(probes) == 0 {
+		return
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+	for port, hits := range probes {
+		if _, err := tx.Exec(`INSERT INTO port_probe_stats (port, day, hits, last_seen)
+			VALUES (?, date('now'), ?, datetime('now'))
+			ON CONFLICT(port, day) DO UPDATE SET hits = hits + excluded.hits, last_seen = excluded.last_seen`,
+			port, hits); err != nil {
+			log.Printf("port stats: failed to record probes: %v", err)
+			return
+		}
+	}
+	tx.Commit()
+}
+
+// prunePortProbes drops tally rows past the retention period
+func (s *Service) prunePortProbes() {
+	cfg := loadPortStatsConfig()
+	s.db.Exec(`DELETE FROM port_probe_stats WHERE day < date('now', ?)`,
+		fmt.Sprintf("-%d days", cfg.RetentionDays))
+}
+
+// topProbedPorts returns the most-dropped ports over the last days
+func (s *Service) topProbedPorts(days, limit int) ([]PortProbeStat, int, error) {
+	window := fmt.Sprintf("-%d days", days-1)
+	var total int
+	s.db.QueryRow(`SELECT COALESCE(SUM(hits), 0) FROM port_probe_stats WHERE day >= date('now', ?)`, window).Scan(&total)
+
+	rows, err := s.db.Query(`
+		SELECT port, SUM(hits), COUNT(*), MAX(last_seen)
+		FROM port_probe_stats
+		WHERE day >= date('now', ?)
+		GROUP BY port
+		ORDER BY SUM(hits) DESC, port
+		LIMIT ?`, window, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	stats := []PortProbeStat{}
+	for rows.Next() {
+		var p PortProbeStat
+		if rows.Scan(&p.Port, &p.Hits, &p.Days, &p.LastSeen) == nil {
+			stats = append(stats, p)
+		}
+	}
+	return stats, total, rows.Err()
+}
+
+// handleGetPortScanStats returns the most-probed ports (?days=7&limit=20)
+func (s *Service) handleGetPortScanStats(w http.ResponseWriter, r *http.Request) {
+	cfg := loadPortStatsConfig()
+	days := router.QueryParamInt(r, "days", 7)
+	if days < 1 || days > cfg.RetentionDays {
+		days = min(7, cfg.RetentionDays)
+	}
+	limit := router.QueryParamInt(r, "limit", 20)
+	if limit < 1 || limit > 500 {
+		limit = 20
+	}
+
+	ports, total, err := s.topProbedPorts(days, limit)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"config":    cfg,
+		"days":      days,
+		"totalHits": total,
+		"ports":     ports,
+	})
+}
+
+// handleSetPortScanStats stores the tally config; disabling keeps collected rows
+func (s *Service) handleSetPortScanStats(w http.ResponseWriter, r *http.Request) {
+	var cfg PortStatsConfig
+	if !router.DecodeJSONOrError(w, r, &cfg) {
+		return
+	}
+	if cfg.RetentionDays < 0 || cfg.RetentionDays > 365 {
+		router.JSONError(w, "retentionDays must be between 0 and 365", http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := settings.SetSetting(portStatsSetting, string(data)); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.portStats.Store(cfg.Enabled)
+	s.prunePortProbes()
+
+	router.JSON(w, map[string]interface{}{"config": loadPortStatsConfig()})
+}
@@ -1,12 +1,14 @@
 package firewall
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -19,10 +21,31 @@ import (
 type PortEntry struct {
 	ID        int64  `json:"id,omitempty"`
 	Port      int    `json:"port"`
+	EndPort   int    `json:"endPort,omitempty"` // set for port ranges
 	Protocol  string `json:"protocol"`
 	Essential bool   `json:"essential"`
 	Service   string `json:"service,omitempty"`
-	Source    string `json:"source,omitempty"`
+	Source    string `json:"source,omitempty"` // db, docker or essential
+	Removable bool   `json:"removable"`
+}
+
+// Port source attribution returned by handleGetPorts
+const (
+	PortSourceDB        = "db"        // user-managed row, can be removed
+	PortSourceDocker    = "docker"    // published by a running container
+	PortSourceEssential = "essential" // required by the panel itself
+)
+
+// portSource maps a firewall_entries source column to a port attribution
+func portSource(dbSource string, essential bool) string {
+	switch {
+	case dbSource == "docker":
+		return PortSourceDocker
+	case essential:
+		return PortSourceEssential
+	default:
+		return PortSourceDB
+	}
 }
 
 // handleGetPorts returns allowed ports (from firewall_entries + Docker)
@@ -46,9 +69,20 @@ func (s *Service) handleGetPorts(w http.ResponseWriter, r *http.Request) {
 		if err := rows.Scan(&p.ID, &portStr, &p.Protocol, &p.Essential, &p.Service, &p.Source); err != nil {
 			continue
 		}
-		p.Port, _ = strconv.Atoi(portStr)
+		start, end, err := helper.ParsePortRange(portStr)
+		if err != nil {
+			continue
+		}
+		p.Port = start
+		if end != start {
+			p.EndPort = end
+		}
+		p.Source = portSource(p.Source, p.Essential)
+		p.Removable = !p.Essential
 		ports = append(ports, p)
-		portMap[fmt.Sprintf("%d-%s", p.Port, p.Protocol)] = true
+		for port := start; port <= end; port++ {
+			portMap[fmt.Sprintf("%d-%s", port, p.Protocol)] = true
+		}
 	}
 
 	// Add Docker exposed ports (mark as essential since they're required for containers)
@@ -56,6 +90,7 @@ func (s *Service) handleGetPorts(w http.ResponseWriter, r *http.Request) {
 	for _, dp := range dockerPorts {
 		key := fmt.Sprintf("%d-%s", dp.Port, dp.Protocol)
 		if !portMap[key] {
+			dp.Source = PortSourceDocker
 			ports = append(ports, dp)
 		}
 	}
@@ -67,6 +102,7 @@ func (s *Service) handleGetPorts(w http.ResponseWriter, r *http.Request) {
 func (s *Service) handleAddPort(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Port     int    `json:"port"`
+		EndPort  int    `json:"endPort"` // optional, opens port..endPort
 		Protocol string `json:"protocol"`
 		Service  string `json:"service"`
 	}
@@ -74,10 +110,18 @@ func (s *Service) handleAddPort(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Port < 1 || req.Port > 65535 {
+	if req.EndPort == 0 {
+		req.EndPort = req.Port
+	}
+	if helper.ValidatePort(req.Port) != nil || helper.ValidatePort(req.EndPort) != nil {
 		router.JSONError(w, "invalid port number (must be 1-65535)", http.StatusBadRequest)
 		return
 	}
+	if req.Port > req.EndPort {
+		router.JSONError(w, "port range start must not exceed end", http.StatusBadRequest)
+		return
+	}
+	value := helper.FormatPortRange(req.Port, req.EndPort)
 
 	if req.Protocol == "" {
 		req.Protocol = nftables.ProtocolTCP
@@ -85,13 +129,12 @@ func (s *Service) handleAddPort(w http.ResponseWriter, r *http.Request) {
 
 	// Check if it's an essential port
 	isEssential := false
-	for _, ep := range s.config.EssentialPorts {
-		if req.Port == ep.Port && req.Protocol == ep.Protocol {
+	if req.Port == req.EndPort {
+		if ep, ok := s.isEssentialPort(req.Port, req.Protocol); ok {
 			isEssential = true
 			if req.Service == "" {
 				req.Service = ep.Service
 			}
-			break
 		}
 	}
 
@@ -100,7 +143,7 @@ func (s *Service) handleAddPort(w http.ResponseWriter, r *http.Request) {
 		VALUES ('port', ?, 'allow', 'inbound', ?, 'manual', ?, ?, 1)
 		ON CONFLICT(entry_type, value, protocol) DO UPDATE SET
 		name = excluded.name, enabled = 1`,
-		strconv.Itoa(req.Port), req.Protocol, req.Service, isEssential)
+		value, req.Protocol, req.Service, isEssential)
 	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -109,6 +152,7 @@ func (s *Service) handleAddPort(w http.ResponseWriter, r *http.Request) {
 	s.RequestApply()
 	router.JSON(w, map[string]interface{}{
 		"port":      req.Port,
+		"endPort":   req.EndPort,
 		"protocol":  req.Protocol,
 		"essential": isEssential,
 		"service":   req.Service,
@@ -118,28 +162,50 @@ func (s *Service) handleAddPort(w http.ResponseWriter, r *http.Request) {
 // handleRemovePort removes an allowed port
 func (s *Service) handleRemovePort(w http.ResponseWriter, r *http.Request) {
 	portStr := router.ExtractPathParam(r, "/api/fw/ports/")
-	port, err := strconv.Atoi(portStr)
+	start, end, err := helper.ParsePortRange(portStr)
 	if err != nil {
 		router.JSONError(w, "invalid port", http.StatusBadRequest)
 		return
 	}
+	portStr = helper.FormatPortRange(start, end)
+
+	// Optional protocol limits removal to that row; empty removes every protocol
+	protocol := r.URL.Query().Get("protocol")
+	switch protocol {
+	case "", nftables.ProtocolTCP, nftables.ProtocolUDP, nftables.ProtocolBoth:
+	default:
+		router.JSONError(w, "invalid protocol (must be tcp, udp or both)", http.StatusBadRequest)
+		return
+	}
+	essentialProtocol := protocol
+	if protocol == nftables.ProtocolBoth {
+		essentialProtocol = "" // removing both conflicts with an essential tcp or udp port
+	}
 
-	// Check if essential
+	// Check if essential (configured essential ports and Docker-published rows)
+	if _, ok := s.isEssentialPort(start, essentialProtocol); ok && start == end {
+		router.JSONError(w, "cannot remove essential port", http.StatusForbidden)
+		return
+	}
 	var essential bool
-	err = s.db.QueryRow("SELECT essential FROM firewall_entries WHERE entry_type = 'port' AND value = ?", portStr).Scan(&essential)
+	err = s.db.QueryRow(`SELECT COALESCE(MAX(essential), 0) FROM firewall_entries
+		WHERE entry_type = 'port' AND value = ? AND (? = '' OR protocol = ?)`,
+		portStr, protocol, protocol).Scan(&essential)
 	if err == nil && essential {
 		router.JSONError(w, "cannot remove essential port", http.StatusForbidden)
 		return
 	}
 
-	_, err = s.db.Exec("DELETE FROM firewall_entries WHERE entry_type = 'port' AND value = ? AND essential = 0", portStr)
+	_, err = s.db.Exec(`DELETE FROM firewall_entries
+		WHERE entry_type = 'port' AND value = ? AND (? = '' OR protocol = ?) AND essential = 0`,
+		portStr, protocol, protocol)
 	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	s.RequestApply()
-	router.JSON(w, map[string]interface{}{"status": "removed", "port": port})
+	router.JSON(w, map[string]interface{}{"status": "removed", "port": start, "endPort": end, "protocol": protocol})
 }
 
 // handleChangeSSHPort changes the SSH port
@@ -218,7 +284,7 @@ func (s *Service) handleChangeSSHPort(w http.ResponseWriter, r *http.Request) {
 		if _, err := s.db.Exec("DELETE FROM firewall_entries WHERE entry_type = 'port' AND value = ? AND name = 'SSH'", strconv.Itoa(oldPort)); err != nil {
 			log.Printf("Warning: failed to delete old SSH port entry: %v", err)
 		}
-		s.config.EssentialPorts = helper.BuildEssentialPorts()
+		s.config.EssentialPorts = loadEssentialPorts()
 		s.ApplyRules()
 	}
 
@@ -235,7 +301,8 @@ func (s *Service) handleChangeSSHPort(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// getDockerExposedPorts returns ports exposed by Docker containers
+// getDockerExposedPorts returns ports exposed by Docker containers, or nil
+// when the Docker API can't be reached
 func (s *Service) getDockerExposedPorts() []PortEntry {
 	client := helper.NewDockerHTTPClientWithTimeout(helper.DockerQuickTimeout)
 
@@ -249,6 +316,9 @@ func (s *Service) getDockerExposedPorts() []PortEntry {
 		return nil
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
 
 	var rawContainers []struct {
 		Names []string
@@ -305,6 +375,7 @@ func (s *Service) getDockerExposedPorts() []PortEntry {
 //     ports currently published by running containers. That way containers that
 //     have stopped drop their allow rules; manually-added rows (source='manual')
 //     and system rows (source='system') are never touched.
+//   - Leaves the rows alone when the Docker API can't be reached.
 //   - Uses INSERT OR IGNORE so if a port is already present with a different
 //     source (e.g. an essential port that a container happens to publish), the
 //     existing row wins and no duplicate is created.
@@ -316,31 +387,101 @@ func (s *Service) SyncDockerPortsToDB() (int, error) {
 		return 0, nil
 	}
 
+	// nil means Docker couldn't be asked (not that nothing is published); keep
+	// the existing rows rather than dropping every container's allow rules
 	discovered := s.getDockerExposedPorts()
+	if discovered == nil {
+		return 0, fmt.Errorf("Docker API unavailable, keeping existing docker ports")
+	}
+
+	// Wipe old docker-source rows and re-insert in one transaction, so a failed
+	// sync never leaves the published ports without their allow rules
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("database error: %w", err)
+	}
+	defer tx.Rollback()
 
-	// Wipe old docker-source rows so ports of removed containers stop being allowed.
-	if _, err := s.db.Exec("DELETE FROM firewall_entries WHERE entry_type = 'port' AND source = 'docker'"); err != nil {
+	if _, err := tx.Exec("DELETE FROM firewall_entries WHERE entry_type = 'port' AND source = 'docker'"); err != nil {
 		return 0, fmt.Errorf("clear stale docker ports: %w", err)
 	}
+	added, _, err := insertDockerPorts(tx, discovered)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("save docker ports: %w", err)
+	}
+	inserted := len(added)
 
-	inserted := 0
+	log.Printf("firewall: docker port sync — %d discovered, %d inserted (rest were already present)", len(discovered), inserted)
+	return inserted, nil
+}
+
+// insertDockerPorts adds discovered ports as essential docker-source allow rows
+// within tx. Ports already present (any source) are returned as skipped; the
+// first failed insert aborts, leaving the caller to roll back.
+func insertDockerPorts(tx *sql.Tx, discovered []PortEntry) (added, skipped []PortEntry, err error) {
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO firewall_entries
+		(entry_type, value, action, direction, protocol, source, name, essential, enabled)
+		VALUES ('port', ?, 'allow', 'inbound', ?, 'docker', ?, 1, 1)`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	defer stmt.Close()
+
+	added, skipped = []PortEntry{}, []PortEntry{}
 	for _, dp := range discovered {
 		if dp.Port < 1 || dp.Port > 65535 {
 			continue
 		}
-		res, err := s.db.Exec(`INSERT OR IGNORE INTO firewall_entries
-			(entry_type, value, action, direction, protocol, source, name, essential, enabled)
-			VALUES ('port', ?, 'allow', 'inbound', ?, 'docker', ?, 1, 1)`,
-			strconv.Itoa(dp.Port), dp.Protocol, dp.Service)
+		res, err := stmt.Exec(strconv.Itoa(dp.Port), dp.Protocol, dp.Service)
 		if err != nil {
-			log.Printf("firewall: failed to insert docker port %d/%s: %v", dp.Port, dp.Protocol, err)
-			continue
+			return nil, nil, fmt.Errorf("insert docker port %d/%s: %w", dp.Port, dp.Protocol, err)
 		}
 		if n, _ := res.RowsAffected(); n > 0 {
-			inserted++
+			added = append(added, dp)
+		} else {
+			skipped = append(skipped, dp)
 		}
 	}
+	return added, skipped, nil
+}
 
-	log.Printf("firewall: docker port sync — %d discovered, %d inserted (rest were already present)", len(discovered), inserted)
-	return inserted, nil
+// handleImportDockerPorts allows every port published by a running container
+// in one go. Rows use the docker source, so like SyncDockerPortsToDB they are
+// dropped on the next sync once their container is gone. Ports already present
+// (any source) are reported as skipped.
+func (s *Service) handleImportDockerPorts(w http.ResponseWriter, r *http.Request) {
+	discovered := s.getDockerExposedPorts()
+	if discovered == nil {
+		router.JSONError(w, "Docker API unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	sort.Slice(discovered, func(i, j int) bool { return discovered[i].Port < discovered[j].Port })
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	added, skipped, err := insertDockerPorts(tx, discovered)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		router.JSONError(w, "failed to save ports: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(added) > 0 {
+		s.RequestApply()
+	}
+	router.JSON(w, map[string]interface{}{
+		"added":   added,
+		"skipped": skipped,
+		"count":   len(added),
+	})
 }
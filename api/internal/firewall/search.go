@@ -0,0 +1,191 @@
+package firewall
+
+import (
+	"bytes"
+	"database/sql"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"api/internal/database"
+	"api/internal/router"
+)
+
+// Block stores searched by SearchBlocks
+const (
+	StoreEntries  = "firewall_entries" // configured blocks (DB)
+	StoreNftables = "nftables"         // elements loaded in the live address sets
+)
+
+// maxBlockSearchResults caps a search response
+const maxBlockSearchResults = 500
+
+// blockSearchSets are the live address sets holding IP and range blocks
+var blockSearchSets = []string{
+	"blocked_ips", "blocked_ranges", "blocked_ips_out", "blocked_ranges_out",
+	"blocked_ips6", "blocked_ranges6", "blocked_ips6_out", "blocked_ranges6_out",
+}
+
+// BlockSearchResult is one block found by SearchBlocks, merged across stores
+type BlockSearchResult struct {
+	Value     string     `json:"value"`
+	Stores    []string   `json:"stores"`         // where the block was found
+	Match     string     `json:"match"`          // exact, covering, contained or text
+	Sets      []string   `json:"sets,omitempty"` // live sets holding the value
+	ID        int64      `json:"id,omitempty"`   // firewall_entries row
+	Type      string     `json:"type,omitempty"` // ip, range, country
+	Direction string     `json:"direction,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Enabled   bool       `json:"enabled"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// SearchBlocks finds blocks matching q in the DB entries and the live nftables
+// sets. An IP matches itself and covering ranges, a CIDR matches the blocks
+// inside or around it, anything else is a text search on value/source/reason.
+// Results with the same value are merged, so a block present in both stores is
+// listed once; one found only in nftables is not backed by any entry.
+func (s *Service) SearchBlocks(q string) ([]BlockSearchResult, error) {
+	q = strings.TrimSpace(q)
+	queryNet := parseNetwork(q)
+	if !strings.Contains(q, "/") && net.ParseIP(q) == nil {
+		queryNet = nil
+	}
+
+	results := make(map[string]*BlockSearchResult)
+	var order []string
+	add := func(r BlockSearchResult) *BlockSearchResult {
+		if existing, ok := results[r.Value]; ok {
+			return existing
+		}
+		results[r.Value] = &r
+		order = append(order, r.Value)
+		return &r
+	}
+
+	if err := s.searchEntries(q, queryNet, add); err != nil {
+		return nil, err
+	}
+	if queryNet != nil {
+		s.searchSets(queryNet, add)
+	}
+
+	out := make([]BlockSearchResult, 0, len(order))
+	for _, v := range order {
+		out = append(out, *results[v])
+	}
+	// Exact matches first, then covering ranges, then the rest
+	rank := map[string]int{"exact": 0, "covering": 1, "contained": 2, "text": 3}
+	sort.SliceStable(out, func(i, j int) bool { return rank[out[i].Match] < rank[out[j].Match] })
+	if len(out) > maxBlockSearchResults {
+		out = out[:maxBlockSearchResults]
+	}
+	return out, nil
+}
+
+func (s *Service) searchEntries(q string, queryNet *net.IPNet, add func(BlockSearchResult) *BlockSearchResult) error {
+	where := "action = 'block' AND entry_type IN ('ip', 'range', 'country')"
+	args := []interface{}{}
+	if queryNet == nil {
+		pattern := "%" + database.EscapeLikePattern(q) + "%"
+		where += " AND (value LIKE ? ESCAPE '\\' OR source LIKE ? ESCAPE '\\' OR reason LIKE ? ESCAPE '\\' OR name LIKE ? ESCAPE '\\')"
+		args = append(args, pattern, pattern, pattern, pattern)
+	} else {
+		where += " AND entry_type IN ('ip', 'range')"
+	}
+
+	rows, err := s.db.Query(`SELECT id, entry_type, value, direction, source, COALESCE(reason, ''), enabled, expires_at
+		FROM firewall_entries WHERE `+where+` ORDER BY created_at DESC`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := BlockSearchResult{Stores: []string{StoreEntries}, Match: "text"}
+		var expiresAt sql.NullTime
+		if rows.Scan(&r.ID, &r.Type, &r.Value, &r.Direction, &r.Source, &r.Reason, &r.Enabled, &expiresAt) != nil {
+			continue
+		}
+		if queryNet != nil {
+			if r.Match = networkMatch(queryNet, parseNetwork(r.Value)); r.Match == "" {
+				continue
+			}
+		}
+		r.ExpiresAt = database.TimePointerFromNull(expiresAt)
+		add(r)
+	}
+	return nil
+}
+
+// searchSets adds live set elements overlapping queryNet
+func (s *Service) searchSets(queryNet *net.IPNet, add func(BlockSearchResult) *BlockSearchResult) {
+	if s.nft == nil {
+		return
+	}
+	for _, set := range blockSearchSets {
+		elements, err := s.nft.ListSetElements("inet", "wgadmin_firewall", set)
+		if err != nil {
+			continue
+		}
+		for _, el := range elements {
+			match := networkMatch(queryNet, parseNetwork(el))
+			if match == "" {
+				continue
+			}
+			r := add(BlockSearchResult{Value: el, Match: match})
+			if !slices.Contains(r.Stores, StoreNftables) {
+				r.Stores = append(r.Stores, StoreNftables)
+			}
+			if !slices.Contains(r.Sets, set) {
+				r.Sets = append(r.Sets, set)
+			}
+		}
+	}
+}
+
+// networkMatch classifies how a block network relates to the searched one
+func networkMatch(query, block *net.IPNet) string {
+	if block == nil {
+		return ""
+	}
+	qOnes, qBits := query.Mask.Size()
+	bOnes, bBits := block.Mask.Size()
+	if qBits != bBits {
+		return ""
+	}
+	switch {
+	case qOnes == bOnes && bytes.Equal(query.IP, block.IP):
+		return "exact"
+	case bOnes < qOnes && block.Contains(query.IP):
+		return "covering"
+	case bOnes > qOnes && query.Contains(block.IP):
+		return "contained"
+	}
+	return ""
+}
+
+// handleSearchBlocks searches blocks across the DB and live nftables sets (?q=)
+func (s *Service) handleSearchBlocks(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		router.JSONError(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.SearchBlocks(q)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"query":   q,
+		"results": results,
+		"total":   len(results),
+	})
+}
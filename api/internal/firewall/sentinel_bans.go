@@ -0,0 +1,82 @@
+package firewall
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"api/internal/scheduler"
+	"api/internal/traefik"
+)
+
+// sentinelBansJob is the scheduler job name for ingesting sentinel's ban feed
+const sentinelBansJob = "firewall-sentinel-bans"
+
+// sentinelJailName is the synthetic jail sentinel-reported bans are recorded under
+const sentinelJailName = "sentinel"
+
+// scheduleSentinelBans reads the ban feed every minute
+func (s *Service) scheduleSentinelBans() {
+	scheduler.Every(sentinelBansJob, "Ban IPs sentinel reported over their violation threshold", time.Minute,
+		func(ctx context.Context) error {
+			_, err := s.ingestSentinelBans()
+			return err
+		})
+}
+
+// ingestSentinelBans bans the IPs sentinel appended to its ban feed since the
+// last run. The feed is renamed before reading so lines the plugin appends
+// meanwhile land in a fresh file. Bans use the jail defaults' ban time.
+func (s *Service) ingestSentinelBans() (int, error) {
+	path := traefik.LocalSentinelBanFeedFile()
+	processing := path + ".processing"
+	// A leftover from a failed run is read first; otherwise take the live feed
+	if _, err := os.Stat(processing); os.IsNotExist(err) {
+		if err := os.Rename(path, processing); err != nil {
+			if os.IsNotExist(err) {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("failed to take sentinel ban feed: %v", err)
+		}
+	}
+
+	f, err := os.Open(processing)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sentinel ban feed: %v", err)
+	}
+	// Line format: IP<TAB>middleware<TAB>reason<TAB>time
+	seen := make(map[string]bool)
+	banTime := loadJailDefaults().BanTime
+	banned := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), "\t")
+		ip := fields[0]
+		if net.ParseIP(ip) == nil || seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		if s.isIgnoredIP(ip) || s.isIPBlocked(ip) {
+			continue
+		}
+		reason := "Auto-blocked: sentinel violation threshold"
+		if len(fields) >= 3 {
+			reason = fmt.Sprintf("Auto-blocked: sentinel %s violations on %s", fields[2], fields[1])
+		}
+		s.blockIP(ip, sentinelJailName, reason, banTime)
+		banned++
+	}
+	f.Close()
+	if err := scanner.Err(); err != nil {
+		return banned, fmt.Errorf("failed to read sentinel ban feed: %v", err)
+	}
+	if banned > 0 {
+		log.Printf("sentinel: banned %d IPs over their violation threshold", banned)
+	}
+	return banned, os.Remove(processing)
+}
@@ -13,6 +13,8 @@ import (
 	"api/internal/helper"
 	"api/internal/nftables"
 	"api/internal/router"
+	"api/internal/scheduler"
+	"api/internal/settings"
 	"api/internal/ws"
 )
 
@@ -20,6 +22,9 @@ func init() {
 	LoadBlocklistSources()
 }
 
+// expirationCleanupJob is the scheduler job name for expired entry cleanup
+const expirationCleanupJob = "firewall-expiration-cleanup"
+
 // New creates a new firewall service
 func New(dataDir string, nftSvc *nftables.Service) (*Service, error) {
 	db, err := database.GetDB()
@@ -32,14 +37,13 @@ func New(dataDir string, nftSvc *nftables.Service) (*Service, error) {
 
 	svc := &Service{
 		db:           db,
-		dnsCache:     newLRUDNSCache(dnsCacheMaxSize, dnsCacheTTL),
 		blockCache:   &blockCache{ttl: 10 * time.Second},
 		jailMonitors: make(map[int64]*jailMonitor),
 		ctx:          ctx,
 		cancel:       cancel,
 		nft:          nftSvc,
 		config: Config{
-			EssentialPorts:         helper.BuildEssentialPorts(),
+			EssentialPorts:         loadEssentialPorts(),
 			IgnoreNetworks:         helper.ParseStringList(helper.GetEnv("IGNORE_NETWORKS")),
 			MaxAttempts:            fwCfg.MaxAttempts,
 			DataDir:                dataDir,
@@ -48,11 +52,14 @@ func New(dataDir string, nftSvc *nftables.Service) (*Service, error) {
 			HeadscaleIPPrefix:      helper.ExtractIPPrefix(helper.GetEnv("HEADSCALE_IP_RANGE")),
 			JailCheckInterval:      fwCfg.JailCheckIntervalSec,
 			CleanupInterval:        fwCfg.CleanupIntervalMin,
-			DNSLookupTimeout:       fwCfg.DNSLookupTimeoutSec,
-			ServerIP:               helper.GetEnv("SERVER_IP"),
+			DriftInterval:          fwCfg.DriftCheckIntervalMin,
+			MaxJailMonitors:        fwCfg.MaxJailMonitors,
 		},
 	}
 
+	// Reverse DNS timeout and cache, as tuned via SetDNSConfig
+	svc.applyDNSConfig(loadDNSConfig(fwCfg.DNSLookupTimeoutSec))
+
 	// Get geolocation service for country zones
 	geoSvc := geolocation.GetService()
 	svc.geo = geoSvc
@@ -66,13 +73,16 @@ func New(dataDir string, nftSvc *nftables.Service) (*Service, error) {
 	firewallTable := nftables.NewFirewallTable(db, geoSvc)
 	nftSvc.RegisterTable(firewallTable)
 
+	// Operator-supplied rules live in their own table so a bad snippet can't affect the firewall table
+	nftSvc.RegisterTable(nftables.NewCustomRulesTable(db))
+
 	// Ensure default jails exist
 	if err := svc.ensureDefaultJails(); err != nil {
 		log.Printf("Warning: Failed to ensure default jails: %v", err)
 	}
 
-	// Ensure essential ports are in firewall_entries
-	if err := svc.ensureEssentialPorts(); err != nil {
+	// Ensure essential ports are in firewall_entries (and only they are flagged essential)
+	if err := svc.syncEssentialFlags(); err != nil {
 		log.Printf("Warning: Failed to ensure essential ports: %v", err)
 	}
 
@@ -83,25 +93,54 @@ func New(dataDir string, nftSvc *nftables.Service) (*Service, error) {
 		log.Printf("Warning: Failed to sync Docker ports: %v", err)
 	}
 
-	// Apply initial rules
-	if err := svc.ApplyRules(); err != nil {
+	// Apply initial rules, correcting whatever the live ruleset drifted to
+	// (e.g. after a crash or reboot left stale tables behind)
+	if _, err := svc.reconcile(); err != nil {
 		log.Printf("Warning: Failed to apply initial firewall rules: %v", err)
 	}
 
 	// Start background tasks
+	svc.portStats.Store(loadPortStatsConfig().Enabled)
 	go svc.runJailMonitors()
-	go svc.runExpirationCleanup()
+	scheduler.Every(expirationCleanupJob, "Remove expired firewall entries",
+		time.Duration(svc.config.CleanupInterval)*time.Minute, func(ctx context.Context) error {
+			svc.cleanupExpiredData()
+			return nil
+		})
+	svc.scheduleSlowlist()
+	svc.scheduleMultiJail()
+	svc.scheduleSentinelBans()
+	if dockerEventsEnabled() {
+		go svc.watchDockerEvents()
+	}
+	settings.RegisterEffectiveConfig("firewall", svc.effectiveConfig)
+	if nftSvc != nil {
+		svc.scheduleDriftCheck(time.Duration(svc.config.DriftInterval) * time.Minute)
+	}
 
 	log.Printf("Firewall service initialized")
 	return svc, nil
 }
 
-// ensureEssentialPorts adds essential ports to firewall_entries if not present
+// EnsureDefaults re-creates missing default jails and essential ports, then reapplies rules
+func (s *Service) EnsureDefaults() error {
+	if err := s.ensureDefaultJails(); err != nil {
+		return err
+	}
+	if err := s.syncEssentialFlags(); err != nil {
+		return err
+	}
+	s.RequestApply()
+	return nil
+}
+
+// ensureEssentialPorts adds essential ports to firewall_entries, marking existing rows essential
 func (s *Service) ensureEssentialPorts() error {
 	for _, ep := range s.config.EssentialPorts {
-		_, err := s.db.Exec(`INSERT OR IGNORE INTO firewall_entries
+		_, err := s.db.Exec(`INSERT INTO firewall_entries
 			(entry_type, value, action, direction, protocol, source, name, essential, enabled)
-			VALUES ('port', ?, 'allow', 'inbound', ?, 'system', ?, 1, 1)`,
+			VALUES ('port', ?, 'allow', 'inbound', ?, 'system', ?, 1, 1)
+			ON CONFLICT(entry_type, value, protocol) DO UPDATE SET essential = 1, enabled = 1`,
 			strconv.Itoa(ep.Port), ep.Protocol, ep.Service)
 		if err != nil {
 			return fmt.Errorf("failed to add essential port %d: %v", ep.Port, err)
@@ -184,9 +223,28 @@ func (s *Service) GetSyncStatus() nftables.SyncStatus {
 	return s.nft.GetSyncStatus()
 }
 
+// enforcement reports whether nftables can enforce the firewall rules
+func (s *Service) enforcement() nftables.Availability {
+	if s.nft == nil {
+		return nftables.Availability{Error: "nftables service not initialized"}
+	}
+	return s.nft.Availability()
+}
+
+// enforcementWarning explains, when nftables is unavailable, that saved
+// entries won't take effect; empty when rules are enforced
+func (s *Service) enforcementWarning() string {
+	if a := s.enforcement(); !a.Available {
+		return "firewall is not enforcing rules (" + a.Error + "); entries are saved but have no effect"
+	}
+	return ""
+}
+
 // Stop stops the firewall service
 func (s *Service) Stop() {
 	s.cancel()
+	scheduler.Remove(expirationCleanupJob)
+	scheduler.Remove(driftCheckJob)
 	if s.nft != nil {
 		s.nft.Stop()
 	}
@@ -196,36 +254,86 @@ func (s *Service) Stop() {
 func (s *Service) Handlers() router.ServiceHandlers {
 	return router.ServiceHandlers{
 		// Status and config
-		"GetStatus":      s.handleStatus,
-		"GetConfig":      s.handleGetConfig,
-		"UpdateConfig":   s.handleUpdateConfig,
-		"ApplyRules":     s.handleApplyRules,
-		"SyncStatus":     s.handleSyncStatus,
+		"GetStatus":          s.handleStatus,
+		"GetConfig":          s.handleGetConfig,
+		"UpdateConfig":       s.handleUpdateConfig,
+		"ApplyRules":         s.handleApplyRules,
+		"SyncStatus":         s.handleSyncStatus,
+		"GetDriftStatus":     s.handleGetDriftStatus,
+		"ReconcileFirewall":  s.handleReconcileFirewall,
+		"GetSets":            s.handleGetSets,
+		"GetSetMembers":      s.handleGetSetMembers,
+		"GetDNSConfig":       s.handleGetDNSConfig,
+		"SetDNSConfig":       s.handleSetDNSConfig,
+		"GetDNSCacheStats":   s.handleGetDNSCacheStats,
+		"GetBlockCategories": s.handleGetBlockCategories,
+		"GetAttemptHeatmap":  s.handleGetAttemptHeatmap,
 
 		// Unified entries API
-		"GetEntries":      s.handleGetEntries,
-		"CreateEntry":     s.handleCreateEntry,
-		"DeleteEntry":     s.handleDeleteEntry,
-		"ToggleEntry":     s.handleToggleEntry,
-		"BulkEntries":     s.handleBulkEntries,
-		"ImportEntries":   s.handleImportEntries,
-		"DeleteBySource":  s.handleDeleteBySource,
-		"DeleteAll":       s.handleDeleteAll,
+		"GetEntries":          s.handleGetEntries,
+		"CreateEntry":         s.handleCreateEntry,
+		"DeleteEntry":         s.handleDeleteEntry,
+		"ToggleEntry":         s.handleToggleEntry,
+		"BulkEntries":         s.handleBulkEntries,
+		"BulkBlock":           s.handleBulkBlock,
+		"SearchBlocks":        s.handleSearchBlocks,
+		"ImportEntries":       s.handleImportEntries,
+		"ImportProgress":      s.handleGetImportProgress,
+		"DeleteBySource":      s.handleDeleteBySource,
+		"PreviewDeleteSource": s.handlePreviewDeleteSource,
+		"DeleteAll":           s.handleDeleteAll,
+		"PurgeInvalid":        s.handlePurgeInvalidBlocks,
+		"LintRules":           s.handleLintRules,
 
 		// Legacy endpoints (ports, blocklists)
-		"GetPorts":        s.handleGetPorts,
-		"AddPort":         s.handleAddPort,
-		"RemovePort":      s.handleRemovePort,
-		"GetBlocklists":   s.handleGetBlocklists,
+		"GetPorts":          s.handleGetPorts,
+		"AddPort":           s.handleAddPort,
+		"RemovePort":        s.handleRemovePort,
+		"SuggestPorts":      s.handleSuggestPorts,
+		"ApplySuggested":    s.handleApplySuggestedPorts,
+		"ImportDockerPorts": s.handleImportDockerPorts,
+		"GetBlocklists":     s.handleGetBlocklists,
+
+		// Essential (non-removable) ports
+		"GetEssentialPorts": s.handleGetEssentialPorts,
+		"SetEssentialPorts": s.handleSetEssentialPorts,
+
+		// Custom nftables rules
+		"GetCustomRules":      s.handleGetCustomRules,
+		"SetCustomRules":      s.handleSetCustomRules,
+		"ValidateCustomRules": s.handleValidateCustomRules,
 
 		// SSH port management
 		"ChangeSSHPort": s.handleChangeSSHPort,
 
 		// Jails (fail2ban)
-		"GetJails":   s.handleGetJails,
-		"CreateJail": s.handleCreateJail,
-		"GetJail":    s.handleGetJail,
-		"UpdateJail": s.handleUpdateJail,
-		"DeleteJail": s.handleDeleteJail,
+		"GetJails":          s.handleGetJails,
+		"CreateJail":        s.handleCreateJail,
+		"GetJail":           s.handleGetJail,
+		"UpdateJail":        s.handleUpdateJail,
+		"DeleteJail":        s.handleDeleteJail,
+		"ValidateJailRegex": s.handleValidateJailRegex,
+		"TestJailRegex":     s.handleTestJailRegex,
+		"GetJailDefaults":   s.handleGetJailDefaults,
+		"SetJailDefaults":   s.handleSetJailDefaults,
+		"GetMonitorStats":   s.handleGetMonitorStats,
+		"ExportJails":       s.handleExportJails,
+		"ImportJails":       s.handleImportJails,
+		"GetSlowlist":       s.handleGetSlowlist,
+		"SetSlowlist":       s.handleSetSlowlist,
+		"GetMultiJail":      s.handleGetMultiJail,
+		"SetMultiJail":      s.handleSetMultiJail,
+		"GetPortScanStats":  s.handleGetPortScanStats,
+		"SetPortScanStats":  s.handleSetPortScanStats,
+
+		// Country block exceptions
+		"GetCountryExceptions":   s.handleGetCountryExceptions,
+		"AddCountryException":    s.handleAddCountryException,
+		"DeleteCountryException": s.handleDeleteCountryException,
+
+		// Permanent allowlist (never banned by jails or imports)
+		"GetAllowlist":    s.handleGetAllowlist,
+		"AddAllowlist":    s.handleAddAllowlist,
+		"RemoveAllowlist": s.handleRemoveAllowlist,
 	}
 }
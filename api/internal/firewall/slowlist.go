@@ -0,0 +1,191 @@
+package firewall
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"api/internal/router"
+	"api/internal/scheduler"
+	"api/internal/settings"
+	"api/internal/traefik"
+)
+
+// slowlistSetting stores the slowlist publisher config as JSON
+const slowlistSetting = "firewall_slowlist"
+
+// slowlistJob is the scheduler job name for publishing the slowlist
+const slowlistJob = "firewall-slowlist"
+
+// maxSlowlistIPs bounds the published file; the most active offenders win
+const maxSlowlistIPs = 5000
+
+// SlowlistConfig controls which suspicious IPs the firewall publishes for
+// sentinel to delay. An IP is suspicious when jails recorded at least
+// Threshold attempts from it within the window but it isn't banned yet.
+type SlowlistConfig struct {
+	Enabled       bool `json:"enabled"`
+	Threshold     int  `json:"threshold"`
+	WindowMinutes int  `json:"windowMinutes"`
+}
+
+var defaultSlowlistConfig = SlowlistConfig{Threshold: 3, WindowMinutes: 60}
+
+// SlowlistEntry is one published IP
+type SlowlistEntry struct {
+	IP       string `json:"ip"`
+	Attempts int    `json:"attempts"`
+	LastSeen string `json:"lastSeen"`
+}
+
+func loadSlowlistConfig() SlowlistConfig {
+	cfg := defaultSlowlistConfig
+	raw, err := settings.GetSetting(slowlistSetting)
+	if err != nil || raw == "" {
+		return cfg
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		log.Printf("slowlist: invalid %s setting: %v (using defaults)", slowlistSetting, err)
+		return defaultSlowlistConfig
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = defaultSlowlistConfig.Threshold
+	}
+	if cfg.WindowMinutes <= 0 {
+		cfg.WindowMinutes = defaultSlowlistConfig.WindowMinutes
+	}
+	return cfg
+}
+
+// scheduleSlowlist publishes the slowlist every minute, matching the plugin's
+// default reload interval
+func (s *Service) scheduleSlowlist() {
+	scheduler.Every(slowlistJob, "Publish suspicious IPs for sentinel slowlist", time.Minute,
+		func(ctx context.Context) error {
+			_, err := s.publishSlowlist()
+			return err
+		})
+}
+
+// suspiciousIPs returns IPs with recent jail attempts that are not banned, ignored or allowlisted
+func (s *Service) suspiciousIPs(cfg SlowlistConfig) ([]SlowlistEntry, error) {
+	allowlist := s.allowlistNets()
+	rows, err := s.db.Query(`
+		SELECT logs_src_ip, COUNT(*), MAX(logs_timestamp)
+		FROM logs
+		WHERE logs_type = 'fw' AND logs_timestamp >= datetime('now', ?)
+		GROUP BY logs_src_ip
+		HAVING COUNT(*) >= ?
+		ORDER BY COUNT(*) DESC`,
+		fmt.Sprintf("-%d minutes", cfg.WindowMinutes), cfg.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []SlowlistEntry{}
+	for rows.Next() {
+		var e SlowlistEntry
+		if rows.Scan(&e.IP, &e.Attempts, &e.LastSeen) != nil {
+			continue
+		}
+		if s.isIgnoredIP(e.IP) || allowlistMatch(e.IP, allowlist) != nil || s.isIPBlocked(e.IP) {
+			continue
+		}
+		entries = append(entries, e)
+		if len(entries) >= maxSlowlistIPs {
+			break
+		}
+	}
+	return entries, rows.Err()
+}
+
+// publishSlowlist writes the suspicious IPs to the file sentinel reads. When
+// disabled the file is emptied so middlewares stop delaying.
+func (s *Service) publishSlowlist() ([]SlowlistEntry, error) {
+	cfg := loadSlowlistConfig()
+	entries := []SlowlistEntry{}
+	if cfg.Enabled {
+		var err error
+		if entries, err = s.suspiciousIPs(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Suspicious IPs published by the firewall, delayed by sentinel slowlist\n")
+	for _, e := range entries {
+		sb.WriteString(e.IP + "\n")
+	}
+
+	// Write atomically so the plugin never reads a partial list
+	path := traefik.LocalSentinelSlowlistFile()
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write slowlist: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write slowlist: %v", err)
+	}
+	return entries, nil
+}
+
+// handleGetSlowlist returns the slowlist config and the currently published IPs
+func (s *Service) handleGetSlowlist(w http.ResponseWriter, r *http.Request) {
+	cfg := loadSlowlistConfig()
+	entries := []SlowlistEntry{}
+	if cfg.Enabled {
+		var err error
+		if entries, err = s.suspiciousIPs(cfg); err != nil {
+			router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	router.JSON(w, map[string]interface{}{
+		"config": cfg,
+		"file":   traefik.SentinelSlowlistFile,
+		"ips":    entries,
+		"count":  len(entries),
+	})
+}
+
+// handleSetSlowlist stores the slowlist config and republishes the file
+func (s *Service) handleSetSlowlist(w http.ResponseWriter, r *http.Request) {
+	var cfg SlowlistConfig
+	if !router.DecodeJSONOrError(w, r, &cfg) {
+		return
+	}
+	if cfg.Threshold < 0 || cfg.WindowMinutes < 0 || cfg.WindowMinutes > 10080 {
+		router.JSONError(w, "threshold must not be negative and windowMinutes must be between 0 and 10080", http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := settings.SetSetting(slowlistSetting, string(data)); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entries, err := s.publishSlowlist()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"config": loadSlowlistConfig(),
+		"file":   traefik.SentinelSlowlistFile,
+		"ips":    entries,
+		"count":  len(entries),
+	})
+}
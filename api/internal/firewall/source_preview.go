@@ -0,0 +1,169 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"api/internal/router"
+)
+
+// maxPreviewAttackers bounds the recent attacker IPs checked against a source
+const maxPreviewAttackers = 5000
+
+// ActiveSourceEntry is an entry of the source that recent attempts matched
+type ActiveSourceEntry struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	Attempts int    `json:"attempts"`
+	IPs      int    `json:"ips"` // distinct attacker IPs inside the entry
+	LastSeen string `json:"lastSeen"`
+}
+
+// handlePreviewDeleteSource reports what DeleteBySource would remove and which
+// of those entries recent firewall attempts (fw logs) still match, so actively
+// attacking IPs aren't unblocked with a stale source (?hours=24)
+func (s *Service) handlePreviewDeleteSource(w http.ResponseWriter, r *http.Request) {
+	source := router.ExtractPathParam(r, "/api/fw/entries/source/")
+	if source == "" {
+		router.JSONError(w, "source required", http.StatusBadRequest)
+		return
+	}
+	hours := router.QueryParamInt(r, "hours", 24)
+	if hours < 1 || hours > 24*30 {
+		router.JSONError(w, "hours must be between 1 and 720", http.StatusBadRequest)
+		return
+	}
+
+	byType := map[string]int{}
+	var total, essential int
+	rows, err := s.db.Query(`SELECT entry_type, essential, COUNT(*) FROM firewall_entries
+		WHERE source = ? GROUP BY entry_type, essential`, source)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for rows.Next() {
+		var entryType string
+		var isEssential bool
+		var count int
+		if rows.Scan(&entryType, &isEssential, &count) != nil {
+			continue
+		}
+		if isEssential {
+			essential += count
+			continue
+		}
+		byType[entryType] += count
+		total += count
+	}
+	rows.Close()
+
+	active, err := s.activeSourceEntries(source, hours)
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"source":        source,
+		"wouldDelete":   total,
+		"byType":        byType,
+		"essentialKept": essential,
+		"hours":         hours,
+		"active":        active,
+		"activeCount":   len(active),
+	})
+}
+
+// activeSourceEntries matches the source's IP and range entries against IPs
+// with firewall attempts in the window. Ranges are matched by looking up each
+// attacker IP's enclosing prefixes, which keeps large sources cheap.
+func (s *Service) activeSourceEntries(source string, hours int) ([]ActiveSourceEntry, error) {
+	type attacker struct {
+		ip       string
+		attempts int
+		lastSeen string
+	}
+	rows, err := s.db.Query(`
+		SELECT logs_src_ip, COUNT(*), MAX(logs_timestamp) FROM logs
+		WHERE logs_type = 'fw' AND logs_timestamp >= datetime('now', ?)
+		GROUP BY logs_src_ip ORDER BY COUNT(*) DESC LIMIT ?`,
+		fmt.Sprintf("-%d hours", hours), maxPreviewAttackers)
+	if err != nil {
+		return nil, err
+	}
+	var attackers []attacker
+	for rows.Next() {
+		var a attacker
+		if rows.Scan(&a.ip, &a.attempts, &a.lastSeen) == nil {
+			attackers = append(attackers, a)
+		}
+	}
+	rows.Close()
+	if len(attackers) == 0 {
+		return []ActiveSourceEntry{}, nil
+	}
+
+	// Entries keyed by normalized value: bare IP or CIDR network
+	entries := map[string]*ActiveSourceEntry{}
+	rows, err = s.db.Query(`SELECT id, entry_type, value FROM firewall_entries
+		WHERE source = ? AND essential = 0 AND entry_type IN ('ip', 'range')`, source)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		e := &ActiveSourceEntry{}
+		if rows.Scan(&e.ID, &e.Type, &e.Value) != nil {
+			continue
+		}
+		key := e.Value
+		if strings.Contains(key, "/") {
+			if _, network, err := net.ParseCIDR(key); err == nil {
+				key = network.String()
+			}
+		}
+		entries[key] = e
+	}
+	rows.Close()
+
+	var active []*ActiveSourceEntry
+	for _, a := range attackers {
+		ip := net.ParseIP(a.ip)
+		if ip == nil {
+			continue
+		}
+		bits := 128
+		if v4 := ip.To4(); v4 != nil {
+			ip, bits = v4, 32
+		}
+		// The exact IP entry, then every enclosing prefix from /bits down to /0
+		matches := []*ActiveSourceEntry{entries[ip.String()]}
+		for ones := bits; ones >= 0; ones-- {
+			network := net.IPNet{IP: ip.Mask(net.CIDRMask(ones, bits)), Mask: net.CIDRMask(ones, bits)}
+			matches = append(matches, entries[network.String()])
+		}
+		for _, e := range matches {
+			if e == nil {
+				continue
+			}
+			if e.Attempts == 0 {
+				active = append(active, e)
+			}
+			e.Attempts += a.attempts
+			e.IPs++
+			if a.lastSeen > e.LastSeen {
+				e.LastSeen = a.lastSeen
+			}
+		}
+	}
+
+	result := make([]ActiveSourceEntry, 0, len(active))
+	for _, e := range active {
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, k int) bool { return result[i].Attempts > result[k].Attempts })
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package firewall
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"api/internal/helper"
+	"api/internal/nftables"
+	"api/internal/router"
+)
+
+// traefikEntrypoints names the default Traefik entrypoints by published port
+var traefikEntrypoints = map[int]string{80: "web", 443: "websecure"}
+
+// PortSuggestion is a port recommended from what is actually running
+type PortSuggestion struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Service  string `json:"service"`
+	Label    string `json:"label"`  // "22/tcp — SSH"
+	Origin   string `json:"origin"` // ssh, vpn or docker
+	Allowed  bool   `json:"allowed"`
+}
+
+// SuggestPorts recommends allowed ports from the detected SSH port, the
+// WireGuard port and the ports published by running Docker containers.
+// Allowed marks suggestions already covered by an enabled allow entry.
+func (s *Service) SuggestPorts() ([]PortSuggestion, error) {
+	suggestions := []PortSuggestion{}
+	seen := make(map[string]bool)
+	add := func(port int, protocol, service, origin string) {
+		key := fmt.Sprintf("%d-%s", port, protocol)
+		if port < 1 || port > 65535 || seen[key] {
+			return
+		}
+		seen[key] = true
+		suggestions = append(suggestions, PortSuggestion{
+			Port:     port,
+			Protocol: protocol,
+			Service:  service,
+			Label:    fmt.Sprintf("%d/%s — %s", port, protocol, service),
+			Origin:   origin,
+		})
+	}
+
+	add(helper.GetSSHPort(), nftables.ProtocolTCP, "SSH", "ssh")
+	add(s.config.WgPort, nftables.ProtocolUDP, "WireGuard", "vpn")
+
+	dockerPorts := s.getDockerExposedPorts()
+	sort.Slice(dockerPorts, func(i, j int) bool { return dockerPorts[i].Port < dockerPorts[j].Port })
+	for _, dp := range dockerPorts {
+		container := strings.TrimPrefix(dp.Service, "Docker: ")
+		service := dp.Service
+		if strings.Contains(strings.ToLower(container), "traefik") {
+			if ep, ok := traefikEntrypoints[dp.Port]; ok {
+				service = "Traefik: " + ep
+			}
+		}
+		add(dp.Port, dp.Protocol, service, "docker")
+	}
+
+	allowed, err := s.allowedPortRanges()
+	if err != nil {
+		return nil, err
+	}
+	for i := range suggestions {
+		suggestions[i].Allowed = portAllowed(allowed, suggestions[i].Port, suggestions[i].Protocol)
+	}
+	return suggestions, nil
+}
+
+// allowedPortRange is an enabled inbound allow entry for a port or range
+type allowedPortRange struct {
+	start, end int
+	protocol   string
+}
+
+func (s *Service) allowedPortRanges() ([]allowedPortRange, error) {
+	rows, err := s.db.Query(`SELECT value, protocol FROM firewall_entries
+		WHERE entry_type = 'port' AND action = 'allow' AND enabled = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranges []allowedPortRange
+	for rows.Next() {
+		var value, protocol string
+		if rows.Scan(&value, &protocol) != nil {
+			continue
+		}
+		start, end, err := helper.ParsePortRange(value)
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, allowedPortRange{start: start, end: end, protocol: protocol})
+	}
+	return ranges, nil
+}
+
+func portAllowed(ranges []allowedPortRange, port int, protocol string) bool {
+	for _, r := range ranges {
+		if port >= r.start && port <= r.end && (r.protocol == protocol || r.protocol == nftables.ProtocolBoth) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSuggestPorts returns the recommended allowed ports with explanations
+func (s *Service) handleSuggestPorts(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := s.SuggestPorts()
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	missing := 0
+	for _, sg := range suggestions {
+		if !sg.Allowed {
+			missing++
+		}
+	}
+	router.JSON(w, map[string]interface{}{
+		"suggestions": suggestions,
+		"missing":     missing,
+	})
+}
+
+// handleApplySuggestedPorts allows the suggested ports that aren't allowed yet.
+// An optional "ports" list (e.g. ["22/tcp"]) limits which suggestions are applied.
+func (s *Service) handleApplySuggestedPorts(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Ports []string `json:"ports"`
+	}
+	if r.ContentLength > 0 && !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	selected := make(map[string]bool)
+	for _, p := range req.Ports {
+		selected[strings.ToLower(strings.TrimSpace(p))] = true
+	}
+
+	suggestions, err := s.SuggestPorts()
+	if err != nil {
+		router.JSONError(w, "database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	applied := []PortSuggestion{}
+	for _, sg := range suggestions {
+		if sg.Allowed || (len(selected) > 0 && !selected[fmt.Sprintf("%d/%s", sg.Port, sg.Protocol)]) {
+			continue
+		}
+		_, essential := s.isEssentialPort(sg.Port, sg.Protocol)
+		if _, err := s.db.Exec(`INSERT INTO firewall_entries
+			(entry_type, value, action, direction, protocol, source, name, essential, enabled)
+			VALUES ('port', ?, 'allow', 'inbound', ?, 'manual', ?, ?, 1)
+			ON CONFLICT(entry_type, value, protocol) DO UPDATE SET
+			action = excluded.action, direction = excluded.direction, enabled = 1`,
+			fmt.Sprintf("%d", sg.Port), sg.Protocol, sg.Service, essential); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sg.Allowed = true
+		applied = append(applied, sg)
+	}
+
+	if len(applied) > 0 {
+		s.RequestApply()
+	}
+	router.JSON(w, map[string]interface{}{
+		"applied": applied,
+		"count":   len(applied),
+	})
+}
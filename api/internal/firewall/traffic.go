@@ -2,33 +2,47 @@ package firewall
 
 import (
 	"log"
-	"time"
+
+	"api/internal/nftables"
 )
 
-// runExpirationCleanup periodically cleans up expired bans and old data
-func (s *Service) runExpirationCleanup() {
-	ticker := time.NewTicker(time.Duration(s.config.CleanupInterval) * time.Minute)
-	defer ticker.Stop()
+// cleanupExpiredData removes expired bans. Expired ip/range blocks leave the
+// live sets element by element; anything else needs a full apply.
+func (s *Service) cleanupExpiredData() {
+	const expired = "expires_at IS NOT NULL AND expires_at < datetime('now')"
 
-	for {
-		select {
-		case <-s.ctx.Done():
-			log.Printf("Expiration cleanup stopping (context cancelled)")
-			return
-		case <-ticker.C:
-			s.cleanupExpiredData()
+	var blocks []setBlock
+	otherRules := false
+	rows, err := s.db.Query(`SELECT entry_type, value, action, direction, enabled FROM firewall_entries WHERE ` + expired)
+	if err != nil {
+		return
+	}
+	for rows.Next() {
+		var b setBlock
+		var action string
+		var enabled bool
+		if rows.Scan(&b.entryType, &b.value, &action, &b.direction, &enabled) != nil || !enabled {
+			continue
+		}
+		if action == nftables.ActionBlock && (b.entryType == nftables.EntryTypeIP || b.entryType == nftables.EntryTypeRange) {
+			blocks = append(blocks, b)
+		} else {
+			otherRules = true
 		}
 	}
-}
+	rows.Close()
 
-// cleanupExpiredData removes expired bans
-func (s *Service) cleanupExpiredData() {
-	// Remove expired entries from firewall_entries
-	result, err := s.db.Exec("DELETE FROM firewall_entries WHERE expires_at IS NOT NULL AND expires_at < datetime('now')")
+	result, err := s.db.Exec("DELETE FROM firewall_entries WHERE " + expired)
 	if err == nil {
 		if count, _ := result.RowsAffected(); count > 0 {
 			log.Printf("Cleaned up %d expired firewall entries", count)
-			s.RequestApply()
+			if otherRules {
+				s.RequestApply()
+			} else {
+				s.removeBlocksFromSets(blocks)
+			}
 		}
 	}
+
+	s.prunePortProbes()
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"api/internal/database"
@@ -14,8 +15,10 @@ import (
 
 // jailMonitor tracks a running jail monitor
 type jailMonitor struct {
-	cancel context.CancelFunc
-	name   string
+	cancel    context.CancelFunc
+	name      string
+	logFile   string
+	startedAt time.Time
 }
 
 // jailConfig holds config needed for jail monitoring (internal use)
@@ -46,13 +49,19 @@ type Service struct {
 	dbMutex      sync.RWMutex
 	config       Config
 	dnsCache     *lruDNSCache
+	dnsTimeout   int                    // reverse DNS lookup timeout in seconds
+	dnsMutex     sync.RWMutex           // guards dnsCache and dnsTimeout
 	blockCache   *blockCache            // cached blocked IPs/ranges for fast lookup
 	ctx          context.Context
 	cancel       context.CancelFunc
 	jailMonitors map[int64]*jailMonitor
 	jailMutex    sync.RWMutex
+	jailFiles    atomic.Int64           // jail log files currently open
 	nft          *nftables.Service      // nftables service for rule application
 	geo          *geolocation.Service   // geolocation service for country zones
+	imports      importTracker          // background blocklist import jobs
+	drift        driftAlertState        // nftables drift alert state
+	portStats    atomic.Bool            // tally dropped packets per port (PortStatsConfig.Enabled)
 }
 
 // Config holds firewall configuration
@@ -66,8 +75,8 @@ type Config struct {
 	HeadscaleIPPrefix string                 `json:"-"`
 	JailCheckInterval int                    `json:"-"`
 	CleanupInterval   int                    `json:"-"`
-	DNSLookupTimeout  int                    `json:"-"`
-	ServerIP          string                 `json:"-"` // Server's own IP for self-protection
+	DriftInterval     int                    `json:"-"` // minutes between nftables drift checks
+	MaxJailMonitors   int                    `json:"-"` // cap on concurrently running jail monitors
 }
 
 // Jail represents a blocking rule configuration (fail2ban-style)
@@ -87,6 +96,13 @@ type Jail struct {
 	EscalateEnabled   bool   `json:"escalateEnabled"`
 	EscalateThreshold int    `json:"escalateThreshold"`
 	EscalateWindow    int    `json:"escalateWindow"`
+	IsDefault         bool   `json:"isDefault"`     // built-in jail: recreated on startup, can be disabled but not deleted
+	ActionCommand     string `json:"actionCommand"` // run on each ban; host-specific, so not part of jail exports
+	// Effectiveness: manual unblocks of this jail's bans are a proxy for false positives
+	BanCount       int     `json:"banCount"`       // bans issued (re-bans included)
+	ManualUnblocks int     `json:"manualUnblocks"` // active bans removed or disabled by an operator
+	UnblockRatio   float64 `json:"unblockRatio"`   // manualUnblocks / banCount
+	TooAggressive  bool    `json:"tooAggressive"`  // ratio above jailAggressiveRatio with enough bans to judge
 }
 
 // BlocklistSource represents a blocklist source configuration
@@ -51,6 +51,20 @@ func getSubnet24(ip string) string {
 	return ""
 }
 
+// getSubnetForEscalation returns the range an IP escalates to: its /24 for
+// IPv4, its /64 for IPv6. Returns "" for anything that doesn't parse.
+func getSubnetForEscalation(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if parsed.To4() != nil {
+		return getSubnet24(ip)
+	}
+	network := net.IPNet{IP: parsed.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}
+	return network.String()
+}
+
 // isPrivateRange checks if an IP or CIDR is in private IP space
 // Uses helper.IsPrivateIPOrCIDR for consistent behavior across packages
 func isPrivateRange(input string) bool {
@@ -96,13 +110,17 @@ func (s *Service) isPrivateIP(ip string) bool {
 
 // reverseDNS performs a reverse DNS lookup with caching
 func (s *Service) reverseDNS(ip string) string {
+	s.dnsMutex.RLock()
+	cache, timeout := s.dnsCache, s.dnsTimeout
+	s.dnsMutex.RUnlock()
+
 	// Check cache first
-	if domain, found := s.dnsCache.get(ip); found {
+	if domain, found := cache.get(ip); found {
 		return domain
 	}
 
 	// Do reverse lookup with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.DNSLookupTimeout)*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
 	defer cancel()
 
 	var domain string
@@ -122,6 +140,6 @@ func (s *Service) reverseDNS(ip string) string {
 	}
 
 	// Cache the result (even empty ones to avoid repeated lookups)
-	s.dnsCache.set(ip, domain)
+	cache.set(ip, domain)
 	return domain
 }
@@ -0,0 +1,188 @@
+package geolocation
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"api/internal/router"
+	"api/internal/settings"
+)
+
+// BlockingStatus reports whether country blocking is configured and actually loaded
+type BlockingStatus struct {
+	Enabled          bool `json:"enabled"` // configured state
+	Active           bool `json:"active"`  // country sets loaded in nftables
+	InSync           bool `json:"in_sync"` // Active matches Enabled and no apply is queued
+	ApplyPending     bool `json:"apply_pending"`
+	Countries        int  `json:"countries"`         // preserved country entries
+	EnabledCountries int  `json:"enabled_countries"` // entries applied when blocking is on
+	CachedZones      int  `json:"cached_zones"`      // countries with cached zone data
+	RangesIn         int  `json:"ranges_in"`         // loaded blocked_countries elements
+	RangesOut        int  `json:"ranges_out"`        // loaded blocked_countries_out elements
+}
+
+// Blocked countries have a single source of truth: firewall_entries rows with
+// entry_type 'country' and action 'block'. The geo views, the zone cache and
+// the nftables country sets are all derived from them.
+
+// BlockingDivergence lists where the derived country-blocking state disagrees
+// with the country entries
+type BlockingDivergence struct {
+	InSync           bool     `json:"in_sync"`
+	BlockingDisabled bool     `json:"blocking_disabled"` // entries exist but blocking is off, nothing is loaded
+	MissingZones     []string `json:"missing_zones"`     // enabled entries without cached zones, so nothing blocked
+	UnknownCountries []string `json:"unknown_countries"` // entries whose code isn't a known country
+	NonBlockEntries  []string `json:"non_block_entries"` // country entries with an action other than block
+	OrphanedZones    []string `json:"orphaned_zones"`    // cached zones without an entry (harmless, never loaded)
+	SetsNotLoaded    bool     `json:"sets_not_loaded"`   // blocking is on with zones but the nftables set is empty
+}
+
+// GetBlockingDivergence compares the country entries with the geo view, the
+// zone cache and the loaded nftables sets
+func (s *Service) GetBlockingDivergence() (BlockingDivergence, error) {
+	d := BlockingDivergence{
+		MissingZones:     []string{},
+		UnknownCountries: []string{},
+		NonBlockEntries:  []string{},
+		OrphanedZones:    []string{},
+	}
+	if s.db == nil {
+		return d, fmt.Errorf("database not available")
+	}
+
+	var err error
+	if d.MissingZones, err = s.countryCodes(`SELECT f.value FROM firewall_entries f
+		LEFT JOIN country_zones_cache c ON c.country_code = f.value
+		WHERE f.entry_type = 'country' AND f.action = 'block' AND f.enabled = 1
+			AND COALESCE(c.zones, '') = ''`); err != nil {
+		return d, err
+	}
+	if d.NonBlockEntries, err = s.countryCodes(`SELECT value FROM firewall_entries
+		WHERE entry_type = 'country' AND action != 'block'`); err != nil {
+		return d, err
+	}
+	if d.OrphanedZones, err = s.countryCodes(`SELECT c.country_code FROM country_zones_cache c
+		WHERE NOT EXISTS (SELECT 1 FROM firewall_entries f
+			WHERE f.entry_type = 'country' AND f.value = c.country_code)`); err != nil {
+		return d, err
+	}
+	entries, err := s.countryCodes(`SELECT value FROM firewall_entries WHERE entry_type = 'country'`)
+	if err != nil {
+		return d, err
+	}
+	for _, code := range entries {
+		if _, ok := s.countryConfigs[code]; !ok {
+			d.UnknownCountries = append(d.UnknownCountries, code)
+		}
+	}
+
+	status := s.GetBlockingStatus()
+	d.BlockingDisabled = !status.Enabled && status.EnabledCountries > 0
+	d.SetsNotLoaded = status.Enabled && !status.ApplyPending && status.CachedZones > 0 && status.RangesIn == 0
+
+	d.InSync = !d.BlockingDisabled && !d.SetsNotLoaded && len(d.MissingZones) == 0 &&
+		len(d.UnknownCountries) == 0 && len(d.NonBlockEntries) == 0
+	return d, nil
+}
+
+// countryCodes returns the sorted codes a query selects
+func (s *Service) countryCodes(query string) ([]string, error) {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	codes := []string{}
+	for rows.Next() {
+		var code string
+		if rows.Scan(&code) == nil {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return codes, rows.Err()
+}
+
+// GetBlockingStatus compares the configured blocking state with nftables
+func (s *Service) GetBlockingStatus() BlockingStatus {
+	status := BlockingStatus{Enabled: s.IsBlockingEnabled()}
+
+	if s.db != nil {
+		s.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(enabled), 0) FROM firewall_entries WHERE entry_type = 'country'`).
+			Scan(&status.Countries, &status.EnabledCountries)
+		s.db.QueryRow(`SELECT COUNT(DISTINCT c.country_code) FROM country_zones_cache c
+			INNER JOIN firewall_entries f ON c.country_code = f.value
+			WHERE f.entry_type = 'country'`).Scan(&status.CachedZones)
+	}
+
+	if s.nft != nil {
+		status.ApplyPending = s.nft.GetSyncStatus().ApplyPending
+		status.Active = s.nft.SetExists("inet", "wgadmin_firewall", "blocked_countries")
+		if status.Active {
+			status.RangesIn = s.nft.CountSetElements("inet", "wgadmin_firewall", "blocked_countries")
+			status.RangesOut = s.nft.CountSetElements("inet", "wgadmin_firewall", "blocked_countries_out")
+		}
+	}
+	status.InSync = status.Active == status.Enabled && !status.ApplyPending
+
+	return status
+}
+
+// SetBlocking persists the blocking state and applies it immediately, so the
+// returned status reflects the live ruleset
+func (s *Service) SetBlocking(enabled bool) (BlockingStatus, error) {
+	if err := settings.SetSetting("geo_blocking_enabled", strconv.FormatBool(enabled)); err != nil {
+		return BlockingStatus{}, fmt.Errorf("failed to save setting: %w", err)
+	}
+
+	s.mu.Lock()
+	s.config.BlockingEnabled = enabled
+	if enabled && s.blockingProvider == nil {
+		s.blockingProvider = NewIPDenyProvider(s.db)
+	}
+	s.mu.Unlock()
+
+	if s.nft == nil {
+		return s.GetBlockingStatus(), fmt.Errorf("nftables service not available")
+	}
+	if err := s.nft.ApplyAll(); err != nil {
+		return s.GetBlockingStatus(), fmt.Errorf("failed to apply firewall rules: %w", err)
+	}
+	return s.GetBlockingStatus(), nil
+}
+
+// handleGetBlockingDivergence reports where country blocking drifted from the country entries
+func (s *Service) handleGetBlockingDivergence(w http.ResponseWriter, r *http.Request) {
+	d, err := s.GetBlockingDivergence()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, d)
+}
+
+// handleGetBlockingStatus returns configured vs. loaded country blocking state
+func (s *Service) handleGetBlockingStatus(w http.ResponseWriter, r *http.Request) {
+	router.JSON(w, s.GetBlockingStatus())
+}
+
+// handleEnableBlocking re-applies country blocking from the preserved entries and zones
+func (s *Service) handleEnableBlocking(w http.ResponseWriter, r *http.Request) {
+	s.setBlocking(w, true)
+}
+
+// handleDisableBlocking removes the country sets and rules, keeping entries and zones
+func (s *Service) handleDisableBlocking(w http.ResponseWriter, r *http.Request) {
+	s.setBlocking(w, false)
+}
+
+func (s *Service) setBlocking(w http.ResponseWriter, enabled bool) {
+	status, err := s.SetBlocking(enabled)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, status)
+}
@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"api/internal/router"
 	"api/internal/settings"
@@ -63,6 +65,18 @@ func (s *Service) handleUpdateSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.IP2LocationVariant != nil {
+		*req.IP2LocationVariant = strings.ToUpper(strings.TrimSpace(*req.IP2LocationVariant))
+		var known []ProviderVariant
+		if cfg, ok := s.providersConfig.Providers["ip2location"]; ok {
+			known = cfg.Variants
+		}
+		if err := ValidateIP2LocationVariant(*req.IP2LocationVariant, known); err != nil {
+			router.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	needsReload := false
 
 	// Update settings
@@ -197,6 +211,7 @@ func (s *Service) GetStatus() *Status {
 		if ip, ok := s.lookupProvider.(*IP2LocationProvider); ok {
 			ip2locStatus.FileSize = ip.GetFileSize()
 			ip2locStatus.FilePath = ip.GetFilePath()
+			ip2locStatus.Database = ip.GetInfo()
 		}
 	}
 	providers["ip2location"] = ip2locStatus
@@ -215,6 +230,11 @@ func (s *Service) GetStatus() *Status {
 	lastUpdateLookup, _ := settings.GetSetting("geo_last_update_lookup")
 	lastUpdateBlocking, _ := settings.GetSetting("geo_last_update_blocking")
 
+	nextUpdate := ""
+	if next := s.nextScheduledUpdate(time.Now()); !next.IsZero() {
+		nextUpdate = next.Format(time.RFC3339)
+	}
+
 	return &Status{
 		LookupProvider:     s.config.LookupProvider,
 		BlockingEnabled:    s.config.BlockingEnabled,
@@ -224,6 +244,9 @@ func (s *Service) GetStatus() *Status {
 		UpdateServices:     s.config.UpdateServices,
 		LastUpdateLookup:   lastUpdateLookup,
 		LastUpdateBlocking: lastUpdateBlocking,
+		LastUpdateError:    s.lastUpdateError,
+		UpdateInProgress:   s.updateInProgress,
+		NextUpdate:         nextUpdate,
 		Providers:          providers,
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -24,9 +25,97 @@ const (
 	defaultFileNameTemplate = "IP2LOCATION-LITE-{variant}.BIN"
 )
 
+// ip2locationProductCode matches IP2Location product codes (DB1-DB26)
+var ip2locationProductCode = regexp.MustCompile(`^DB([1-9]|1[0-9]|2[0-6])$`)
+
+// Probe addresses used to check which fields a BIN actually provides
+const (
+	ip2locationProbeIPv4 = "8.8.8.8"
+	ip2locationProbeIPv6 = "2001:4860:4860::8888"
+)
+
+// ValidateIP2LocationVariant checks a variant against the IP2Location product codes
+// and, when the provider config lists variants, against the downloadable ones
+func ValidateIP2LocationVariant(variant string, known []ProviderVariant) error {
+	if !ip2locationProductCode.MatchString(variant) {
+		return fmt.Errorf("invalid IP2Location variant %q: expected a product code like DB1, DB3, DB5 or DB11", variant)
+	}
+	if len(known) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(known))
+	for _, v := range known {
+		if v.ID == variant {
+			return nil
+		}
+		ids = append(ids, v.ID)
+	}
+	return fmt.Errorf("unsupported IP2Location variant %q (available: %s)", variant, strings.Join(ids, ", "))
+}
+
+// IP2LocationInfo describes a loaded BIN: its type, version and the fields it provides
+type IP2LocationInfo struct {
+	DatabaseType    string   `json:"database_type"`
+	DatabaseVersion string   `json:"database_version"`
+	Coverage        []string `json:"coverage"`
+	IPv6            bool     `json:"ipv6"`
+}
+
+// inspectIP2LocationDB reads the BIN header and probes a lookup to verify the file
+// matches the expected variant and returns countries
+func inspectIP2LocationDB(db *ip2location.DB, variant string) (*IP2LocationInfo, error) {
+	info := &IP2LocationInfo{
+		DatabaseType:    "DB" + db.PackageVersion(),
+		DatabaseVersion: db.DatabaseVersion(),
+	}
+	if info.DatabaseType != variant {
+		return info, fmt.Errorf("database is %s but variant %s was configured", info.DatabaseType, variant)
+	}
+
+	record, err := db.Get_all(ip2locationProbeIPv4)
+	if err != nil {
+		return info, fmt.Errorf("test lookup failed: %v", err)
+	}
+	if len(record.Country_short) != 2 {
+		return info, fmt.Errorf("test lookup returned no country (%q), file may be corrupt or incompatible", record.Country_short)
+	}
+
+	available := func(s string) bool {
+		return s != "" && !strings.Contains(s, "unavailable")
+	}
+	info.Coverage = []string{"country"}
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"region", record.Region},
+		{"city", record.City},
+		{"isp", record.Isp},
+		{"domain", record.Domain},
+		{"zipcode", record.Zipcode},
+		{"timezone", record.Timezone},
+		{"usage_type", record.Usagetype},
+	}
+	for _, f := range fields {
+		if available(f.value) {
+			info.Coverage = append(info.Coverage, f.name)
+		}
+	}
+	if record.Latitude != 0 || record.Longitude != 0 {
+		info.Coverage = append(info.Coverage, "coordinates")
+	}
+
+	if v6, err := db.Get_all(ip2locationProbeIPv6); err == nil && len(v6.Country_short) == 2 {
+		info.IPv6 = true
+	}
+
+	return info, nil
+}
+
 // IP2LocationProvider provides IP geolocation using IP2Location
 type IP2LocationProvider struct {
 	db               *ip2location.DB
+	info             *IP2LocationInfo
 	dataDir          string
 	token            string
 	variant          string
@@ -38,6 +127,7 @@ type IP2LocationProvider struct {
 
 // NewIP2LocationProvider creates a new IP2Location provider
 func NewIP2LocationProvider(dataDir, token, variant, fileCodeTemplate, fileNameTemplate string) *IP2LocationProvider {
+	variant = strings.ToUpper(strings.TrimSpace(variant))
 	if variant == "" {
 		variant = "DB1"
 	}
@@ -90,8 +180,16 @@ func (p *IP2LocationProvider) Init() error {
 		return fmt.Errorf("failed to open database: %v", err)
 	}
 
+	info, err := inspectIP2LocationDB(db, p.variant)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("IP2Location database %s is not usable: %v", p.filePath, err)
+	}
+
 	p.db = db
-	log.Printf("IP2Location database loaded: %s (variant: %s)", p.filePath, p.variant)
+	p.info = info
+	log.Printf("IP2Location database loaded: %s (type: %s, version: %s, coverage: %s)",
+		p.filePath, info.DatabaseType, info.DatabaseVersion, strings.Join(info.Coverage, ", "))
 
 	// Clean up other variant files
 	p.cleanupOtherVariants()
@@ -233,10 +331,22 @@ func (p *IP2LocationProvider) Update() error {
 		os.Remove(tempPath)
 		return fmt.Errorf("downloaded database is invalid: %v", err)
 	}
+	info, err := inspectIP2LocationDB(testDB, p.variant)
 	testDB.Close()
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("downloaded database is not usable: %v", err)
+	}
 
 	// Hot-reload: swap the database
-	return p.hotReload(tempPath)
+	if err := p.hotReload(tempPath); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.info = info
+	p.mu.Unlock()
+	return nil
 }
 
 // hotReload atomically swaps the database file and reloads
@@ -394,6 +504,7 @@ func (p *IP2LocationProvider) SetVariant(variant string) {
 		p.db.Close()
 		p.db = nil
 	}
+	p.info = nil
 
 	// Update to new variant
 	p.variant = variant
@@ -423,6 +534,13 @@ func (p *IP2LocationProvider) GetFilePath() string {
 	return p.filePath
 }
 
+// GetInfo returns the detected database type and coverage (nil if not loaded)
+func (p *IP2LocationProvider) GetInfo() *IP2LocationInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.info
+}
+
 // GetVariant returns the current database variant
 func (p *IP2LocationProvider) GetVariant() string {
 	return p.variant
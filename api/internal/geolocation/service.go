@@ -40,6 +40,11 @@ type Service struct {
 	// Thread safety
 	mu sync.RWMutex
 
+	// Update state (guarded by mu)
+	updateInProgress  bool
+	lastUpdateError   string
+	lastScheduledDate string // date of the last scheduled run (2006-01-02)
+
 	// Background tasks
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -94,6 +99,11 @@ func New(dataDir string) (*Service, error) {
 	// Load configuration from settings
 	s.loadConfig()
 
+	// Last update error is persisted so it survives restarts
+	if val, err := settings.GetSetting("geo_last_update_error"); err == nil {
+		s.lastUpdateError = val
+	}
+
 	// Initialize providers based on config
 	if err := s.initProviders(); err != nil {
 		log.Printf("Warning: failed to initialize some providers: %v", err)
@@ -105,6 +115,9 @@ func New(dataDir string) (*Service, error) {
 	// Start background update scheduler
 	go s.runUpdateScheduler()
 
+	// Adaptive country blocking (opt-in)
+	s.scheduleWatchlist()
+
 	log.Printf("Geolocation service initialized (lookup: %s, blocking: %v)",
 		s.config.LookupProvider, s.config.BlockingEnabled)
 
@@ -322,6 +335,16 @@ func (s *Service) Handlers() router.ServiceHandlers {
 		"GetCountries":  s.handleGetCountries,
 		// Zone management
 		"RefreshZones": s.handleRefreshZones,
+		// Country blocking toggle
+		"GetBlockingStatus":     s.handleGetBlockingStatus,
+		"GetBlockingDivergence": s.handleGetBlockingDivergence,
+		"EnableBlocking":        s.handleEnableBlocking,
+		"DisableBlocking":       s.handleDisableBlocking,
+		// Country watchlist
+		"GetWatchlist":    s.handleGetWatchlist,
+		"UpdateWatchlist": s.handleUpdateWatchlist,
+		"CheckWatchlist":  s.handleCheckWatchlist,
+		"WatchlistAction": s.handleWatchlistAction,
 	}
 }
 
@@ -341,6 +364,9 @@ func (s *Service) Shutdown() {
 
 // IsBlockingEnabled returns whether country blocking is enabled
 func (s *Service) IsBlockingEnabled() bool {
+	if s == nil {
+		return false
+	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.config.BlockingEnabled
@@ -378,6 +404,14 @@ func (s *Service) FetchAndCacheCountryZones(countryCode string) (int, error) {
 	return rangeCount, nil
 }
 
+// CountryCIDRs returns a country's zone ranges, fetching and caching them if needed
+func (s *Service) CountryCIDRs(countryCode string) ([]string, error) {
+	if _, err := s.FetchAndCacheCountryZones(countryCode); err != nil {
+		return nil, err
+	}
+	return s.blockingProvider.GetCountryCIDRs(strings.ToUpper(countryCode))
+}
+
 // GetAllBlockedCIDRs returns all blocked country CIDRs (implements nftables.CountryZonesProvider)
 func (s *Service) GetAllBlockedCIDRs(outboundOnly bool) ([]string, error) {
 	if s.blockingProvider == nil {
@@ -415,7 +449,7 @@ func (s *Service) DisableBlocking() {
 	s.config.BlockingEnabled = false
 	s.mu.Unlock()
 
-	// Trigger nftables apply (will result in empty country sets)
+	// Trigger nftables apply (country sets and rules are dropped, entries and zones kept)
 	if s.nft != nil {
 		s.nft.RequestApply()
 	}
@@ -63,6 +63,9 @@ type Status struct {
 	UpdateServices    string                    `json:"update_services"`
 	LastUpdateLookup  string                    `json:"last_update_lookup"`
 	LastUpdateBlocking string                   `json:"last_update_blocking"`
+	LastUpdateError   string                    `json:"last_update_error,omitempty"`
+	UpdateInProgress  bool                      `json:"update_in_progress"`
+	NextUpdate        string                    `json:"next_update,omitempty"`
 	Providers         map[string]ProviderStatus `json:"providers"`
 }
 
@@ -75,6 +78,9 @@ type ProviderStatus struct {
 	FilePath   string `json:"file_path,omitempty"`
 	LastUpdate string `json:"last_update,omitempty"`
 	Error      string `json:"error,omitempty"`
+
+	// IP2Location only: detected BIN type and field coverage
+	Database *IP2LocationInfo `json:"database,omitempty"`
 }
 
 // BlockedCountry represents a country that is blocked
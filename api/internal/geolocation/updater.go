@@ -1,29 +1,34 @@
 package geolocation
 
 import (
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"api/internal/scheduler"
 	"api/internal/settings"
 )
 
 // runUpdateScheduler runs the unified geo data update scheduler
 func (s *Service) runUpdateScheduler() {
+	task := scheduler.RegisterTask("geo-update-scheduler", "Run the daily geo data update at the configured hour", time.Minute)
+	defer task.Done(s.ctx)
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
-	lastRunDate := ""
-
 	for {
 		select {
 		case <-s.ctx.Done():
 			log.Printf("Geolocation update scheduler stopping")
 			return
 		case <-ticker.C:
+			task.Tick()
 			s.mu.RLock()
 			enabled := s.config.AutoUpdate
 			targetHour := s.config.UpdateHour
 			updateServices := s.config.UpdateServices
+			lastRunDate := s.lastScheduledDate
 			s.mu.RUnlock()
 
 			if !enabled {
@@ -36,55 +41,97 @@ func (s *Service) runUpdateScheduler() {
 
 			// Only run once per day at the target hour
 			if currentHour == targetHour && currentDate != lastRunDate {
+				s.mu.Lock()
+				s.lastScheduledDate = currentDate
+				s.mu.Unlock()
+
 				log.Printf("Running scheduled geolocation update at %s", now.Format(time.RFC3339))
-				s.runScheduledUpdate(updateServices)
-				lastRunDate = currentDate
+				if err := s.runUpdate(updateServices); err != nil {
+					log.Printf("Scheduled geolocation update: %v", err)
+				}
 			}
 		}
 	}
 }
 
-// runScheduledUpdate performs the scheduled update based on settings
-func (s *Service) runScheduledUpdate(updateServices string) {
-	switch updateServices {
-	case "all":
-		s.updateLookupProvider()
-		s.updateBlockingProvider()
-	case "lookup":
-		s.updateLookupProvider()
-	case "blocking":
-		s.updateBlockingProvider()
-	default:
-		s.updateLookupProvider()
-		s.updateBlockingProvider()
+// nextScheduledUpdate returns when the scheduler will next run an update,
+// or zero time if auto update is disabled. Caller must hold s.mu.
+func (s *Service) nextScheduledUpdate(now time.Time) time.Time {
+	if !s.config.AutoUpdate {
+		return time.Time{}
 	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), s.config.UpdateHour, 0, 0, 0, now.Location())
+	switch {
+	case now.Hour() == s.config.UpdateHour && s.lastScheduledDate != now.Format("2006-01-02"):
+		// Inside the target hour and not run yet: fires on the next ticker tick
+		return now.Truncate(time.Minute).Add(time.Minute)
+	case !next.After(now):
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// runUpdate updates the selected providers, tracking progress and the last error.
+// Returns an error without running if another update is in progress.
+func (s *Service) runUpdate(updateServices string) error {
+	s.mu.Lock()
+	if s.updateInProgress {
+		s.mu.Unlock()
+		return fmt.Errorf("geolocation update already in progress")
+	}
+	s.updateInProgress = true
+	s.mu.Unlock()
+
+	var errs []string
+	if updateServices != "blocking" {
+		if err := s.updateLookupProvider(); err != nil {
+			errs = append(errs, "lookup: "+err.Error())
+		}
+	}
+	if updateServices != "lookup" {
+		if err := s.updateBlockingProvider(); err != nil {
+			errs = append(errs, "blocking: "+err.Error())
+		}
+	}
+
+	lastError := strings.Join(errs, "; ")
+	settings.SetSetting("geo_last_update_error", lastError)
+
+	s.mu.Lock()
+	s.updateInProgress = false
+	s.lastUpdateError = lastError
+	s.mu.Unlock()
+
+	return nil
 }
 
 // updateLookupProvider updates the lookup provider (MaxMind or IP2Location)
-func (s *Service) updateLookupProvider() {
+func (s *Service) updateLookupProvider() error {
 	s.mu.RLock()
 	provider := s.lookupProvider
 	s.mu.RUnlock()
 
 	if provider == nil {
-		return
+		return nil
 	}
 
 	log.Printf("Updating lookup provider: %s", provider.Name())
 	if err := provider.Update(); err != nil {
 		log.Printf("Error updating lookup provider: %v", err)
-		return
+		return err
 	}
 
 	// Update last update timestamp
 	settings.SetSetting("geo_last_update_lookup", time.Now().Format(time.RFC3339))
 	log.Printf("Lookup provider %s updated successfully", provider.Name())
+	return nil
 }
 
 // updateBlockingProvider updates the blocking provider (ipdeny zones)
-func (s *Service) updateBlockingProvider() {
+func (s *Service) updateBlockingProvider() error {
 	if !s.IsBlockingEnabled() {
-		return
+		return nil
 	}
 
 	s.mu.RLock()
@@ -92,7 +139,7 @@ func (s *Service) updateBlockingProvider() {
 	s.mu.RUnlock()
 
 	if provider == nil {
-		return
+		return nil
 	}
 
 	log.Printf("Updating blocking provider: %s", provider.Name())
@@ -108,26 +155,29 @@ func (s *Service) updateBlockingProvider() {
 	// Update last update timestamp
 	settings.SetSetting("geo_last_update_blocking", time.Now().Format(time.RFC3339))
 	log.Printf("Blocking provider update complete: %d updated, %d errors", updated, errors)
+	if errors > 0 {
+		return fmt.Errorf("%d zones failed to refresh", errors)
+	}
+	return nil
 }
 
 // TriggerUpdate manually triggers an update
 func (s *Service) TriggerUpdate(updateServices string) (map[string]string, error) {
 	results := make(map[string]string)
 
+	if err := s.runUpdate(updateServices); err != nil {
+		return nil, err
+	}
+
 	switch updateServices {
 	case "lookup":
-		s.updateLookupProvider()
 		results["lookup"] = "update triggered"
 	case "blocking":
-		s.updateBlockingProvider()
 		results["blocking"] = "update triggered"
 	default:
-		s.updateLookupProvider()
-		s.updateBlockingProvider()
 		results["lookup"] = "update triggered"
 		results["blocking"] = "update triggered"
 	}
 
 	return results, nil
 }
-
@@ -0,0 +1,366 @@
+package geolocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/scheduler"
+	"api/internal/settings"
+	"api/internal/ws"
+)
+
+// watchlistSetting stores the country watchlist config as JSON
+const watchlistSetting = "geo_watchlist"
+
+// watchlistCheckInterval is how often firewall attempts are evaluated
+const watchlistCheckInterval = 5 * time.Minute
+
+// watchlistJob is the scheduler job name for the watchlist check
+const watchlistJob = "geo-watchlist"
+
+// Watchlist suggestion states
+const (
+	WatchlistPending   = "pending"   // waiting for confirmation
+	WatchlistBlocked   = "blocked"   // country added to the block list
+	WatchlistDismissed = "dismissed" // never suggested again until reset
+)
+
+// WatchlistConfig controls adaptive country blocking. Countries whose
+// firewall attempts reach the threshold within the window are suggested,
+// or blocked directly when confirmation isn't required.
+type WatchlistConfig struct {
+	Enabled             bool     `json:"enabled"`
+	Threshold           int      `json:"threshold"`      // attempts within the window
+	WindowMinutes       int      `json:"window_minutes"` // sliding window
+	MinUniqueIPs        int      `json:"min_unique_ips"` // distinct sources, so one noisy IP doesn't flag a country
+	RequireConfirmation bool     `json:"require_confirmation"`
+	Exclude             []string `json:"exclude"` // country codes never suggested
+}
+
+var defaultWatchlistConfig = WatchlistConfig{
+	Threshold:           500,
+	WindowMinutes:       60,
+	MinUniqueIPs:        10,
+	RequireConfirmation: true,
+	Exclude:             []string{},
+}
+
+// WatchlistEntry is a country flagged by the watchlist
+type WatchlistEntry struct {
+	CountryCode string `json:"country_code"`
+	Name        string `json:"name"`
+	Attempts    int    `json:"attempts"`
+	UniqueIPs   int    `json:"unique_ips"`
+	Status      string `json:"status"`
+	DetectedAt  string `json:"detected_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// WatchlistNotifyFunc is called when a country is flagged (blocked=false) or
+// auto-blocked (blocked=true), for push notifications
+type WatchlistNotifyFunc func(countryCode, name string, attempts int, blocked bool)
+
+var (
+	watchlistNotifyCallback WatchlistNotifyFunc
+	watchlistNotifyMu       sync.RWMutex
+)
+
+// SetWatchlistNotifyCallback sets the callback for watchlist notifications
+func SetWatchlistNotifyCallback(fn WatchlistNotifyFunc) {
+	watchlistNotifyMu.Lock()
+	defer watchlistNotifyMu.Unlock()
+	watchlistNotifyCallback = fn
+}
+
+// loadWatchlistConfig returns the stored watchlist config over the defaults
+func loadWatchlistConfig() WatchlistConfig {
+	cfg := defaultWatchlistConfig
+	if raw, err := settings.GetSetting(watchlistSetting); err == nil && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+			log.Printf("geo-watchlist: invalid %s setting: %v (using defaults)", watchlistSetting, err)
+			return defaultWatchlistConfig
+		}
+	}
+	if cfg.Exclude == nil {
+		cfg.Exclude = []string{}
+	}
+	return cfg
+}
+
+// scheduleWatchlist evaluates the watchlist periodically; the job is a no-op
+// unless the watchlist is enabled
+func (s *Service) scheduleWatchlist() {
+	scheduler.Every(watchlistJob, "Flag countries over the watchlist attempt threshold", watchlistCheckInterval,
+		func(ctx context.Context) error {
+			cfg := loadWatchlistConfig()
+			if !cfg.Enabled {
+				return nil
+			}
+			_, err := s.CheckWatchlist(cfg)
+			return err
+		})
+}
+
+// CheckWatchlist flags countries over the threshold that aren't blocked yet
+// and returns the newly flagged entries. Attempts come from the firewall log
+// (logs_type 'fw') once the country enrichment has filled logs_src_country.
+func (s *Service) CheckWatchlist(cfg WatchlistConfig) ([]WatchlistEntry, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	rows, err := s.db.Query(`
+		SELECT logs_src_country, COUNT(*), COUNT(DISTINCT logs_src_ip)
+		FROM logs
+		WHERE logs_type = 'fw' AND logs_timestamp >= datetime('now', ?)
+			AND logs_src_country IS NOT NULL AND logs_src_country != ''
+			AND logs_src_country NOT IN (SELECT value FROM firewall_entries WHERE entry_type = 'country')
+			AND logs_src_country NOT IN (SELECT country_code FROM geo_watchlist)
+		GROUP BY logs_src_country
+		HAVING COUNT(*) >= ? AND COUNT(DISTINCT logs_src_ip) >= ?
+		ORDER BY COUNT(*) DESC`,
+		fmt.Sprintf("-%d minutes", cfg.WindowMinutes), cfg.Threshold, cfg.MinUniqueIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []WatchlistEntry
+	for rows.Next() {
+		var e WatchlistEntry
+		if rows.Scan(&e.CountryCode, &e.Attempts, &e.UniqueIPs) == nil {
+			candidates = append(candidates, e)
+		}
+	}
+	rows.Close()
+
+	excluded := make(map[string]bool)
+	for _, code := range cfg.Exclude {
+		excluded[strings.ToUpper(code)] = true
+	}
+	// Never flag the server's own country
+	if ip := helper.ServerIP(); ip != "" {
+		if res, err := s.LookupIP(ip); err == nil && res != nil && res.CountryCode != "" {
+			excluded[strings.ToUpper(res.CountryCode)] = true
+		}
+	}
+
+	flagged := []WatchlistEntry{}
+	for _, e := range candidates {
+		e.CountryCode = strings.ToUpper(e.CountryCode)
+		if excluded[e.CountryCode] {
+			continue
+		}
+		e.Name = s.countryName(e.CountryCode)
+		e.Status = WatchlistPending
+
+		if _, err := s.db.Exec(`INSERT OR IGNORE INTO geo_watchlist (country_code, attempts, unique_ips, status)
+			VALUES (?, ?, ?, ?)`, e.CountryCode, e.Attempts, e.UniqueIPs, WatchlistPending); err != nil {
+			return flagged, err
+		}
+
+		blocked := false
+		if !cfg.RequireConfirmation {
+			if err := s.blockWatchlistCountry(e.CountryCode, e.Attempts); err != nil {
+				log.Printf("geo-watchlist: failed to block %s: %v", e.CountryCode, err)
+			} else {
+				e.Status = WatchlistBlocked
+				blocked = true
+			}
+		}
+
+		log.Printf("geo-watchlist: %s flagged (%d attempts from %d IPs in %d min, %s)",
+			e.CountryCode, e.Attempts, e.UniqueIPs, cfg.WindowMinutes, e.Status)
+		ws.Broadcast("general_info", map[string]interface{}{
+			"event":   "geo:watchlist",
+			"country": e.CountryCode,
+			"status":  e.Status,
+		})
+
+		watchlistNotifyMu.RLock()
+		notify := watchlistNotifyCallback
+		watchlistNotifyMu.RUnlock()
+		if notify != nil {
+			go notify(e.CountryCode, e.Name, e.Attempts, blocked)
+		}
+
+		flagged = append(flagged, e)
+	}
+	return flagged, nil
+}
+
+// blockWatchlistCountry adds an inbound country block and fetches its zones.
+// The entry only takes effect while country blocking is enabled.
+func (s *Service) blockWatchlistCountry(code string, attempts int) error {
+	_, err := s.db.Exec(`INSERT INTO firewall_entries
+		(entry_type, value, action, direction, protocol, source, reason, name, enabled)
+		VALUES ('country', ?, 'block', 'inbound', 'both', 'watchlist', ?, ?, 1)
+		ON CONFLICT(entry_type, value, protocol) DO UPDATE SET action = 'block', enabled = 1`,
+		code, fmt.Sprintf("Watchlist: %d attempts", attempts), s.countryName(code))
+	if err != nil {
+		return err
+	}
+	s.db.Exec(`UPDATE geo_watchlist SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE country_code = ?`,
+		WatchlistBlocked, code)
+
+	go func() {
+		if count, err := s.FetchAndCacheCountryZones(code); err != nil {
+			log.Printf("geo-watchlist: failed to fetch zones for %s: %v", code, err)
+		} else {
+			s.db.Exec("UPDATE firewall_entries SET hit_count = ? WHERE entry_type = 'country' AND value = ?", count, code)
+		}
+		if s.nft != nil {
+			s.nft.RequestApply()
+		}
+	}()
+	return nil
+}
+
+// countryName returns the configured name of a country code
+func (s *Service) countryName(code string) string {
+	if cfg, ok := s.countryConfigs[code]; ok {
+		return cfg.Name
+	}
+	return code
+}
+
+// watchlistEntries returns the flagged countries, newest first
+func (s *Service) watchlistEntries() ([]WatchlistEntry, error) {
+	rows, err := s.db.Query(`SELECT country_code, attempts, unique_ips, status, detected_at, updated_at
+		FROM geo_watchlist ORDER BY detected_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []WatchlistEntry{}
+	for rows.Next() {
+		var e WatchlistEntry
+		if rows.Scan(&e.CountryCode, &e.Attempts, &e.UniqueIPs, &e.Status, &e.DetectedAt, &e.UpdatedAt) == nil {
+			e.Name = s.countryName(e.CountryCode)
+			entries = append(entries, e)
+		}
+	}
+	return entries, rows.Err()
+}
+
+// handleGetWatchlist returns the watchlist config and flagged countries
+func (s *Service) handleGetWatchlist(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		router.JSONError(w, "database not available", http.StatusInternalServerError)
+		return
+	}
+	entries, err := s.watchlistEntries()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"config":           loadWatchlistConfig(),
+		"entries":          entries,
+		"blocking_enabled": s.IsBlockingEnabled(),
+	})
+}
+
+// handleUpdateWatchlist replaces the watchlist config
+func (s *Service) handleUpdateWatchlist(w http.ResponseWriter, r *http.Request) {
+	cfg := loadWatchlistConfig()
+	if !router.DecodeJSONOrError(w, r, &cfg) {
+		return
+	}
+	if cfg.Threshold < 1 || cfg.WindowMinutes < 1 || cfg.MinUniqueIPs < 1 {
+		router.JSONError(w, "threshold, window_minutes and min_unique_ips must be at least 1", http.StatusBadRequest)
+		return
+	}
+	exclude := []string{}
+	for _, code := range cfg.Exclude {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if len(code) != 2 {
+			router.JSONError(w, "invalid country code in exclude: "+code, http.StatusBadRequest)
+			return
+		}
+		exclude = append(exclude, code)
+	}
+	cfg.Exclude = exclude
+
+	data, _ := json.Marshal(cfg)
+	if err := settings.SetSetting(watchlistSetting, string(data)); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, cfg)
+}
+
+// handleCheckWatchlist evaluates the watchlist now. While the watchlist is
+// disabled countries are only suggested, never blocked.
+func (s *Service) handleCheckWatchlist(w http.ResponseWriter, r *http.Request) {
+	cfg := loadWatchlistConfig()
+	if !cfg.Enabled {
+		cfg.RequireConfirmation = true
+	}
+	flagged, err := s.CheckWatchlist(cfg)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{"flagged": flagged, "count": len(flagged)})
+}
+
+// handleWatchlistAction confirms (block), dismisses or resets (DELETE) a
+// flagged country: /api/geo/watchlist/{code}/block|dismiss
+func (s *Service) handleWatchlistAction(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/geo/watchlist/"), "/")
+	code := strings.ToUpper(parts[0])
+
+	var status string
+	var attempts int
+	if err := s.db.QueryRow(`SELECT status, attempts FROM geo_watchlist WHERE country_code = ?`, code).
+		Scan(&status, &attempts); err != nil {
+		router.JSONError(w, "country not on the watchlist", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		// Forget the entry so the country can be flagged again
+		s.db.Exec(`DELETE FROM geo_watchlist WHERE country_code = ?`, code)
+		router.JSON(w, map[string]interface{}{"country_code": code, "status": "removed"})
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	switch action {
+	case "block":
+		if status == WatchlistBlocked {
+			router.JSONError(w, code+" is already blocked", http.StatusConflict)
+			return
+		}
+		if err := s.blockWatchlistCountry(code, attempts); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		status = WatchlistBlocked
+	case "dismiss":
+		s.db.Exec(`UPDATE geo_watchlist SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE country_code = ?`,
+			WatchlistDismissed, code)
+		status = WatchlistDismissed
+	default:
+		router.JSONError(w, "unknown action: use block or dismiss", http.StatusBadRequest)
+		return
+	}
+
+	resp := map[string]interface{}{"country_code": code, "status": status}
+	if status == WatchlistBlocked && !s.IsBlockingEnabled() {
+		resp["warning"] = "country blocking is disabled; the entry applies once it is enabled"
+	}
+	router.JSON(w, resp)
+}
@@ -119,6 +119,20 @@ func ExpireNode(nodeID string) error {
 	return nil
 }
 
+// SetNodeTags replaces the forced tags of a Headscale node ("tag:" prefixed)
+func SetNodeTags(nodeID string, tags []string) error {
+	body, _ := json.Marshal(map[string][]string{"tags": tags})
+	resp, err := helper.HeadscalePost("/node/"+nodeID+"/tags", string(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("headscale returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // ExpireAllNodes expires all Headscale nodes, returns count of expired nodes
 func ExpireAllNodes() (int, error) {
 	nodes, err := GetNodes()
@@ -258,6 +272,7 @@ func (s *Service) Handlers() router.ServiceHandlers {
 		"GetAPIKeys":        s.handleGetAPIKeys,
 		"CreateAPIKey":      s.handleCreateAPIKey,
 		"DeleteAPIKey":      s.handleDeleteAPIKey,
+		"TestConnection":    s.handleTestConnection,
 	}
 }
 
@@ -301,6 +316,12 @@ func (s *Service) proxyDelete(w http.ResponseWriter, path string) {
 	s.proxyResponse(w, resp)
 }
 
+// --- Connection ---
+
+func (s *Service) handleTestConnection(w http.ResponseWriter, r *http.Request) {
+	router.JSON(w, helper.TestHeadscaleConnection())
+}
+
 // --- Users ---
 
 func (s *Service) handleGetUsers(w http.ResponseWriter, r *http.Request) {
@@ -41,6 +41,7 @@ const (
 	DockerRequestTimeout      = 60 * time.Second
 	DockerQuickTimeout        = 5 * time.Second
 	HTTPClientTimeout         = 10 * time.Second
+	UpstreamFetchTimeout      = 5 * time.Second // per-fetch deadline for dashboard overview fan-outs
 	WebSocketReadTimeout      = 10 * time.Second
 	PortScanTimeout           = 5 * time.Minute
 	GeoDBDownloadTimeout      = 5 * time.Minute
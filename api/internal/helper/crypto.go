@@ -8,16 +8,39 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
-var encryptionKey []byte
+var (
+	encryptionKey []byte
+	keyMu         sync.RWMutex // write-locked while the key is being rotated
+)
+
+// EncryptionKeyFile holds a rotated key (hex) in DATA_DIR. Once present it
+// takes precedence over ENCRYPTION_SECRET.
+const EncryptionKeyFile = "encryption.key"
 
-// InitEncryption initializes the encryption key from environment.
+// InitEncryption initializes the encryption key from the rotated key file or environment.
 // Must be called early in main() before any encryption/decryption operations.
 func InitEncryption() {
+	if dataDir := os.Getenv("DATA_DIR"); dataDir != "" {
+		key, err := ReadKeyFile(filepath.Join(dataDir, EncryptionKeyFile))
+		if err == nil {
+			encryptionKey = key
+			log.Printf("Encryption key loaded from %s", EncryptionKeyFile)
+			return
+		}
+		if !os.IsNotExist(err) {
+			log.Fatalf("FATAL: Failed to read %s: %v", EncryptionKeyFile, err)
+		}
+	}
+
 	keyHex := os.Getenv("ENCRYPTION_SECRET")
 	if keyHex == "" {
 		log.Fatal("FATAL: ENCRYPTION_SECRET environment variable is required but not set. Generate one with: openssl rand -hex 32")
@@ -33,13 +56,100 @@ func InitEncryption() {
 	encryptionKey = key
 }
 
+// GenerateEncryptionKey returns a new random AES-256 key
+func GenerateEncryptionKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ReadKeyFile reads a hex-encoded AES-256 key
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s does not contain a 32-byte hex key", filepath.Base(path))
+	}
+	return key, nil
+}
+
+// WriteKeyFile atomically writes a hex-encoded key readable only by the owner
+func WriteKeyFile(path string, key []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	f.Close()
+	return os.Rename(tmp, path)
+}
+
+// SetEncryptionKey replaces the active key (used when recovering an interrupted rotation)
+func SetEncryptionKey(key []byte) {
+	keyMu.Lock()
+	encryptionKey = key
+	keyMu.Unlock()
+}
+
+// RotateEncryptionKey runs fn with the current key while blocking all other
+// Encrypt/Decrypt calls, then activates newKey if fn succeeds. fn re-encrypts
+// stored data with EncryptWithKey/DecryptWithKey.
+func RotateEncryptionKey(newKey []byte, fn func(oldKey []byte) error) error {
+	if len(newKey) != 32 {
+		return errors.New("encryption key must be 32 bytes")
+	}
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if encryptionKey == nil {
+		return errors.New("encryption not initialized")
+	}
+	if err := fn(encryptionKey); err != nil {
+		return err
+	}
+	encryptionKey = newKey
+	return nil
+}
+
 // Encrypt encrypts a string value using AES-256-GCM
 func Encrypt(plaintext string) (string, error) {
-	if encryptionKey == nil {
+	keyMu.RLock()
+	key := encryptionKey
+	keyMu.RUnlock()
+	if key == nil {
 		return "", errors.New("encryption not initialized")
 	}
+	return EncryptWithKey(key, plaintext)
+}
 
-	block, err := aes.NewCipher(encryptionKey)
+// Decrypt decrypts an AES-256-GCM encrypted string
+func Decrypt(ciphertext string) (string, error) {
+	keyMu.RLock()
+	key := encryptionKey
+	keyMu.RUnlock()
+	if key == nil {
+		return "", errors.New("encryption not initialized")
+	}
+	return DecryptWithKey(key, ciphertext)
+}
+
+// EncryptWithKey encrypts with an explicit key (key rotation)
+func EncryptWithKey(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -58,18 +168,14 @@ func Encrypt(plaintext string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// Decrypt decrypts an AES-256-GCM encrypted string
-func Decrypt(ciphertext string) (string, error) {
-	if encryptionKey == nil {
-		return "", errors.New("encryption not initialized")
-	}
-
+// DecryptWithKey decrypts with an explicit key (key rotation)
+func DecryptWithKey(key []byte, ciphertext string) (string, error) {
 	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(encryptionKey)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
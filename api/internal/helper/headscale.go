@@ -112,6 +112,70 @@ func HeadscaleGetJSON(path string, result interface{}) error {
 	return json.NewDecoder(resp.Body).Decode(result)
 }
 
+// HeadscaleConnectionStatus is the result of a Headscale API connection test
+type HeadscaleConnectionStatus struct {
+	Configured    bool   `json:"configured"`
+	Reachable     bool   `json:"reachable"`
+	Authenticated bool   `json:"authenticated"`
+	Version       string `json:"version,omitempty"`
+	URL           string `json:"url,omitempty"`
+	Message       string `json:"message"`
+}
+
+// TestHeadscaleConnection makes a lightweight authenticated call (list users)
+// to tell apart a missing config, an unreachable server and a rejected API key
+func TestHeadscaleConnection() HeadscaleConnectionStatus {
+	var status HeadscaleConnectionStatus
+
+	config, err := getHeadscaleConfig()
+	if err != nil || config.URL == "" || config.APIKey == "" {
+		status.Message = "Headscale API URL or API key is not configured"
+		return status
+	}
+	status.Configured = true
+	status.URL = config.URL
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	req, err := http.NewRequest("GET", config.URL+"/user", nil)
+	if err != nil {
+		status.Message = fmt.Sprintf("invalid Headscale API URL: %v", err)
+		return status
+	}
+	req.Header.Set("Authorization", "Bearer "+config.APIKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		status.Message = fmt.Sprintf("Headscale is unreachable at %s: %v", config.URL, err)
+		return status
+	}
+	resp.Body.Close()
+	status.Reachable = true
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		status.Message = "Headscale is reachable but rejected the API key"
+		return status
+	case resp.StatusCode != http.StatusOK:
+		status.Message = fmt.Sprintf("Headscale returned unexpected status %d", resp.StatusCode)
+		return status
+	}
+	status.Authenticated = true
+	status.Message = "Connected to Headscale"
+
+	// Version endpoint is public and lives outside /api/v1 (best-effort, older releases lack it)
+	if resp, err := client.Get(strings.TrimSuffix(config.URL, "/api/v1") + "/version"); err == nil {
+		var v struct {
+			Version string `json:"version"`
+		}
+		if resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&v) == nil {
+			status.Version = v.Version
+		}
+		resp.Body.Close()
+	}
+
+	return status
+}
+
 // Docker client helpers
 
 // DockerConfig holds Docker API configuration
@@ -9,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // DefaultEssentialPortsFile is where BuildEssentialPorts looks for the port
@@ -55,6 +56,15 @@ func GetEnvIntOptional(key string, def int) int {
 	return def
 }
 
+// FetchTimeout returns the deadline for parallel upstream fetches (overview
+// endpoints), overridable with UPSTREAM_FETCH_TIMEOUT in seconds
+func FetchTimeout() time.Duration {
+	if secs := GetEnvIntOptional("UPSTREAM_FETCH_TIMEOUT", 0); secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return UpstreamFetchTimeout
+}
+
 // ParsePortList parses a comma-separated list of ports (e.g., "22,80,443")
 func ParsePortList(s string) []int {
 	var ports []int
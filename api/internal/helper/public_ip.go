@@ -0,0 +1,237 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Public IP detection defaults
+const (
+	DefaultPublicIPEchoURL     = "https://api.ipify.org"
+	DefaultPublicIPIntervalMin = 10
+	publicIPMinInterval        = time.Minute // rate limit for lookups, including forced refreshes
+)
+
+// PublicIPStatus is the configured SERVER_IP next to the detected public IP
+type PublicIPStatus struct {
+	Configured string     `json:"configured"`
+	Detected   string     `json:"detected,omitempty"`
+	Effective  string     `json:"effective"`
+	Source     string     `json:"source,omitempty"` // echo or interface
+	AutoDetect bool       `json:"autoDetect"`
+	EchoURL    string     `json:"echoUrl"`
+	DetectedAt *time.Time `json:"detectedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+var publicIP struct {
+	mu        sync.RWMutex
+	detected  string
+	source    string
+	checkedAt time.Time
+	lastErr   string
+	onChange  []func(oldIP, newIP string)
+	startOnce sync.Once
+	cancel    context.CancelFunc
+}
+
+var publicIPClient = &http.Client{Timeout: HTTPClientTimeout}
+
+// PublicIPAutoDetect reports whether the detected IP overrides SERVER_IP.
+// Detection is on when SERVER_IP_AUTODETECT=true or SERVER_IP is unset.
+func PublicIPAutoDetect() bool {
+	return GetEnvOptional("SERVER_IP_AUTODETECT", "") == "true" || GetEnvOptional("SERVER_IP", "") == ""
+}
+
+// ServerIP returns the effective public IP of the server: the detected IP when
+// auto-detection is on and has succeeded, otherwise the configured SERVER_IP
+func ServerIP() string {
+	configured := GetEnvOptional("SERVER_IP", "")
+	if !PublicIPAutoDetect() {
+		return configured
+	}
+	publicIP.mu.RLock()
+	defer publicIP.mu.RUnlock()
+	if publicIP.detected != "" {
+		return publicIP.detected
+	}
+	return configured
+}
+
+// OnPublicIPChange registers a callback run after the detected public IP changes
+func OnPublicIPChange(fn func(oldIP, newIP string)) {
+	publicIP.mu.Lock()
+	publicIP.onChange = append(publicIP.onChange, fn)
+	publicIP.mu.Unlock()
+}
+
+// GetPublicIPStatus returns the configured, detected and effective server IP
+func GetPublicIPStatus() PublicIPStatus {
+	status := PublicIPStatus{
+		Configured: GetEnvOptional("SERVER_IP", ""),
+		AutoDetect: PublicIPAutoDetect(),
+		EchoURL:    GetEnvOptional("PUBLIC_IP_ECHO_URL", DefaultPublicIPEchoURL),
+		Effective:  ServerIP(),
+	}
+
+	publicIP.mu.RLock()
+	defer publicIP.mu.RUnlock()
+	status.Detected = publicIP.detected
+	status.Source = publicIP.source
+	status.Error = publicIP.lastErr
+	if !publicIP.checkedAt.IsZero() {
+		t := publicIP.checkedAt
+		status.DetectedAt = &t
+	}
+	return status
+}
+
+// RefreshPublicIP detects the public IP now, unless the last lookup was less
+// than a minute ago. Change callbacks run when the detected IP changes.
+func RefreshPublicIP() error {
+	publicIP.mu.Lock()
+	if !publicIP.checkedAt.IsZero() && time.Since(publicIP.checkedAt) < publicIPMinInterval {
+		publicIP.mu.Unlock()
+		return nil
+	}
+	publicIP.checkedAt = time.Now()
+	publicIP.mu.Unlock()
+
+	ip, source, err := detectPublicIP()
+
+	publicIP.mu.Lock()
+	if err != nil {
+		publicIP.lastErr = err.Error()
+		publicIP.mu.Unlock()
+		return err
+	}
+	old := publicIP.detected
+	publicIP.detected = ip
+	publicIP.source = source
+	publicIP.lastErr = ""
+	callbacks := publicIP.onChange
+	publicIP.mu.Unlock()
+
+	if old != ip {
+		log.Printf("Public IP detected: %s (via %s)", ip, source)
+		// The first detection replaces the configured SERVER_IP
+		if old == "" {
+			old = GetEnvOptional("SERVER_IP", "")
+		}
+		if old != ip && PublicIPAutoDetect() {
+			for _, fn := range callbacks {
+				fn(old, ip)
+			}
+		}
+	}
+	return nil
+}
+
+// detectPublicIP asks the echo service, falling back to the address of the
+// default route interface when that address is public
+func detectPublicIP() (string, string, error) {
+	echoURL := GetEnvOptional("PUBLIC_IP_ECHO_URL", DefaultPublicIPEchoURL)
+	ip, echoErr := echoPublicIP(echoURL)
+	if echoErr == nil {
+		return ip, "echo", nil
+	}
+
+	ip, ifaceErr := defaultRouteIP()
+	if ifaceErr == nil {
+		return ip, "interface", nil
+	}
+	return "", "", fmt.Errorf("echo service: %v; interface: %v", echoErr, ifaceErr)
+}
+
+// echoPublicIP fetches the caller's IP from a plain-text echo service
+func echoPublicIP(url string) (string, error) {
+	resp, err := publicIPClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return "", err
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return "", fmt.Errorf("invalid response %q", strings.TrimSpace(string(body)))
+	}
+	return ip.String(), nil
+}
+
+// defaultRouteIP returns the source address used for outbound traffic; a UDP
+// "connection" only selects the route, no packet is sent
+func defaultRouteIP() (string, error) {
+	conn, err := net.Dial("udp4", "1.1.1.1:53")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	ip := conn.LocalAddr().(*net.UDPAddr).IP
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return "", fmt.Errorf("default route address %s is not public", ip)
+	}
+	return ip.String(), nil
+}
+
+// StartPublicIPDetection refreshes the public IP in the background every
+// PUBLIC_IP_DETECT_INTERVAL minutes (default 10) while auto-detection is on
+func StartPublicIPDetection() {
+	if !PublicIPAutoDetect() {
+		return
+	}
+	publicIP.startOnce.Do(func() {
+		interval := GetEnvIntOptional("PUBLIC_IP_DETECT_INTERVAL", DefaultPublicIPIntervalMin)
+		if interval < 1 {
+			interval = 1
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		publicIP.mu.Lock()
+		publicIP.cancel = cancel
+		publicIP.mu.Unlock()
+
+		go runPublicIPDetection(ctx, time.Duration(interval)*time.Minute)
+		log.Printf("Public IP detection started (every %d min)", interval)
+	})
+}
+
+// StopPublicIPDetection stops the background detection
+func StopPublicIPDetection() {
+	publicIP.mu.RLock()
+	cancel := publicIP.cancel
+	publicIP.mu.RUnlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func runPublicIPDetection(ctx context.Context, interval time.Duration) {
+	if err := RefreshPublicIP(); err != nil {
+		log.Printf("Warning: public IP detection failed: %v", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := RefreshPublicIP(); err != nil {
+				log.Printf("Warning: public IP detection failed: %v", err)
+			}
+		}
+	}
+}
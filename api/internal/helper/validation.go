@@ -70,6 +70,37 @@ func ValidatePortString(portStr string) (int, error) {
 	return port, nil
 }
 
+// ParsePortRange parses a single port ("443") or an inclusive range ("30000-30100")
+func ParsePortRange(value string) (int, int, error) {
+	value = strings.TrimSpace(value)
+	startStr, endStr, isRange := strings.Cut(value, "-")
+	if !isRange {
+		port, err := ValidatePortString(value)
+		return port, port, err
+	}
+
+	start, err := ValidatePortString(startStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := ValidatePortString(endStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start > end {
+		return 0, 0, &ValidationError{Field: "port", Message: "port range start must not exceed end"}
+	}
+	return start, end, nil
+}
+
+// FormatPortRange formats a port range as "start-end", or a single port if start == end
+func FormatPortRange(start, end int) string {
+	if start == end {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
 // ValidateDomain validates a domain name
 // Allows formats like: wiki.local, my-app.home, sub.domain.local, *.domain.tld
 func ValidateDomain(domain string) error {
@@ -0,0 +1,303 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"api/internal/helper"
+	"api/internal/router"
+)
+
+// App log levels, lowest first. The standard logger has no levels, so they
+// are inferred from the message (see appLogLevel).
+const (
+	AppLogDebug = "debug" // per-request access lines
+	AppLogInfo  = "info"
+	AppLogWarn  = "warn"
+	AppLogError = "error"
+)
+
+var appLogLevelRank = map[string]int{AppLogDebug: 0, AppLogInfo: 1, AppLogWarn: 2, AppLogError: 3}
+
+// App log buffer bounds (APP_LOG_BUFFER lines, default 2000)
+const (
+	defaultAppLogLines = 2000
+	minAppLogLines     = 100
+	maxAppLogLines     = 20000
+	maxAppLogLineLen   = 4096
+)
+
+var (
+	// "2006/01/02 15:04:05[.000000] " prefix added by the standard logger
+	stdLogPrefix = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(\.\d+)? `)
+	// Access lines written by the router's logging middleware
+	accessLogLine = regexp.MustCompile(`^(\[[0-9a-z]+\] |\{"request_id":)`)
+)
+
+// AppLogEntry is one line of the panel's own log output
+type AppLogEntry struct {
+	Seq     int64     `json:"seq"`
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+// appLogSink keeps the most recent log lines in a ring buffer and fans new
+// lines out to tail subscribers
+type appLogSink struct {
+	mu      sync.RWMutex
+	entries []AppLogEntry
+	next    int // ring position of the next write
+	full    bool
+	seq     int64
+	subs    map[chan AppLogEntry]struct{}
+}
+
+var appLog *appLogSink
+
+// InstallAppLogSink tees the standard logger into the in-memory app log
+// buffer. Call once at startup, before services start logging.
+func InstallAppLogSink() {
+	if appLog != nil {
+		return
+	}
+	size := helper.GetEnvIntOptional("APP_LOG_BUFFER", defaultAppLogLines)
+	if size < minAppLogLines {
+		size = minAppLogLines
+	} else if size > maxAppLogLines {
+		size = maxAppLogLines
+	}
+	appLog = &appLogSink{
+		entries: make([]AppLogEntry, size),
+		subs:    make(map[chan AppLogEntry]struct{}),
+	}
+	log.SetOutput(io.MultiWriter(os.Stderr, appLog))
+}
+
+// Write implements io.Writer; the standard logger writes one line per call
+func (s *appLogSink) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		line = stdLogPrefix.ReplaceAllString(line, "")
+		if line == "" {
+			continue
+		}
+		if len(line) > maxAppLogLineLen {
+			line = line[:maxAppLogLineLen] + "…"
+		}
+		s.add(line)
+	}
+	return len(p), nil
+}
+
+func (s *appLogSink) add(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	entry := AppLogEntry{Seq: s.seq, Time: time.Now(), Level: appLogLevel(msg), Message: msg}
+	s.entries[s.next] = entry
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+
+	// Slow subscribers miss lines rather than blocking the logger
+	for ch := range s.subs {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// snapshot returns the buffered entries after seq, oldest first
+func (s *appLogSink) snapshot(afterSeq int64) []AppLogEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ordered []AppLogEntry
+	if s.full {
+		ordered = append(ordered, s.entries[s.next:]...)
+	}
+	ordered = append(ordered, s.entries[:s.next]...)
+
+	result := []AppLogEntry{}
+	for _, e := range ordered {
+		if e.Seq > afterSeq {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (s *appLogSink) subscribe() chan AppLogEntry {
+	ch := make(chan AppLogEntry, 64)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *appLogSink) unsubscribe(ch chan AppLogEntry) {
+	s.mu.Lock()
+	delete(s.subs, ch)
+	s.mu.Unlock()
+}
+
+// appLogLevel infers a level from the message wording used across the services
+func appLogLevel(msg string) string {
+	if accessLogLine.MatchString(msg) {
+		return AppLogDebug
+	}
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "error") || strings.Contains(lower, "failed") || strings.Contains(lower, "panic"):
+		if strings.HasPrefix(lower, "warning") {
+			return AppLogWarn
+		}
+		return AppLogError
+	case strings.HasPrefix(lower, "warning") || strings.Contains(lower, "warning:"):
+		return AppLogWarn
+	}
+	return AppLogInfo
+}
+
+// appLogFilter selects entries at or above a level that contain a search term
+type appLogFilter struct {
+	minRank int
+	search  string
+}
+
+func parseAppLogFilter(r *http.Request) (appLogFilter, error) {
+	level := strings.ToLower(router.QueryParam(r, "level", AppLogInfo))
+	rank, ok := appLogLevelRank[level]
+	if !ok {
+		return appLogFilter{}, fmt.Errorf("invalid level: use debug, info, warn or error")
+	}
+	return appLogFilter{minRank: rank, search: strings.ToLower(router.QueryParam(r, "search", ""))}, nil
+}
+
+func (f appLogFilter) match(e AppLogEntry) bool {
+	if appLogLevelRank[e.Level] < f.minRank {
+		return false
+	}
+	return f.search == "" || strings.Contains(strings.ToLower(e.Message), f.search)
+}
+
+// handleGetAppLogs returns recent panel log lines, newest last.
+// Query: level (minimum, default info), search, limit (default 200), after (seq).
+func (s *Service) handleGetAppLogs(w http.ResponseWriter, r *http.Request) {
+	if appLog == nil {
+		router.JSONError(w, "app log buffer not installed", http.StatusServiceUnavailable)
+		return
+	}
+	filter, err := parseAppLogFilter(r)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(router.QueryParam(r, "limit", "200"))
+	if limit <= 0 || limit > maxAppLogLines {
+		limit = 200
+	}
+	after, _ := strconv.ParseInt(router.QueryParam(r, "after", "0"), 10, 64)
+
+	matched := []AppLogEntry{}
+	for _, e := range appLog.snapshot(after) {
+		if filter.match(e) {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+
+	appLog.mu.RLock()
+	lastSeq, capacity := appLog.seq, len(appLog.entries)
+	appLog.mu.RUnlock()
+
+	router.JSON(w, map[string]interface{}{
+		"logs":     matched,
+		"lastSeq":  lastSeq,
+		"capacity": capacity,
+	})
+}
+
+// handleTailAppLogs streams panel log lines as server-sent events. Buffered
+// lines after ?after= (or Last-Event-ID) are replayed first.
+func (s *Service) handleTailAppLogs(w http.ResponseWriter, r *http.Request) {
+	if appLog == nil {
+		router.JSONError(w, "app log buffer not installed", http.StatusServiceUnavailable)
+		return
+	}
+	filter, err := parseAppLogFilter(r)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after := int64(-1)
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		after, _ = strconv.ParseInt(v, 10, 64)
+	} else if v := router.QueryParam(r, "after", ""); v != "" {
+		after, _ = strconv.ParseInt(v, 10, 64)
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ch := appLog.subscribe()
+	defer appLog.unsubscribe(ch)
+
+	send := func(e AppLogEntry) bool {
+		if e.Seq <= after || !filter.match(e) {
+			return true
+		}
+		after = e.Seq
+		data, _ := json.Marshal(e)
+		if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.Seq, data); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if after >= 0 {
+		for _, e := range appLog.snapshot(after) {
+			if !send(e) {
+				return
+			}
+		}
+	}
+	rc.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			if !send(e) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
@@ -3,11 +3,16 @@ package logs
 import (
 	"log"
 	"time"
+
+	"api/internal/scheduler"
 )
 
 // runCleanup periodically enforces max entries limit
 func (s *Service) runCleanup() {
-	ticker := time.NewTicker(time.Duration(s.config.CleanupInterval) * time.Minute)
+	interval := time.Duration(s.config.CleanupInterval) * time.Minute
+	task := scheduler.RegisterTask("logs-cleanup", "Enforce the per-type log entry limit", interval)
+	defer task.Done(s.ctx)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -17,6 +22,7 @@ func (s *Service) runCleanup() {
 			return
 		case <-ticker.C:
 			s.cleanup()
+			task.Tick()
 		}
 	}
 }
@@ -5,11 +5,15 @@ import (
 	"time"
 
 	"api/internal/geolocation"
+	"api/internal/scheduler"
 )
 
 // runCountryUpdater periodically updates NULL country fields
 func (s *Service) runCountryUpdater() {
-	ticker := time.NewTicker(time.Duration(s.config.CountryInterval) * time.Minute)
+	interval := time.Duration(s.config.CountryInterval) * time.Minute
+	task := scheduler.RegisterTask("logs-country-updater", "Fill in missing log entry countries", interval)
+	defer task.Done(s.ctx)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	// Run once at startup after a short delay
@@ -23,6 +27,7 @@ func (s *Service) runCountryUpdater() {
 			return
 		case <-ticker.C:
 			s.updateCountries()
+			task.Tick()
 		}
 	}
 }
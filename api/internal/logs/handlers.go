@@ -136,7 +136,8 @@ func (s *Service) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 		COALESCE(l.logs_service, '') as logs_service,
 		COALESCE(l.logs_query_type, '') as logs_query_type,
 		COALESCE(l.logs_upstream, '') as logs_upstream,
-		COALESCE(l.logs_rule, '') as logs_rule
+		COALESCE(l.logs_rule, '') as logs_rule,
+		COALESCE(l.logs_extra, '') as logs_extra
 	FROM logs l
 	LEFT JOIN vpn_clients c ON l.logs_src_ip = c.ip
 	WHERE 1=1`
@@ -181,17 +182,21 @@ func (s *Service) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	logs := []LogEntry{}
 	for rows.Next() {
 		var entry LogEntry
+		var extra string
 		err := rows.Scan(
 			&entry.ID, &entry.Timestamp, &entry.Type, &entry.SrcIP,
 			&entry.SrcClientName, &entry.SrcCountry, &entry.DestIP, &entry.DestPort, &entry.DestCountry,
 			&entry.Domain, &entry.Protocol, &entry.Status, &entry.Duration,
 			&entry.Bytes, &entry.Cached, &entry.Method, &entry.Path,
 			&entry.Router, &entry.Service, &entry.QueryType, &entry.Upstream,
-			&entry.Rule,
+			&entry.Rule, &extra,
 		)
 		if err != nil {
 			continue
 		}
+		if extra != "" {
+			entry.Extra = json.RawMessage(extra)
+		}
 		logs = append(logs, entry)
 	}
 
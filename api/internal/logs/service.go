@@ -193,5 +193,7 @@ func (s *Service) Handlers() router.ServiceHandlers {
 		"GetPeerUsage":   s.handleGetPeerUsage,
 		"ResetPeerUsage": s.handleResetPeerUsage,
 		"GetTopTalkers":  s.handleGetTopTalkers,
+		"GetAppLogs":     s.handleGetAppLogs,
+		"TailAppLogs":    s.handleTailAppLogs,
 	}
 }
@@ -0,0 +1,50 @@
+package sources
+
+import (
+	"sync"
+	"time"
+
+	"api/internal/database"
+)
+
+// clientLoggingTTL bounds how long a changed per-client flag can take to apply
+// when InvalidateClientLogging wasn't called (e.g. the DB was edited directly)
+const clientLoggingTTL = time.Minute
+
+// clientLogging caches the IPs of VPN clients with log_traffic disabled, so the
+// watchers don't query vpn_clients for every log line
+var clientLogging struct {
+	mu       sync.Mutex
+	disabled map[string]bool
+	loadedAt time.Time
+}
+
+// skipClientLogging reports whether traffic from a VPN client IP must not be logged
+func skipClientLogging(db *database.DB, ip string) bool {
+	clientLogging.mu.Lock()
+	defer clientLogging.mu.Unlock()
+
+	if clientLogging.disabled == nil || time.Since(clientLogging.loadedAt) > clientLoggingTTL {
+		disabled := make(map[string]bool)
+		rows, err := db.Query(`SELECT ip FROM vpn_clients WHERE log_traffic = 0`)
+		if err == nil {
+			for rows.Next() {
+				var clientIP string
+				if rows.Scan(&clientIP) == nil {
+					disabled[clientIP] = true
+				}
+			}
+			rows.Close()
+		}
+		clientLogging.disabled = disabled
+		clientLogging.loadedAt = time.Now()
+	}
+	return clientLogging.disabled[ip]
+}
+
+// InvalidateClientLogging drops the cached flags after a client's log_traffic changes
+func InvalidateClientLogging() {
+	clientLogging.mu.Lock()
+	clientLogging.disabled = nil
+	clientLogging.mu.Unlock()
+}
@@ -191,6 +191,9 @@ func (w *ConntrackWatcher) processLine(line string, onClose bool) {
 		!hasPrefix(destIP, w.config.HeadscaleIPPrefix) {
 		return
 	}
+	if skipClientLogging(w.db, peerIP) {
+		return
+	}
 
 	curUp, _ := strconv.ParseInt(bytesM[0][1], 10, 64)   // orig direction = upload
 	curDown, _ := strconv.ParseInt(bytesM[1][1], 10, 64) // reply direction = download
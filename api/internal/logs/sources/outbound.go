@@ -110,6 +110,11 @@ func (w *OutboundWatcher) processLine(line string) {
 		return
 	}
 
+	// Clients with traffic logging turned off
+	if skipClientLogging(w.db, srcIP) {
+		return
+	}
+
 	// Check DNS cache for domain
 	domain := w.dnsCache.Get(dstIP)
 
@@ -0,0 +1,82 @@
+package sources
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"api/internal/database"
+	"api/internal/helper"
+)
+
+// routeLoggingTTL bounds how long a changed route access log config takes to
+// apply (domains can't invalidate the cache: it would be an import cycle)
+const routeLoggingTTL = time.Minute
+
+// routeLogging caches the extra access log fields per domain router name
+// (domain-<sanitized>), so the Traefik watcher doesn't query domain_routes per line
+var routeLogging struct {
+	mu       sync.Mutex
+	fields   map[string][]string
+	loadedAt time.Time
+}
+
+// routeLogFields returns the extra fields configured for the route behind a
+// Traefik router name (e.g. "domain-app-example-com-secure@file")
+func routeLogFields(db *database.DB, routerName string) []string {
+	routeLogging.mu.Lock()
+	defer routeLogging.mu.Unlock()
+
+	if routeLogging.fields == nil || time.Since(routeLogging.loadedAt) > routeLoggingTTL {
+		fields := make(map[string][]string)
+		rows, err := db.Query(`SELECT domain, access_log FROM domain_routes WHERE COALESCE(access_log, '') != ''`)
+		if err == nil {
+			for rows.Next() {
+				var domain, raw string
+				if rows.Scan(&domain, &raw) != nil {
+					continue
+				}
+				var cfg struct {
+					Fields []string `json:"fields"`
+				}
+				if json.Unmarshal([]byte(raw), &cfg) == nil && len(cfg.Fields) > 0 {
+					fields["domain-"+helper.SanitizeDomainName(domain)] = cfg.Fields
+				}
+			}
+			rows.Close()
+		}
+		routeLogging.fields = fields
+		routeLogging.loadedAt = time.Now()
+	}
+
+	name, _, _ := strings.Cut(routerName, "@")
+	if f, ok := routeLogging.fields[name]; ok {
+		return f
+	}
+	return routeLogging.fields[strings.TrimSuffix(name, "-secure")]
+}
+
+// routeLogExtra picks the route's extra fields out of a raw access log line,
+// returning nil when the route has none configured
+func routeLogExtra(db *database.DB, routerName, line string) interface{} {
+	fields := routeLogFields(db, routerName)
+	if len(fields) == 0 {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if json.Unmarshal([]byte(line), &raw) != nil {
+		return nil
+	}
+	extra := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if v, ok := raw[f]; ok {
+			extra[f] = v
+		}
+	}
+	if len(extra) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(extra)
+	return string(b)
+}
@@ -109,8 +109,8 @@ func (w *TraefikWatcher) processLine(line string) {
 		INSERT INTO logs (
 			logs_timestamp, logs_type, logs_src_ip, logs_domain,
 			logs_protocol, logs_status, logs_duration, logs_bytes,
-			logs_method, logs_path, logs_router, logs_service
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			logs_method, logs_path, logs_router, logs_service, logs_extra
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		timestamp,
 		logs.LogTypeInbound,
@@ -124,6 +124,7 @@ func (w *TraefikWatcher) processLine(line string) {
 		entry.RequestPath,
 		entry.RouterName,
 		entry.ServiceName,
+		routeLogExtra(w.db, entry.RouterName, line),
 	)
 
 	if err != nil {
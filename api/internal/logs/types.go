@@ -1,6 +1,9 @@
 package logs
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // LogType represents the type of log entry
 type LogType string
@@ -76,6 +79,7 @@ type LogEntry struct {
 	QueryType   string    `json:"logs_query_type,omitempty"`
 	Upstream    string    `json:"logs_upstream,omitempty"`
 	Rule        string    `json:"logs_rule,omitempty"`
+	Extra       json.RawMessage `json:"logs_extra,omitempty"` // per-route extra access log fields
 }
 
 // Config holds logs service configuration
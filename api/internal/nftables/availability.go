@@ -0,0 +1,69 @@
+package nftables
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ErrUnavailable is returned by applies when nft can't manage the ruleset
+var ErrUnavailable = errors.New("nftables unavailable")
+
+// Availability reports whether nft can run and read the ruleset on this host
+type Availability struct {
+	Available bool      `json:"available"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// probe runs `nft list ruleset` and records the result. It fails when the nft
+// binary is missing or the process lacks CAP_NET_ADMIN (e.g. a container
+// started without NET_ADMIN).
+func (s *Service) probe() Availability {
+	a := Availability{Available: true, CheckedAt: time.Now()}
+	out, err := s.Exec("list", "ruleset")
+	if err != nil {
+		a.Available = false
+		msg := strings.TrimSpace(string(out))
+		switch {
+		case errors.Is(err, exec.ErrNotFound):
+			a.Error = "nft binary not found"
+		case strings.Contains(strings.ToLower(msg), "operation not permitted"):
+			a.Error = "nft: operation not permitted (the container needs the NET_ADMIN capability)"
+		case msg != "":
+			a.Error = fmt.Sprintf("nft: %v - %s", err, msg)
+		default:
+			a.Error = fmt.Sprintf("nft: %v", err)
+		}
+	}
+
+	s.availMu.Lock()
+	s.availability = a
+	s.availMu.Unlock()
+	return a
+}
+
+// Availability returns the last probe result
+func (s *Service) Availability() Availability {
+	s.availMu.RLock()
+	defer s.availMu.RUnlock()
+	return s.availability
+}
+
+// ensureAvailable re-probes after a failed probe, so fixing the host (e.g.
+// installing nft) is picked up by the next apply, and errors while nftables
+// is still unusable
+func (s *Service) ensureAvailable() error {
+	a := s.Availability()
+	if a.Available {
+		return nil
+	}
+	if a = s.probe(); a.Available {
+		log.Printf("nftables: available again, rules will be applied")
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnavailable, a.Error)
+}
@@ -0,0 +1,129 @@
+package nftables
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"api/internal/database"
+)
+
+// Settings keys for operator-supplied custom rules
+const (
+	CustomRulesSetting = "nft_custom_rules"
+	CustomHookSetting  = "nft_custom_hook"
+)
+
+// customChainPriority runs the custom chain before the firewall's base chains
+// (priority 0), so custom drops/rate-limits apply first. An accept here does not
+// bypass the firewall table: nftables evaluates every base chain on a hook.
+const customChainPriority = -10
+
+// CustomRules is an operator-supplied rule snippet for the custom chain
+type CustomRules struct {
+	Hook  string `json:"hook"`  // input, forward, output
+	Rules string `json:"rules"` // one nftables rule per line
+}
+
+// CustomRulesTable holds the operator's custom chain in its own table
+type CustomRulesTable struct {
+	db *database.DB
+}
+
+func NewCustomRulesTable(db *database.DB) *CustomRulesTable {
+	return &CustomRulesTable{db: db}
+}
+
+func (t *CustomRulesTable) Name() string   { return "wgadmin_custom" }
+func (t *CustomRulesTable) Family() string { return "inet" }
+func (t *CustomRulesTable) Priority() int  { return 30 }
+
+// Build generates the nftables script from the stored snippet
+func (t *CustomRulesTable) Build() (string, error) {
+	return BuildCustomRulesScript(LoadCustomRules(t.db))
+}
+
+// LoadCustomRules reads the stored snippet (empty rules if unset)
+func LoadCustomRules(db *database.DB) CustomRules {
+	rules := CustomRules{Hook: "input"}
+	var value string
+	if err := db.QueryRow("SELECT value FROM settings WHERE key = ?", CustomRulesSetting).Scan(&value); err == nil {
+		rules.Rules = value
+	}
+	if err := db.QueryRow("SELECT value FROM settings WHERE key = ?", CustomHookSetting).Scan(&value); err == nil && value != "" {
+		rules.Hook = value
+	}
+	return rules
+}
+
+// BuildCustomRulesScript validates the snippet's structure and wraps it in a
+// dedicated "custom" chain. Syntax is checked separately with CheckScript.
+func BuildCustomRulesScript(c CustomRules) (string, error) {
+	switch c.Hook {
+	case "input", "forward", "output":
+	default:
+		return "", fmt.Errorf("hook must be input, forward or output")
+	}
+
+	var rules []string
+	depth := 0
+	for i, line := range strings.Split(c.Rules, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Rules may use anonymous sets ({ 80, 443 }) but must not close the chain
+		for _, ch := range line {
+			switch ch {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			if depth < 0 {
+				return "", fmt.Errorf("line %d: unbalanced '}'", i+1)
+			}
+		}
+
+		first := strings.ToLower(strings.Fields(line)[0])
+		switch first {
+		case "include", "table", "chain", "flush", "delete", "add", "insert", "replace", "define":
+			return "", fmt.Errorf("line %d: %q statements are not allowed, only chain rules", i+1, first)
+		}
+		rules = append(rules, line)
+	}
+	if depth != 0 {
+		return "", fmt.Errorf("unbalanced '{' in rules")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(TableHeader("inet", "wgadmin_custom"))
+	if len(rules) > 0 {
+		sb.WriteString(BuildChain("custom", "filter", c.Hook, customChainPriority, "accept", rules))
+	}
+	sb.WriteString(TableFooter())
+	return sb.String(), nil
+}
+
+// CheckScript validates a script with "nft -c" without applying it
+func (s *Service) CheckScript(script string) error {
+	tmpFile, err := os.CreateTemp("", "nftables-check-*.nft")
+	if err != nil {
+		return fmt.Errorf("create temp: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmpFile.WriteString(script); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+	tmpFile.Close()
+
+	out, err := s.Exec("-c", "-f", tmpPath)
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(strings.ReplaceAll(string(out), tmpPath, "rules")))
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package nftables
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DriftStatus compares the live ruleset with what the panel last applied
+type DriftStatus struct {
+	Drifted   bool         `json:"drifted"`
+	CheckedAt time.Time    `json:"checkedAt"`
+	Skipped   string       `json:"skipped,omitempty"` // reason the check didn't run
+	Tables    []TableDrift `json:"tables"`
+}
+
+// TableDrift is the drift state of one managed table
+type TableDrift struct {
+	Name      string    `json:"name"`
+	Family    string    `json:"family"`
+	Drifted   bool      `json:"drifted"`
+	Reason    string    `json:"reason,omitempty"` // "missing", "changed" or "not applied yet"
+	AppliedAt time.Time `json:"appliedAt,omitempty"`
+}
+
+// tableBaseline is the fingerprint of a table right after the panel applied it
+type tableBaseline struct {
+	hash      string
+	appliedAt time.Time
+}
+
+// tableFingerprint hashes a table's chains, rules and set definitions.
+// Set elements are left out (-t) since jails and blocks update them
+// incrementally, and counters (-s) since they change with every packet.
+func (s *Service) tableFingerprint(family, name string) (string, error) {
+	out, err := s.Exec("-s", "-t", "list", "table", family, name)
+	if err != nil {
+		return "", fmt.Errorf("%v - %s", err, string(out))
+	}
+	sum := sha256.Sum256(out)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordBaseline stores the fingerprint of a freshly applied table
+func (s *Service) recordBaseline(t Table) {
+	hash, err := s.tableFingerprint(t.Family(), t.Name())
+	if err != nil {
+		return
+	}
+	s.driftMu.Lock()
+	if s.baselines == nil {
+		s.baselines = make(map[string]tableBaseline)
+	}
+	s.baselines[t.Name()] = tableBaseline{hash: hash, appliedAt: time.Now()}
+	s.driftMu.Unlock()
+}
+
+// CheckDrift compares every registered table with its last applied fingerprint.
+// The check is skipped while an apply is queued, since the ruleset is about to change.
+func (s *Service) CheckDrift() DriftStatus {
+	status := DriftStatus{CheckedAt: time.Now(), Tables: []TableDrift{}}
+
+	s.applyMutex.Lock()
+	pending := s.applyPending
+	tables := make([]Table, 0, len(s.tables))
+	for _, t := range s.tables {
+		tables = append(tables, t)
+	}
+	s.applyMutex.Unlock()
+
+	if pending {
+		status.Skipped = "apply pending"
+		return status
+	}
+
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Priority() < tables[j].Priority()
+	})
+
+	s.driftMu.Lock()
+	defer s.driftMu.Unlock()
+
+	for _, t := range tables {
+		td := TableDrift{Name: t.Name(), Family: t.Family()}
+		baseline, ok := s.baselines[t.Name()]
+		if !ok {
+			td.Reason = "not applied yet"
+			status.Tables = append(status.Tables, td)
+			continue
+		}
+		td.AppliedAt = baseline.appliedAt
+
+		hash, err := s.tableFingerprint(t.Family(), t.Name())
+		switch {
+		case err != nil:
+			td.Drifted = true
+			td.Reason = "missing"
+		case hash != baseline.hash:
+			td.Drifted = true
+			td.Reason = "changed"
+		}
+		if td.Drifted {
+			status.Drifted = true
+		}
+		status.Tables = append(status.Tables, td)
+	}
+
+	s.lastDrift = &status
+	return status
+}
+
+// Reconcile rebuilds every registered table from the DB and applies it,
+// reporting the tables whose live fingerprint differed from the rebuilt one.
+// Like drift checks, set elements aren't compared; the apply restores them anyway.
+func (s *Service) Reconcile() (DriftStatus, error) {
+	status := DriftStatus{CheckedAt: time.Now(), Tables: []TableDrift{}}
+	if err := s.ensureAvailable(); err != nil {
+		return status, err
+	}
+
+	s.applyMutex.Lock()
+	tables := make([]Table, 0, len(s.tables))
+	for _, t := range s.tables {
+		tables = append(tables, t)
+	}
+	s.applyMutex.Unlock()
+
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Priority() < tables[j].Priority()
+	})
+
+	for _, t := range tables {
+		td := TableDrift{Name: t.Name(), Family: t.Family()}
+		before, beforeErr := s.tableFingerprint(t.Family(), t.Name())
+		if err := s.applyTable(t); err != nil {
+			return status, fmt.Errorf("table %s: %w", t.Name(), err)
+		}
+
+		s.driftMu.Lock()
+		baseline := s.baselines[t.Name()]
+		s.driftMu.Unlock()
+		td.AppliedAt = baseline.appliedAt
+
+		switch {
+		case beforeErr != nil:
+			td.Drifted = true
+			td.Reason = "missing"
+		case before != baseline.hash:
+			td.Drifted = true
+			td.Reason = "changed"
+		}
+		if td.Drifted {
+			status.Drifted = true
+		}
+		status.Tables = append(status.Tables, td)
+	}
+
+	// Every table now matches its fresh baseline
+	inSync := DriftStatus{CheckedAt: time.Now(), Tables: make([]TableDrift, len(status.Tables))}
+	for i, td := range status.Tables {
+		inSync.Tables[i] = TableDrift{Name: td.Name, Family: td.Family, AppliedAt: td.AppliedAt}
+	}
+	s.driftMu.Lock()
+	s.lastDrift = &inSync
+	s.driftMu.Unlock()
+	return status, nil
+}
+
+// LastDrift returns the result of the most recent drift check (nil before the first)
+func (s *Service) LastDrift() *DriftStatus {
+	s.driftMu.Lock()
+	defer s.driftMu.Unlock()
+	return s.lastDrift
+}
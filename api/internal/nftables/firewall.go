@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"api/internal/database"
+	"api/internal/helper"
 )
 
 // detectWANInterface returns the interface name of the default IPv4 route.
@@ -46,6 +47,34 @@ func loadNoInternetPeerIPs(db *database.DB) []string {
 	return ips
 }
 
+// loadCountryExceptions returns the exception ranges of blocked countries for
+// the inbound and outbound country sets. Like GetAllBlockedCIDRs, every enabled
+// country is blocked inbound and only direction "both" outbound. Overlapping
+// exceptions are rejected when added, so the interval sets stay valid.
+func loadCountryExceptions(db *database.DB) (in, out []string) {
+	query := `SELECT DISTINCT x.cidr FROM country_exceptions x
+		JOIN firewall_entries e ON e.entry_type = 'country' AND e.value = x.country_code
+		WHERE e.action = 'block' AND e.enabled = 1`
+	return scanStrings(db, query), scanStrings(db, query+` AND e.direction = 'both'`)
+}
+
+// scanStrings returns the first column of a query, nil on error
+func scanStrings(db *database.DB, query string) []string {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var v string
+		if rows.Scan(&v) == nil && v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 // FirewallTable builds the inet firewall table
 type FirewallTable struct {
 	db              *database.DB
@@ -74,61 +103,25 @@ func (t *FirewallTable) Build() (string, error) {
 		return "", err
 	}
 
-	// Categorize entries by direction
-	var blockedIPsIn, blockedIPsOut []string
-	var blockedRangesIn, blockedRangesOut []string
-	var allowedTCPPorts, allowedUDPPorts []string
-
+	sets := &firewallSets{}
 	for _, e := range entries {
 		if !e.Enabled {
 			continue
 		}
-
-		switch e.EntryType {
-		case EntryTypeIP:
-			if e.Action == ActionBlock {
-				if e.Direction == DirectionInbound || e.Direction == DirectionBoth {
-					blockedIPsIn = append(blockedIPsIn, e.Value)
-				}
-				if e.Direction == DirectionOutbound || e.Direction == DirectionBoth {
-					blockedIPsOut = append(blockedIPsOut, e.Value)
-				}
-			}
-		case EntryTypeRange:
-			if e.Action == ActionBlock {
-				if e.Direction == DirectionInbound || e.Direction == DirectionBoth {
-					blockedRangesIn = append(blockedRangesIn, e.Value)
-				}
-				if e.Direction == DirectionOutbound || e.Direction == DirectionBoth {
-					blockedRangesOut = append(blockedRangesOut, e.Value)
-				}
-			}
-		case EntryTypePort:
-			if e.Action == ActionAllow {
-				switch e.Protocol {
-				case ProtocolTCP:
-					allowedTCPPorts = append(allowedTCPPorts, e.Value)
-				case ProtocolUDP:
-					allowedUDPPorts = append(allowedUDPPorts, e.Value)
-				case ProtocolBoth:
-					allowedTCPPorts = append(allowedTCPPorts, e.Value)
-					allowedUDPPorts = append(allowedUDPPorts, e.Value)
-				}
-			}
-		case EntryTypeCountry:
-			// Countries handled separately via countryProvider
-		}
+		sets.add(e)
 	}
 
-	// Get country ranges from geolocation provider
-	var countryRangesIn, countryRangesOut []string
-	if t.countryProvider != nil {
+	// Get country ranges from geolocation provider. With blocking disabled the
+	// country sets and rules are left out entirely (entries and zones stay in the DB).
+	if t.countryProvider != nil && t.countryProvider.IsBlockingEnabled() {
+		sets.countryBlocking = true
 		if cidrs, err := t.countryProvider.GetAllBlockedCIDRs(false); err == nil {
-			countryRangesIn = cidrs
+			sets.blockedCountriesIn = cidrs
 		}
 		if cidrs, err := t.countryProvider.GetAllBlockedCIDRs(true); err == nil {
-			countryRangesOut = cidrs
+			sets.blockedCountriesOut = cidrs
 		}
+		sets.countryExceptionsIn, sets.countryExceptionsOut = loadCountryExceptions(t.db)
 	}
 
 	// Per-peer WAN block: list of VPN peer IPs whose internet egress should be dropped.
@@ -141,13 +134,134 @@ func (t *FirewallTable) Build() (string, error) {
 		}
 	}
 
-	return t.buildScript(
-		blockedIPsIn, blockedIPsOut,
-		blockedRangesIn, blockedRangesOut,
-		allowedTCPPorts, allowedUDPPorts,
-		countryRangesIn, countryRangesOut,
-		noInternetPeers, wanIface,
-	), nil
+	return t.buildScript(sets, noInternetPeers, wanIface), nil
+}
+
+// firewallSets holds set elements split by type, action, direction and protocol.
+// Inbound matches the source address (input/forward), outbound the destination
+// address (output/forward). Port entries match the destination port.
+type firewallSets struct {
+	blockedIPsIn, blockedIPsOut             []string
+	blockedRangesIn, blockedRangesOut       []string
+	blockedCountriesIn, blockedCountriesOut []string
+	countryExceptionsIn                     []string // ranges exempt from country drops
+	countryExceptionsOut                    []string
+	allowedIPsIn, allowedIPsOut             []string
+	allowedRangesIn, allowedRangesOut       []string
+	blockedIPs6In, blockedIPs6Out           []string // IPv6 counterparts of the sets above
+	blockedRanges6In, blockedRanges6Out     []string
+	allowedIPs6In, allowedIPs6Out           []string
+	allowedRanges6In, allowedRanges6Out     []string
+	allowedTCPPorts, allowedUDPPorts        []string
+	blockedTCPPortsIn, blockedUDPPortsIn    []string
+	blockedTCPPortsOut, blockedUDPPortsOut  []string
+	countryBlocking                         bool // emit country sets and rules
+}
+
+// isIPv6Value reports whether an ip/range value belongs in the ipv6_addr sets
+func isIPv6Value(value string) bool {
+	return strings.Contains(value, ":")
+}
+
+// add places an entry into every set its direction/protocol combination covers.
+// Empty direction/protocol fall back to the API defaults (inbound, both).
+// IP and range values go to the sets of their address family.
+func (fs *firewallSets) add(e FirewallEntry) {
+	direction := e.Direction
+	if direction == "" {
+		direction = DirectionInbound
+	}
+	protocol := e.Protocol
+	if protocol == "" {
+		protocol = ProtocolBoth
+	}
+
+	var in, out bool
+	switch direction {
+	case DirectionInbound:
+		in = true
+	case DirectionOutbound:
+		out = true
+	case DirectionBoth:
+		in, out = true, true
+	default:
+		log.Printf("nftables/firewall: entry %d has unknown direction %q, skipped", e.ID, e.Direction)
+		return
+	}
+
+	var tcp, udp bool
+	switch protocol {
+	case ProtocolTCP:
+		tcp = true
+	case ProtocolUDP:
+		udp = true
+	case ProtocolBoth:
+		tcp, udp = true, true
+	default:
+		log.Printf("nftables/firewall: entry %d has unknown protocol %q, skipped", e.ID, e.Protocol)
+		return
+	}
+
+	appendIf := func(dst *[]string, ok bool) {
+		if ok {
+			*dst = append(*dst, e.Value)
+		}
+	}
+
+	switch e.EntryType {
+	case EntryTypeIP:
+		if isIPv6Value(e.Value) {
+			if e.Action == ActionBlock {
+				appendIf(&fs.blockedIPs6In, in)
+				appendIf(&fs.blockedIPs6Out, out)
+			} else if e.Action == ActionAllow {
+				appendIf(&fs.allowedIPs6In, in)
+				appendIf(&fs.allowedIPs6Out, out)
+			}
+		} else if e.Action == ActionBlock {
+			appendIf(&fs.blockedIPsIn, in)
+			appendIf(&fs.blockedIPsOut, out)
+		} else if e.Action == ActionAllow {
+			appendIf(&fs.allowedIPsIn, in)
+			appendIf(&fs.allowedIPsOut, out)
+		}
+	case EntryTypeRange:
+		if isIPv6Value(e.Value) {
+			if e.Action == ActionBlock {
+				appendIf(&fs.blockedRanges6In, in)
+				appendIf(&fs.blockedRanges6Out, out)
+			} else if e.Action == ActionAllow {
+				appendIf(&fs.allowedRanges6In, in)
+				appendIf(&fs.allowedRanges6Out, out)
+			}
+		} else if e.Action == ActionBlock {
+			appendIf(&fs.blockedRangesIn, in)
+			appendIf(&fs.blockedRangesOut, out)
+		} else if e.Action == ActionAllow {
+			appendIf(&fs.allowedRangesIn, in)
+			appendIf(&fs.allowedRangesOut, out)
+		}
+	case EntryTypePort:
+		start, end, err := helper.ParsePortRange(e.Value)
+		if err != nil {
+			log.Printf("nftables/firewall: entry %d has invalid port %q, skipped", e.ID, e.Value)
+			return
+		}
+		e.Value = helper.FormatPortRange(start, end)
+		if e.Action == ActionAllow {
+			// Output and forward default to accept, so an allow only has an
+			// effect on inbound traffic to the server
+			appendIf(&fs.allowedTCPPorts, in && tcp)
+			appendIf(&fs.allowedUDPPorts, in && udp)
+		} else if e.Action == ActionBlock {
+			appendIf(&fs.blockedTCPPortsIn, in && tcp)
+			appendIf(&fs.blockedUDPPortsIn, in && udp)
+			appendIf(&fs.blockedTCPPortsOut, out && tcp)
+			appendIf(&fs.blockedUDPPortsOut, out && udp)
+		}
+	case EntryTypeCountry:
+		// Countries handled separately via countryProvider
+	}
 }
 
 func (t *FirewallTable) loadEntries() ([]FirewallEntry, error) {
@@ -185,9 +299,10 @@ func (t *FirewallTable) loadEntries() ([]FirewallEntry, error) {
 }
 
 // cleanOverlappingRanges removes CIDR ranges fully contained in larger ranges
+// with the same action, direction and address family
 func (t *FirewallTable) cleanOverlappingRanges() int {
 	rows, err := t.db.Query(`
-		SELECT id, value FROM firewall_entries
+		SELECT id, value, action, direction FROM firewall_entries
 		WHERE entry_type = 'range' AND enabled = 1
 		AND (expires_at IS NULL OR expires_at > datetime('now'))
 	`)
@@ -203,11 +318,17 @@ func (t *FirewallTable) cleanOverlappingRanges() int {
 		end   uint32
 	}
 
-	var ranges []rangeInfo
+	type range6Info struct {
+		id      int64
+		network *net.IPNet
+	}
+
+	groups := make(map[string][]rangeInfo)
+	groups6 := make(map[string][]range6Info)
 	for rows.Next() {
 		var id int64
-		var cidr string
-		if err := rows.Scan(&id, &cidr); err != nil {
+		var cidr, action, direction string
+		if err := rows.Scan(&id, &cidr, &action, &direction); err != nil {
 			continue
 		}
 		_, network, err := net.ParseCIDR(cidr)
@@ -216,35 +337,56 @@ func (t *FirewallTable) cleanOverlappingRanges() int {
 		}
 		ip4 := network.IP.To4()
 		if ip4 == nil {
+			key := action + "/" + direction
+			groups6[key] = append(groups6[key], range6Info{id: id, network: network})
 			continue
 		}
 		start := uint32(ip4[0])<<24 | uint32(ip4[1])<<16 | uint32(ip4[2])<<8 | uint32(ip4[3])
 		ones, _ := network.Mask.Size()
 		size := uint32(1) << (32 - ones)
-		ranges = append(ranges, rangeInfo{id: id, cidr: cidr, start: start, end: start + size - 1})
+		key := action + "/" + direction
+		groups[key] = append(groups[key], rangeInfo{id: id, cidr: cidr, start: start, end: start + size - 1})
 	}
 
-	if len(ranges) < 2 {
-		return 0
-	}
-
-	// Sort by start, then by size (larger first)
-	sort.Slice(ranges, func(i, j int) bool {
-		if ranges[i].start == ranges[j].start {
-			return ranges[i].end > ranges[j].end
+	var toDelete []int64
+	for _, ranges := range groups {
+		if len(ranges) < 2 {
+			continue
 		}
-		return ranges[i].start < ranges[j].start
-	})
 
-	// Find fully contained ranges
-	var toDelete []int64
-	var currentEnd uint32
+		// Sort by start, then by size (larger first)
+		sort.Slice(ranges, func(i, j int) bool {
+			if ranges[i].start == ranges[j].start {
+				return ranges[i].end > ranges[j].end
+			}
+			return ranges[i].start < ranges[j].start
+		})
+
+		// Find fully contained ranges
+		var currentEnd uint32
+		for _, r := range ranges {
+			if currentEnd > 0 && r.start <= currentEnd && r.end <= currentEnd {
+				toDelete = append(toDelete, r.id)
+			} else if r.end > currentEnd {
+				currentEnd = r.end
+			}
+		}
+	}
 
-	for _, r := range ranges {
-		if currentEnd > 0 && r.start <= currentEnd && r.end <= currentEnd {
-			toDelete = append(toDelete, r.id)
-		} else if r.end > currentEnd {
-			currentEnd = r.end
+	// IPv6 ranges are few, a pairwise check against the larger ones is enough
+	for _, ranges := range groups6 {
+		sort.Slice(ranges, func(i, j int) bool {
+			onesI, _ := ranges[i].network.Mask.Size()
+			onesJ, _ := ranges[j].network.Mask.Size()
+			return onesI < onesJ
+		})
+		for i, r := range ranges {
+			for _, outer := range ranges[:i] {
+				if outer.network.Contains(r.network.IP) {
+					toDelete = append(toDelete, r.id)
+					break
+				}
+			}
 		}
 	}
 
@@ -270,34 +412,124 @@ func (t *FirewallTable) cleanOverlappingRanges() int {
 	return int(deleted)
 }
 
-func (t *FirewallTable) buildScript(blockedIPsIn, blockedIPsOut, blockedRangesIn, blockedRangesOut, tcpPorts, udpPorts, countryIn, countryOut, noInternetPeers []string, wanIface string) string {
+// mergePortIntervals sorts ports/port ranges and merges overlapping ones, since an
+// interval set rejects overlapping elements
+func mergePortIntervals(values []string) []string {
+	type interval struct{ start, end int }
+	intervals := make([]interval, 0, len(values))
+	for _, v := range values {
+		start, end, err := helper.ParsePortRange(v)
+		if err != nil {
+			continue
+		}
+		intervals = append(intervals, interval{start, end})
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+
+	var merged []interval
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 && iv.start <= merged[n-1].end {
+			if iv.end > merged[n-1].end {
+				merged[n-1].end = iv.end
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+
+	result := make([]string, len(merged))
+	for i, iv := range merged {
+		result[i] = helper.FormatPortRange(iv.start, iv.end)
+	}
+	return result
+}
+
+// countryRule returns rule, or a comment in its place when country blocking is off
+// (the sets it references aren't defined then)
+func countryRule(fs *firewallSets, rule string) string {
+	if !fs.countryBlocking {
+		return "# Country blocking disabled"
+	}
+	return rule
+}
+
+func (t *FirewallTable) buildScript(fs *firewallSets, noInternetPeers []string, wanIface string) string {
 	var sb strings.Builder
 
 	sb.WriteString(TableHeader("inet", "wgadmin_firewall"))
 
 	// Sets - inbound
-	sb.WriteString(BuildSet("blocked_ips", "ipv4_addr", nil, blockedIPsIn))
+	sb.WriteString(BuildSet("blocked_ips", "ipv4_addr", nil, fs.blockedIPsIn))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("blocked_ranges", "ipv4_addr", []string{"interval"}, fs.blockedRangesIn))
 	sb.WriteString("\n")
-	sb.WriteString(BuildSet("blocked_ranges", "ipv4_addr", []string{"interval"}, blockedRangesIn))
+	if fs.countryBlocking {
+		sb.WriteString(BuildSet("blocked_countries", "ipv4_addr", []string{"interval"}, fs.blockedCountriesIn))
+		sb.WriteString("\n")
+		sb.WriteString(BuildSet("country_exceptions", "ipv4_addr", []string{"interval"}, fs.countryExceptionsIn))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(BuildSet("allowed_ips", "ipv4_addr", nil, fs.allowedIPsIn))
 	sb.WriteString("\n")
-	sb.WriteString(BuildSet("blocked_countries", "ipv4_addr", []string{"interval"}, countryIn))
+	sb.WriteString(BuildSet("allowed_ranges", "ipv4_addr", []string{"interval"}, fs.allowedRangesIn))
 	sb.WriteString("\n")
 	// Sets - outbound
-	sb.WriteString(BuildSet("blocked_ips_out", "ipv4_addr", nil, blockedIPsOut))
+	sb.WriteString(BuildSet("blocked_ips_out", "ipv4_addr", nil, fs.blockedIPsOut))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("blocked_ranges_out", "ipv4_addr", []string{"interval"}, fs.blockedRangesOut))
+	sb.WriteString("\n")
+	if fs.countryBlocking {
+		sb.WriteString(BuildSet("blocked_countries_out", "ipv4_addr", []string{"interval"}, fs.blockedCountriesOut))
+		sb.WriteString("\n")
+		sb.WriteString(BuildSet("country_exceptions_out", "ipv4_addr", []string{"interval"}, fs.countryExceptionsOut))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(BuildSet("allowed_ips_out", "ipv4_addr", nil, fs.allowedIPsOut))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("allowed_ranges_out", "ipv4_addr", []string{"interval"}, fs.allowedRangesOut))
+	sb.WriteString("\n")
+	// Sets - IPv6 (countries are IPv4 only)
+	sb.WriteString(BuildSet("blocked_ips6", "ipv6_addr", nil, fs.blockedIPs6In))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("blocked_ranges6", "ipv6_addr", []string{"interval"}, fs.blockedRanges6In))
 	sb.WriteString("\n")
-	sb.WriteString(BuildSet("blocked_ranges_out", "ipv4_addr", []string{"interval"}, blockedRangesOut))
+	sb.WriteString(BuildSet("allowed_ips6", "ipv6_addr", nil, fs.allowedIPs6In))
 	sb.WriteString("\n")
-	sb.WriteString(BuildSet("blocked_countries_out", "ipv4_addr", []string{"interval"}, countryOut))
+	sb.WriteString(BuildSet("allowed_ranges6", "ipv6_addr", []string{"interval"}, fs.allowedRanges6In))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("blocked_ips6_out", "ipv6_addr", nil, fs.blockedIPs6Out))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("blocked_ranges6_out", "ipv6_addr", []string{"interval"}, fs.blockedRanges6Out))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("allowed_ips6_out", "ipv6_addr", nil, fs.allowedIPs6Out))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("allowed_ranges6_out", "ipv6_addr", []string{"interval"}, fs.allowedRanges6Out))
 	sb.WriteString("\n")
 	// Sets - ports
-	sb.WriteString(BuildSet("allowed_tcp_ports", "inet_service", nil, tcpPorts))
+	sb.WriteString(BuildSet("allowed_tcp_ports", "inet_service", []string{"interval"}, mergePortIntervals(fs.allowedTCPPorts)))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("allowed_udp_ports", "inet_service", []string{"interval"}, mergePortIntervals(fs.allowedUDPPorts)))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("blocked_tcp_ports", "inet_service", []string{"interval"}, mergePortIntervals(fs.blockedTCPPortsIn)))
 	sb.WriteString("\n")
-	sb.WriteString(BuildSet("allowed_udp_ports", "inet_service", nil, udpPorts))
+	sb.WriteString(BuildSet("blocked_udp_ports", "inet_service", []string{"interval"}, mergePortIntervals(fs.blockedUDPPortsIn)))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("blocked_tcp_ports_out", "inet_service", []string{"interval"}, mergePortIntervals(fs.blockedTCPPortsOut)))
+	sb.WriteString("\n")
+	sb.WriteString(BuildSet("blocked_udp_ports_out", "inet_service", []string{"interval"}, mergePortIntervals(fs.blockedUDPPortsOut)))
 	sb.WriteString("\n")
 	// Set - per-peer WAN block (drop only when traffic egresses the WAN iface)
 	sb.WriteString(BuildSet("no_internet_peers", "ipv4_addr", nil, noInternetPeers))
 	sb.WriteString("\n")
 
+	// Allow entries take precedence over block entries: every address drop
+	// excludes the allowed sets for the same direction. They don't open ports,
+	// inbound traffic from an allowed address still needs an allowed port.
+	notAllowedIn := " ip saddr != @allowed_ips ip saddr != @allowed_ranges"
+	notAllowedOut := " ip daddr != @allowed_ips_out ip daddr != @allowed_ranges_out"
+	notAllowed6In := " ip6 saddr != @allowed_ips6 ip6 saddr != @allowed_ranges6"
+	notAllowed6Out := " ip6 daddr != @allowed_ips6_out ip6 daddr != @allowed_ranges6_out"
+
 	// Input chain - traffic destined TO the server (check source address)
 	sb.WriteString(BuildChain("input", "filter", "input", 0, "drop", []string{
 		"# Allow established connections",
@@ -311,9 +543,15 @@ func (t *FirewallTable) buildScript(blockedIPsIn, blockedIPsOut, blockedRangesIn
 		"ip6 nexthdr icmpv6 accept",
 		"",
 		"# Drop traffic FROM blocked sources (saddr)",
-		"ip saddr @blocked_ips drop",
-		"ip saddr @blocked_ranges drop",
-		"ip saddr @blocked_countries drop",
+		"ip saddr @blocked_ips" + notAllowedIn + " drop",
+		"ip saddr @blocked_ranges" + notAllowedIn + " drop",
+		countryRule(fs, "ip saddr @blocked_countries"+notAllowedIn+" ip saddr != @country_exceptions drop"),
+		"ip6 saddr @blocked_ips6" + notAllowed6In + " drop",
+		"ip6 saddr @blocked_ranges6" + notAllowed6In + " drop",
+		"",
+		"# Drop blocked ports",
+		"tcp dport @blocked_tcp_ports drop",
+		"udp dport @blocked_udp_ports drop",
 		"",
 		"# Allow specific ports",
 		"tcp dport @allowed_tcp_ports accept",
@@ -336,14 +574,22 @@ func (t *FirewallTable) buildScript(blockedIPsIn, blockedIPsOut, blockedRangesIn
 		"ct state established,related accept",
 		"",
 		"# Drop traffic FROM blocked sources (saddr)",
-		"ip saddr @blocked_ips drop",
-		"ip saddr @blocked_ranges drop",
-		"ip saddr @blocked_countries drop",
+		"ip saddr @blocked_ips" + notAllowedIn + " drop",
+		"ip saddr @blocked_ranges" + notAllowedIn + " drop",
+		countryRule(fs, "ip saddr @blocked_countries"+notAllowedIn+" ip saddr != @country_exceptions drop"),
+		"ip6 saddr @blocked_ips6" + notAllowed6In + " drop",
+		"ip6 saddr @blocked_ranges6" + notAllowed6In + " drop",
 		"",
 		"# Drop traffic TO blocked destinations (daddr)",
-		"ip daddr @blocked_ips_out drop",
-		"ip daddr @blocked_ranges_out drop",
-		"ip daddr @blocked_countries_out drop",
+		"ip daddr @blocked_ips_out" + notAllowedOut + " drop",
+		"ip daddr @blocked_ranges_out" + notAllowedOut + " drop",
+		countryRule(fs, "ip daddr @blocked_countries_out"+notAllowedOut+" ip daddr != @country_exceptions_out drop"),
+		"ip6 daddr @blocked_ips6_out" + notAllowed6Out + " drop",
+		"ip6 daddr @blocked_ranges6_out" + notAllowed6Out + " drop",
+		"",
+		"# Drop traffic TO blocked ports",
+		"tcp dport @blocked_tcp_ports_out drop",
+		"udp dport @blocked_udp_ports_out drop",
 	}
 	// Per-peer WAN egress block. Skip silently if WAN couldn't be detected — emitting
 	// the rule without oifname would block *all* peer traffic, including peer↔peer.
@@ -371,9 +617,15 @@ func (t *FirewallTable) buildScript(blockedIPsIn, blockedIPsOut, blockedRangesIn
 		"ct state established,related accept",
 		"",
 		"# Drop traffic TO blocked destinations (daddr)",
-		"ip daddr @blocked_ips_out drop",
-		"ip daddr @blocked_ranges_out drop",
-		"ip daddr @blocked_countries_out drop",
+		"ip daddr @blocked_ips_out" + notAllowedOut + " drop",
+		"ip daddr @blocked_ranges_out" + notAllowedOut + " drop",
+		countryRule(fs, "ip daddr @blocked_countries_out"+notAllowedOut+" ip daddr != @country_exceptions_out drop"),
+		"ip6 daddr @blocked_ips6_out" + notAllowed6Out + " drop",
+		"ip6 daddr @blocked_ranges6_out" + notAllowed6Out + " drop",
+		"",
+		"# Drop traffic TO blocked ports",
+		"tcp dport @blocked_tcp_ports_out drop",
+		"udp dport @blocked_udp_ports_out drop",
 	}))
 
 	sb.WriteString(TableFooter())
@@ -0,0 +1,307 @@
+package nftables
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// parseSets maps each set in a generated script to its elements
+func parseSets(script string) map[string][]string {
+	sets := make(map[string][]string)
+	current := ""
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "set ") && strings.HasSuffix(line, "{"):
+			current = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "set "), "{"))
+			sets[current] = nil
+		case current != "" && strings.HasPrefix(line, "elements = {"):
+			body := strings.TrimSuffix(strings.TrimPrefix(line, "elements = {"), "}")
+			for _, el := range strings.Split(body, ",") {
+				if el = strings.TrimSpace(el); el != "" {
+					sets[current] = append(sets[current], el)
+				}
+			}
+		case line == "}":
+			current = ""
+		}
+	}
+	return sets
+}
+
+// chainRules returns the rule lines of a chain in a generated script
+func chainRules(script, chain string) []string {
+	var rules []string
+	in := false
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "chain "+chain+" {":
+			in = true
+		case in && line == "}":
+			return rules
+		case in && line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "type "):
+			rules = append(rules, line)
+		}
+	}
+	return rules
+}
+
+// setsContaining lists the sets holding value, sorted
+func setsContaining(sets map[string][]string, value string) []string {
+	var names []string
+	for name, elements := range sets {
+		for _, el := range elements {
+			if el == value {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFirewallSetsMatrix(t *testing.T) {
+	directions := []string{DirectionInbound, DirectionOutbound, DirectionBoth}
+	protocols := []string{ProtocolTCP, ProtocolUDP, ProtocolBoth}
+
+	// Address entries ignore the protocol: inbound goes to the base set,
+	// outbound to its _out counterpart
+	addressSets := []struct {
+		entryType, value, action, inSet, outSet string
+	}{
+		{EntryTypeIP, "203.0.113.5", ActionBlock, "blocked_ips", "blocked_ips_out"},
+		{EntryTypeIP, "203.0.113.5", ActionAllow, "allowed_ips", "allowed_ips_out"},
+		{EntryTypeRange, "198.51.100.0/24", ActionBlock, "blocked_ranges", "blocked_ranges_out"},
+		{EntryTypeRange, "198.51.100.0/24", ActionAllow, "allowed_ranges", "allowed_ranges_out"},
+		{EntryTypeIP, "2001:db8::5", ActionBlock, "blocked_ips6", "blocked_ips6_out"},
+		{EntryTypeIP, "2001:db8::5", ActionAllow, "allowed_ips6", "allowed_ips6_out"},
+		{EntryTypeRange, "2001:db8:1::/48", ActionBlock, "blocked_ranges6", "blocked_ranges6_out"},
+		{EntryTypeRange, "2001:db8:1::/48", ActionAllow, "allowed_ranges6", "allowed_ranges6_out"},
+	}
+	for _, as := range addressSets {
+		for _, dir := range directions {
+			for _, proto := range protocols {
+				var want []string
+				if dir != DirectionOutbound {
+					want = append(want, as.inSet)
+				}
+				if dir != DirectionInbound {
+					want = append(want, as.outSet)
+				}
+				sort.Strings(want)
+
+				fs := &firewallSets{}
+				fs.add(FirewallEntry{EntryType: as.entryType, Value: as.value, Action: as.action, Direction: dir, Protocol: proto})
+				got := setsContaining(parseSets((&FirewallTable{}).buildScript(fs, nil, "")), as.value)
+				if strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("%s %s %s/%s %s: sets = %v, want %v", as.action, as.entryType, dir, proto, as.value, got, want)
+				}
+			}
+		}
+	}
+
+	// Allowed ports only open inbound traffic; blocked ports split by direction
+	for _, action := range []string{ActionAllow, ActionBlock} {
+		for _, dir := range directions {
+			for _, proto := range protocols {
+				in := dir != DirectionOutbound
+				out := dir != DirectionInbound
+				tcp := proto != ProtocolUDP
+				udp := proto != ProtocolTCP
+
+				var want []string
+				if action == ActionAllow {
+					if in && tcp {
+						want = append(want, "allowed_tcp_ports")
+					}
+					if in && udp {
+						want = append(want, "allowed_udp_ports")
+					}
+				} else {
+					if in && tcp {
+						want = append(want, "blocked_tcp_ports")
+					}
+					if in && udp {
+						want = append(want, "blocked_udp_ports")
+					}
+					if out && tcp {
+						want = append(want, "blocked_tcp_ports_out")
+					}
+					if out && udp {
+						want = append(want, "blocked_udp_ports_out")
+					}
+				}
+				sort.Strings(want)
+
+				fs := &firewallSets{}
+				fs.add(FirewallEntry{EntryType: EntryTypePort, Value: "8000-8080", Action: action, Direction: dir, Protocol: proto})
+				got := setsContaining(parseSets((&FirewallTable{}).buildScript(fs, nil, "")), "8000-8080")
+				if strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("%s port %s/%s: sets = %v, want %v", action, dir, proto, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestFirewallSetsDefaultsAndInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry FirewallEntry
+		want  []string
+	}{
+		{"empty direction and protocol default to inbound both",
+			FirewallEntry{EntryType: EntryTypePort, Value: "53", Action: ActionAllow},
+			[]string{"allowed_tcp_ports", "allowed_udp_ports"}},
+		{"unknown direction is skipped",
+			FirewallEntry{EntryType: EntryTypePort, Value: "53", Action: ActionAllow, Direction: "sideways"},
+			nil},
+		{"unknown protocol is skipped",
+			FirewallEntry{EntryType: EntryTypePort, Value: "53", Action: ActionBlock, Protocol: "sctp"},
+			nil},
+		{"invalid port is skipped",
+			FirewallEntry{EntryType: EntryTypePort, Value: "70000", Action: ActionBlock},
+			nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &firewallSets{}
+			fs.add(tt.entry)
+			got := setsContaining(parseSets((&FirewallTable{}).buildScript(fs, nil, "")), tt.entry.Value)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("sets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirewallChainRules(t *testing.T) {
+	script := (&FirewallTable{}).buildScript(&firewallSets{}, nil, "")
+
+	// Every set must be referenced from the chains that see its traffic
+	tests := []struct {
+		chain string
+		rule  string
+	}{
+		{"input", "ip saddr @blocked_ips ip saddr != @allowed_ips ip saddr != @allowed_ranges drop"},
+		{"input", "ip saddr @blocked_ranges ip saddr != @allowed_ips ip saddr != @allowed_ranges drop"},
+		{"input", "ip6 saddr @blocked_ips6 ip6 saddr != @allowed_ips6 ip6 saddr != @allowed_ranges6 drop"},
+		{"input", "tcp dport @blocked_tcp_ports drop"},
+		{"input", "udp dport @blocked_udp_ports drop"},
+		{"input", "tcp dport @allowed_tcp_ports accept"},
+		{"input", "udp dport @allowed_udp_ports accept"},
+		{"forward", "ip saddr @blocked_ips ip saddr != @allowed_ips ip saddr != @allowed_ranges drop"},
+		{"forward", "ip daddr @blocked_ips_out ip daddr != @allowed_ips_out ip daddr != @allowed_ranges_out drop"},
+		{"forward", "ip6 daddr @blocked_ranges6_out ip6 daddr != @allowed_ips6_out ip6 daddr != @allowed_ranges6_out drop"},
+		{"forward", "tcp dport @blocked_tcp_ports_out drop"},
+		{"forward", "udp dport @blocked_udp_ports_out drop"},
+		{"output", "ip daddr @blocked_ips_out ip daddr != @allowed_ips_out ip daddr != @allowed_ranges_out drop"},
+		{"output", "ip daddr @blocked_ranges_out ip daddr != @allowed_ips_out ip daddr != @allowed_ranges_out drop"},
+		{"output", "tcp dport @blocked_tcp_ports_out drop"},
+		{"output", "udp dport @blocked_udp_ports_out drop"},
+	}
+	for _, tt := range tests {
+		found := false
+		for _, r := range chainRules(script, tt.chain) {
+			if r == tt.rule {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("chain %s missing rule %q", tt.chain, tt.rule)
+		}
+	}
+
+	// Allowed addresses only bypass address drops, they don't open ports
+	for _, r := range chainRules(script, "input") {
+		if strings.Contains(r, "@allowed_ips") && strings.HasSuffix(r, "accept") {
+			t.Errorf("input chain accepts allowed addresses on any port: %q", r)
+		}
+	}
+
+	// Inbound port sets must not leak into the output chain
+	for _, r := range chainRules(script, "output") {
+		if strings.Contains(r, "@blocked_tcp_ports ") || strings.Contains(r, "@allowed_tcp_ports") {
+			t.Errorf("output chain references inbound port set: %q", r)
+		}
+	}
+}
+
+func TestFirewallCountryRules(t *testing.T) {
+	off := (&FirewallTable{}).buildScript(&firewallSets{}, nil, "")
+	if _, ok := parseSets(off)["blocked_countries"]; ok {
+		t.Error("country sets emitted with country blocking disabled")
+	}
+
+	on := (&FirewallTable{}).buildScript(&firewallSets{countryBlocking: true, blockedCountriesIn: []string{"192.0.2.0/24"}}, nil, "")
+	if got := parseSets(on)["blocked_countries"]; len(got) != 1 || got[0] != "192.0.2.0/24" {
+		t.Errorf("blocked_countries = %v, want [192.0.2.0/24]", got)
+	}
+	want := "ip saddr @blocked_countries ip saddr != @allowed_ips ip saddr != @allowed_ranges ip saddr != @country_exceptions drop"
+	found := false
+	for _, r := range chainRules(on, "input") {
+		if r == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("input chain missing country rule %q", want)
+	}
+}
+
+func TestFirewallSetsIPv6(t *testing.T) {
+	fs := &firewallSets{}
+	for _, e := range []FirewallEntry{
+		{EntryType: EntryTypeIP, Value: "203.0.113.5", Action: ActionBlock, Direction: DirectionBoth},
+		{EntryType: EntryTypeIP, Value: "2001:db8::5", Action: ActionBlock, Direction: DirectionBoth},
+		{EntryType: EntryTypeRange, Value: "198.51.100.0/24", Action: ActionBlock, Direction: DirectionBoth},
+		{EntryType: EntryTypeRange, Value: "2001:db8:1::/48", Action: ActionBlock, Direction: DirectionBoth},
+		{EntryType: EntryTypeIP, Value: "::ffff:203.0.113.9", Action: ActionAllow},
+	} {
+		fs.add(e)
+	}
+	script := (&FirewallTable{}).buildScript(fs, nil, "")
+
+	// Each set is declared with the address type of its family
+	types := make(map[string]string)
+	current := ""
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "set ") && strings.HasSuffix(line, "{") {
+			current = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "set "), "{"))
+		} else if current != "" && strings.HasPrefix(line, "type ") {
+			types[current] = strings.TrimPrefix(line, "type ")
+			current = ""
+		}
+	}
+	for _, name := range FirewallSetNames {
+		if IsCountrySet(name) || strings.HasSuffix(name, "_ports") || strings.HasSuffix(name, "_ports_out") {
+			continue
+		}
+		want := "ipv4_addr"
+		if strings.Contains(name, "6") {
+			want = "ipv6_addr"
+		}
+		if types[name] != want {
+			t.Errorf("set %s type = %q, want %q", name, types[name], want)
+		}
+	}
+
+	// Values only land in sets of their own family
+	sets := parseSets(script)
+	for value, want := range map[string]string{
+		"203.0.113.5":        "blocked_ips,blocked_ips_out",
+		"2001:db8::5":        "blocked_ips6,blocked_ips6_out",
+		"198.51.100.0/24":    "blocked_ranges,blocked_ranges_out",
+		"2001:db8:1::/48":    "blocked_ranges6,blocked_ranges6_out",
+		"::ffff:203.0.113.9": "allowed_ips6",
+	} {
+		if got := strings.Join(setsContaining(sets, value), ","); got != want {
+			t.Errorf("%s: sets = %s, want %s", value, got, want)
+		}
+	}
+}
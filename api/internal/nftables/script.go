@@ -162,12 +162,12 @@ func SanitizeComment(s string) string {
 	return s
 }
 
-// ParseSetElementCount parses nftables output to count set elements
-func ParseSetElementCount(output, setName string) int {
+// ParseSetElements extracts the elements of a named set from "nft list set" output
+func ParseSetElements(output, setName string) []string {
 	lines := strings.Split(output, "\n")
 	inSet := false
 	inElements := false
-	count := 0
+	elements := []string{}
 
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
@@ -195,7 +195,7 @@ func ParseSetElementCount(output, setName string) int {
 				part = strings.TrimPrefix(part, "elements = {")
 				part = strings.TrimSpace(part)
 				if part != "" {
-					count++
+					elements = append(elements, part)
 				}
 			}
 		}
@@ -204,6 +204,10 @@ func ParseSetElementCount(output, setName string) int {
 			inElements = false
 		}
 	}
+	return elements
+}
 
-	return count
+// ParseSetElementCount counts the elements of a named set in "nft list set" output
+func ParseSetElementCount(output, setName string) int {
+	return len(ParseSetElements(output, setName))
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,6 +39,10 @@ func New() (*Service, error) {
 	instance = svc
 	instanceMu.Unlock()
 
+	if a := svc.probe(); !a.Available {
+		log.Printf("WARNING: nftables unavailable, firewall and ACL rules will NOT be enforced: %s", a.Error)
+	}
+
 	log.Printf("nftables service initialized")
 	return svc, nil
 }
@@ -87,14 +92,25 @@ func (s *Service) RequestApply() {
 	s.applyPending = true
 	s.broadcast("firewall:pending", "pending", "Changes queued", nil)
 
-	s.applyTimer = time.AfterFunc(s.debounceDelay, func() {
+	var timer *time.Timer
+	timer = time.AfterFunc(s.debounceDelay, func() {
+		s.runMutex.Lock()
+		defer s.runMutex.Unlock()
+
+		s.applyMutex.Lock()
+		if s.applyTimer == timer {
+			s.applyTimer = nil
+		}
+		s.applyRunning = true
+		s.applyMutex.Unlock()
+
 		s.broadcast("firewall:applying", "applying", "Applying rules...", nil)
 
 		err := s.ApplyAll()
 
 		s.applyMutex.Lock()
-		s.applyPending = false
-		s.applyTimer = nil
+		s.applyRunning = false
+		s.applyPending = s.applyTimer != nil // requested again while running
 		s.lastApplyErr = err
 		if err == nil {
 			s.lastApplyAt = time.Now()
@@ -110,10 +126,15 @@ func (s *Service) RequestApply() {
 			s.broadcast("firewall:applied", "applied", "Rules applied", stats)
 		}
 	})
+	s.applyTimer = timer
 }
 
 // ApplyAll applies all registered tables
 func (s *Service) ApplyAll() error {
+	if err := s.ensureAvailable(); err != nil {
+		return err
+	}
+
 	s.applyMutex.Lock()
 	tables := make([]Table, 0, len(s.tables))
 	for _, t := range s.tables {
@@ -141,22 +162,25 @@ func (s *Service) applyTable(t Table) error {
 		return fmt.Errorf("build: %w", err)
 	}
 
-	// Prepend delete command to script for atomic replacement
-	// (nft flush table only clears chains, NOT set elements!)
-	// Using "delete table" before the new table definition ensures clean state
-	deletePrefix := fmt.Sprintf("delete table %s %s\n", t.Family(), t.Name())
-	atomicScript := deletePrefix + script
-
-	if err := s.ApplyScript(atomicScript); err != nil {
-		// If delete fails (table doesn't exist), try without delete
-		if err := s.ApplyScript(script); err != nil {
-			return fmt.Errorf("apply: %w", err)
-		}
+	// Replace the table in one nft transaction: declaring it first makes the
+	// delete valid even when it doesn't exist yet, and "delete table" (unlike
+	// flush) also drops set elements. nft -f commits the file as a whole, so
+	// the old ruleset stays in place until the new one is loaded, and a
+	// failing script leaves it untouched.
+	if err := s.ApplyScript(replaceTableScript(t.Family(), t.Name(), script)); err != nil {
+		return fmt.Errorf("apply: %w", err)
 	}
 
+	s.recordBaseline(t)
 	return nil
 }
 
+// replaceTableScript prefixes a table definition with the commands that
+// atomically drop the existing table of that name
+func replaceTableScript(family, name, script string) string {
+	return fmt.Sprintf("table %s %s\ndelete table %s %s\n", family, name, family, name) + script
+}
+
 // Exec runs an nft command
 func (s *Service) Exec(args ...string) ([]byte, error) {
 	cmd := exec.Command("nft", args...)
@@ -193,6 +217,12 @@ func (s *Service) TableExists(family, name string) bool {
 	return err == nil && len(out) > 0
 }
 
+// SetExists checks if a named set is loaded
+func (s *Service) SetExists(family, table, setName string) bool {
+	_, err := s.Exec("list", "set", family, table, setName)
+	return err == nil
+}
+
 // CountSetElements counts elements in a named set
 func (s *Service) CountSetElements(family, table, setName string) int {
 	out, err := s.Exec("list", "set", family, table, setName)
@@ -202,18 +232,100 @@ func (s *Service) CountSetElements(family, table, setName string) int {
 	return ParseSetElementCount(string(out), setName)
 }
 
+// FirewallSetNames lists the sets defined in the firewall table
+var FirewallSetNames = []string{
+	"blocked_ips", "blocked_ranges", "blocked_countries", "country_exceptions",
+	"blocked_ips_out", "blocked_ranges_out", "blocked_countries_out", "country_exceptions_out",
+	"allowed_ips", "allowed_ranges", "allowed_ips_out", "allowed_ranges_out",
+	"blocked_ips6", "blocked_ranges6", "blocked_ips6_out", "blocked_ranges6_out",
+	"allowed_ips6", "allowed_ranges6", "allowed_ips6_out", "allowed_ranges6_out",
+	"allowed_tcp_ports", "allowed_udp_ports",
+	"blocked_tcp_ports", "blocked_udp_ports", "blocked_tcp_ports_out", "blocked_udp_ports_out",
+}
+
+// IsFirewallSet reports whether name is one of the firewall table's sets
+func IsFirewallSet(name string) bool {
+	for _, n := range FirewallSetNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCountrySet reports whether name is one of the country sets, which the
+// table only defines while country blocking is enabled
+func IsCountrySet(name string) bool {
+	switch name {
+	case "blocked_countries", "country_exceptions", "blocked_countries_out", "country_exceptions_out":
+		return true
+	}
+	return false
+}
+
+// ListSetElements returns the elements currently loaded in a named set
+func (s *Service) ListSetElements(family, table, setName string) ([]string, error) {
+	out, err := s.Exec("list", "set", family, table, setName)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSetElements(string(out), setName), nil
+}
+
+// AddSetElements adds elements to a live set without rebuilding the table.
+// Callers should fall back to RequestApply on error (e.g. table not loaded yet).
+func (s *Service) AddSetElements(family, table, setName string, elements ...string) error {
+	return s.updateSetElements("add", family, table, setName, elements)
+}
+
+// DeleteSetElements removes elements from a live set without rebuilding the table
+func (s *Service) DeleteSetElements(family, table, setName string, elements ...string) error {
+	return s.updateSetElements("delete", family, table, setName, elements)
+}
+
+func (s *Service) updateSetElements(op, family, table, setName string, elements []string) error {
+	if !ValidateIdentifier(table) || !ValidateIdentifier(setName) || !validFamily.MatchString(family) {
+		return fmt.Errorf("invalid set reference %s %s %s", family, table, setName)
+	}
+
+	sanitized := make([]string, 0, len(elements))
+	for _, e := range elements {
+		if e = SanitizeElement(e); e != "" {
+			sanitized = append(sanitized, e)
+		}
+	}
+	if len(sanitized) == 0 {
+		return nil
+	}
+
+	// A queued full apply rebuilds from the DB and will contain the change anyway.
+	// A running one may have read the DB before it, so queue another.
+	s.applyMutex.Lock()
+	queued := s.applyTimer != nil
+	running := s.applyRunning
+	s.applyMutex.Unlock()
+	if queued {
+		return nil
+	}
+	if running {
+		s.RequestApply()
+		return nil
+	}
+
+	out, err := s.Exec(op, "element", family, table, setName, "{ "+strings.Join(sanitized, ", ")+" }")
+	if err != nil {
+		return fmt.Errorf("nft %s element: %v - %s", op, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
 // GetFirewallSetCounts returns element counts for all firewall sets
 func (s *Service) GetFirewallSetCounts() map[string]int {
-	return map[string]int{
-		"blocked_ips":           s.CountSetElements("inet", "wgadmin_firewall", "blocked_ips"),
-		"blocked_ranges":        s.CountSetElements("inet", "wgadmin_firewall", "blocked_ranges"),
-		"blocked_countries":     s.CountSetElements("inet", "wgadmin_firewall", "blocked_countries"),
-		"blocked_ips_out":       s.CountSetElements("inet", "wgadmin_firewall", "blocked_ips_out"),
-		"blocked_ranges_out":    s.CountSetElements("inet", "wgadmin_firewall", "blocked_ranges_out"),
-		"blocked_countries_out": s.CountSetElements("inet", "wgadmin_firewall", "blocked_countries_out"),
-		"allowed_tcp_ports":     s.CountSetElements("inet", "wgadmin_firewall", "allowed_tcp_ports"),
-		"allowed_udp_ports":     s.CountSetElements("inet", "wgadmin_firewall", "allowed_udp_ports"),
+	counts := make(map[string]int, len(FirewallSetNames))
+	for _, name := range FirewallSetNames {
+		counts[name] = s.CountSetElements("inet", "wgadmin_firewall", name)
 	}
+	return counts
 }
 
 // GetStats returns statistics about applied rules
@@ -270,5 +382,6 @@ func (s *Service) Stop() {
 	if s.applyTimer != nil {
 		s.applyTimer.Stop()
 		s.applyTimer = nil
+		s.applyPending = s.applyRunning
 	}
 }
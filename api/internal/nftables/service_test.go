@@ -0,0 +1,32 @@
+package nftables
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateSetElementsDuringApply(t *testing.T) {
+	s := &Service{tables: make(map[string]Table), debounceDelay: time.Hour}
+	defer s.Stop()
+
+	// Running apply: the update must queue another apply instead of being dropped
+	s.applyRunning = true
+	if err := s.AddSetElements("inet", "wgadmin_firewall", "blocked_ips", "203.0.113.5"); err != nil {
+		t.Fatal(err)
+	}
+	s.applyMutex.Lock()
+	queued, pending := s.applyTimer != nil, s.applyPending
+	s.applyMutex.Unlock()
+	if !queued || !pending {
+		t.Fatalf("update during a running apply: queued=%v pending=%v, want both true", queued, pending)
+	}
+
+	// Queued apply: it reads the DB later, so nothing more is needed
+	timer := s.applyTimer
+	if err := s.DeleteSetElements("inet", "wgadmin_firewall", "blocked_ips", "203.0.113.5"); err != nil {
+		t.Fatal(err)
+	}
+	if s.applyTimer != timer {
+		t.Error("update with an apply already queued rescheduled it")
+	}
+}
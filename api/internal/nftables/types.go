@@ -20,13 +20,25 @@ type Service struct {
 	db     *database.DB
 	tables map[string]Table
 
-	// Debouncing
+	// Debouncing. applyPending covers a queued (applyTimer set) or running
+	// apply; runMutex keeps a queued apply from starting while one runs.
 	applyMutex   sync.Mutex
 	applyPending bool
+	applyRunning bool
 	applyTimer   *time.Timer
+	runMutex     sync.Mutex
 	lastApplyErr error
 	lastApplyAt  time.Time
 
+	// Drift detection (fingerprints of the last applied tables)
+	driftMu   sync.Mutex
+	baselines map[string]tableBaseline
+	lastDrift *DriftStatus
+
+	// Result of the last `nft list ruleset` probe
+	availMu      sync.RWMutex
+	availability Availability
+
 	// Callbacks (set externally to avoid circular imports)
 	broadcastFn func(channel string, data interface{})
 
@@ -36,6 +48,7 @@ type Service struct {
 
 // CountryZonesProvider provides country IP ranges (implemented by geolocation.Service)
 type CountryZonesProvider interface {
+	IsBlockingEnabled() bool
 	GetAllBlockedCIDRs(outboundOnly bool) ([]string, error)
 }
 
@@ -94,6 +107,7 @@ type FirewallEntry struct {
 	Protocol  string     `json:"protocol"`
 	Source    string     `json:"source"`
 	Reason    string     `json:"reason,omitempty"`
+	Category  string     `json:"category,omitempty"` // block category, see firewall.BlockCategories
 	Name      string     `json:"name,omitempty"`
 	Essential bool       `json:"essential"`
 	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
@@ -26,7 +26,7 @@ func (t *VPNACLTable) Priority() int    { return 20 }
 func (t *VPNACLTable) Build() (string, error) {
 	wgIPRange := helper.GetEnvOptional("WG_IP_RANGE", "")
 	hsIPRange := helper.GetEnvOptional("HEADSCALE_IP_RANGE", "")
-	serverIP := helper.GetEnvOptional("SERVER_IP", "")
+	serverIP := helper.ServerIP()
 
 	// Load clients
 	clients, err := t.loadClients()
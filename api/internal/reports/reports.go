@@ -0,0 +1,442 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"api/internal/database"
+	"api/internal/geolocation"
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/scheduler"
+	"api/internal/settings"
+	"api/internal/traefik"
+)
+
+// dailyReportJob is the scheduler job name for the daily digest
+const dailyReportJob = "daily-report"
+
+// Delivery channels
+const (
+	ChannelWebhook = "webhook"
+	ChannelSMTP    = "smtp"
+)
+
+// Config holds report delivery settings (stored in the settings table).
+// Email goes through the shared SMTP settings; only the recipients are per report.
+type Config struct {
+	Enabled    bool   `json:"enabled"`
+	Hour       int    `json:"hour"`    // local hour 0-23
+	Channel    string `json:"channel"` // webhook, smtp
+	WebhookURL string `json:"webhookUrl"`
+	SMTPTo     string `json:"smtpTo"` // comma-separated
+}
+
+// CountItem is a labelled count in a report ranking
+type CountItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// CertWarning is a certificate close to (or past) expiry
+type CertWarning struct {
+	Domain   string `json:"domain"`
+	DaysLeft int    `json:"daysLeft"`
+	Status   string `json:"status"`
+}
+
+// Report is the daily activity digest
+type Report struct {
+	From            time.Time     `json:"from"`
+	To              time.Time     `json:"to"`
+	NewBans         int           `json:"newBans"`
+	BansBySource    []CountItem   `json:"bansBySource"`
+	TopCountries    []CountItem   `json:"topCountries"`
+	TopNetworks     []CountItem   `json:"topNetworks"` // ISP/ASN of newly banned IPs, when the geo provider has it
+	CertWarnings    []CertWarning `json:"certWarnings"`
+	NewClients      []string      `json:"newClients"`
+	UpdatedClients  []string      `json:"updatedClients"`
+	TotalClients    int           `json:"totalClients"`
+	FirewallBlocked int           `json:"firewallBlocked"` // dropped packets logged in the period
+}
+
+// Service handles scheduled activity reports
+type Service struct {
+	db *database.DB
+}
+
+// New creates the reports service and schedules the daily report if enabled
+func New() (*Service, error) {
+	db, err := database.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{db: db}
+	migrateSMTPSettings()
+	s.schedule(loadConfig())
+
+	log.Printf("Reports service initialized")
+	return s, nil
+}
+
+// Handlers returns the handler map for the router
+func (s *Service) Handlers() router.ServiceHandlers {
+	return router.ServiceHandlers{
+		"GetConfig":     s.handleGetConfig,
+		"UpdateConfig":  s.handleUpdateConfig,
+		"Preview":       s.handlePreview,
+		"SendReportNow": s.handleSendReportNow,
+	}
+}
+
+// loadConfig reads the report configuration from settings
+func loadConfig() Config {
+	cfg := Config{
+		Enabled: getSetting("report_enabled") == "true",
+		Hour:    settings.GetSettingInt("report_hour", 8),
+		Channel: getSetting("report_channel"),
+
+		WebhookURL: getSetting("report_webhook_url"),
+		SMTPTo:     getSetting("report_smtp_to"),
+	}
+	if cfg.Channel == "" {
+		cfg.Channel = ChannelWebhook
+	}
+	return cfg
+}
+
+func getSetting(key string) string {
+	val, _ := settings.GetSetting(key)
+	return val
+}
+
+// legacySMTPSettings maps the report's own SMTP keys, from before email moved
+// to the shared SMTP settings, to their shared counterparts
+var legacySMTPSettings = map[string]string{
+	"report_smtp_host": "smtp_host",
+	"report_smtp_port": "smtp_port",
+	"report_smtp_user": "smtp_username",
+	"report_smtp_from": "smtp_from",
+}
+
+// migrateSMTPSettings moves the report's old SMTP server settings into the
+// shared ones, unless those are already configured, then drops the old keys
+func migrateSMTPSettings() {
+	if getSetting("report_smtp_host") == "" {
+		return
+	}
+	if !settings.LoadSMTPConfig().Configured() {
+		for oldKey, newKey := range legacySMTPSettings {
+			if value := getSetting(oldKey); value != "" {
+				if err := settings.SetSetting(newKey, value); err != nil {
+					log.Printf("Warning: failed to migrate %s: %v", oldKey, err)
+					return
+				}
+			}
+		}
+		if password, _ := settings.GetSettingEncrypted("report_smtp_password"); password != "" {
+			if err := settings.SetSettingEncrypted("smtp_password", password); err != nil {
+				log.Printf("Warning: failed to migrate report_smtp_password: %v", err)
+				return
+			}
+		}
+		log.Printf("Reports: moved report SMTP server settings to the shared SMTP settings")
+	}
+	for oldKey := range legacySMTPSettings {
+		settings.DeleteSetting(oldKey)
+	}
+	settings.DeleteSetting("report_smtp_password")
+}
+
+// schedule registers or removes the daily report job
+func (s *Service) schedule(cfg Config) {
+	if !cfg.Enabled {
+		scheduler.Remove(dailyReportJob)
+		return
+	}
+	scheduler.Daily(dailyReportJob, "Send daily activity report via "+cfg.Channel, cfg.Hour,
+		func(ctx context.Context) error {
+			return s.send(loadConfig(), time.Now())
+		})
+}
+
+// validate checks that the delivery channel is usable
+func (c Config) validate() error {
+	if c.Hour < 0 || c.Hour > 23 {
+		return fmt.Errorf("hour must be between 0 and 23")
+	}
+	switch c.Channel {
+	case ChannelWebhook:
+		if c.WebhookURL == "" {
+			return fmt.Errorf("webhook URL is required")
+		}
+		return helper.ValidateURL(c.WebhookURL)
+	case ChannelSMTP:
+		if strings.TrimSpace(c.SMTPTo) == "" {
+			return fmt.Errorf("SMTP recipients (smtpTo) are required")
+		}
+		if !settings.LoadSMTPConfig().Configured() {
+			return fmt.Errorf("SMTP is not configured, set it up in the SMTP settings first")
+		}
+	default:
+		return fmt.Errorf("channel must be webhook or smtp")
+	}
+	return nil
+}
+
+// Build assembles the report for the 24 hours before now
+func (s *Service) Build(now time.Time) Report {
+	from := now.Add(-24 * time.Hour)
+	since := from.UTC().Format("2006-01-02 15:04:05")
+	r := Report{From: from, To: now}
+
+	// New bans
+	s.db.QueryRow(`SELECT COUNT(*) FROM firewall_entries
+		WHERE action = 'block' AND entry_type IN ('ip', 'range') AND created_at > ?`, since).Scan(&r.NewBans)
+	r.BansBySource = s.countQuery(`SELECT source, COUNT(*) AS cnt FROM firewall_entries
+		WHERE action = 'block' AND created_at > ?
+		GROUP BY source ORDER BY cnt DESC LIMIT 10`, since)
+
+	// Attack origins from firewall drop logs
+	s.db.QueryRow(`SELECT COUNT(*) FROM logs
+		WHERE logs_type = 'fw' AND logs_timestamp > ?`, since).Scan(&r.FirewallBlocked)
+	r.TopCountries = s.countQuery(`SELECT logs_src_country, COUNT(*) AS cnt FROM logs
+		WHERE logs_type = 'fw' AND logs_timestamp > ? AND COALESCE(logs_src_country, '') != ''
+		GROUP BY logs_src_country ORDER BY cnt DESC LIMIT 10`, since)
+	r.TopNetworks = s.topNetworks(since)
+
+	// Certificates expiring within 30 days
+	r.CertWarnings = []CertWarning{}
+	if certs, err := traefik.GetCertificates(); err == nil {
+		for _, c := range certs {
+			if c.Status == "warning" || c.Status == "critical" || c.Status == "expired" {
+				r.CertWarnings = append(r.CertWarnings, CertWarning{Domain: c.Domain, DaysLeft: c.DaysLeft, Status: c.Status})
+			}
+		}
+	}
+
+	// VPN client changes
+	r.NewClients = s.stringQuery(`SELECT name FROM vpn_clients WHERE created_at > ? ORDER BY name`, since)
+	r.UpdatedClients = s.stringQuery(`SELECT name FROM vpn_clients
+		WHERE updated_at > ? AND created_at <= ? ORDER BY name`, since, since)
+	s.db.QueryRow("SELECT COUNT(*) FROM vpn_clients").Scan(&r.TotalClients)
+
+	return r
+}
+
+// topNetworks looks up the ISP of newly banned IPs (needs a geo provider with ISP data)
+func (s *Service) topNetworks(since string) []CountItem {
+	result := []CountItem{}
+	geo := geolocation.GetService()
+	if geo == nil || !geo.IsLookupAvailable() {
+		return result
+	}
+
+	ips := s.stringQuery(`SELECT value FROM firewall_entries
+		WHERE action = 'block' AND entry_type = 'ip' AND created_at > ?
+		ORDER BY created_at DESC LIMIT 200`, since)
+	found, _ := geo.LookupBulk(ips)
+
+	counts := make(map[string]int)
+	for _, res := range found {
+		if res == nil || res.Extra == nil {
+			continue
+		}
+		if isp, ok := res.Extra["isp"].(string); ok && isp != "" && isp != "-" {
+			counts[isp]++
+		}
+	}
+	for name, count := range counts {
+		result = append(result, CountItem{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+	if len(result) > 10 {
+		result = result[:10]
+	}
+	return result
+}
+
+func (s *Service) countQuery(query string, args ...interface{}) []CountItem {
+	items := []CountItem{}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return items
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item CountItem
+		if rows.Scan(&item.Name, &item.Count) == nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (s *Service) stringQuery(query string, args ...interface{}) []string {
+	values := []string{}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return values
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		if rows.Scan(&v) == nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Text renders the report as a plain-text digest
+func (r Report) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Daily report %s - %s\n\n", r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"))
+
+	fmt.Fprintf(&sb, "Firewall\n  New bans: %d\n  Dropped connections logged: %d\n", r.NewBans, r.FirewallBlocked)
+	writeCounts(&sb, "  By source:", r.BansBySource)
+	writeCounts(&sb, "  Top attacking countries:", r.TopCountries)
+	writeCounts(&sb, "  Top attacking networks:", r.TopNetworks)
+
+	sb.WriteString("\nCertificates\n")
+	if len(r.CertWarnings) == 0 {
+		sb.WriteString("  No certificates expiring within 30 days\n")
+	}
+	for _, c := range r.CertWarnings {
+		fmt.Fprintf(&sb, "  %s: %s (%d days left)\n", c.Domain, c.Status, c.DaysLeft)
+	}
+
+	fmt.Fprintf(&sb, "\nVPN clients (%d total)\n", r.TotalClients)
+	if len(r.NewClients) > 0 {
+		fmt.Fprintf(&sb, "  Added: %s\n", strings.Join(r.NewClients, ", "))
+	}
+	if len(r.UpdatedClients) > 0 {
+		fmt.Fprintf(&sb, "  Changed: %s\n", strings.Join(r.UpdatedClients, ", "))
+	}
+	if len(r.NewClients) == 0 && len(r.UpdatedClients) == 0 {
+		sb.WriteString("  No changes\n")
+	}
+	return sb.String()
+}
+
+func writeCounts(sb *strings.Builder, title string, items []CountItem) {
+	if len(items) == 0 {
+		return
+	}
+	sb.WriteString(title + "\n")
+	for _, item := range items {
+		fmt.Fprintf(sb, "    %-30s %d\n", item.Name, item.Count)
+	}
+}
+
+// send builds the report and delivers it on the configured channel
+func (s *Service) send(cfg Config, now time.Time) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	report := s.Build(now)
+	switch cfg.Channel {
+	case ChannelSMTP:
+		return settings.SendMail(cfg.SMTPTo, "WireGuard Admin daily report", report.Text())
+	default:
+		return sendWebhook(cfg.WebhookURL, report)
+	}
+}
+
+// sendWebhook POSTs the report as JSON; "text" carries the rendered digest for chat webhooks
+func sendWebhook(url string, report Report) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"text":   report.Text(),
+		"report": report,
+	})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *Service) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := loadConfig()
+
+	result := map[string]interface{}{
+		"config":    cfg,
+		"lastRun":   nil,
+		"lastError": "",
+		"nextRun":   nil,
+	}
+	if job, ok := scheduler.GetJob(dailyReportJob); ok {
+		result["lastRun"] = job.LastRun
+		result["lastError"] = job.LastError
+		result["nextRun"] = job.NextRun
+	}
+	router.JSON(w, result)
+}
+
+func (s *Service) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := loadConfig()
+	if !router.DecodeJSONOrError(w, r, &cfg) {
+		return
+	}
+	cfg.Channel = strings.ToLower(strings.TrimSpace(cfg.Channel))
+
+	if cfg.Enabled {
+		if err := cfg.validate(); err != nil {
+			router.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	values := map[string]string{
+		"report_enabled":     strconv.FormatBool(cfg.Enabled),
+		"report_hour":        strconv.Itoa(cfg.Hour),
+		"report_channel":     cfg.Channel,
+		"report_webhook_url": cfg.WebhookURL,
+		"report_smtp_to":     cfg.SMTPTo,
+	}
+	for key, value := range values {
+		if err := settings.SetSetting(key, value); err != nil {
+			router.JSONError(w, "failed to save settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	s.schedule(cfg)
+	s.handleGetConfig(w, r)
+}
+
+func (s *Service) handlePreview(w http.ResponseWriter, r *http.Request) {
+	report := s.Build(time.Now())
+	router.JSON(w, map[string]interface{}{
+		"report": report,
+		"text":   report.Text(),
+	})
+}
+
+func (s *Service) handleSendReportNow(w http.ResponseWriter, r *http.Request) {
+	cfg := loadConfig()
+	if err := s.send(cfg, time.Now()); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	router.JSON(w, map[string]string{"status": "sent", "channel": cfg.Channel})
+}
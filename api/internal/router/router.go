@@ -22,6 +22,20 @@ type AuthValidator func(token string) bool
 // authValidator is the registered auth validator
 var authValidator AuthValidator
 
+// APITokenPrefix marks read-only API tokens; session IDs never carry it
+const APITokenPrefix = "wgat_"
+
+// apiTokenLength is the prefix plus 32 random bytes hex-encoded
+const apiTokenLength = len(APITokenPrefix) + 64
+
+// apiTokenValidator validates read-only API tokens, nil while tokens are unsupported
+var apiTokenValidator AuthValidator
+
+// IsAPIToken reports whether a bearer token has the API token format
+func IsAPIToken(token string) bool {
+	return len(token) == apiTokenLength && strings.HasPrefix(token, APITokenPrefix)
+}
+
 // HandlerFunc is the standard handler function type
 type HandlerFunc func(w http.ResponseWriter, r *http.Request)
 
@@ -30,9 +44,10 @@ type ServiceHandlers map[string]HandlerFunc
 
 // Router manages HTTP routing based on configuration
 type Router struct {
-	mux      *http.ServeMux
-	config   *config.Config
-	handlers map[string]ServiceHandlers
+	mux         *http.ServeMux
+	config      *config.Config
+	handlers    map[string]ServiceHandlers
+	tokenRoutes []string // full patterns of GET endpoints open to API tokens
 }
 
 // New creates a new router with the given configuration
@@ -80,6 +95,10 @@ func (r *Router) Build() http.Handler {
 				actualPattern = fullPattern[:strings.Index(fullPattern, "{")]
 			}
 
+			if endpoint.ReadToken && r.methodAllowed(http.MethodGet, endpoint.Methods) {
+				r.tokenRoutes = append(r.tokenRoutes, fullPattern)
+			}
+
 			actualPatterns[actualPattern] = append(actualPatterns[actualPattern], routeHandler{
 				fullPattern: fullPattern,
 				methods:     endpoint.Methods,
@@ -189,6 +208,22 @@ func SetAuthValidator(validator AuthValidator) {
 	authValidator = validator
 }
 
+// SetAPITokenValidator sets the validator for read-only API tokens. They're
+// accepted only for GET requests to endpoints marked readToken.
+func SetAPITokenValidator(validator AuthValidator) {
+	apiTokenValidator = validator
+}
+
+// tokenRouteAllowed reports whether path is an endpoint open to API tokens
+func (r *Router) tokenRouteAllowed(path string) bool {
+	for _, pattern := range r.tokenRoutes {
+		if r.pathMatches(path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
 // applyMiddleware wraps the handler with configured middleware
 func (r *Router) applyMiddleware(handler http.Handler) http.Handler {
 	h := handler
@@ -286,6 +321,24 @@ func (r *Router) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Read-only API tokens never reach mutations or unlisted endpoints
+		if apiTokenValidator != nil && IsAPIToken(token) {
+			if req.Method != http.MethodGet {
+				JSONError(w, "API tokens are read-only", http.StatusForbidden)
+				return
+			}
+			if !r.tokenRouteAllowed(path) {
+				JSONError(w, "Endpoint not available to API tokens", http.StatusForbidden)
+				return
+			}
+			if !apiTokenValidator(token) {
+				JSONError(w, "Invalid or expired API token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+			return
+		}
+
 		// Validate token
 		if !authValidator(token) {
 			JSONError(w, "Invalid or expired session", http.StatusUnauthorized)
@@ -465,6 +518,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController (Flush for SSE)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // handleAPIInfo returns information about available endpoints
 func (r *Router) handleAPIInfo(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -513,6 +571,22 @@ type HealthResponse struct {
 	Checks    map[string]HealthCheck `json:"checks"`
 }
 
+// HealthCheckFunc reports the health of a component registered by a service
+type HealthCheckFunc func() HealthCheck
+
+var (
+	healthChecks   = make(map[string]HealthCheckFunc)
+	healthChecksMu sync.RWMutex
+)
+
+// RegisterHealthCheck adds a named check to /health; an "error" status
+// marks the panel degraded
+func RegisterHealthCheck(name string, check HealthCheckFunc) {
+	healthChecksMu.Lock()
+	defer healthChecksMu.Unlock()
+	healthChecks[name] = check
+}
+
 // handleHealth returns comprehensive health status
 func (r *Router) handleHealth(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -537,6 +611,17 @@ func (r *Router) handleHealth(w http.ResponseWriter, req *http.Request) {
 		response.Status = "degraded"
 	}
 
+	// Checks registered by services (e.g. nftables)
+	healthChecksMu.RLock()
+	for name, check := range healthChecks {
+		c := check()
+		response.Checks[name] = c
+		if c.Status == "error" && response.Status == "ok" {
+			response.Status = "degraded"
+		}
+	}
+	healthChecksMu.RUnlock()
+
 	// Set appropriate HTTP status
 	statusCode := http.StatusOK
 	if response.Status != "ok" {
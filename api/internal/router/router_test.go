@@ -0,0 +1,84 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"api/internal/config"
+)
+
+func TestAuthMiddlewareAPITokens(t *testing.T) {
+	var (
+		apiToken     = APITokenPrefix + strings.Repeat("a", 64)
+		revokedToken = APITokenPrefix + strings.Repeat("b", 64)
+		sessionToken = "session"
+	)
+
+	defer func(auth, api AuthValidator) {
+		authValidator, apiTokenValidator = auth, api
+	}(authValidator, apiTokenValidator)
+	SetAuthValidator(func(token string) bool { return token == sessionToken })
+	SetAPITokenValidator(func(token string) bool { return token == apiToken })
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r := New(&config.Config{Services: map[string]config.ServiceConfig{
+		"test": {
+			Prefix:  "/api/test",
+			Enabled: true,
+			Endpoints: []config.EndpointConfig{
+				{Path: "/items", Methods: []string{"GET", "POST"}, Handler: "ok", ReadToken: true},
+				{Path: "/items/{id}", Methods: []string{"GET"}, Handler: "ok", ReadToken: true},
+				{Path: "/secret", Methods: []string{"GET"}, Handler: "ok"},
+			},
+		},
+	}})
+	r.RegisterService("test", ServiceHandlers{"ok": ok})
+	handler := r.Build()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"token on readToken GET", "GET", "/api/test/items", apiToken, http.StatusOK},
+		{"token on readToken GET with path param", "GET", "/api/test/items/42", apiToken, http.StatusOK},
+		{"token on readToken POST", "POST", "/api/test/items", apiToken, http.StatusForbidden},
+		{"token on unlisted GET", "GET", "/api/test/secret", apiToken, http.StatusForbidden},
+		{"revoked or expired token", "GET", "/api/test/items", revokedToken, http.StatusUnauthorized},
+		{"no token", "GET", "/api/test/items", "", http.StatusUnauthorized},
+		{"session on POST", "POST", "/api/test/items", sessionToken, http.StatusOK},
+		{"session on unlisted GET", "GET", "/api/test/secret", sessionToken, http.StatusOK},
+		{"invalid session", "GET", "/api/test/secret", "expired", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenRouteAllowed(t *testing.T) {
+	r := &Router{tokenRoutes: []string{"/api/fw/entries", "/api/fw/entries/{id}"}}
+	for path, want := range map[string]bool{
+		"/api/fw/entries":       true,
+		"/api/fw/entries/7":     true,
+		"/api/fw/entries/7/log": false,
+		"/api/fw/config":        false,
+	} {
+		if got := r.tokenRouteAllowed(path); got != want {
+			t.Errorf("tokenRouteAllowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"api/internal/router"
+)
+
+// JobFunc is the work performed by a scheduled job
+type JobFunc func(ctx context.Context) error
+
+// JobInfo is the observable state of a registered job
+type JobInfo struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Schedule     string     `json:"schedule"`
+	Running      bool       `json:"running"`
+	Runs         int64      `json:"runs"`
+	LastRun      *time.Time `json:"lastRun,omitempty"`
+	LastDuration string     `json:"lastDuration,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	NextRun      *time.Time `json:"nextRun,omitempty"`
+}
+
+// job is a registered job with its run loop
+type job struct {
+	name        string
+	description string
+	schedule    string
+	next        func(from time.Time) time.Time
+	fn          JobFunc
+	cancel      context.CancelFunc
+	trigger     chan struct{}
+
+	mu           sync.Mutex
+	running      bool
+	runs         int64
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    string
+	nextRun      time.Time
+}
+
+var (
+	mu     sync.Mutex
+	jobs   = make(map[string]*job)
+	ctx    context.Context
+	cancel context.CancelFunc
+)
+
+func init() {
+	ctx, cancel = context.WithCancel(context.Background())
+}
+
+// Every registers a job that runs every interval (first run after one interval).
+// Re-registering a name replaces the previous job.
+func Every(name, description string, interval time.Duration, fn JobFunc) {
+	register(name, description, "every "+interval.String(), func(from time.Time) time.Time {
+		return from.Add(interval)
+	}, fn)
+}
+
+// Daily registers a job that runs once a day at the given local hour (0-23)
+func Daily(name, description string, hour int, fn JobFunc) {
+	register(name, description, fmt.Sprintf("daily at %02d:00", hour), func(from time.Time) time.Time {
+		return nextDaily(from, hour)
+	}, fn)
+}
+
+// Remove stops and unregisters a job
+func Remove(name string) {
+	mu.Lock()
+	j, ok := jobs[name]
+	delete(jobs, name)
+	mu.Unlock()
+
+	if ok {
+		j.cancel()
+	}
+}
+
+// StopAll cancels every job (used on shutdown)
+func StopAll() {
+	cancel()
+}
+
+// RunNow triggers a job immediately, returns an error if unknown or already running
+func RunNow(name string) error {
+	mu.Lock()
+	j, ok := jobs[name]
+	mu.Unlock()
+	if !ok {
+		return fmt.Errorf("job not found: %s", name)
+	}
+
+	j.mu.Lock()
+	running := j.running
+	j.mu.Unlock()
+	if running {
+		return fmt.Errorf("job %s is already running", name)
+	}
+
+	select {
+	case j.trigger <- struct{}{}:
+		return nil
+	default:
+		return fmt.Errorf("job %s is already queued", name)
+	}
+}
+
+// ListJobs returns the state of all registered jobs sorted by name
+func ListJobs() []JobInfo {
+	mu.Lock()
+	list := make([]*job, 0, len(jobs))
+	for _, j := range jobs {
+		list = append(list, j)
+	}
+	mu.Unlock()
+
+	result := make([]JobInfo, 0, len(list))
+	for _, j := range list {
+		result = append(result, j.info())
+	}
+	sort.Slice(result, func(i, k int) bool { return result[i].Name < result[k].Name })
+	return result
+}
+
+// GetJob returns the state of a single job
+func GetJob(name string) (JobInfo, bool) {
+	mu.Lock()
+	j, ok := jobs[name]
+	mu.Unlock()
+	if !ok {
+		return JobInfo{}, false
+	}
+	return j.info(), true
+}
+
+func register(name, description, schedule string, next func(time.Time) time.Time, fn JobFunc) {
+	jobCtx, jobCancel := context.WithCancel(ctx)
+	j := &job{
+		name:        name,
+		description: description,
+		schedule:    schedule,
+		next:        next,
+		fn:          fn,
+		cancel:      jobCancel,
+		trigger:     make(chan struct{}, 1),
+	}
+
+	mu.Lock()
+	if old, ok := jobs[name]; ok {
+		old.cancel()
+	}
+	jobs[name] = j
+	mu.Unlock()
+
+	go j.loop(jobCtx)
+}
+
+// loop waits for the next scheduled time or a manual trigger and runs the job
+func (j *job) loop(ctx context.Context) {
+	for {
+		next := j.next(time.Now())
+		j.mu.Lock()
+		j.nextRun = next
+		j.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-j.trigger:
+			timer.Stop()
+		}
+
+		j.run(ctx)
+	}
+}
+
+// run executes the job once, recovering from panics so the loop keeps going
+func (j *job) run(ctx context.Context) {
+	j.mu.Lock()
+	j.running = true
+	j.mu.Unlock()
+
+	start := time.Now()
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		err = j.fn(ctx)
+	}()
+
+	j.mu.Lock()
+	j.running = false
+	j.runs++
+	j.lastRun = start
+	j.lastDuration = time.Since(start)
+	j.lastError = ""
+	if err != nil {
+		j.lastError = err.Error()
+		log.Printf("Scheduler: job %s failed: %v", j.name, err)
+	}
+	j.mu.Unlock()
+}
+
+func (j *job) info() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := JobInfo{
+		Name:        j.name,
+		Description: j.description,
+		Schedule:    j.schedule,
+		Running:     j.running,
+		Runs:        j.runs,
+		LastError:   j.lastError,
+	}
+	if !j.lastRun.IsZero() {
+		lastRun := j.lastRun
+		info.LastRun = &lastRun
+		info.LastDuration = j.lastDuration.Round(time.Millisecond).String()
+	}
+	if !j.nextRun.IsZero() && !j.running {
+		nextRun := j.nextRun
+		info.NextRun = &nextRun
+	}
+	return info
+}
+
+// nextDaily returns the next occurrence of hour:00 local time after from
+func nextDaily(from time.Time, hour int) time.Time {
+	next := time.Date(from.Year(), from.Month(), from.Day(), hour, 0, 0, 0, from.Location())
+	if !next.After(from) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Service exposes scheduled jobs over HTTP
+type Service struct{}
+
+// New creates a new scheduler service
+func New() *Service {
+	log.Printf("Scheduler service initialized")
+	return &Service{}
+}
+
+// Handlers returns the handler map for the router
+func (s *Service) Handlers() router.ServiceHandlers {
+	return router.ServiceHandlers{
+		"ListJobs":           s.handleListJobs,
+		"RunJobNow":          s.handleRunJobNow,
+		"GetBackgroundTasks": s.handleGetBackgroundTasks,
+	}
+}
+
+func (s *Service) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	router.JSON(w, ListJobs())
+}
+
+func (s *Service) handleRunJobNow(w http.ResponseWriter, r *http.Request) {
+	name := router.ExtractPathParam(r, "/api/scheduler/jobs/")
+	if _, ok := GetJob(name); !ok {
+		router.JSONError(w, "job not found", http.StatusNotFound)
+		return
+	}
+
+	if err := RunNow(name); err != nil {
+		router.JSONError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	router.JSONWithStatus(w, map[string]string{"status": "started", "name": name}, http.StatusAccepted)
+}
+
+// handleGetBackgroundTasks lists long-running tasks and scheduled jobs with
+// their health. A job is unhealthy when its last run failed.
+func (s *Service) handleGetBackgroundTasks(w http.ResponseWriter, r *http.Request) {
+	taskList := ListTasks()
+	jobList := ListJobs()
+	unhealthy := 0
+	for _, t := range taskList {
+		if !t.Healthy {
+			unhealthy++
+		}
+	}
+	for _, j := range jobList {
+		if j.LastError != "" {
+			unhealthy++
+		}
+	}
+	router.JSON(w, map[string]interface{}{
+		"tasks":     taskList,
+		"jobs":      jobList,
+		"unhealthy": unhealthy,
+		"healthy":   unhealthy == 0,
+	})
+}
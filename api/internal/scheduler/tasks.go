@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// taskStaleFactor is how many intervals a task may go without ticking before
+// it counts as unhealthy
+const taskStaleFactor = 3
+
+// taskStaleGrace is added to the allowance so short intervals don't flap when
+// a tick is slow
+const taskStaleGrace = 30 * time.Second
+
+// Task is a long-running goroutine with its own loop (not a scheduled job)
+// that reports liveness. The loop calls Tick each iteration and defers Done.
+type Task struct {
+	name        string
+	description string
+	interval    time.Duration
+
+	mu        sync.Mutex
+	startedAt time.Time
+	lastTick  time.Time
+	ticks     int64
+	running   bool
+	exitError string
+}
+
+// TaskInfo is the observable state of a background task
+type TaskInfo struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Interval    string     `json:"interval"`
+	Running     bool       `json:"running"`
+	Healthy     bool       `json:"healthy"`
+	Problem     string     `json:"problem,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	LastTick    *time.Time `json:"lastTick,omitempty"`
+	Ticks       int64      `json:"ticks"`
+}
+
+var (
+	tasksMu sync.Mutex
+	tasks   = make(map[string]*Task)
+)
+
+// RegisterTask records a task that is expected to tick every interval.
+// Re-registering a name (e.g. a restarted jail monitor) replaces the entry.
+func RegisterTask(name, description string, interval time.Duration) *Task {
+	t := &Task{
+		name:        name,
+		description: description,
+		interval:    interval,
+		startedAt:   time.Now(),
+		running:     true,
+	}
+	tasksMu.Lock()
+	tasks[name] = t
+	tasksMu.Unlock()
+	return t
+}
+
+// Tick records that the task's loop completed an iteration
+func (t *Task) Tick() {
+	t.mu.Lock()
+	t.lastTick = time.Now()
+	t.ticks++
+	t.mu.Unlock()
+}
+
+// Stop unregisters a task that exited on purpose (shutdown, jail removed)
+func (t *Task) Stop() {
+	tasksMu.Lock()
+	if tasks[t.name] == t {
+		delete(tasks, t.name)
+	}
+	tasksMu.Unlock()
+}
+
+// Fail marks a task that exited on its own; it stays listed as unhealthy
+func (t *Task) Fail(reason string) {
+	t.mu.Lock()
+	t.running = false
+	t.exitError = reason
+	t.mu.Unlock()
+}
+
+// Done is deferred by the task's goroutine. A task whose context was cancelled
+// stopped on purpose and is unregistered; any other exit is recorded as a
+// failure. Panics are recovered so one loop can't take the daemon down.
+func (t *Task) Done(ctx context.Context) {
+	if r := recover(); r != nil {
+		log.Printf("Background task %s panicked: %v", t.name, r)
+		t.Fail(fmt.Sprintf("panic: %v", r))
+		return
+	}
+	if ctx.Err() != nil {
+		t.Stop()
+		return
+	}
+	t.Fail("exited unexpectedly")
+}
+
+func (t *Task) info(now time.Time) TaskInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info := TaskInfo{
+		Name:        t.name,
+		Description: t.description,
+		Interval:    t.interval.String(),
+		Running:     t.running,
+		StartedAt:   t.startedAt,
+		Ticks:       t.ticks,
+	}
+	last := t.startedAt
+	if !t.lastTick.IsZero() {
+		lastTick := t.lastTick
+		info.LastTick = &lastTick
+		last = lastTick
+	}
+
+	switch {
+	case !t.running:
+		info.Problem = "exited: " + t.exitError
+	case now.Sub(last) > taskStaleFactor*t.interval+taskStaleGrace:
+		info.Problem = "no tick for " + now.Sub(last).Round(time.Second).String()
+	}
+	info.Healthy = info.Problem == ""
+	return info
+}
+
+// ListTasks returns the state of all background tasks sorted by name
+func ListTasks() []TaskInfo {
+	tasksMu.Lock()
+	list := make([]*Task, 0, len(tasks))
+	for _, t := range tasks {
+		list = append(list, t)
+	}
+	tasksMu.Unlock()
+
+	now := time.Now()
+	result := make([]TaskInfo, 0, len(list))
+	for _, t := range list {
+		result = append(result, t.info(now))
+	}
+	sort.Slice(result, func(i, k int) bool { return result[i].Name < result[k].Name })
+	return result
+}
@@ -0,0 +1,133 @@
+package settings
+
+import (
+	"net/http"
+	"os"
+	"sync"
+
+	"api/internal/config"
+	"api/internal/router"
+)
+
+// Sources of an effective config value, highest precedence first
+const (
+	SourceSettings = "settings" // settings table, changed from the panel
+	SourceEnv      = "env"
+	SourceConfig   = "config" // app section of the endpoints config file
+	SourceDefault  = "default"
+)
+
+// EffectiveValue is the runtime value of one config key and where it came from
+type EffectiveValue struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Origin string      `json:"origin,omitempty"` // env var, config path or setting key read
+}
+
+// EffectiveConfigFunc reports a service's effective config values
+type EffectiveConfigFunc func() []EffectiveValue
+
+var (
+	effectiveConfig   = make(map[string]EffectiveConfigFunc)
+	effectiveConfigMu sync.RWMutex
+)
+
+// RegisterEffectiveConfig makes a service's values available to GetEffectiveConfig.
+// Services register from their constructors, since settings can't import them.
+func RegisterEffectiveConfig(service string, fn EffectiveConfigFunc) {
+	effectiveConfigMu.Lock()
+	defer effectiveConfigMu.Unlock()
+	effectiveConfig[service] = fn
+}
+
+// EnvValue reports an env var, or def when it's unset
+func EnvValue(key, envVar string, def interface{}) EffectiveValue {
+	if v := os.Getenv(envVar); v != "" {
+		return EffectiveValue{Key: key, Value: v, Source: SourceEnv, Origin: envVar}
+	}
+	return EffectiveValue{Key: key, Value: def, Source: SourceDefault, Origin: envVar}
+}
+
+// SecretEnvValue reports only whether a sensitive env var is set
+func SecretEnvValue(key, envVar string) EffectiveValue {
+	if os.Getenv(envVar) != "" {
+		return EffectiveValue{Key: key, Value: "(set)", Source: SourceEnv, Origin: envVar}
+	}
+	return EffectiveValue{Key: key, Value: "(unset)", Source: SourceDefault, Origin: envVar}
+}
+
+// ConfigIntValue reports an app config int: the file sets it when non-zero,
+// otherwise the effective value is the built-in default
+func ConfigIntValue(key, path string, fileValue, effective int) EffectiveValue {
+	source := SourceDefault
+	if fileValue != 0 {
+		source = SourceConfig
+	}
+	return EffectiveValue{Key: key, Value: effective, Source: source, Origin: path}
+}
+
+// StoredValue reports a value backed by a settings key: it comes from the
+// settings table when the key is stored, otherwise from the defaults
+func StoredValue(key, settingKey string, effective interface{}) EffectiveValue {
+	source := SourceDefault
+	if raw, err := getSetting(settingKey); err == nil && raw != "" {
+		source = SourceSettings
+	}
+	return EffectiveValue{Key: key, Value: effective, Source: source, Origin: settingKey}
+}
+
+// coreEffectiveConfig covers values read by the API itself rather than a service
+func coreEffectiveConfig() []EffectiveValue {
+	var app config.AppConfig
+	if a := config.GetApp(); a != nil {
+		app = *a
+	}
+	smtp := LoadSMTPConfig()
+	return []EffectiveValue{
+		EnvValue("apiPort", "API_PORT", nil),
+		EnvValue("dataDir", "DATA_DIR", nil),
+		EnvValue("configPath", "CONFIG_PATH", nil),
+		EnvValue("serverIP", "SERVER_IP", nil),
+		EnvValue("sslDomain", "SSL_DOMAIN", nil),
+		EnvValue("trustedProxies", "TRUSTED_PROXIES", ""),
+		EnvValue("upstreamFetchTimeoutSec", "UPSTREAM_FETCH_TIMEOUT", 0),
+		SecretEnvValue("encryptionSecret", "ENCRYPTION_SECRET"),
+		StoredValue("sessionTimeoutHours", "session_timeout", getSettingInt("session_timeout", 24)),
+		StoredValue("smtpHost", "smtp_host", smtp.Host),
+		StoredValue("smtpPort", "smtp_port", smtp.Port),
+		StoredValue("smtpTlsMode", "smtp_tls_mode", smtp.TLSMode),
+		{Key: "websocketStatusCheckIntervalSec", Value: app.WebSocket.StatusCheckIntervalSec, Source: SourceConfig,
+			Origin: "app.websocket.statusCheckIntervalSec"},
+	}
+}
+
+// handleGetEffectiveConfig returns every registered service's effective
+// config with the source of each value (?service= limits it to one)
+func (s *Service) handleGetEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	effectiveConfigMu.RLock()
+	providers := make(map[string]EffectiveConfigFunc, len(effectiveConfig)+1)
+	for name, fn := range effectiveConfig {
+		providers[name] = fn
+	}
+	effectiveConfigMu.RUnlock()
+	providers["core"] = coreEffectiveConfig
+
+	if name := router.QueryParam(r, "service", ""); name != "" {
+		fn, ok := providers[name]
+		if !ok {
+			router.JSONError(w, "unknown service: "+name, http.StatusNotFound)
+			return
+		}
+		providers = map[string]EffectiveConfigFunc{name: fn}
+	}
+
+	services := make(map[string][]EffectiveValue, len(providers))
+	for name, fn := range providers {
+		services[name] = fn()
+	}
+	router.JSON(w, map[string]interface{}{
+		"services":   services,
+		"precedence": []string{SourceSettings, SourceEnv, SourceConfig, SourceDefault},
+	})
+}
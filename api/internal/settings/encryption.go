@@ -0,0 +1,221 @@
+package settings
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"api/internal/database"
+	"api/internal/helper"
+	"api/internal/router"
+)
+
+// encryptedColumns lists every column holding helper.Encrypt output
+var encryptedColumns = []struct {
+	table, idCol, col, where string
+}{
+	{"settings", "key", "value", "encrypted = 1"},
+	{"users", "id", "totp_secret_enc", "totp_secret_enc IS NOT NULL AND totp_secret_enc != ''"},
+	{"vpn_clients", "id", "private_key_enc", "private_key_enc IS NOT NULL AND private_key_enc != ''"},
+	{"vpn_clients", "id", "preshared_key_enc", "preshared_key_enc IS NOT NULL AND preshared_key_enc != ''"},
+	{"users_push_subscriptions", "id", "key_p256dh", "key_p256dh != ''"},
+	{"users_push_subscriptions", "id", "key_auth", "key_auth != ''"},
+}
+
+// The check value is written with the new key in the rotation transaction, so
+// at startup it tells whether a staged key was committed
+const (
+	keyCheckSetting = "encryption_key_check"
+	keyCheckValue   = "wgadmin-key-check"
+)
+
+var rotateMu sync.Mutex
+
+// KeyRotationResult describes a completed rotation
+type KeyRotationResult struct {
+	Reencrypted    int    `json:"reencrypted"`
+	DatabaseBackup string `json:"databaseBackup"` // snapshot encrypted with the old key
+	KeyBackup      string `json:"keyBackup"`      // old key, needed to read the snapshot
+}
+
+// RotateEncryptionKey re-encrypts every stored secret with a new key.
+//
+// The database is snapshotted and the old key saved next to it first. The new
+// key is staged as encryption.key.new, secrets are re-encrypted in a single
+// transaction, and the staged key is promoted once it commits. If the process
+// dies between commit and promotion, RecoverEncryptionKey finishes the job.
+func RotateEncryptionKey(dataDir string) (*KeyRotationResult, error) {
+	if !rotateMu.TryLock() {
+		return nil, fmt.Errorf("a key rotation is already running")
+	}
+	defer rotateMu.Unlock()
+
+	db, err := database.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	newKey, err := helper.GenerateEncryptionKey()
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+
+	keyPath := filepath.Join(dataDir, helper.EncryptionKeyFile)
+	stagedPath := keyPath + ".new"
+	stamp := time.Now().UTC().Format("20060102-150405")
+	result := &KeyRotationResult{
+		DatabaseBackup: filepath.Join(dataDir, "app.db.pre-rotation-"+stamp),
+		KeyBackup:      keyPath + ".pre-rotation-" + stamp,
+	}
+
+	err = helper.RotateEncryptionKey(newKey, func(oldKey []byte) error {
+		if _, err := db.Exec("VACUUM INTO ?", result.DatabaseBackup); err != nil {
+			return fmt.Errorf("database backup: %w", err)
+		}
+		if err := helper.WriteKeyFile(result.KeyBackup, oldKey); err != nil {
+			return fmt.Errorf("key backup: %w", err)
+		}
+		if err := helper.WriteKeyFile(stagedPath, newKey); err != nil {
+			return fmt.Errorf("stage key: %w", err)
+		}
+
+		n, err := reencryptAll(db, oldKey, newKey)
+		if err != nil {
+			os.Remove(stagedPath)
+			return err
+		}
+		result.Reencrypted = n
+
+		// Committed: from here on the new key is the only one that works
+		if err := os.Rename(stagedPath, keyPath); err != nil {
+			log.Printf("Warning: key rotation committed but %s was not promoted (recovered on next start): %v", stagedPath, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Encryption key rotated: %d secrets re-encrypted, backup %s", result.Reencrypted, result.DatabaseBackup)
+	return result, nil
+}
+
+// reencryptAll rewrites every encrypted column and the check value in one transaction
+func reencryptAll(db *database.DB, oldKey, newKey []byte) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // No-op if committed
+
+	total := 0
+	for _, c := range encryptedColumns {
+		n, err := reencryptColumn(tx, c.table, c.idCol, c.col, c.where, oldKey, newKey)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+
+	check, err := helper.EncryptWithKey(newKey, keyCheckValue)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`INSERT INTO settings (key, value, encrypted, updated_at) VALUES (?, ?, 0, CURRENT_TIMESTAMP)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP`, keyCheckSetting, check); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func reencryptColumn(tx *sql.Tx, table, idCol, col, where string, oldKey, newKey []byte) (int, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s", idCol, col, table, where))
+	if err != nil {
+		return 0, fmt.Errorf("read %s.%s: %w", table, col, err)
+	}
+	type row struct {
+		id    interface{}
+		value string
+	}
+	var pending []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.value); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, r)
+	}
+	rows.Close()
+
+	update := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", table, col, idCol)
+	for _, r := range pending {
+		plain, err := helper.DecryptWithKey(oldKey, r.value)
+		if err != nil {
+			return 0, fmt.Errorf("%s.%s (%s %v) can't be decrypted with the current key: %w", table, col, idCol, r.id, err)
+		}
+		enc, err := helper.EncryptWithKey(newKey, plain)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(update, enc, r.id); err != nil {
+			return 0, fmt.Errorf("update %s.%s: %w", table, col, err)
+		}
+	}
+	return len(pending), nil
+}
+
+// RecoverEncryptionKey completes or discards a rotation interrupted before the
+// staged key was promoted. Must run after InitEncryption and database init.
+func RecoverEncryptionKey(dataDir string) {
+	keyPath := filepath.Join(dataDir, helper.EncryptionKeyFile)
+	stagedPath := keyPath + ".new"
+	staged, err := helper.ReadKeyFile(stagedPath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: ignoring unreadable staged key %s: %v", stagedPath, err)
+		return
+	}
+
+	check, err := getSetting(keyCheckSetting)
+	if err == nil {
+		if plain, err := helper.DecryptWithKey(staged, check); err == nil && plain == keyCheckValue {
+			if err := os.Rename(stagedPath, keyPath); err != nil {
+				log.Fatalf("FATAL: Data is encrypted with %s but it could not be promoted: %v", stagedPath, err)
+			}
+			helper.SetEncryptionKey(staged)
+			log.Printf("Encryption key rotation recovered: promoted staged key")
+			return
+		}
+	}
+
+	// The transaction never committed, the data still uses the current key
+	os.Remove(stagedPath)
+	log.Printf("Encryption key rotation was interrupted before commit, staged key discarded")
+}
+
+// handleRotateEncryptionKey rotates the encryption key for all stored secrets
+func (s *Service) handleRotateEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	result, err := RotateEncryptionKey(helper.GetEnv("DATA_DIR"))
+	if err != nil {
+		router.JSONError(w, "key rotation failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"status":         "rotated",
+		"reencrypted":    result.Reencrypted,
+		"databaseBackup": result.DatabaseBackup,
+		"keyBackup":      result.KeyBackup,
+		"message":        "The key is now stored in " + helper.EncryptionKeyFile + " in the data directory and overrides ENCRYPTION_SECRET. Delete the backups once the panel works.",
+	})
+}
@@ -39,6 +39,13 @@ func (s *Service) Handlers() router.ServiceHandlers {
 		"GetSettings":    s.handleGetSettings,
 		"SelectSettings": s.handleSelectSettings,
 		"UpdateSettings": s.handleUpdateSettings,
+
+		"RotateEncryptionKey": s.handleRotateEncryptionKey,
+		"GetServerIP":         s.handleGetServerIP,
+		"GetEffectiveConfig":  s.handleGetEffectiveConfig,
+		"GetSMTPConfig":       s.handleGetSMTPConfig,
+		"SetSMTPConfig":       s.handleSetSMTPConfig,
+		"TestSMTP":            s.handleTestSMTP,
 	}
 }
 
@@ -161,7 +168,7 @@ func (s *Service) buildSettingsMap() map[string]interface{} {
 			dashEnabled := strings.Contains(content, "address: 0.0.0.0:")
 			result["adguard_dashboard_enabled"] = dashEnabled
 			if dashEnabled {
-				serverIP := os.Getenv("SERVER_IP")
+				serverIP := helper.ServerIP()
 				adguardPort := os.Getenv("ADGUARD_PORT")
 				if serverIP != "" && adguardPort != "" {
 					result["adguard_dashboard_url"] = "http://" + serverIP + ":" + adguardPort
@@ -449,3 +456,14 @@ func DeleteSetting(key string) error {
 	_, err = db.Exec("DELETE FROM settings WHERE key = ?", key)
 	return err
 }
+
+// handleGetServerIP returns the configured SERVER_IP next to the detected public
+// IP (?refresh=true re-detects now, at most once a minute)
+func (s *Service) handleGetServerIP(w http.ResponseWriter, r *http.Request) {
+	if router.QueryParam(r, "refresh", "false") == "true" {
+		if err := helper.RefreshPublicIP(); err != nil {
+			log.Printf("Warning: public IP detection failed: %v", err)
+		}
+	}
+	router.JSON(w, helper.GetPublicIPStatus())
+}
@@ -0,0 +1,246 @@
+package settings
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"api/internal/helper"
+	"api/internal/router"
+)
+
+// SMTP TLS modes
+const (
+	SMTPTLSStartTLS = "starttls" // plain connection upgraded with STARTTLS (required)
+	SMTPTLSImplicit = "tls"      // TLS from the first byte, usually port 465
+	SMTPTLSNone     = "none"     // no encryption; auth is refused unless the host is local
+)
+
+// smtpDialTimeout bounds connecting to and talking with the SMTP server
+const smtpDialTimeout = 15 * time.Second
+
+// SMTPConfig is the shared email delivery config notification features send through
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	TLSMode  string `json:"tlsMode"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"` // write-only, never returned; empty keeps the stored one
+	From     string `json:"from"`
+}
+
+// LoadSMTPConfig reads the SMTP config, including the decrypted password
+func LoadSMTPConfig() SMTPConfig {
+	cfg := SMTPConfig{Port: getSettingInt("smtp_port", 587), TLSMode: SMTPTLSStartTLS}
+	cfg.Host, _ = getSetting("smtp_host")
+	cfg.Username, _ = getSetting("smtp_username")
+	cfg.From, _ = getSetting("smtp_from")
+	if mode, _ := getSetting("smtp_tls_mode"); mode != "" {
+		cfg.TLSMode = mode
+	}
+	cfg.Password, _ = getSettingEncrypted("smtp_password")
+	return cfg
+}
+
+// Configured reports whether email can be sent at all
+func (c SMTPConfig) Configured() bool {
+	return c.Host != "" && c.From != ""
+}
+
+func (c SMTPConfig) validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if err := helper.ValidatePort(c.Port); err != nil {
+		return err
+	}
+	switch c.TLSMode {
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return fmt.Errorf("tlsMode must be starttls, tls or none")
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("invalid from address: %v", err)
+	}
+	return nil
+}
+
+// parseRecipients splits a comma-separated address list
+func parseRecipients(to string) ([]string, error) {
+	list, err := mail.ParseAddressList(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %v", err)
+	}
+	recipients := make([]string, len(list))
+	for i, addr := range list {
+		recipients[i] = addr.Address
+	}
+	return recipients, nil
+}
+
+// SendMail sends a plain-text email with the stored SMTP config to a
+// comma-separated recipient list
+func SendMail(to, subject, text string) error {
+	return sendMail(LoadSMTPConfig(), to, subject, text)
+}
+
+func sendMail(cfg SMTPConfig, to, subject, text string) error {
+	if !cfg.Configured() {
+		return fmt.Errorf("SMTP is not configured")
+	}
+	recipients, err := parseRecipients(to)
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %v", err)
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+	if cfg.TLSMode == SMTPTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect to %s: %v", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpDialTimeout))
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP handshake failed: %v", err)
+	}
+	defer c.Close()
+
+	if cfg.TLSMode == SMTPTLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not offer STARTTLS (use tlsMode tls or none)")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %v", err)
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP auth failed: %v", err)
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM rejected: %v", err)
+	}
+	for _, rcpt := range recipients {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s rejected: %v", rcpt, err)
+		}
+	}
+	// Callers may pass user-supplied text; keep it out of the headers
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA rejected: %v", err)
+	}
+	msg := "From: " + cfg.From + "\r\n" +
+		"To: " + strings.Join(recipients, ", ") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + strings.ReplaceAll(text, "\n", "\r\n")
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("SMTP send failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP send failed: %v", err)
+	}
+	return c.Quit()
+}
+
+// handleGetSMTPConfig returns the SMTP config without the password
+func (s *Service) handleGetSMTPConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := LoadSMTPConfig()
+	hasPassword := cfg.Password != ""
+	cfg.Password = ""
+	router.JSON(w, map[string]interface{}{
+		"config":      cfg,
+		"hasPassword": hasPassword,
+		"configured":  cfg.Configured(),
+	})
+}
+
+// handleSetSMTPConfig validates and stores the SMTP config; the password is
+// encrypted and only replaced when one is sent
+func (s *Service) handleSetSMTPConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := LoadSMTPConfig()
+	cfg.Password = ""
+	if !router.DecodeJSONOrError(w, r, &cfg) {
+		return
+	}
+	cfg.Host = strings.TrimSpace(cfg.Host)
+	cfg.TLSMode = strings.ToLower(strings.TrimSpace(cfg.TLSMode))
+	if err := cfg.validate(); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values := map[string]string{
+		"smtp_host":     cfg.Host,
+		"smtp_port":     strconv.Itoa(cfg.Port),
+		"smtp_tls_mode": cfg.TLSMode,
+		"smtp_username": cfg.Username,
+		"smtp_from":     cfg.From,
+	}
+	for key, value := range values {
+		if err := setSetting(key, value); err != nil {
+			router.JSONError(w, "failed to save settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if cfg.Password != "" {
+		if err := setSettingEncrypted("smtp_password", cfg.Password); err != nil {
+			router.JSONError(w, "failed to save password: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.handleGetSMTPConfig(w, r)
+}
+
+// handleTestSMTP sends a sample email with the stored config and reports the
+// SMTP error, if any
+func (s *Service) handleTestSMTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		To string `json:"to"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.To) == "" {
+		router.JSONError(w, "to is required", http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	err := SendMail(req.To, "WireGuard Admin test email",
+		"This is a test email from WireGuard Admin.\n\nIf you received it, SMTP delivery is working.\n")
+	result := map[string]interface{}{
+		"success":    err == nil,
+		"durationMs": time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result["error"] = err.Error()
+	}
+	router.JSON(w, result)
+}
@@ -85,6 +85,8 @@ func (s *Service) Handlers() router.ServiceHandlers {
 		"CompleteSetup":    s.handleCompleteSetup,
 		"DetectHeadscale":  s.handleDetectHeadscale,
 		"GenerateAPIKey":   s.handleGenerateAPIKey,
+		"GetSetupStatus":   s.handleGetSetupStatus,
+		"RerunSetupStep":   s.handleRerunSetupStep,
 	}
 }
 
@@ -383,6 +385,12 @@ func (s *Service) CompleteSetup(req *SetupRequest) error {
 	// Clean up pending key if exists
 	_ = settings.DeleteSetting("headscale_api_key_pending")
 
+	recordStep("admin")
+	recordStep("headscale")
+	if err := applySSLDomain(); err == nil {
+		recordStep("ssl_domain")
+	}
+
 	log.Printf("Setup completed successfully")
 	return nil
 }
@@ -0,0 +1,191 @@
+package setup
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/settings"
+)
+
+// EnsureFirewallDefaults re-creates default jails and essential ports.
+// Wired in main.go since firewall isn't available to this package at init.
+var EnsureFirewallDefaults func() error
+
+// setupStep is one recorded part of the first-run setup
+type setupStep struct {
+	Name        string
+	Description string
+	Done        func(s *Service) bool
+	Rerun       func() error // nil when the step needs wizard input
+}
+
+// StepStatus reports one setup step
+type StepStatus struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"` // unknown for installs predating step tracking
+	Rerunnable  bool       `json:"rerunnable"`
+}
+
+// SetupStepsStatus is the detailed setup state
+type SetupStepsStatus struct {
+	SetupStatus
+	Steps []StepStatus `json:"steps"`
+}
+
+var setupSteps = []setupStep{
+	{
+		Name:        "admin",
+		Description: "Create the admin user",
+		Done:        func(s *Service) bool { return s.auth != nil && s.auth.HasUsers() },
+	},
+	{
+		Name:        "headscale",
+		Description: "Connect to Headscale",
+		Done:        func(s *Service) bool { return settingExists("headscale_api_url") },
+	},
+	{
+		Name:        "ssl_domain",
+		Description: "Record the panel SSL domain from SSL_DOMAIN",
+		Done:        func(s *Service) bool { return settingExists("ssl_domain") },
+		Rerun:       applySSLDomain,
+	},
+	{
+		Name:        "firewall_defaults",
+		Description: "Ensure default jails and essential ports",
+		Done:        func(s *Service) bool { return stepCompletedAt("firewall_defaults") != nil },
+		Rerun: func() error {
+			if EnsureFirewallDefaults == nil {
+				return fmt.Errorf("firewall service not available")
+			}
+			return EnsureFirewallDefaults()
+		},
+	},
+	{
+		Name:        "adguard_password",
+		Description: "Change the default AdGuard password",
+		Done:        func(s *Service) bool { return settingExists("adguard_pass_changed") },
+	},
+}
+
+func settingExists(key string) bool {
+	v, err := settings.GetSetting(key)
+	return err == nil && v != ""
+}
+
+func stepSettingKey(name string) string {
+	return "setup_step_" + name
+}
+
+// recordStep stores when a setup step last ran
+func recordStep(name string) {
+	if err := settings.SetSetting(stepSettingKey(name), time.Now().UTC().Format(time.RFC3339)); err != nil {
+		log.Printf("Warning: failed to record setup step %s: %v", name, err)
+	}
+}
+
+func stepCompletedAt(name string) *time.Time {
+	v, err := settings.GetSetting(stepSettingKey(name))
+	if err != nil {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// applySSLDomain stores SSL_DOMAIN as the system domain shown on the domains page
+func applySSLDomain() error {
+	domain := strings.TrimSpace(helper.GetEnvOptional("SSL_DOMAIN", ""))
+	if domain == "" {
+		return fmt.Errorf("SSL_DOMAIN is not set")
+	}
+	return settings.SetSetting("ssl_domain", domain)
+}
+
+// GetStepsStatus returns the setup status with per-step details
+func (s *Service) GetStepsStatus() (*SetupStepsStatus, error) {
+	status, err := s.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SetupStepsStatus{SetupStatus: *status, Steps: make([]StepStatus, 0, len(setupSteps))}
+	for _, step := range setupSteps {
+		result.Steps = append(result.Steps, StepStatus{
+			Name:        step.Name,
+			Description: step.Description,
+			Completed:   step.Done(s),
+			CompletedAt: stepCompletedAt(step.Name),
+			Rerunnable:  step.Rerun != nil,
+		})
+	}
+	return result, nil
+}
+
+// RerunStep re-executes an idempotent setup step
+func (s *Service) RerunStep(name string) error {
+	for _, step := range setupSteps {
+		if step.Name != name {
+			continue
+		}
+		if step.Rerun == nil {
+			return fmt.Errorf("step %s can't be re-run", name)
+		}
+		if err := step.Rerun(); err != nil {
+			return err
+		}
+		recordStep(name)
+		log.Printf("Setup step %s re-run", name)
+		return nil
+	}
+	return fmt.Errorf("unknown setup step: %s", name)
+}
+
+func (s *Service) handleGetSetupStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAuthIfCompleted(w, r) {
+		return
+	}
+
+	status, err := s.GetStepsStatus()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	router.JSON(w, status)
+}
+
+func (s *Service) handleRerunSetupStep(w http.ResponseWriter, r *http.Request) {
+	status, _ := s.GetStatus()
+	if !status.Completed {
+		router.JSONError(w, "Setup not completed, use the setup wizard", http.StatusBadRequest)
+		return
+	}
+	if !s.requireAuthIfCompleted(w, r) {
+		return
+	}
+
+	s.setupMu.Lock()
+	defer s.setupMu.Unlock()
+
+	name := router.ExtractPathParam(r, "/api/setup/steps/")
+	if err := s.RerunStep(name); err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"message":     "Step re-run",
+		"step":        name,
+		"completedAt": stepCompletedAt(name),
+	})
+}
@@ -0,0 +1,511 @@
+package traefik
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"api/internal/helper"
+)
+
+// The sentinel plugin is a separate module loaded by Traefik, so the API can't
+// import it. EvaluateSentinel mirrors the plugin's Evaluate for the config that
+// generateDomainsConfig emits; keep both in step when changing a check. Both
+// run the cases in the plugin's testdata/evaluate_cases.json, so add one there
+// for any behaviour change.
+
+// Sentinel check names, in the order the plugin applies them
+const (
+	SentinelCheckMaintenance = "maintenance"
+	SentinelCheckIPFilter    = "ipFilter"
+	SentinelCheckUserAgents  = "userAgents"
+	SentinelCheckJA3Filter   = "ja3Filter"
+	SentinelCheckHeaders     = "headers"
+	SentinelCheckTimeAccess  = "timeAccess"
+	SentinelCheckSlowlist    = "slowlist"
+)
+
+// LocalSentinelASNFile is SentinelASNFile as seen from the API container
+func LocalSentinelASNFile() string {
+	return filepath.Join(helper.GetEnvOptional("TRAEFIK_CONFIG", "/traefik/dynamic"), filepath.Base(SentinelASNFile))
+}
+
+// LocalSentinelSlowlistFile is SentinelSlowlistFile as seen from the API container
+func LocalSentinelSlowlistFile() string {
+	return filepath.Join(helper.GetEnvOptional("TRAEFIK_CONFIG", "/traefik/dynamic"), filepath.Base(SentinelSlowlistFile))
+}
+
+// LocalSentinelBanFeedFile is SentinelBanFeedFile as seen from the API container,
+// next to the metrics file in the shared traefik logs directory
+func LocalSentinelBanFeedFile() string {
+	metrics := helper.GetEnvOptional("TRAEFIK_SENTINEL_METRICS", "/traefik/logs/sentinel-metrics.json")
+	return filepath.Join(filepath.Dir(metrics), filepath.Base(SentinelBanFeedFile))
+}
+
+// SentinelTestRequest describes a synthetic request to evaluate
+type SentinelTestRequest struct {
+	ClientIP  string            `json:"clientIp"`
+	UserAgent string            `json:"userAgent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Time      *time.Time        `json:"time,omitempty"` // defaults to now
+}
+
+// SentinelCheckResult is the outcome of one check: pass, fail or skipped (not configured)
+type SentinelCheckResult struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SentinelTestResult is what the middleware would do with the request
+type SentinelTestResult struct {
+	Action   string                `json:"action"`             // allow, block or maintenance
+	Check    string                `json:"check,omitempty"`    // the check that decided
+	Detail   string                `json:"detail,omitempty"`   // why
+	Response string                `json:"response,omitempty"` // what a blocked client receives
+	DelayMs  int                   `json:"delayMs,omitempty"`  // added delay for slowlisted clients that are allowed
+	Checks   []SentinelCheckResult `json:"checks"`             // every check, including ones after the decisive one
+}
+
+// EvaluateSentinel evaluates a synthetic request against a sentinel config.
+// asnFile is the ASN prefix dataset as seen from the API; AllowASN entries
+// match nothing when it can't be read.
+func EvaluateSentinel(cfg *SentinelConfig, req SentinelTestRequest, asnFile string) SentinelTestResult {
+	now := time.Now()
+	if req.Time != nil {
+		now = *req.Time
+	}
+	httpReq := &http.Request{Header: make(http.Header), RemoteAddr: net.JoinHostPort(req.ClientIP, "0")}
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	if req.UserAgent != "" {
+		httpReq.Header.Set("User-Agent", req.UserAgent)
+	}
+	clientIP := sentinelClientIP(httpReq)
+
+	result := SentinelTestResult{Action: "allow", Checks: []SentinelCheckResult{}}
+	record := func(check, failure string, configured bool, action string) {
+		status := "pass"
+		switch {
+		case !configured:
+			status = "skipped"
+		case failure != "":
+			status = "fail"
+		}
+		result.Checks = append(result.Checks, SentinelCheckResult{Check: check, Status: status, Detail: failure})
+		if status == "fail" && result.Check == "" {
+			result.Action, result.Check, result.Detail = action, check, failure
+		}
+	}
+
+	maintenance := cfg.Maintenance != nil && cfg.Maintenance.Enabled
+	maintenanceFailure := ""
+	if maintenance && !ipInNetworks(clientIP, sentinelNetworks(cfg.Maintenance.Bypass)) {
+		maintenanceFailure = "maintenance mode is enabled and the client is not in the bypass ranges"
+	}
+	record(SentinelCheckMaintenance, maintenanceFailure, maintenance, "maintenance")
+
+	ipFilter := len(cfg.IPFilter.SourceRange) > 0 || len(cfg.IPFilter.AllowASN) > 0
+	record(SentinelCheckIPFilter, sentinelIPFailure(cfg, clientIP, asnFile), ipFilter, "block")
+
+	userAgents := cfg.UserAgents != nil && (len(cfg.UserAgents.Block) > 0 || len(cfg.UserAgents.Allow) > 0)
+	uaFailure := ""
+	if userAgents {
+		uaFailure = sentinelUserAgentFailure(cfg, httpReq.Header.Get("User-Agent"))
+	}
+	record(SentinelCheckUserAgents, uaFailure, userAgents, "block")
+
+	ja3 := cfg.JA3Filter != nil && cfg.JA3Filter.Enabled && (len(cfg.JA3Filter.Block) > 0 || cfg.JA3Filter.ListURL != "")
+	ja3Failure := ""
+	if ja3 {
+		ja3Failure = sentinelJA3Failure(cfg, httpReq)
+	}
+	record(SentinelCheckJA3Filter, ja3Failure, ja3, "block")
+
+	record(SentinelCheckHeaders, sentinelHeaderFailure(cfg, httpReq), len(cfg.Headers) > 0, "block")
+
+	timeAccess := cfg.TimeAccess != nil && (len(cfg.TimeAccess.Days) > 0 || cfg.TimeAccess.AllowRange != "" || cfg.TimeAccess.DenyRange != "")
+	timeFailure := ""
+	if timeAccess {
+		timeFailure = sentinelTimeFailure(cfg, now)
+	}
+	record(SentinelCheckTimeAccess, timeFailure, timeAccess, "block")
+
+	// Slowlisted clients are still allowed, just delayed
+	slowlist := cfg.Slowlist != nil && cfg.Slowlist.Enabled
+	slowCheck := SentinelCheckResult{Check: SentinelCheckSlowlist, Status: "skipped"}
+	if slowlist {
+		slowCheck.Status = "pass"
+		if ipInNetworks(clientIP, readSentinelSlowlist(LocalSentinelSlowlistFile())) {
+			delay := SentinelSlowlistDelayMs(cfg)
+			slowCheck.Status = "delay"
+			slowCheck.Detail = fmt.Sprintf("client IP %v is slowlisted, requests are delayed %dms", clientIP, delay)
+			if result.Action == "allow" {
+				result.Check, result.Detail, result.DelayMs = SentinelCheckSlowlist, slowCheck.Detail, delay
+			}
+		}
+	}
+	result.Checks = append(result.Checks, slowCheck)
+
+	switch result.Action {
+	case "maintenance":
+		result.Response = "503 maintenance page"
+	case "block":
+		result.Response = sentinelBlockResponse(cfg)
+	}
+	return result
+}
+
+// SentinelSlowlistDelayMs is the delay the plugin applies for a config, defaults and caps included
+func SentinelSlowlistDelayMs(cfg *SentinelConfig) int {
+	delay := cfg.Slowlist.DelayMs
+	if delay <= 0 {
+		delay = 3000
+	}
+	if delay > 30000 {
+		delay = 30000
+	}
+	return delay
+}
+
+// readSentinelSlowlist reads the slowlist file; a missing file is an empty list
+func readSentinelSlowlist(file string) []*net.IPNet {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, strings.Fields(line)[0])
+	}
+	return sentinelNetworks(entries)
+}
+
+// sentinelClientIP resolves the client IP like the plugin: CF-Connecting-IP,
+// X-Forwarded-For, X-Real-IP, then the remote address
+func sentinelClientIP(req *http.Request) net.IP {
+	if cfIP := req.Header.Get("CF-Connecting-IP"); cfIP != "" {
+		if ip := net.ParseIP(strings.TrimSpace(cfIP)); ip != nil {
+			return ip
+		}
+	}
+	if xff := req.Header.Get("X-Forwarded-For"); xff != "" {
+		if ip := net.ParseIP(strings.TrimSpace(strings.Split(xff, ",")[0])); ip != nil {
+			return ip
+		}
+	}
+	if xri := req.Header.Get("X-Real-IP"); xri != "" {
+		if ip := net.ParseIP(strings.TrimSpace(xri)); ip != nil {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return net.ParseIP(req.RemoteAddr)
+	}
+	return net.ParseIP(host)
+}
+
+// sentinelNetworks parses CIDRs, accepting single IPs; invalid entries are skipped
+func sentinelNetworks(cidrs []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// VPNAllowlist returns the allowlist behind the sentinel_vpn@file middlewares;
+// ok is false when the Traefik config can't be read
+func VPNAllowlist() (allowlist []string, ok bool) {
+	if tsvc := GetService(); tsvc != nil {
+		if cfg := tsvc.GetConfig(); cfg != nil {
+			return cfg.IPAllowlist, true
+		}
+	}
+	return nil, false
+}
+
+// InSourceRange reports whether ip matches one of the IPs/CIDRs of a sentinel sourceRange
+func InSourceRange(ip string, sourceRange []string) bool {
+	return ipInNetworks(net.ParseIP(ip), sentinelNetworks(sourceRange))
+}
+
+func ipInNetworks(ip net.IP, networks []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func sentinelIPFailure(cfg *SentinelConfig, clientIP net.IP, asnFile string) string {
+	if clientIP == nil {
+		return "client IP could not be determined"
+	}
+	if ipInNetworks(clientIP, sentinelNetworks(cfg.IPFilter.SourceRange)) {
+		return ""
+	}
+	if len(cfg.IPFilter.AllowASN) > 0 {
+		networks, err := sentinelASNNetworks(asnFile, cfg.IPFilter.AllowASN)
+		if err != nil {
+			return fmt.Sprintf("client IP %v is not in the allowed ranges (ASN dataset unavailable: %v)", clientIP, err)
+		}
+		if ipInNetworks(clientIP, networks) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("client IP %v is not in the allowed ranges or ASNs", clientIP)
+}
+
+// sentinelASNNetworks reads the prefixes of the given ASNs from the "CIDR ASN" dataset
+func sentinelASNNetworks(file string, asns []string) ([]*net.IPNet, error) {
+	wanted := make(map[string]bool)
+	for _, asn := range asns {
+		wanted[normalizeSentinelASN(asn)] = true
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var networks []*net.IPNet
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) < 2 || !wanted[normalizeSentinelASN(fields[1])] {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(fields[0]); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks, scanner.Err()
+}
+
+// normalizeSentinelASN strips the optional "AS" prefix: "AS13335" -> "13335"
+func normalizeSentinelASN(asn string) string {
+	asn = strings.TrimSpace(asn)
+	if len(asn) > 2 && strings.EqualFold(asn[:2], "AS") {
+		asn = asn[2:]
+	}
+	return asn
+}
+
+// sentinelUserAgentFailure applies the custom allow/block patterns; allow wins
+func sentinelUserAgentFailure(cfg *SentinelConfig, ua string) string {
+	if ua == "" {
+		return ""
+	}
+	for _, pattern := range cfg.UserAgents.Allow {
+		if re, err := regexp.Compile("(?i)" + pattern); err == nil && re.MatchString(ua) {
+			return ""
+		}
+	}
+	for _, pattern := range cfg.UserAgents.Block {
+		if re, err := regexp.Compile("(?i)" + pattern); err == nil && re.MatchString(ua) {
+			return fmt.Sprintf("user-agent matches block pattern %q", pattern)
+		}
+	}
+	return ""
+}
+
+// sentinelJA3Failure matches the request's JA3 header against the block list.
+// The remote list is only fetched by the plugin, so it isn't evaluated here.
+func sentinelJA3Failure(cfg *SentinelConfig, req *http.Request) string {
+	header := cfg.JA3Filter.Header
+	if header == "" {
+		header = "X-JA3"
+	}
+	hash := normalizeJA3(req.Header.Get(header))
+	if hash == "" {
+		return ""
+	}
+	for _, blocked := range cfg.JA3Filter.Block {
+		if normalizeJA3(blocked) == hash {
+			return fmt.Sprintf("JA3 %s is on the block list", hash)
+		}
+	}
+	return ""
+}
+
+// normalizeJA3 lowercases a JA3 hash, returning "" unless it is 32 hex digits
+func normalizeJA3(hash string) string {
+	h := strings.ToLower(strings.TrimSpace(hash))
+	if len(h) != 32 {
+		return ""
+	}
+	for _, c := range h {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ""
+		}
+	}
+	return h
+}
+
+func sentinelHeaderFailure(cfg *SentinelConfig, req *http.Request) string {
+	for _, h := range cfg.Headers {
+		value := req.Header.Get(h.Name)
+		if value == "" {
+			if h.Required {
+				return fmt.Sprintf("header %s is required but missing", h.Name)
+			}
+			continue
+		}
+
+		// The plugin skips value matching when a valid regex is set
+		if h.Regex != "" {
+			if re, err := regexp.Compile(h.Regex); err == nil {
+				if !re.MatchString(value) {
+					return fmt.Sprintf("header %s does not match regex %q", h.Name, h.Regex)
+				}
+				continue
+			}
+		}
+
+		if len(h.Values) == 0 {
+			continue
+		}
+		matchCount := 0
+		for _, v := range h.Values {
+			if (h.Contains && strings.Contains(value, v)) || (!h.Contains && value == v) {
+				matchCount++
+			}
+		}
+		switch h.MatchType {
+		case "all":
+			if matchCount != len(h.Values) {
+				return fmt.Sprintf("header %s matches %d of %d required values", h.Name, matchCount, len(h.Values))
+			}
+		case "none":
+			if matchCount > 0 {
+				return fmt.Sprintf("header %s matches a blocked value", h.Name)
+			}
+		default:
+			if matchCount == 0 {
+				return fmt.Sprintf("header %s matches none of the allowed values", h.Name)
+			}
+		}
+	}
+	return ""
+}
+
+func sentinelTimeFailure(cfg *SentinelConfig, now time.Time) string {
+	tz := cfg.TimeAccess.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.UTC
+	}
+	now = now.In(loc)
+	minutes := now.Hour()*60 + now.Minute()
+	weekday := strings.ToLower(now.Weekday().String()[:3])
+
+	if len(cfg.TimeAccess.Days) > 0 {
+		dayAllowed := false
+		for _, d := range cfg.TimeAccess.Days {
+			if strings.ToLower(d) == weekday {
+				dayAllowed = true
+				break
+			}
+		}
+		if !dayAllowed {
+			return fmt.Sprintf("day %s is not in the allowed days", weekday)
+		}
+	}
+
+	// Deny range takes precedence
+	if start, end, ok := parseSentinelTimeRange(cfg.TimeAccess.DenyRange); ok && inSentinelTimeRange(minutes, start, end) {
+		return fmt.Sprintf("time %02d:%02d is in the deny range %s", now.Hour(), now.Minute(), cfg.TimeAccess.DenyRange)
+	}
+	if start, end, ok := parseSentinelTimeRange(cfg.TimeAccess.AllowRange); ok && !inSentinelTimeRange(minutes, start, end) {
+		return fmt.Sprintf("time %02d:%02d is outside the allow range %s", now.Hour(), now.Minute(), cfg.TimeAccess.AllowRange)
+	}
+	return ""
+}
+
+// parseSentinelTimeRange parses "HH:MM-HH:MM" into minutes since midnight
+func parseSentinelTimeRange(s string) (int, int, bool) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	var sh, sm, eh, em int
+	if _, err := fmt.Sscanf(strings.TrimSpace(parts[0]), "%d:%d", &sh, &sm); err != nil {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(strings.TrimSpace(parts[1]), "%d:%d", &eh, &em); err != nil {
+		return 0, 0, false
+	}
+	return sh*60 + sm, eh*60 + em, true
+}
+
+// inSentinelTimeRange handles overnight ranges (e.g. 22:00-06:00)
+func inSentinelTimeRange(current, start, end int) bool {
+	if start > end {
+		return current >= start || current < end
+	}
+	return current >= start && current < end
+}
+
+// sentinelBlockResponse describes what the configured error mode sends
+func sentinelBlockResponse(cfg *SentinelConfig) string {
+	switch cfg.ErrorMode {
+	case "404", "503":
+		return cfg.ErrorMode + " error page"
+	case "silent":
+		mode := cfg.DropMode
+		if mode != "close" && mode != "tarpit" {
+			mode = "rst"
+		}
+		return "connection dropped (" + mode + ")"
+	case "redirect":
+		code := cfg.RedirectCode
+		if code == 0 {
+			code = http.StatusFound
+		}
+		return fmt.Sprintf("%d redirect to %s", code, cfg.RedirectURL)
+	case "static":
+		return "200 static response"
+	default:
+		return "403 error page"
+	}
+}
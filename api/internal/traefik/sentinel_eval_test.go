@@ -0,0 +1,63 @@
+package traefik
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sentinelCasesFile holds the cases the plugin's Evaluate is tested against.
+// Running them here too keeps EvaluateSentinel in step with the plugin.
+const sentinelCasesFile = "../../../traefik/plugins-local/src/local/sentinel/testdata/evaluate_cases.json"
+
+func TestEvaluateSentinelMatchesPlugin(t *testing.T) {
+	data, err := os.ReadFile(sentinelCasesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []struct {
+		Name     string              `json:"name"`
+		Config   json.RawMessage     `json:"config"`
+		ASN      []string            `json:"asn"`
+		Slowlist []string            `json:"slowlist"`
+		Request  SentinelTestRequest `json:"request"`
+		Want     struct {
+			Action  string `json:"action"`
+			Check   string `json:"check"`
+			DelayMs int    `json:"delayMs"`
+		} `json:"want"`
+	}
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var cfg SentinelConfig
+			if err := json.Unmarshal(tc.Config, &cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			dir := t.TempDir()
+			t.Setenv("TRAEFIK_CONFIG", dir)
+			writeLines := func(path string, lines []string) {
+				if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			asnFile := filepath.Join(dir, "asn.txt")
+			writeLines(asnFile, tc.ASN)
+			writeLines(LocalSentinelSlowlistFile(), tc.Slowlist)
+
+			got := EvaluateSentinel(&cfg, tc.Request, asnFile)
+			if got.Action != tc.Want.Action || got.Check != tc.Want.Check {
+				t.Errorf("EvaluateSentinel = %s/%s (%s), want %s/%s", got.Action, got.Check, got.Detail, tc.Want.Action, tc.Want.Check)
+			}
+			if got.DelayMs != tc.Want.DelayMs {
+				t.Errorf("delay = %dms, want %dms", got.DelayMs, tc.Want.DelayMs)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package traefik
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
@@ -41,12 +42,35 @@ const (
 	MiddlewareSentinelVPNSilentFile = "sentinel_vpn_silent@file"
 )
 
+// SentinelMetricsFile is where sentinel middlewares write their counters, as seen
+// from inside the traefik container (./traefik/logs is mounted at /var/log/traefik).
+const SentinelMetricsFile = "/var/log/traefik/sentinel-metrics.json"
+
+// SentinelASNFile is the ASN->prefix dataset ("CIDR ASN" per line) used for ASN
+// allow-listing, as seen from inside the traefik container. Drop the file into
+// ./traefik/dynamic; Traefik's file provider ignores non-YAML files there.
+const SentinelASNFile = "/etc/traefik/dynamic/asn-prefixes.txt"
+
+// SentinelSlowlistFile lists IPs/CIDRs whose requests sentinel delays, as seen
+// from inside the traefik container. The firewall publishes it from recent attempts.
+const SentinelSlowlistFile = "/etc/traefik/dynamic/sentinel-slowlist.txt"
+
+// SentinelBanFeedFile is where sentinel appends IPs over their violation
+// threshold, as seen from inside the traefik container. The firewall renames it
+// to .processing, bans the IPs in it and then deletes it.
+const SentinelBanFeedFile = "/var/log/traefik/sentinel-bans.log"
+
+// SentinelMaintenancePagesDir holds the custom maintenance pages, as seen from
+// inside the traefik container. MaintenanceConfig.PageFile names a file in it.
+const SentinelMaintenancePagesDir = "/etc/traefik/dynamic/maintenance-pages"
+
 // Service handles Traefik operations
 type Service struct {
 	traefikAPI    string
 	configPath    string
 	staticPath    string
 	accessLogPath string
+	metricsPath   string // sentinel metrics file as seen from the api container
 }
 
 var instance *Service
@@ -61,6 +85,7 @@ func New() *Service {
 		configPath:    configPath,
 		staticPath:    helper.GetEnv("TRAEFIK_STATIC"),
 		accessLogPath: helper.GetEnv("TRAEFIK_LOGS"),
+		metricsPath:   helper.GetEnvOptional("TRAEFIK_SENTINEL_METRICS", "/traefik/logs/sentinel-metrics.json"),
 	}
 	return instance
 }
@@ -73,13 +98,131 @@ func GetService() *Service {
 // Handlers returns the handler map for the router
 func (s *Service) Handlers() router.ServiceHandlers {
 	return router.ServiceHandlers{
-		"GetOverview":  s.handleOverview,
-		"GetConfig":    s.handleGetConfig,
-		"UpdateConfig": s.handleUpdateConfig,
-		"GetVPNOnly":   s.handleGetVPNOnly,
-		"SetVPNOnly":   s.handleSetVPNOnly,
-		"GetResolvers": s.handleGetResolvers,
+		"GetOverview":      s.handleOverview,
+		"GetConfig":        s.handleGetConfig,
+		"UpdateConfig":     s.handleUpdateConfig,
+		"GetVPNOnly":       s.handleGetVPNOnly,
+		"SetVPNOnly":       s.handleSetVPNOnly,
+		"GetResolvers":     s.handleGetResolvers,
+		"GetSentinelStats": s.handleGetSentinelStats,
+	}
+}
+
+// SentinelStats holds the counters a sentinel middleware reports
+type SentinelStats struct {
+	Total       int64  `json:"total"`
+	Allowed     int64  `json:"allowed"`
+	Robots      int64  `json:"robots"`
+	IP          int64  `json:"ip"`
+	UserAgent   int64  `json:"userAgent"`
+	JA3         int64  `json:"ja3"`
+	Header      int64  `json:"header"`
+	Time        int64  `json:"time"`
+	Maintenance int64  `json:"maintenance"`
+	Slowed      int64  `json:"slowed"`
+	Domain      string `json:"domain,omitempty"` // set for per-route sentinel_domain-* middlewares
+}
+
+// GetSentinelStats reads the sentinel metrics file. Counters are keyed by middleware
+// name without the provider suffix. Returns nil map (no error) if nothing was written yet.
+func (s *Service) GetSentinelStats() (map[string]*SentinelStats, string, error) {
+	data, err := os.ReadFile(s.metricsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+
+	var file struct {
+		UpdatedAt   string                    `json:"updatedAt"`
+		Middlewares map[string]*SentinelStats `json:"middlewares"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("failed to parse sentinel metrics: %v", err)
+	}
+
+	stats := make(map[string]*SentinelStats, len(file.Middlewares))
+	for name, st := range file.Middlewares {
+		name = strings.TrimSuffix(name, "@file")
+		stats[name] = st
+	}
+
+	// Map per-route middlewares back to their domains
+	if domains, err := readDomainMiddlewareMap(); err == nil {
+		for name, st := range stats {
+			if domain, ok := domains[name]; ok {
+				st.Domain = domain
+			}
+		}
+	}
+
+	return stats, file.UpdatedAt, nil
+}
+
+// handleGetSentinelStats returns allow/block counters for all sentinel middlewares
+func (s *Service) handleGetSentinelStats(w http.ResponseWriter, r *http.Request) {
+	stats, updatedAt, err := s.GetSentinelStats()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	if stats == nil {
+		stats = map[string]*SentinelStats{}
+	}
+	router.JSON(w, map[string]interface{}{
+		"middlewares": stats,
+		"updatedAt":   updatedAt,
+	})
+}
+
+// readDomainMiddlewareMap maps sentinel_domain-* middleware names in domains.yml to route domains
+func readDomainMiddlewareMap() (map[string]string, error) {
+	dynamicPath := helper.GetEnvOptional("TRAEFIK_CONFIG", "/traefik/dynamic")
+	data, err := os.ReadFile(filepath.Join(dynamicPath, "domains.yml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		HTTP struct {
+			Routers map[string]struct {
+				Rule        string   `yaml:"rule"`
+				Middlewares []string `yaml:"middlewares"`
+			} `yaml:"routers"`
+		} `yaml:"http"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	for _, rt := range cfg.HTTP.Routers {
+		domain := ruleDomain(rt.Rule)
+		for _, mw := range rt.Middlewares {
+			if strings.HasPrefix(mw, "sentinel_domain-") {
+				result[mw] = domain
+			}
+		}
+	}
+	return result, nil
+}
+
+// ruleDomain extracts the host from a generated Host(`...`) or HostRegexp rule
+func ruleDomain(rule string) string {
+	if start := strings.Index(rule, "Host(`"); start != -1 {
+		rest := rule[start+6:]
+		if end := strings.Index(rest, "`"); end != -1 {
+			return rest[:end]
+		}
+	}
+	if start := strings.Index(rule, "HostRegexp(`^(.+\\.)?"); start != -1 {
+		rest := rule[start+len("HostRegexp(`^(.+\\.)?"):]
+		if end := strings.Index(rest, "$`"); end != -1 {
+			return "*." + strings.ReplaceAll(rest[:end], "\\.", ".")
+		}
+	}
+	return ""
 }
 
 // handleGetResolvers returns the list of certificate resolver names defined in traefik.yml.
@@ -102,8 +245,20 @@ func (s *Service) handleGetResolvers(w http.ResponseWriter, r *http.Request) {
 	router.JSON(w, map[string]interface{}{"resolvers": names})
 }
 
-func (s *Service) fetchTraefikAPI(path string) (map[string]interface{}, error) {
-	resp, err := http.Get(s.traefikAPI + path)
+// apiClient bounds Traefik API calls that aren't given a shorter context deadline
+var apiClient = &http.Client{Timeout: helper.HTTPClientTimeout}
+
+// getTraefikAPI issues a GET against the Traefik API, honouring ctx cancellation
+func (s *Service) getTraefikAPI(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.traefikAPI+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return apiClient.Do(req)
+}
+
+func (s *Service) fetchTraefikAPI(ctx context.Context, path string) (map[string]interface{}, error) {
+	resp, err := s.getTraefikAPI(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -116,8 +271,8 @@ func (s *Service) fetchTraefikAPI(path string) (map[string]interface{}, error) {
 	return result, nil
 }
 
-func (s *Service) fetchTraefikAPIArray(path string) ([]interface{}, error) {
-	resp, err := http.Get(s.traefikAPI + path)
+func (s *Service) fetchTraefikAPIArray(ctx context.Context, path string) ([]interface{}, error) {
+	resp, err := s.getTraefikAPI(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -130,41 +285,53 @@ func (s *Service) fetchTraefikAPIArray(path string) ([]interface{}, error) {
 	return result, nil
 }
 
-// handleOverview fetches overview, routers, services, and middlewares in parallel
+// handleOverview fetches overview, routers, services, and middlewares in parallel.
+// Fetches share a deadline; any that miss it are reported as empty.
 func (s *Service) handleOverview(w http.ResponseWriter, r *http.Request) {
 	type result struct {
 		data interface{}
 		err  error
 	}
 
-	// Fetch all endpoints in parallel
+	ctx, cancel := context.WithTimeout(r.Context(), helper.FetchTimeout())
+	defer cancel()
+
+	// Fetch all endpoints in parallel (buffered so late senders never block)
 	overviewCh := make(chan result, 1)
 	routersCh := make(chan result, 1)
 	servicesCh := make(chan result, 1)
 	middlewaresCh := make(chan result, 1)
 
 	go func() {
-		data, err := s.fetchTraefikAPI("/overview")
+		data, err := s.fetchTraefikAPI(ctx, "/overview")
 		overviewCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchTraefikAPIArray("/http/routers")
+		data, err := s.fetchTraefikAPIArray(ctx, "/http/routers")
 		routersCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchTraefikAPIArray("/http/services")
+		data, err := s.fetchTraefikAPIArray(ctx, "/http/services")
 		servicesCh <- result{data, err}
 	}()
 	go func() {
-		data, err := s.fetchTraefikAPIArray("/http/middlewares")
+		data, err := s.fetchTraefikAPIArray(ctx, "/http/middlewares")
 		middlewaresCh <- result{data, err}
 	}()
 
-	// Collect results
-	overview := <-overviewCh
-	routers := <-routersCh
-	services := <-servicesCh
-	middlewares := <-middlewaresCh
+	// Collect results, giving up on any fetch still running at the deadline
+	collect := func(ch chan result) result {
+		select {
+		case res := <-ch:
+			return res
+		case <-ctx.Done():
+			return result{err: ctx.Err()}
+		}
+	}
+	overview := collect(overviewCh)
+	routers := collect(routersCh)
+	services := collect(servicesCh)
+	middlewares := collect(middlewaresCh)
 
 	// Check for errors on overview (required)
 	if overview.err != nil {
@@ -405,6 +572,68 @@ func extractIPList(content string) []string {
 	return ips
 }
 
+// ListMiddlewares returns the names of all middlewares routes may reference, with
+// provider suffix (e.g. "rate-limit@file"). File-provider names come from every
+// dynamic config file except the generated domains.yml; other providers (docker
+// labels, etc.) come from the Traefik API when reachable.
+func (s *Service) ListMiddlewares() ([]string, error) {
+	seen := map[string]bool{
+		MiddlewareSentinelVPNFile:       true,
+		MiddlewareSentinelVPNSilentFile: true,
+	}
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(s.configPath), "*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	readAny := false
+	for _, f := range files {
+		if filepath.Base(f) == "domains.yml" {
+			continue // per-route sentinel middlewares are generated, not user-selectable
+		}
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var cfg struct {
+			HTTP struct {
+				Middlewares map[string]interface{} `yaml:"middlewares"`
+			} `yaml:"http"`
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			log.Printf("Warning: failed to parse %s: %v", f, err)
+			continue
+		}
+		readAny = true
+		for name := range cfg.HTTP.Middlewares {
+			seen[name+"@file"] = true
+		}
+	}
+	if !readAny {
+		return nil, fmt.Errorf("no readable dynamic config in %s", filepath.Dir(s.configPath))
+	}
+
+	if items, err := s.fetchTraefikAPIArray(context.Background(), "/http/middlewares"); err == nil {
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := m["name"].(string)
+			if name != "" && !strings.HasSuffix(name, "@internal") && !strings.HasPrefix(name, "sentinel_domain-") {
+				seen[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // middlewareExists checks if a middleware is defined in the config
 func middlewareExists(content, middlewareName string) bool {
 	path := "http.middlewares." + middlewareName
@@ -686,16 +915,21 @@ func escapeYAMLString(s string) string {
 
 // SentinelConfig represents per-domain sentinel middleware configuration
 type SentinelConfig struct {
-	Enabled   bool   `json:"enabled"`
-	ErrorMode string `json:"errorMode,omitempty"` // "403", "404", "503", "silent"
-	IPFilter  struct {
+	Enabled       bool   `json:"enabled"`
+	ErrorMode     string `json:"errorMode,omitempty"`     // "403", "404", "503", "silent", "redirect", "static"
+	DropMode      string `json:"dropMode,omitempty"`      // silent mode only: "rst", "close", "tarpit"
+	TarpitSeconds int    `json:"tarpitSeconds,omitempty"` // tarpit hold time (plugin caps at 300)
+	// Redirect mode sends blocked clients to RedirectURL; static mode serves StaticBody with a 200
+	RedirectURL       string `json:"redirectUrl,omitempty"`
+	RedirectCode      int    `json:"redirectCode,omitempty"` // 301, 302 (default), 303, 307, 308
+	StaticBody        string `json:"staticBody,omitempty"`
+	StaticContentType string `json:"staticContentType,omitempty"` // default text/html
+	IPFilter          struct {
 		SourceRange []string `json:"sourceRange,omitempty"`
+		AllowASN    []string `json:"allowAsn,omitempty"` // e.g. "AS13335", expanded via SentinelASNFile
 	} `json:"ipFilter,omitempty"`
-	Maintenance *struct {
-		Enabled bool   `json:"enabled"`
-		Message string `json:"message,omitempty"`
-	} `json:"maintenance,omitempty"`
-	TimeAccess *struct {
+	Maintenance *MaintenanceConfig `json:"maintenance,omitempty"`
+	TimeAccess  *struct {
 		Enabled    bool     `json:"enabled,omitempty"`
 		Timezone   string   `json:"timezone,omitempty"`
 		Days       []string `json:"days,omitempty"`       // mon, tue, wed, thu, fri, sat, sun
@@ -715,6 +949,77 @@ type SentinelConfig struct {
 		Block   []string `json:"block,omitempty"`
 		Allow   []string `json:"allow,omitempty"`
 	} `json:"userAgents,omitempty"`
+	// JA3Filter blocks TLS client fingerprints; the hash must be set in Header
+	// by the TLS-terminating hop in front of Traefik
+	JA3Filter *struct {
+		Enabled  bool     `json:"enabled,omitempty"`
+		Header   string   `json:"header,omitempty"`   // default X-JA3
+		Block    []string `json:"block,omitempty"`    // 32-hex-digit JA3 hashes
+		ListURL  string   `json:"listUrl,omitempty"`  // remote hash list, fetched by the plugin
+		CacheTTL int      `json:"cacheTTL,omitempty"` // seconds, default 86400
+	} `json:"ja3Filter,omitempty"`
+	// Slowlist delays requests from IPs the firewall published to SentinelSlowlistFile
+	Slowlist *struct {
+		Enabled bool `json:"enabled,omitempty"`
+		DelayMs int  `json:"delayMs,omitempty"` // default 3000, max 30000
+	} `json:"slowlist,omitempty"`
+	// ViolationThreshold reports IPs blocked Count times within Window seconds to
+	// SentinelBanFeedFile, where the firewall picks them up and bans them
+	ViolationThreshold *struct {
+		Enabled bool     `json:"enabled,omitempty"`
+		Count   int      `json:"count,omitempty"`   // default 10
+		Window  int      `json:"window,omitempty"`  // seconds, default 600
+		Reasons []string `json:"reasons,omitempty"` // header, userAgent, ja3, ip, time (default header, userAgent, ja3)
+		// TrustedProxies are peers (CIDR) whose X-Forwarded-For/CF-Connecting-IP
+		// is reported; otherwise the TCP peer is, so forged headers can't ban others
+		TrustedProxies []string `json:"trustedProxies,omitempty"`
+	} `json:"violationThreshold,omitempty"`
+}
+
+// ParseSentinelConfig decodes a route's sentinel_config column, nil when empty or invalid
+func ParseSentinelConfig(raw string) *SentinelConfig {
+	if raw == "" {
+		return nil
+	}
+	var sc SentinelConfig
+	if err := json.Unmarshal([]byte(raw), &sc); err != nil {
+		log.Printf("Warning: failed to parse sentinel config: %v", err)
+		return nil
+	}
+	return &sc
+}
+
+// MaintenanceConfig represents sentinel maintenance mode for a domain route
+type MaintenanceConfig struct {
+	Enabled        bool     `json:"enabled"`
+	Message        string   `json:"message,omitempty"`
+	Bypass         []string `json:"bypass,omitempty"`         // CIDRs that skip the maintenance page (admins)
+	PageFile       string   `json:"pageFile,omitempty"`       // static HTML served instead of the built-in page, a file name in SentinelMaintenancePagesDir
+	PageSubstitute bool     `json:"pageSubstitute,omitempty"` // replace {CODE}, {TITLE}, {MESSAGE} in PageFile
+	// Global is set while global maintenance is on and holds what it overwrote
+	Global *MaintenanceRestore `json:"global,omitempty"`
+}
+
+// ValidateMaintenancePageFile checks that a maintenance page is a bare file
+// name, so it can't point the plugin outside SentinelMaintenancePagesDir
+func ValidateMaintenancePageFile(name string) error {
+	if name == "" {
+		return nil
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("pageFile must be a file name in %s", SentinelMaintenancePagesDir)
+	}
+	return nil
+}
+
+// MaintenanceRestore is a route's state from before global maintenance,
+// put back when global maintenance is turned off
+type MaintenanceRestore struct {
+	SentinelEnabled    bool     `json:"sentinelEnabled"`
+	MaintenanceEnabled bool     `json:"maintenanceEnabled"`
+	Message            string   `json:"message,omitempty"`
+	Bypass             []string `json:"bypass,omitempty"`
+	PageFile           string   `json:"pageFile,omitempty"`
 }
 
 // DomainRouteConfig represents a domain route for Traefik config generation
@@ -729,10 +1034,141 @@ type DomainRouteConfig struct {
 	FrontendSSL     bool            // use websecure entrypoint with TLS
 	CertResolver    string          // explicit resolver name; empty = auto (wildcard/public/vpn tree)
 	SentinelConfig  *SentinelConfig // per-domain sentinel middleware config
+	Backends        []Backend       // additional servers load-balanced with TargetIP:TargetPort
+	TLSMinVersion   string          // "1.2", "1.3", ...; empty = Traefik default
+	TLSServerName   string          // certificate domain requested instead of Domain (non-wildcard routes)
+	AccessLog       *AccessLogConfig // per-route access logging; nil = logged with the default fields
+	RateLimit       *RateLimitConfig // dedicated rate-limit middleware; nil = only the route's Middlewares
+}
+
+// RateLimitConfig is a route's own Traefik rate limit, applied on top of any
+// global rate-limit/rate-limit-strict middleware the route lists
+type RateLimitConfig struct {
+	Average int    `json:"average"`          // requests per Period, per client IP
+	Burst   int    `json:"burst"`            // requests allowed above Average in a spike
+	Period  string `json:"period,omitempty"` // Go duration ("1s", "1m"); empty = 1s
+}
+
+// ValidateRateLimitConfig checks a route rate limit and normalizes its period
+func ValidateRateLimitConfig(cfg *RateLimitConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Average < 1 || cfg.Average > 100000 {
+		return fmt.Errorf("rate limit average must be between 1 and 100000")
+	}
+	if cfg.Burst < 1 || cfg.Burst > 100000 {
+		return fmt.Errorf("rate limit burst must be between 1 and 100000")
+	}
+	cfg.Period = strings.TrimSpace(cfg.Period)
+	if cfg.Period == "" {
+		return nil
+	}
+	period, err := time.ParseDuration(cfg.Period)
+	if err != nil {
+		return fmt.Errorf("invalid rate limit period %q", cfg.Period)
+	}
+	if period < time.Second || period > time.Hour {
+		return fmt.Errorf("rate limit period must be between 1s and 1h")
+	}
+	cfg.Period = period.String()
+	return nil
+}
+
+// AccessLogConfig is a route's access logging override. Traefik can only turn
+// access logs on or off per router (field selection is global), so Fields are
+// picked out of the access log by the panel's log watcher.
+type AccessLogConfig struct {
+	Enabled *bool    `json:"enabled,omitempty"` // nil = logged, so a fields-only override keeps logging
+	Fields  []string `json:"fields,omitempty"`  // extra Traefik fields stored with this route's logs
+}
+
+// Logging reports whether the route's requests are written to the access log
+func (c *AccessLogConfig) Logging() bool {
+	return c == nil || c.Enabled == nil || *c.Enabled
+}
+
+// AccessLogFields are the fields of Traefik's JSON access log
+var AccessLogFields = map[string]bool{
+	"StartUTC": true, "StartLocal": true, "Duration": true, "entryPointName": true,
+	"RouterName": true, "ServiceName": true, "ServiceURL": true, "ServiceAddr": true,
+	"ClientAddr": true, "ClientHost": true, "ClientPort": true, "ClientUsername": true,
+	"RequestAddr": true, "RequestHost": true, "RequestPort": true, "RequestMethod": true,
+	"RequestPath": true, "RequestProtocol": true, "RequestScheme": true, "RequestLine": true,
+	"RequestContentSize": true, "RequestCount": true,
+	"OriginDuration": true, "OriginContentSize": true, "OriginStatus": true, "OriginStatusLine": true,
+	"DownstreamStatus": true, "DownstreamStatusLine": true, "DownstreamContentSize": true,
+	"GzipRatio": true, "Overhead": true, "RetryAttempts": true,
+	"TLSVersion": true, "TLSCipher": true, "TLSClientSubject": true, "TraceId": true, "SpanId": true,
+}
+
+// ValidateAccessLogConfig checks field names against AccessLogFields and dedupes them
+func ValidateAccessLogConfig(cfg *AccessLogConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	fields := []string{}
+	for _, f := range cfg.Fields {
+		f = strings.TrimSpace(f)
+		if !AccessLogFields[f] {
+			return fmt.Errorf("unknown access log field %q", f)
+		}
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, f)
+		}
+	}
+	cfg.Fields = fields
+	return nil
+}
+
+// TLSMinVersions maps the accepted per-route minimum TLS versions to Traefik's names
+var TLSMinVersions = map[string]string{
+	"1.0": "VersionTLS10",
+	"1.1": "VersionTLS11",
+	"1.2": "VersionTLS12",
+	"1.3": "VersionTLS13",
+}
+
+// tlsOptionsName is the generated TLS options entry enforcing a minimum version
+func tlsOptionsName(minVersion string) string {
+	return "tls-min-" + strings.ReplaceAll(minVersion, ".", "")
+}
+
+// Backend is an additional server of a domain route's load balancer
+type Backend struct {
+	TargetIP   string `json:"targetIp"`
+	TargetPort int    `json:"targetPort"`
+	Weight     int    `json:"weight,omitempty"` // relative share of requests; 0 = 1
+}
+
+// writeRouterObservability disables the router's access logs when the route opts out
+func writeRouterObservability(sb *strings.Builder, cfg *AccessLogConfig) {
+	if cfg.Logging() {
+		return
+	}
+	sb.WriteString("      observability:\n")
+	sb.WriteString("        accessLogs: false\n")
 }
 
 // GenerateDomainRoutes writes domain routes to Traefik's dynamic config directory
 func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
+	// Write to domains.yml
+	configPath := configDir + "/" + DomainsConfigFile
+	if err := os.WriteFile(configPath, []byte(BuildDomainRoutes(routes)), 0644); err != nil {
+		return fmt.Errorf("failed to write config: %v", err)
+	}
+
+	log.Printf("Generated Traefik domain routes config with %d routes", len(routes))
+	return nil
+}
+
+// DomainsConfigFile is the generated dynamic config file holding the domain routes
+const DomainsConfigFile = "domains.yml"
+
+// BuildDomainRoutes renders the domains.yml dynamic config for the routes
+func BuildDomainRoutes(routes []DomainRouteConfig) string {
 	var sb strings.Builder
 
 	sb.WriteString("# Domain Routes - Auto-generated, do not edit manually\n")
@@ -744,11 +1180,18 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 		sb.WriteString("http:\n")
 		sb.WriteString("  routers:\n")
 
+		// Minimum TLS versions used by routes, emitted as TLS options below
+		tlsMinVersions := make(map[string]bool)
+
 		// Track routes that need per-domain middlewares
 		var sentinelMiddlewares []struct {
 			name   string
 			config *SentinelConfig
 		}
+		var rateLimitMiddlewares []struct {
+			name   string
+			config *RateLimitConfig
+		}
 
 		for _, route := range routes {
 			name := helper.SanitizeDomainName(route.Domain)
@@ -757,6 +1200,16 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 			middlewares := make([]string, len(route.Middlewares))
 			copy(middlewares, route.Middlewares)
 
+			// Per-route rate limit runs before the listed middlewares, after sentinel
+			if route.RateLimit != nil {
+				mwName := fmt.Sprintf("ratelimit_domain-%s", name)
+				middlewares = append([]string{mwName}, middlewares...) // prepend
+				rateLimitMiddlewares = append(rateLimitMiddlewares, struct {
+					name   string
+					config *RateLimitConfig
+				}{mwName, route.RateLimit})
+			}
+
 			// Add per-domain sentinel config middleware if configured and enabled
 			if route.SentinelConfig != nil && route.SentinelConfig.Enabled {
 				mwName := fmt.Sprintf("sentinel_domain-%s", name)
@@ -794,6 +1247,7 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 					sb.WriteString(fmt.Sprintf("        - %s\n", mw))
 				}
 			}
+			writeRouterObservability(&sb, route.AccessLog)
 			sb.WriteString("\n")
 
 			// HTTPS router (websecure entrypoint) - only if FrontendSSL is enabled
@@ -810,13 +1264,19 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 						sb.WriteString(fmt.Sprintf("        - %s\n", mw))
 					}
 				}
+				writeRouterObservability(&sb, route.AccessLog)
 				// TLS configuration
+				// SNI override replaces the certificate domain of non-wildcard routes
+				certDomain := route.Domain
+				if route.TLSServerName != "" {
+					certDomain = route.TLSServerName
+				}
 				// Explicit user-selected resolver wins over the auto tree below.
 				if route.CertResolver != "" {
 					sb.WriteString("      tls:\n")
 					sb.WriteString(fmt.Sprintf("        certResolver: %s\n", route.CertResolver))
 					sb.WriteString("        domains:\n")
-					sb.WriteString(fmt.Sprintf("          - main: \"%s\"\n", route.Domain))
+					sb.WriteString(fmt.Sprintf("          - main: \"%s\"\n", certDomain))
 				} else if isWildcard {
 					// Wildcard: use DNS-01 resolver. Cover BOTH the apex and the wildcard so
 					// e.g. `example.com` + `*.example.com` end up in one Let's Encrypt cert.
@@ -831,11 +1291,22 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 					sb.WriteString("      tls:\n")
 					sb.WriteString("        certResolver: letsencrypt\n")
 					sb.WriteString("        domains:\n")
-					sb.WriteString(fmt.Sprintf("          - main: \"%s\"\n", route.Domain))
+					sb.WriteString(fmt.Sprintf("          - main: \"%s\"\n", certDomain))
+				} else if route.TLSServerName != "" || route.TLSMinVersion != "" {
+					// VPN mode with TLS settings: still the default/self-signed cert
+					sb.WriteString("      tls:\n")
+					if route.TLSServerName != "" {
+						sb.WriteString("        domains:\n")
+						sb.WriteString(fmt.Sprintf("          - main: \"%s\"\n", certDomain))
+					}
 				} else {
 					// VPN mode: use default/self-signed cert
 					sb.WriteString("      tls: {}\n")
 				}
+				if route.TLSMinVersion != "" {
+					sb.WriteString(fmt.Sprintf("        options: %s\n", tlsOptionsName(route.TLSMinVersion)))
+					tlsMinVersions[route.TLSMinVersion] = true
+				}
 				sb.WriteString("\n")
 			}
 		}
@@ -855,7 +1326,24 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 				hasSkipCertVerify = true
 			}
 			sb.WriteString("        servers:\n")
-			sb.WriteString(fmt.Sprintf("          - url: \"%s://%s:%d\"\n", protocol, route.TargetIP, route.TargetPort))
+			// Weights are only written when set, so single-backend output is unchanged
+			weighted := false
+			for _, b := range route.Backends {
+				if b.Weight > 0 {
+					weighted = true
+				}
+			}
+			servers := append([]Backend{{TargetIP: route.TargetIP, TargetPort: route.TargetPort}}, route.Backends...)
+			for _, b := range servers {
+				sb.WriteString(fmt.Sprintf("          - url: \"%s://%s:%d\"\n", protocol, b.TargetIP, b.TargetPort))
+				if weighted {
+					weight := b.Weight
+					if weight <= 0 {
+						weight = 1
+					}
+					sb.WriteString(fmt.Sprintf("            weight: %d\n", weight))
+				}
+			}
 			sb.WriteString("\n")
 		}
 
@@ -866,33 +1354,122 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 			sb.WriteString("\n")
 		}
 
+		if len(sentinelMiddlewares) > 0 || len(rateLimitMiddlewares) > 0 {
+			sb.WriteString("  middlewares:\n")
+		}
+
+		// Generate per-domain rate limit middlewares
+		for _, mw := range rateLimitMiddlewares {
+			sb.WriteString(fmt.Sprintf("    %s:\n", mw.name))
+			sb.WriteString("      rateLimit:\n")
+			sb.WriteString(fmt.Sprintf("        average: %d\n", mw.config.Average))
+			sb.WriteString(fmt.Sprintf("        burst: %d\n", mw.config.Burst))
+			if mw.config.Period != "" {
+				sb.WriteString(fmt.Sprintf("        period: %s\n", mw.config.Period))
+			}
+			sb.WriteString("\n")
+		}
+
 		// Generate per-domain sentinel middlewares
 		if len(sentinelMiddlewares) > 0 {
-			sb.WriteString("  middlewares:\n")
 			for _, mw := range sentinelMiddlewares {
 				sb.WriteString(fmt.Sprintf("    %s:\n", mw.name))
 				sb.WriteString("      plugin:\n")
 				sb.WriteString("        sentinel:\n")
 
 				// IP Filter
-				if len(mw.config.IPFilter.SourceRange) > 0 {
+				if len(mw.config.IPFilter.SourceRange) > 0 || len(mw.config.IPFilter.AllowASN) > 0 {
 					sb.WriteString("          ipFilter:\n")
-					sb.WriteString("            sourceRange:\n")
-					for _, ip := range mw.config.IPFilter.SourceRange {
-						sb.WriteString(fmt.Sprintf("              - \"%s\"\n", escapeYAMLString(ip)))
+					if len(mw.config.IPFilter.SourceRange) > 0 {
+						sb.WriteString("            sourceRange:\n")
+						for _, ip := range mw.config.IPFilter.SourceRange {
+							sb.WriteString(fmt.Sprintf("              - \"%s\"\n", escapeYAMLString(ip)))
+						}
+					}
+					if len(mw.config.IPFilter.AllowASN) > 0 {
+						sb.WriteString("            allowAsn:\n")
+						for _, asn := range mw.config.IPFilter.AllowASN {
+							sb.WriteString(fmt.Sprintf("              - \"%s\"\n", escapeYAMLString(asn)))
+						}
+						sb.WriteString(fmt.Sprintf("            asnFile: \"%s\"\n", SentinelASNFile))
 					}
 				}
 
 				// Error Mode (whitelist valid values)
 				errorMode := mw.config.ErrorMode
 				switch errorMode {
-				case "403", "404", "503", "silent":
+				case "403", "404", "503", "silent", "redirect", "static":
 					// valid
 				default:
 					errorMode = "403"
 				}
 				sb.WriteString(fmt.Sprintf("          errorMode: \"%s\"\n", errorMode))
 
+				// Redirect target / static response
+				switch errorMode {
+				case "redirect":
+					sb.WriteString(fmt.Sprintf("          redirectUrl: \"%s\"\n", escapeYAMLString(mw.config.RedirectURL)))
+					if mw.config.RedirectCode > 0 {
+						sb.WriteString(fmt.Sprintf("          redirectCode: %d\n", mw.config.RedirectCode))
+					}
+				case "static":
+					sb.WriteString(fmt.Sprintf("          staticBody: \"%s\"\n", escapeYAMLString(mw.config.StaticBody)))
+					if mw.config.StaticContentType != "" {
+						sb.WriteString(fmt.Sprintf("          staticContentType: \"%s\"\n", escapeYAMLString(mw.config.StaticContentType)))
+					}
+				}
+
+				// Drop Mode (only meaningful for silent)
+				if errorMode == "silent" {
+					switch mw.config.DropMode {
+					case "close", "tarpit":
+						sb.WriteString(fmt.Sprintf("          dropMode: \"%s\"\n", mw.config.DropMode))
+						if mw.config.DropMode == "tarpit" && mw.config.TarpitSeconds > 0 {
+							sb.WriteString(fmt.Sprintf("          tarpitSeconds: %d\n", mw.config.TarpitSeconds))
+						}
+					}
+				}
+
+				// Metrics (always on so the panel can show per-route stats)
+				sb.WriteString("          metrics:\n")
+				sb.WriteString("            enabled: true\n")
+				sb.WriteString(fmt.Sprintf("            file: \"%s\"\n", SentinelMetricsFile))
+
+				// Slowlist
+				if mw.config.Slowlist != nil && mw.config.Slowlist.Enabled {
+					sb.WriteString("          slowlist:\n")
+					sb.WriteString("            enabled: true\n")
+					sb.WriteString(fmt.Sprintf("            file: \"%s\"\n", SentinelSlowlistFile))
+					if mw.config.Slowlist.DelayMs > 0 {
+						sb.WriteString(fmt.Sprintf("            delayMs: %d\n", mw.config.Slowlist.DelayMs))
+					}
+				}
+
+				// Violation threshold
+				if vt := mw.config.ViolationThreshold; vt != nil && vt.Enabled {
+					sb.WriteString("          violationThreshold:\n")
+					sb.WriteString("            enabled: true\n")
+					sb.WriteString(fmt.Sprintf("            file: \"%s\"\n", SentinelBanFeedFile))
+					if vt.Count > 0 {
+						sb.WriteString(fmt.Sprintf("            count: %d\n", vt.Count))
+					}
+					if vt.Window > 0 {
+						sb.WriteString(fmt.Sprintf("            window: %d\n", vt.Window))
+					}
+					if len(vt.Reasons) > 0 {
+						sb.WriteString("            reasons:\n")
+						for _, reason := range vt.Reasons {
+							sb.WriteString(fmt.Sprintf("              - \"%s\"\n", escapeYAMLString(reason)))
+						}
+					}
+					if len(vt.TrustedProxies) > 0 {
+						sb.WriteString("            trustedProxies:\n")
+						for _, cidr := range vt.TrustedProxies {
+							sb.WriteString(fmt.Sprintf("              - \"%s\"\n", escapeYAMLString(cidr)))
+						}
+					}
+				}
+
 				// Maintenance Mode
 				if mw.config.Maintenance != nil && mw.config.Maintenance.Enabled {
 					sb.WriteString("          maintenance:\n")
@@ -900,6 +1477,18 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 					if mw.config.Maintenance.Message != "" {
 						sb.WriteString(fmt.Sprintf("            message: \"%s\"\n", escapeYAMLString(mw.config.Maintenance.Message)))
 					}
+					if len(mw.config.Maintenance.Bypass) > 0 {
+						sb.WriteString("            bypass:\n")
+						for _, ip := range mw.config.Maintenance.Bypass {
+							sb.WriteString(fmt.Sprintf("              - \"%s\"\n", escapeYAMLString(ip)))
+						}
+					}
+					if mw.config.Maintenance.PageFile != "" && ValidateMaintenancePageFile(mw.config.Maintenance.PageFile) == nil {
+						sb.WriteString(fmt.Sprintf("            pageFile: \"%s\"\n", escapeYAMLString(mw.config.Maintenance.PageFile)))
+						if mw.config.Maintenance.PageSubstitute {
+							sb.WriteString("            pageSubstitute: true\n")
+						}
+					}
 				}
 
 				// Time Access
@@ -974,19 +1563,49 @@ func GenerateDomainRoutes(configDir string, routes []DomainRouteConfig) error {
 					}
 				}
 
+				// JA3 fingerprints
+				if ja3 := mw.config.JA3Filter; ja3 != nil && ja3.Enabled && (len(ja3.Block) > 0 || ja3.ListURL != "") {
+					sb.WriteString("          ja3Filter:\n")
+					sb.WriteString("            enabled: true\n")
+					if ja3.Header != "" {
+						sb.WriteString(fmt.Sprintf("            header: \"%s\"\n", escapeYAMLString(ja3.Header)))
+					}
+					if len(ja3.Block) > 0 {
+						sb.WriteString("            block:\n")
+						for _, hash := range ja3.Block {
+							sb.WriteString(fmt.Sprintf("              - \"%s\"\n", escapeYAMLString(hash)))
+						}
+					}
+					if ja3.ListURL != "" {
+						sb.WriteString(fmt.Sprintf("            listUrl: \"%s\"\n", escapeYAMLString(ja3.ListURL)))
+						if ja3.CacheTTL > 0 {
+							sb.WriteString(fmt.Sprintf("            cacheTTL: %d\n", ja3.CacheTTL))
+						}
+					}
+				}
+
 				sb.WriteString("\n")
 			}
 		}
-	}
 
-	// Write to domains.yml
-	configPath := configDir + "/domains.yml"
-	if err := os.WriteFile(configPath, []byte(sb.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write config: %v", err)
+		// TLS options referenced by routes with a minimum version
+		if len(tlsMinVersions) > 0 {
+			versions := make([]string, 0, len(tlsMinVersions))
+			for v := range tlsMinVersions {
+				versions = append(versions, v)
+			}
+			sort.Strings(versions)
+			sb.WriteString("tls:\n")
+			sb.WriteString("  options:\n")
+			for _, v := range versions {
+				sb.WriteString(fmt.Sprintf("    %s:\n", tlsOptionsName(v)))
+				sb.WriteString(fmt.Sprintf("      minVersion: %s\n", TLSMinVersions[v]))
+			}
+			sb.WriteString("\n")
+		}
 	}
 
-	log.Printf("Generated Traefik domain routes config with %d routes", len(routes))
-	return nil
+	return sb.String()
 }
 
 // CertificateInfo holds certificate data parsed from acme.json
@@ -0,0 +1,56 @@
+package traefik
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogOverride(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string // access_log column; "" = no override
+		want bool
+	}{
+		{"no override", "", true},
+		{"fields only", `{"fields":["RequestHost"]}`, true},
+		{"enabled", `{"enabled":true}`, true},
+		{"disabled", `{"enabled":false,"fields":["RequestHost"]}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg *AccessLogConfig
+			if tt.raw != "" {
+				cfg = &AccessLogConfig{}
+				if err := json.Unmarshal([]byte(tt.raw), cfg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := cfg.Logging(); got != tt.want {
+				t.Errorf("Logging() = %v, want %v", got, tt.want)
+			}
+
+			var sb strings.Builder
+			writeRouterObservability(&sb, cfg)
+			if off := strings.Contains(sb.String(), "accessLogs: false"); off == tt.want {
+				t.Errorf("router observability = %q, want logging %v", sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMaintenancePageFile(t *testing.T) {
+	for name, ok := range map[string]bool{
+		"":                       true,
+		"maintenance.html":       true,
+		"..":                     false,
+		"../acme.json":           false,
+		"/etc/traefik/acme.json": false,
+		"pages/maintenance.html": false,
+		`..\acme.json`:           false,
+	} {
+		if err := ValidateMaintenancePageFile(name); (err == nil) != ok {
+			t.Errorf("ValidateMaintenancePageFile(%q) = %v, want ok=%v", name, err, ok)
+		}
+	}
+}
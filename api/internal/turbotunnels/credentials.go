@@ -103,7 +103,10 @@ func expandCred(s string, creds Credentials) string {
 // baked default) and reports each proxy endpoint with its real credentials.
 // Generated creds only appear where the config uses ${PROXY_USER}/${PROXY_PASS}.
 func tunnelList(creds Credentials) []TunnelInfo {
-	host := helper.GetEnvOptional("SERVER_IP", "<server-ip>")
+	host := helper.ServerIP()
+	if host == "" {
+		host = "<server-ip>"
+	}
 
 	raw := strings.TrimSpace(helper.GetEnvOptional("TUNNELS_JSON", ""))
 	if raw == "" {
@@ -0,0 +1,304 @@
+package vpn
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api/internal/database"
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/scheduler"
+)
+
+// aclElevationJob is the scheduler job name for reverting expired elevations
+const aclElevationJob = "vpn-acl-elevation-revert"
+
+// maxElevationMinutes caps a temporary elevation at one week
+const maxElevationMinutes = 7 * 24 * 60
+
+// aclElevationSnapshot is what a client had before it was elevated to
+// allow_all, stored as JSON in vpn_clients.acl_elevation. Only its outbound
+// rules are kept: allow_all deletes those, while rules targeting it survive.
+type aclElevationSnapshot struct {
+	PreviousPolicy string           `json:"previousPolicy"`
+	Rules          []aclRuleSummary `json:"rules"`
+}
+
+type aclRuleSummary struct {
+	TargetID      int  `json:"targetId"`
+	Bidirectional bool `json:"bidirectional"`
+}
+
+// ACLElevation describes a client temporarily running with allow_all
+type ACLElevation struct {
+	ClientID       int              `json:"clientId"`
+	Name           string           `json:"name"`
+	PreviousPolicy string           `json:"previousPolicy"`
+	Rules          []aclRuleSummary `json:"rules"`
+	ExpiresAt      time.Time        `json:"expiresAt"`
+}
+
+// scheduleACLElevationRevert checks for lapsed elevations every minute
+func (s *Service) scheduleACLElevationRevert() {
+	scheduler.Every(aclElevationJob, "Revert temporary VPN client allow_all elevations", time.Minute,
+		func(ctx context.Context) error {
+			return s.revertExpiredElevations()
+		})
+}
+
+// elevationClientID extracts the client ID from /api/vpn/clients/{id}/acl/elevate
+func elevationClientID(r *http.Request) (int, error) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/vpn/clients/")
+	return strconv.Atoi(strings.Split(path, "/")[0])
+}
+
+// handleTempElevateACL switches a client to allow_all for a number of minutes,
+// recording its policy and outbound rules so they're restored on expiry.
+// Elevating an elevated client only moves the expiry.
+func (s *Service) handleTempElevateACL(w http.ResponseWriter, r *http.Request) {
+	clientID, err := elevationClientID(r)
+	if err != nil {
+		router.JSONError(w, "invalid client ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Minutes int `json:"minutes"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if req.Minutes < 1 || req.Minutes > maxElevationMinutes {
+		router.JSONError(w, fmt.Sprintf("minutes must be between 1 and %d", maxElevationMinutes), http.StatusBadRequest)
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var name, policy, raw string
+	err = tx.QueryRow(`SELECT name, acl_policy, COALESCE(acl_elevation, '') FROM vpn_clients WHERE id = ?`, clientID).
+		Scan(&name, &policy, &raw)
+	if err == sql.ErrNoRows {
+		router.JSONError(w, "client not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var snap aclElevationSnapshot
+	if raw != "" {
+		// Already elevated: keep the original snapshot
+		if err := json.Unmarshal([]byte(raw), &snap); err != nil {
+			router.JSONError(w, "corrupt elevation record: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		if policy == helper.ACLPolicyAllowAll {
+			router.JSONError(w, "client already has allow_all", http.StatusConflict)
+			return
+		}
+		snap = aclElevationSnapshot{PreviousPolicy: policy, Rules: []aclRuleSummary{}}
+		rows, err := tx.Query(`SELECT target_client_id, COALESCE(bidirectional, 0) FROM vpn_acl_rules WHERE source_client_id = ?`, clientID)
+		if err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for rows.Next() {
+			var rule aclRuleSummary
+			if rows.Scan(&rule.TargetID, &rule.Bidirectional) == nil {
+				snap.Rules = append(snap.Rules, rule)
+			}
+		}
+		rows.Close()
+	}
+
+	// setClientPolicy clears any elevation record, so write it afterwards
+	if err := setClientPolicy(tx, clientID, helper.ACLPolicyAllowAll); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, _ := json.Marshal(snap)
+	expiresAt := time.Now().UTC().Add(time.Duration(req.Minutes) * time.Minute)
+	if _, err := tx.Exec(`UPDATE vpn_clients SET acl_elevation = ?, acl_elevated_until = ? WHERE id = ?`,
+		string(data), expiresAt.Format("2006-01-02 15:04:05"), clientID); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("VPN client %s elevated to allow_all until %s (was %s)", name, expiresAt.Format(time.RFC3339), snap.PreviousPolicy)
+	s.RequestApply()
+	router.JSON(w, ACLElevation{
+		ClientID:       clientID,
+		Name:           name,
+		PreviousPolicy: snap.PreviousPolicy,
+		Rules:          snap.Rules,
+		ExpiresAt:      expiresAt,
+	})
+}
+
+// handleRevertACLElevation ends a client's elevation early
+func (s *Service) handleRevertACLElevation(w http.ResponseWriter, r *http.Request) {
+	clientID, err := elevationClientID(r)
+	if err != nil {
+		router.JSONError(w, "invalid client ID", http.StatusBadRequest)
+		return
+	}
+	restored, err := s.revertElevation(clientID)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if restored == "" {
+		router.JSONError(w, "client is not elevated", http.StatusNotFound)
+		return
+	}
+	s.RequestApply()
+	router.JSON(w, map[string]interface{}{
+		"clientId": clientID,
+		"policy":   restored,
+	})
+}
+
+// handleGetACLElevations lists clients currently elevated to allow_all
+func (s *Service) handleGetACLElevations(w http.ResponseWriter, r *http.Request) {
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rows, err := db.Query(`SELECT id, name, acl_elevation, acl_elevated_until FROM vpn_clients
+		WHERE COALESCE(acl_elevation, '') != '' ORDER BY acl_elevated_until`)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	elevations := []ACLElevation{}
+	for rows.Next() {
+		var e ACLElevation
+		var raw string
+		if rows.Scan(&e.ClientID, &e.Name, &raw, &e.ExpiresAt) != nil {
+			continue
+		}
+		var snap aclElevationSnapshot
+		if json.Unmarshal([]byte(raw), &snap) == nil {
+			e.PreviousPolicy, e.Rules = snap.PreviousPolicy, snap.Rules
+		}
+		elevations = append(elevations, e)
+	}
+	router.JSON(w, elevations)
+}
+
+// revertExpiredElevations restores every client whose elevation has lapsed
+// and queues one apply
+func (s *Service) revertExpiredElevations() error {
+	db, err := database.GetDB()
+	if err != nil {
+		return err
+	}
+	rows, err := db.Query(`SELECT id FROM vpn_clients
+		WHERE COALESCE(acl_elevation, '') != '' AND acl_elevated_until <= datetime('now')`)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	reverted := 0
+	for _, id := range ids {
+		policy, err := s.revertElevation(id)
+		if err != nil {
+			log.Printf("VPN client %d: failed to revert ACL elevation: %v", id, err)
+			continue
+		}
+		if policy != "" {
+			log.Printf("VPN client %d: ACL elevation expired, restored %s", id, policy)
+			reverted++
+		}
+	}
+	if reverted > 0 {
+		s.RequestApply()
+	}
+	return nil
+}
+
+// revertElevation restores a client's recorded policy and outbound rules.
+// Rules to clients deleted in the meantime are dropped. Returns the restored
+// policy, or "" when the client isn't elevated.
+func (s *Service) revertElevation(clientID int) (string, error) {
+	db, err := database.GetDB()
+	if err != nil {
+		return "", err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var raw string
+	err = tx.QueryRow(`SELECT COALESCE(acl_elevation, '') FROM vpn_clients WHERE id = ?`, clientID).Scan(&raw)
+	if err == sql.ErrNoRows || raw == "" {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	var snap aclElevationSnapshot
+	if err := json.Unmarshal([]byte(raw), &snap); err != nil || !helper.IsValidACLPolicy(snap.PreviousPolicy) {
+		// Unreadable record: fall back to the safest policy rather than staying open
+		snap = aclElevationSnapshot{PreviousPolicy: helper.ACLPolicyBlockAll}
+	}
+
+	if err := setClientPolicy(tx, clientID, snap.PreviousPolicy); err != nil {
+		return "", err
+	}
+	if snap.PreviousPolicy == helper.ACLPolicySelected {
+		for _, rule := range snap.Rules {
+			// The target may have allowed this client while it was elevated; the
+			// pair keeps one row, which now has to work both ways
+			res, err := tx.Exec(`UPDATE vpn_acl_rules SET bidirectional = 1
+				WHERE source_client_id = ? AND target_client_id = ?`, rule.TargetID, clientID)
+			if err != nil {
+				return "", err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT INTO vpn_acl_rules (source_client_id, target_client_id, bidirectional)
+				SELECT ?, id, ? FROM vpn_clients WHERE id = ?
+				ON CONFLICT(source_client_id, target_client_id) DO UPDATE SET
+				bidirectional = MAX(bidirectional, excluded.bidirectional)`, clientID, rule.Bidirectional, rule.TargetID); err != nil {
+				return "", err
+			}
+		}
+	}
+	return snap.PreviousPolicy, tx.Commit()
+}
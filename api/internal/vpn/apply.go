@@ -0,0 +1,128 @@
+package vpn
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"api/internal/router"
+)
+
+// aclApplyDelay is how long RequestApply waits for further ACL changes
+const aclApplyDelay = time.Second
+
+// applyState coalesces ACL applies (nftables + Headscale ACL push)
+type applyState struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	queued  int        // requests waiting for the next run
+	runMu   sync.Mutex // serializes runs
+	running bool
+
+	lastAt        time.Time
+	lastErr       error
+	lastCoalesced int
+	runs          int
+}
+
+// ApplyStatus reports the debounced apply state
+type ApplyStatus struct {
+	Pending        bool       `json:"pending"`
+	QueuedRequests int        `json:"queuedRequests"`
+	Running        bool       `json:"running"`
+	LastApplyAt    *time.Time `json:"lastApplyAt,omitempty"`
+	LastApplyError string     `json:"lastApplyError,omitempty"`
+	LastCoalesced  int        `json:"lastCoalesced"` // requests served by the last run
+	Runs           int        `json:"runs"`          // applies since startup
+}
+
+// RequestApply schedules a debounced ApplyRules; changes arriving within
+// aclApplyDelay of each other result in a single apply
+func (s *Service) RequestApply() {
+	s.apply.mu.Lock()
+	defer s.apply.mu.Unlock()
+
+	s.apply.queued++
+	if s.apply.timer != nil {
+		s.apply.timer.Stop()
+	}
+	s.apply.timer = time.AfterFunc(aclApplyDelay, func() {
+		if err := s.runApply(false); err != nil {
+			log.Printf("vpn: debounced apply failed: %v", err)
+		}
+	})
+}
+
+// ApplyNow runs ApplyRules immediately, absorbing any queued request.
+// Returns the number of queued requests the run covered.
+func (s *Service) ApplyNow() (int, error) {
+	s.apply.mu.Lock()
+	if s.apply.timer != nil {
+		s.apply.timer.Stop()
+		s.apply.timer = nil
+	}
+	coalesced := s.apply.queued
+	s.apply.mu.Unlock()
+
+	return coalesced, s.runApply(true)
+}
+
+// runApply takes the queued requests and applies once. Without force it is a
+// no-op when an ApplyNow already served the queue while it waited for runMu.
+func (s *Service) runApply(force bool) error {
+	s.apply.runMu.Lock()
+	defer s.apply.runMu.Unlock()
+
+	s.apply.mu.Lock()
+	coalesced := s.apply.queued
+	if !force && coalesced == 0 {
+		s.apply.mu.Unlock()
+		return nil
+	}
+	s.apply.queued = 0
+	s.apply.timer = nil
+	s.apply.running = true
+	s.apply.mu.Unlock()
+
+	err := s.ApplyRules()
+
+	s.apply.mu.Lock()
+	s.apply.running = false
+	s.apply.lastAt = time.Now()
+	s.apply.lastErr = err
+	s.apply.lastCoalesced = coalesced
+	s.apply.runs++
+	s.apply.mu.Unlock()
+
+	if err == nil && coalesced > 1 {
+		log.Printf("vpn: applied ACL rules (%d changes coalesced)", coalesced)
+	}
+	return err
+}
+
+// GetApplyStatus returns the debounced apply state
+func (s *Service) GetApplyStatus() ApplyStatus {
+	s.apply.mu.Lock()
+	defer s.apply.mu.Unlock()
+
+	status := ApplyStatus{
+		Pending:        s.apply.timer != nil,
+		QueuedRequests: s.apply.queued,
+		Running:        s.apply.running,
+		LastCoalesced:  s.apply.lastCoalesced,
+		Runs:           s.apply.runs,
+	}
+	if !s.apply.lastAt.IsZero() {
+		last := s.apply.lastAt
+		status.LastApplyAt = &last
+	}
+	if s.apply.lastErr != nil {
+		status.LastApplyError = s.apply.lastErr.Error()
+	}
+	return status
+}
+
+func (s *Service) handleGetApplyStatus(w http.ResponseWriter, r *http.Request) {
+	router.JSON(w, s.GetApplyStatus())
+}
@@ -0,0 +1,172 @@
+package vpn
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"api/internal/database"
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/traefik"
+)
+
+// Domain reachability states
+const (
+	ReachReachable   = "reachable"   // every check passes for the client's IP
+	ReachConditional = "conditional" // depends on request details (headers, user-agent, time) or client DNS
+	ReachBlocked     = "blocked"
+)
+
+// ClientReachableDomain is a domain route and whether the client can open it
+type ClientReachableDomain struct {
+	ID         int      `json:"id"`
+	Domain     string   `json:"domain"`
+	AccessMode string   `json:"accessMode"`
+	URL        string   `json:"url"`
+	Status     string   `json:"status"`
+	Reasons    []string `json:"reasons"`
+}
+
+// clientDomainReach resolves which domain routes a client can open through
+// Traefik: the route must be enabled, the client not isolated by the VPN ACL
+// (Traefik is reached through the forward chain), and its IP must pass the
+// sentinel_vpn allowlist and the route's own sentinel config.
+func clientDomainReach(db *database.DB, clientIP, policy, dnsServer string) ([]ClientReachableDomain, error) {
+	rows, err := db.Query(`
+		SELECT id, domain, enabled, COALESCE(access_mode, 'vpn'), frontend_ssl,
+		       COALESCE(middlewares, '[]'), COALESCE(sentinel_config, '')
+		FROM domain_routes
+		ORDER BY domain
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Global VPN allowlist used by the sentinel_vpn@file middlewares
+	allowlist, allowlistKnown := traefik.VPNAllowlist()
+
+	result := []ClientReachableDomain{}
+	for rows.Next() {
+		var d ClientReachableDomain
+		var enabled bool
+		var frontendSSL sql.NullBool
+		var middlewaresJSON, sentinelJSON string
+		if rows.Scan(&d.ID, &d.Domain, &enabled, &d.AccessMode, &frontendSSL, &middlewaresJSON, &sentinelJSON) != nil {
+			continue
+		}
+		scheme := "http"
+		if frontendSSL.Valid && frontendSSL.Bool {
+			scheme = "https"
+		}
+		d.URL = scheme + "://" + strings.TrimPrefix(d.Domain, "*.")
+		d.Reasons = []string{}
+
+		blocked := func(reason string) { d.Status, d.Reasons = ReachBlocked, append(d.Reasons, reason) }
+		conditional := func(reason string) {
+			if d.Status != ReachBlocked {
+				d.Status = ReachConditional
+			}
+			d.Reasons = append(d.Reasons, reason)
+		}
+		d.Status = ReachReachable
+
+		if !enabled {
+			blocked("route is disabled")
+		}
+		if policy == helper.ACLPolicyBlockAll {
+			blocked("client is isolated (block_all), its forwarded traffic is dropped")
+		}
+
+		var middlewares []string
+		json.Unmarshal([]byte(middlewaresJSON), &middlewares)
+		for _, mw := range middlewares {
+			if mw != traefik.MiddlewareSentinelVPNFile && mw != traefik.MiddlewareSentinelVPNSilentFile {
+				continue
+			}
+			if !allowlistKnown {
+				conditional(mw + " allowlist could not be read")
+			} else if !traefik.InSourceRange(clientIP, allowlist) {
+				blocked(fmt.Sprintf("%s allowlist does not include %s", mw, clientIP))
+			}
+		}
+
+		if sc := traefik.ParseSentinelConfig(sentinelJSON); sc != nil && sc.Enabled {
+			eval := traefik.EvaluateSentinel(sc, traefik.SentinelTestRequest{ClientIP: clientIP}, traefik.LocalSentinelASNFile())
+			for _, check := range eval.Checks {
+				if check.Status != "fail" {
+					continue
+				}
+				switch check.Check {
+				case traefik.SentinelCheckMaintenance, traefik.SentinelCheckIPFilter:
+					blocked("sentinel: " + check.Detail)
+				default:
+					conditional("sentinel " + check.Check + ": " + check.Detail)
+				}
+			}
+		}
+
+		// VPN mode domains resolve through the panel's AdGuard rewrites
+		if d.AccessMode == "vpn" && dnsServer != "" {
+			conditional("client uses DNS server " + dnsServer + ", which may not resolve VPN-only domains")
+		}
+
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// handleGetClientDomains lists the domain routes a client can open, answering
+// "what internal apps can this device reach?" (?status=reachable to filter)
+func (s *Service) handleGetClientDomains(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/vpn/clients/")
+	id, ok := router.ParseIDOrError(w, strings.Split(path, "/")[0])
+	if !ok {
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var name, ip, policy, dnsServer string
+	err = db.QueryRow(`SELECT name, ip, acl_policy, COALESCE(dns_server, '') FROM vpn_clients WHERE id = ?`, id).
+		Scan(&name, &ip, &policy, &dnsServer)
+	if err == sql.ErrNoRows {
+		router.JSONError(w, "client not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	domains, err := clientDomainReach(db, ip, policy, dnsServer)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	statusFilter := router.QueryParam(r, "status", "")
+	counts := map[string]int{ReachReachable: 0, ReachConditional: 0, ReachBlocked: 0}
+	filtered := []ClientReachableDomain{}
+	for _, d := range domains {
+		counts[d.Status]++
+		if statusFilter == "" || d.Status == statusFilter {
+			filtered = append(filtered, d)
+		}
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"clientId": id,
+		"name":     name,
+		"ip":       ip,
+		"domains":  filtered,
+		"counts":   counts,
+	})
+}
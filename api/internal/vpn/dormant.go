@@ -0,0 +1,236 @@
+package vpn
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"api/internal/database"
+	"api/internal/helper"
+	"api/internal/router"
+	"api/internal/scheduler"
+	"api/internal/settings"
+	"api/internal/wireguard"
+)
+
+// dormantSetting stores the dormant client policy as JSON
+const dormantSetting = "vpn_dormant_policy"
+
+// dormantPruneJob is the scheduler job name for the opt-in auto-prune
+const dormantPruneJob = "vpn-dormant-prune"
+
+// permanentTag exempts a client from dormant pruning
+const permanentTag = "permanent"
+
+// DormantPolicy flags WireGuard peers without a handshake for Days days.
+// AutoPrune removes them daily; otherwise they are only reported.
+type DormantPolicy struct {
+	Days      int  `json:"days"`
+	AutoPrune bool `json:"autoPrune"`
+}
+
+var defaultDormantPolicy = DormantPolicy{Days: 90}
+
+// DormantClient is a WireGuard peer idle for longer than the policy allows
+type DormantClient struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	IP         string     `json:"ip"`
+	ExternalID string     `json:"externalId"`
+	LastSeen   *time.Time `json:"lastSeen,omitempty"` // nil = never connected since it was added
+	IdleDays   int        `json:"idleDays"`
+}
+
+func loadDormantPolicy() DormantPolicy {
+	policy := defaultDormantPolicy
+	raw, err := settings.GetSetting(dormantSetting)
+	if err != nil || raw == "" {
+		return policy
+	}
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		log.Printf("dormant clients: invalid %s setting: %v (using defaults)", dormantSetting, err)
+		return defaultDormantPolicy
+	}
+	if policy.Days <= 0 {
+		policy.Days = defaultDormantPolicy.Days
+	}
+	return policy
+}
+
+// scheduleDormantPrune checks daily; the job is a no-op unless AutoPrune is on
+func (s *Service) scheduleDormantPrune() {
+	scheduler.Every(dormantPruneJob, "Remove WireGuard peers dormant past the configured days", 24*time.Hour,
+		func(ctx context.Context) error {
+			policy := loadDormantPolicy()
+			if !policy.AutoPrune {
+				return nil
+			}
+			candidates, err := s.dormantClients(policy)
+			if err != nil {
+				return err
+			}
+			removed := s.pruneDormant(candidates)
+			if len(removed) > 0 {
+				log.Printf("Dormant clients: removed %d WireGuard peer(s) idle for %d+ days", len(removed), policy.Days)
+			}
+			return nil
+		})
+}
+
+// dormantClients syncs clients and returns the WireGuard peers last seen (or,
+// if never seen, added) more than policy.Days ago. The router node and clients
+// tagged "permanent" are never candidates.
+func (s *Service) dormantClients(policy DormantPolicy) ([]DormantClient, error) {
+	if _, _, err := s.SyncClients(); err != nil {
+		return nil, err
+	}
+	db, err := database.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT id, name, ip, COALESCE(external_id, ''), COALESCE(tags, ''), last_seen_at, created_at
+		FROM vpn_clients
+		WHERE type = 'wireguard'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routerName := helper.GetRouterName()
+	cutoff := time.Now().AddDate(0, 0, -policy.Days)
+	candidates := []DormantClient{}
+	for rows.Next() {
+		var c DormantClient
+		var tags string
+		var lastSeen sql.NullTime
+		var createdAt time.Time
+		if err := rows.Scan(&c.ID, &c.Name, &c.IP, &c.ExternalID, &tags, &lastSeen, &createdAt); err != nil {
+			continue
+		}
+		if c.Name == routerName || c.ExternalID == "" || hasTag(parseTags(tags), permanentTag) {
+			continue
+		}
+		idleSince := createdAt
+		if lastSeen.Valid {
+			c.LastSeen = &lastSeen.Time
+			idleSince = lastSeen.Time
+		}
+		if idleSince.After(cutoff) {
+			continue
+		}
+		c.IdleDays = int(time.Since(idleSince).Hours() / 24)
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// pruneDormant deletes the candidates' WireGuard peers, then re-syncs so their
+// client rows, domain routes and ACL rules go with them
+func (s *Service) pruneDormant(candidates []DormantClient) []DormantClient {
+	wgSvc := wireguard.GetService()
+	if wgSvc == nil || len(candidates) == 0 {
+		return nil
+	}
+	for _, c := range candidates {
+		wgSvc.DeletePeer(c.ExternalID)
+		log.Printf("Dormant clients: removed WireGuard peer %s (%s), idle %d days", c.Name, c.IP, c.IdleDays)
+	}
+	if _, _, err := s.SyncClients(); err != nil {
+		log.Printf("Warning: failed to sync clients after dormant prune: %v", err)
+	}
+	return candidates
+}
+
+// handleGetDormantClients reports the policy and current candidates.
+// ?days= overrides the policy's threshold for this report only.
+func (s *Service) handleGetDormantClients(w http.ResponseWriter, r *http.Request) {
+	policy := loadDormantPolicy()
+	reportPolicy := policy
+	if days := router.QueryParamInt(r, "days", 0); days > 0 {
+		reportPolicy.Days = days
+	}
+	candidates, err := s.dormantClients(reportPolicy)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, map[string]interface{}{
+		"policy":     policy,
+		"days":       reportPolicy.Days,
+		"candidates": candidates,
+		"count":      len(candidates),
+	})
+}
+
+// handleSetDormantPolicy updates the threshold and the auto-prune opt-in
+func (s *Service) handleSetDormantPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy DormantPolicy
+	if !router.DecodeJSONOrError(w, r, &policy) {
+		return
+	}
+	if policy.Days < 1 || policy.Days > 3650 {
+		router.JSONError(w, "days must be between 1 and 3650", http.StatusBadRequest)
+		return
+	}
+	data, _ := json.Marshal(policy)
+	if err := settings.SetSetting(dormantSetting, string(data)); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, policy)
+}
+
+// handlePruneDormantClients removes dormant peers after confirmation: the
+// body lists the client ids from the report, and only those still dormant
+// under the current policy are removed
+func (s *Service) handlePruneDormantClients(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if len(req.IDs) == 0 {
+		router.JSONError(w, "ids is required: confirm the candidates from the dormant report", http.StatusBadRequest)
+		return
+	}
+
+	policy := loadDormantPolicy()
+	candidates, err := s.dormantClients(policy)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	byID := make(map[int]DormantClient, len(candidates))
+	for _, c := range candidates {
+		byID[c.ID] = c
+	}
+
+	var confirmed []DormantClient
+	skipped := []string{}
+	for _, id := range req.IDs {
+		c, ok := byID[id]
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("client %d is not dormant for %d days", id, policy.Days))
+			continue
+		}
+		confirmed = append(confirmed, c)
+		delete(byID, id)
+	}
+
+	removed := s.pruneDormant(confirmed)
+	if removed == nil {
+		removed = []DormantClient{}
+	}
+	router.JSON(w, map[string]interface{}{
+		"removed": removed,
+		"skipped": skipped,
+	})
+}
@@ -0,0 +1,19 @@
+package vpn
+
+import "api/internal/settings"
+
+// effectiveConfig reports the VPN runtime config and the source of each
+// value, for the settings GetEffectiveConfig endpoint
+func effectiveConfig() []settings.EffectiveValue {
+	return []settings.EffectiveValue{
+		settings.EnvValue("wgIPRange", "WG_IP_RANGE", nil),
+		settings.EnvValue("headscaleIPRange", "HEADSCALE_IP_RANGE", nil),
+		settings.EnvValue("wgServerIP", "WG_SERVER_IP", nil),
+		settings.EnvValue("wgInterface", "WG_INTERFACE", "wg0"),
+		settings.EnvValue("wgPort", "WG_PORT", nil),
+		settings.EnvValue("wgDNS", "WG_DNS", nil),
+		settings.EnvValue("routerName", "VPN_ROUTER_NAME", "vpn-router"),
+		settings.EnvValue("headscaleBaseDomain", "HEADSCALE_BASE_DOMAIN", ""),
+		settings.StoredValue("dormantPolicy", dormantSetting, loadDormantPolicy()),
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 
 	"api/internal/database"
 	"api/internal/helper"
+	"api/internal/router"
 )
 
 // getHeadscaleACLPath returns the configured Headscale ACL path
@@ -32,17 +34,27 @@ type ACLEntry struct {
 	Dst    []string `json:"dst"`
 }
 
-// GenerateAndApplyHeadscaleACL generates Headscale ACL policy from the database and applies it
-func GenerateAndApplyHeadscaleACL() error {
+// renderHeadscaleACL generates the ACL policy and the exact file content written
+// on apply; shared by apply and preview so both always agree
+func renderHeadscaleACL() (*HeadscaleACL, []byte, error) {
 	acl, err := generateHeadscaleACL()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Marshal to JSON with indentation
 	data, err := json.MarshalIndent(acl, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal Headscale ACL: %v", err)
+		return nil, nil, fmt.Errorf("failed to marshal Headscale ACL: %v", err)
+	}
+	return acl, data, nil
+}
+
+// GenerateAndApplyHeadscaleACL generates Headscale ACL policy from the database and applies it
+func GenerateAndApplyHeadscaleACL() error {
+	_, data, err := renderHeadscaleACL()
+	if err != nil {
+		return err
 	}
 
 	aclPath := getHeadscaleACLPath()
@@ -72,7 +84,7 @@ func generateHeadscaleACL() (*HeadscaleACL, error) {
 	wgIPRange := helper.GetEnv("WG_IP_RANGE")
 
 	// Get all clients
-	rows, err := db.Query(`SELECT id, name, ip, type, acl_policy FROM vpn_clients`)
+	rows, err := db.Query(`SELECT id, name, ip, type, acl_policy, COALESCE(tags, '') FROM vpn_clients`)
 	if err != nil {
 		return nil, err
 	}
@@ -84,14 +96,17 @@ func generateHeadscaleACL() (*HeadscaleACL, error) {
 		IP     string
 		Type   string
 		Policy string
+		Tags   []string
 	}
 	clients := make(map[int]client)
 
 	for rows.Next() {
 		var c client
-		if err := rows.Scan(&c.ID, &c.Name, &c.IP, &c.Type, &c.Policy); err != nil {
+		var tags string
+		if err := rows.Scan(&c.ID, &c.Name, &c.IP, &c.Type, &c.Policy, &tags); err != nil {
 			continue
 		}
+		c.Tags = parseTags(tags)
 		clients[c.ID] = c
 	}
 
@@ -127,6 +142,17 @@ func generateHeadscaleACL() (*HeadscaleACL, error) {
 		acl.Hosts[safeName] = c.IP
 	}
 
+	// Declare the tags in use so Headscale accepts them as forced node tags
+	// and policies can reference them (owners are empty: only the admin assigns tags)
+	for _, c := range clients {
+		for _, tag := range headscaleTags(c.Tags) {
+			if acl.TagOwners == nil {
+				acl.TagOwners = make(map[string][]string)
+			}
+			acl.TagOwners[tag] = []string{}
+		}
+	}
+
 	// Add WireGuard network as a host entry for routing
 	if wgIPRange != "" {
 		acl.Hosts["wireguard-network"] = wgIPRange
@@ -217,6 +243,39 @@ func generateHeadscaleACL() (*HeadscaleACL, error) {
 	return acl, nil
 }
 
+// HeadscaleACLPreview is the generated ACL next to the policy file currently on disk
+type HeadscaleACLPreview struct {
+	Path    string        `json:"path"`
+	ACL     *HeadscaleACL `json:"acl"`
+	Changed bool          `json:"changed"`           // applying would change the policy file
+	Current string        `json:"current,omitempty"` // policy file content, if it exists
+}
+
+// PreviewHeadscaleACL generates the ACL without writing or reloading anything
+func PreviewHeadscaleACL() (HeadscaleACLPreview, error) {
+	acl, data, err := renderHeadscaleACL()
+	if err != nil {
+		return HeadscaleACLPreview{}, err
+	}
+
+	preview := HeadscaleACLPreview{Path: getHeadscaleACLPath(), ACL: acl, Changed: true}
+	if current, err := os.ReadFile(preview.Path); err == nil {
+		preview.Current = string(current)
+		preview.Changed = string(current) != string(data)
+	}
+	return preview, nil
+}
+
+// handlePreviewHeadscaleACL returns the ACL that would be applied, without applying it
+func (s *Service) handlePreviewHeadscaleACL(w http.ResponseWriter, r *http.Request) {
+	preview, err := PreviewHeadscaleACL()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	router.JSON(w, preview)
+}
+
 // sanitizeHostName converts a client name to a valid Headscale host name
 func sanitizeHostName(name string) string {
 	// Replace spaces and special characters with hyphens
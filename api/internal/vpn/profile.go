@@ -0,0 +1,250 @@
+package vpn
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api/internal/database"
+	"api/internal/helper"
+	"api/internal/router"
+)
+
+// ClientProfile is the consolidated effective policy of one VPN client
+type ClientProfile struct {
+	Client       VPNClient           `json:"client"`
+	Connection   ClientConnection    `json:"connection"`
+	ACL          ClientACLProfile    `json:"acl"`
+	DNS          ClientDNSProfile    `json:"dns"`
+	DomainRoutes []ClientDomainRoute `json:"domainRoutes"`
+	Egress       ClientEgress        `json:"egress"`
+}
+
+// ClientConnection is the live status reported by WireGuard/Headscale at last sync
+type ClientConnection struct {
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"lastSeen,omitempty"`
+}
+
+// ClientACLProfile lists the peers the client can effectively reach and be reached from
+type ClientACLProfile struct {
+	Policy        string    `json:"policy"`
+	CanReach      []ACLPeer `json:"canReach"`
+	ReachableFrom []ACLPeer `json:"reachableFrom"`
+}
+
+// ACLPeer is another client with the reason traffic is allowed
+type ACLPeer struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	IP   string `json:"ip"`
+	Type string `json:"type"`
+	Via  string `json:"via"` // "allow_all" or "rule"
+}
+
+// ClientDNSProfile describes the client's DNS setup
+type ClientDNSProfile struct {
+	Rewrite bool   `json:"rewrite"`          // AdGuard rewrite exists for the client name
+	Domain  string `json:"domain,omitempty"` // rewrite domain (empty without HEADSCALE_BASE_DOMAIN)
+	Server  string `json:"server"`           // per-client DNS override ("" = server default)
+}
+
+// ClientDomainRoute is a domain route linked to the client or targeting its IP
+type ClientDomainRoute struct {
+	ID         int    `json:"id"`
+	Domain     string `json:"domain"`
+	TargetIP   string `json:"targetIp"`
+	TargetPort int    `json:"targetPort"`
+	Enabled    bool   `json:"enabled"`
+	AccessMode string `json:"accessMode"`
+}
+
+// ClientEgress describes what the client can reach beyond the VPN
+type ClientEgress struct {
+	InternetBlocked bool `json:"internetBlocked"` // per-peer WAN block
+}
+
+// handleGetClientProfile aggregates ACL, DNS, domain routes, connection and egress
+// for one client, the view needed when a user reports "I can't reach X"
+func (s *Service) handleGetClientProfile(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/vpn/clients/")
+	id, err := strconv.Atoi(strings.Split(path, "/")[0])
+	if err != nil {
+		router.JSONError(w, "invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var p ClientProfile
+	c := &p.Client
+	var externalID, rawData sql.NullString
+	var blockInternetInt int
+	err = db.QueryRow(`
+		SELECT id, name, ip, type, external_id, raw_data, acl_policy, total_tx, total_rx,
+		       COALESCE(block_internet, 0), COALESCE(dns_server, ''), created_at, updated_at
+		FROM vpn_clients WHERE id = ?
+	`, id).Scan(&c.ID, &c.Name, &c.IP, &c.Type, &externalID, &rawData, &c.ACLPolicy, &c.TotalTx, &c.TotalRx,
+		&blockInternetInt, &c.DNSServer, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		router.JSONError(w, "client not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.ExternalID = database.StringFromNull(externalID, "")
+	c.BlockInternet = blockInternetInt == 1
+
+	if rawData.Valid {
+		p.Connection = parseConnection([]byte(rawData.String))
+	}
+
+	p.ACL, err = s.effectiveACL(db, c.ID, c.ACLPolicy)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p.DNS = ClientDNSProfile{Server: c.DNSServer}
+	if suffix := getVPNDNSSuffix(); suffix != "" {
+		p.DNS.Domain = sanitizeForDNS(c.Name) + suffix
+		p.DNS.Rewrite = HasClientDNS(c.Name)
+	}
+
+	p.DomainRoutes, err = clientDomainRoutes(db, c.ID, c.IP)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p.Egress = ClientEgress{InternetBlocked: c.BlockInternet}
+
+	router.JSON(w, p)
+}
+
+// parseConnection reads online/last-seen from a client's raw sync data.
+// WireGuard peers report lastHandshake, Headscale nodes lastSeen. A client is
+// online when its source says so or it was seen within PeerOnlineThreshold.
+func parseConnection(raw []byte) ClientConnection {
+	var data struct {
+		Online        bool      `json:"online"`
+		LastSeen      time.Time `json:"lastSeen"`
+		LastHandshake time.Time `json:"lastHandshake"`
+	}
+	conn := ClientConnection{Online: isNodeOnline(raw)}
+	if json.Unmarshal(raw, &data) != nil {
+		return conn
+	}
+	last := data.LastSeen
+	if data.LastHandshake.After(last) {
+		last = data.LastHandshake
+	}
+	if !last.IsZero() {
+		conn.LastSeen = &last
+		if time.Since(last) < helper.PeerOnlineThreshold {
+			conn.Online = true
+		}
+	}
+	return conn
+}
+
+// effectiveACL resolves the client's policy against every other client the same
+// way the vpn_acl table does: block_all isolates, allow_all opens both ways, and
+// otherwise rules (plus the reverse of bidirectional ones) decide.
+func (s *Service) effectiveACL(db *database.DB, clientID int, policy string) (ClientACLProfile, error) {
+	profile := ClientACLProfile{Policy: policy, CanReach: []ACLPeer{}, ReachableFrom: []ACLPeer{}}
+
+	out := make(map[int]bool) // client -> peer allowed by a rule
+	in := make(map[int]bool)  // peer -> client allowed by a rule
+	rows, err := db.Query(`
+		SELECT source_client_id, target_client_id, bidirectional
+		FROM vpn_acl_rules
+		WHERE source_client_id = ? OR target_client_id = ?
+	`, clientID, clientID)
+	if err != nil {
+		return profile, err
+	}
+	for rows.Next() {
+		var src, tgt int
+		var bi bool
+		if rows.Scan(&src, &tgt, &bi) != nil {
+			continue
+		}
+		if src == clientID {
+			out[tgt] = true
+			in[tgt] = in[tgt] || bi
+		} else {
+			in[src] = true
+			out[src] = out[src] || bi
+		}
+	}
+	rows.Close()
+
+	if policy == helper.ACLPolicyBlockAll {
+		return profile, nil
+	}
+
+	rows, err = db.Query(`SELECT id, name, ip, type, acl_policy FROM vpn_clients WHERE id != ? ORDER BY type, name`, clientID)
+	if err != nil {
+		return profile, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var peer ACLPeer
+		var peerPolicy string
+		if rows.Scan(&peer.ID, &peer.Name, &peer.IP, &peer.Type, &peerPolicy) != nil {
+			continue
+		}
+		if peerPolicy == helper.ACLPolicyBlockAll {
+			continue
+		}
+		if policy == helper.ACLPolicyAllowAll || peerPolicy == helper.ACLPolicyAllowAll {
+			peer.Via = helper.ACLPolicyAllowAll
+			profile.CanReach = append(profile.CanReach, peer)
+			profile.ReachableFrom = append(profile.ReachableFrom, peer)
+			continue
+		}
+		peer.Via = "rule"
+		if out[peer.ID] {
+			profile.CanReach = append(profile.CanReach, peer)
+		}
+		if in[peer.ID] {
+			profile.ReachableFrom = append(profile.ReachableFrom, peer)
+		}
+	}
+	return profile, nil
+}
+
+// clientDomainRoutes returns routes linked to the client or proxying to its IP
+func clientDomainRoutes(db *database.DB, clientID int, ip string) ([]ClientDomainRoute, error) {
+	rows, err := db.Query(`
+		SELECT id, domain, target_ip, target_port, enabled, COALESCE(access_mode, 'vpn')
+		FROM domain_routes
+		WHERE vpn_client_id = ? OR target_ip = ?
+		ORDER BY domain
+	`, clientID, ip)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routes := []ClientDomainRoute{}
+	for rows.Next() {
+		var rt ClientDomainRoute
+		if rows.Scan(&rt.ID, &rt.Domain, &rt.TargetIP, &rt.TargetPort, &rt.Enabled, &rt.AccessMode) != nil {
+			continue
+		}
+		routes = append(routes, rt)
+	}
+	return routes, nil
+}
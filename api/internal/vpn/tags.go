@@ -0,0 +1,270 @@
+package vpn
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"api/internal/database"
+	"api/internal/headscale"
+	"api/internal/helper"
+	"api/internal/router"
+)
+
+// Client tag limits
+const (
+	maxClientTags = 20
+	maxTagLength  = 32
+)
+
+// validTag matches a normalized tag (without the Headscale "tag:" prefix)
+var validTag = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ClientTagsUpdate is the request body for setting a client's tags
+type ClientTagsUpdate struct {
+	Tags []string `json:"tags"`
+}
+
+// TagSummary lists the clients carrying a tag
+type TagSummary struct {
+	Tag       string `json:"tag"`
+	Count     int    `json:"count"`
+	ClientIDs []int  `json:"clientIds"`
+}
+
+// normalizeTag lowercases a tag and strips the Headscale "tag:" prefix
+func normalizeTag(tag string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(tag)), "tag:")
+}
+
+// normalizeTags validates, dedupes and sorts tags
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, t := range tags {
+		tag := normalizeTag(t)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength || !validTag.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q: use lowercase letters, digits, '-' and '_' (max %d chars)", t, maxTagLength)
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxClientTags {
+		return nil, fmt.Errorf("too many tags (max %d)", maxClientTags)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// parseTags decodes the tags column; empty or malformed values mean no tags
+func parseTags(raw string) []string {
+	tags := []string{}
+	if raw != "" {
+		json.Unmarshal([]byte(raw), &tags)
+	}
+	return tags
+}
+
+// hasTag reports whether tags contains tag
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// headscaleTags prefixes tags the way Headscale expects them
+func headscaleTags(tags []string) []string {
+	prefixed := make([]string, len(tags))
+	for i, t := range tags {
+		prefixed[i] = "tag:" + t
+	}
+	return prefixed
+}
+
+// handleSetClientTags replaces the tags of a client. Headscale nodes also get
+// them as forced tags; a Headscale failure is reported but doesn't undo the update.
+func (s *Service) handleSetClientTags(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/vpn/clients/")
+	id, ok := router.ParseIDOrError(w, strings.Split(path, "/")[0])
+	if !ok {
+		return
+	}
+
+	var req ClientTagsUpdate
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var clientType string
+	var externalID sql.NullString
+	if err := db.QueryRow(`SELECT type, external_id FROM vpn_clients WHERE id = ?`, id).Scan(&clientType, &externalID); err != nil {
+		router.JSONError(w, "client not found", http.StatusNotFound)
+		return
+	}
+
+	data, _ := json.Marshal(tags)
+	if _, err := db.Exec(`UPDATE vpn_clients SET tags = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, string(data), id); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]interface{}{"id": id, "tags": tags}
+	if clientType == "headscale" && externalID.String != "" {
+		if err := headscale.SetNodeTags(externalID.String, headscaleTags(tags)); err != nil {
+			log.Printf("Warning: failed to set Headscale tags for node %s: %v", externalID.String, err)
+			resp["warning"] = "tags saved, but Headscale update failed: " + err.Error()
+		}
+	}
+
+	// Tags are declared in the Headscale ACL tagOwners
+	s.RequestApply()
+
+	router.JSON(w, resp)
+}
+
+// handleGetTags lists every tag in use with the clients carrying it
+func (s *Service) handleGetTags(w http.ResponseWriter, r *http.Request) {
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := db.Query(`SELECT id, COALESCE(tags, '') FROM vpn_clients WHERE COALESCE(tags, '') != '' ORDER BY id`)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	byTag := make(map[string]*TagSummary)
+	for rows.Next() {
+		var id int
+		var raw string
+		if rows.Scan(&id, &raw) != nil {
+			continue
+		}
+		for _, tag := range parseTags(raw) {
+			if byTag[tag] == nil {
+				byTag[tag] = &TagSummary{Tag: tag, ClientIDs: []int{}}
+			}
+			byTag[tag].Count++
+			byTag[tag].ClientIDs = append(byTag[tag].ClientIDs, id)
+		}
+	}
+
+	summaries := []TagSummary{}
+	for _, t := range byTag {
+		summaries = append(summaries, *t)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Tag < summaries[j].Tag })
+
+	router.JSON(w, summaries)
+}
+
+// taggedClientIDs returns the IDs of the clients carrying tag
+func taggedClientIDs(db *database.DB, tag string) ([]int, error) {
+	rows, err := db.Query(`SELECT id, COALESCE(tags, '') FROM vpn_clients WHERE COALESCE(tags, '') != ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		var raw string
+		if rows.Scan(&id, &raw) == nil && hasTag(parseTags(raw), tag) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, rows.Err()
+}
+
+// handleSetTagPolicy sets the ACL policy of every client carrying a tag.
+// "selected" keeps each client's existing rules, like switching one client.
+func (s *Service) handleSetTagPolicy(w http.ResponseWriter, r *http.Request) {
+	// Extract tag from path like /api/vpn/tags/iot/acl
+	path := strings.TrimPrefix(r.URL.Path, "/api/vpn/tags/")
+	tag := normalizeTag(strings.Split(path, "/")[0])
+	if !validTag.MatchString(tag) {
+		router.JSONError(w, "invalid tag", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Policy string `json:"policy"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if !helper.IsValidACLPolicy(req.Policy) {
+		router.JSONError(w, "invalid policy", http.StatusBadRequest)
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ids, err := taggedClientIDs(db, tag)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(ids) == 0 {
+		router.JSONError(w, "no clients with tag "+tag, http.StatusNotFound)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		// Same rule cleanup as a single-client policy change
+		if err := setClientPolicy(tx, id, req.Policy); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.RequestApply()
+
+	router.JSON(w, map[string]interface{}{
+		"tag":       tag,
+		"policy":    req.Policy,
+		"clientIds": ids,
+		"count":     len(ids),
+	})
+}
@@ -12,6 +12,7 @@ import (
 
 	"api/internal/database"
 	"api/internal/helper"
+	"api/internal/scheduler"
 )
 
 // PeerTransfer represents WireGuard transfer stats for a peer
@@ -149,6 +150,8 @@ func GetTrafficRates() (rateTx, rateRx int64) {
 
 // runTrafficSync runs the traffic sync loop
 func runTrafficSync(ctx context.Context) {
+	task := scheduler.RegisterTask("vpn-traffic-sync", "Sync WireGuard peer traffic to the database", 30*time.Second)
+	defer task.Done(ctx)
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
@@ -162,6 +165,7 @@ func runTrafficSync(ctx context.Context) {
 			return
 		case <-ticker.C:
 			syncTrafficStats(&prevTotalTx, &prevTotalRx, &prevTime)
+			task.Tick()
 		}
 	}
 }
@@ -16,6 +16,7 @@ import (
 	"api/internal/database"
 	"api/internal/domains"
 	"api/internal/helper"
+	"api/internal/logs/sources"
 	"api/internal/nftables"
 	"api/internal/router"
 	"api/internal/settings"
@@ -27,12 +28,17 @@ import (
 func syncClient(db *database.DB, existing map[string]int, seen map[string]bool,
 	name, ip, clientType, externalID, rawData string, added *int) {
 	seen[ip] = true
+	// Keep the last known activity: WireGuard forgets handshakes when the interface restarts
+	var lastSeen interface{}
+	if conn := parseConnection([]byte(rawData)); conn.LastSeen != nil {
+		lastSeen = conn.LastSeen.UTC()
+	}
 	if id, exists := existing[ip]; exists {
-		db.Exec(`UPDATE vpn_clients SET name = ?, raw_data = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
-			name, rawData, id)
+		db.Exec(`UPDATE vpn_clients SET name = ?, raw_data = ?, last_seen_at = COALESCE(?, last_seen_at), updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			name, rawData, lastSeen, id)
 	} else {
-		_, err := db.Exec(`INSERT INTO vpn_clients (name, ip, type, external_id, raw_data, acl_policy) VALUES (?, ?, ?, ?, ?, ?)`,
-			name, ip, clientType, externalID, rawData, helper.DefaultACLPolicy)
+		_, err := db.Exec(`INSERT INTO vpn_clients (name, ip, type, external_id, raw_data, acl_policy, last_seen_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			name, ip, clientType, externalID, rawData, helper.DefaultACLPolicy, lastSeen)
 		if err == nil {
 			*added++
 		}
@@ -133,6 +139,7 @@ func isNodeOnline(rawData []byte) bool {
 type Service struct {
 	wgIPRange string
 	hsIPRange string
+	apply     applyState // debounced nftables + Headscale ACL apply
 }
 
 // VPNClient represents a unified view of a VPN client (WireGuard or Headscale)
@@ -147,10 +154,15 @@ type VPNClient struct {
 	TotalTx       int64           `json:"totalTx"`           // Total bytes transmitted
 	TotalRx       int64           `json:"totalRx"`           // Total bytes received
 	BlockInternet bool            `json:"blockInternet"`     // Per-peer WAN egress block
+	DNSServer     string          `json:"dnsServer"`         // Per-client DNS override ("" = server default)
+	LogTraffic    bool            `json:"logTraffic"`        // Connections logged by the traffic monitor
+	Tags          []string        `json:"tags"`              // Client tags (without the "tag:" prefix)
 	CreatedAt     time.Time       `json:"createdAt"`
 	UpdatedAt     time.Time       `json:"updatedAt"`
 	// Enriched fields (not stored in DB)
-	AllowedCount int `json:"allowedCount,omitempty"`
+	AllowedCount int        `json:"allowedCount,omitempty"`
+	Online       bool       `json:"online"`             // Handshake/Headscale activity within PeerOnlineThreshold
+	LastSeen     *time.Time `json:"lastSeen,omitempty"` // Last WireGuard handshake or Headscale last-seen
 }
 
 // ACLRule represents an ACL rule between two clients
@@ -183,6 +195,17 @@ type ClientACLUpdate struct {
 type ACLRuleReq struct {
 	TargetID      int  `json:"targetId"`
 	Bidirectional bool `json:"bidirectional"`
+	FlipBlocked   bool `json:"flipBlocked"` // Confirms switching a block_all target to selected so the reverse rule takes effect
+}
+
+// ACLReverseResult reports whether a bidirectional request's reverse direction
+// (target -> viewer) is in effect, and why not when it isn't
+type ACLReverseResult struct {
+	TargetID      int    `json:"targetId"`
+	TargetPolicy  string `json:"targetPolicy"`
+	ReverseActive bool   `json:"reverseActive"`
+	Flipped       bool   `json:"flipped,omitempty"` // Target was switched from block_all to selected
+	Reason        string `json:"reason"`
 }
 
 // New creates a new VPN service
@@ -191,22 +214,44 @@ func New() *Service {
 	hsRange := helper.GetEnv("HEADSCALE_IP_RANGE")
 
 	log.Printf("VPN service initialized (WG: %s, HS: %s)", wgRange, hsRange)
-	return &Service{
+	svc := &Service{
 		wgIPRange: wgRange,
 		hsIPRange: hsRange,
 	}
+	svc.scheduleDormantPrune()
+	svc.scheduleACLElevationRevert()
+	settings.RegisterEffectiveConfig("vpn", effectiveConfig)
+	return svc
 }
 
 // Handlers returns the handler map for the router
 func (s *Service) Handlers() router.ServiceHandlers {
 	return router.ServiceHandlers{
 		// Clients & ACL
-		"GetClients":   s.handleGetClients,
-		"GetClient":    s.handleGetClient,
-		"UpdateACL":    s.handleUpdateACL,
-		"ApplyRules":   s.handleApplyRules,
-		"ToggleDNS":    s.handleToggleDNS,
-		"ResetTraffic": s.handleResetTraffic,
+		"GetClients":              s.handleGetClients,
+		"GetClient":               s.handleGetClient,
+		"GetClientProfile":        s.handleGetClientProfile,
+		"GetClientDomains":        s.handleGetClientDomains,
+		"UpdateACL":               s.handleUpdateACL,
+		"BulkUpdateACL":           s.handleBulkUpdateACL,
+		"TempElevateACL":          s.handleTempElevateACL,
+		"RevertElevation":         s.handleRevertACLElevation,
+		"GetElevations":           s.handleGetACLElevations,
+		"ApplyRules":              s.handleApplyRules,
+		"GetApplyStatus":          s.handleGetApplyStatus,
+		"PreviewACL":              s.handlePreviewHeadscaleACL,
+		"CleanOrphanedACL":        s.handleCleanOrphanedACL,
+		"ToggleDNS":               s.handleToggleDNS,
+		"SetClientDNS":            s.handleSetClientDNS,
+		"SetClientTrafficLogging": s.handleSetClientTrafficLogging,
+		"SetClientTags":           s.handleSetClientTags,
+		"GetTags":                 s.handleGetTags,
+		"SetTagPolicy":            s.handleSetTagPolicy,
+		"ResetTraffic":            s.handleResetTraffic,
+		// Dormant clients
+		"GetDormantClients":   s.handleGetDormantClients,
+		"SetDormantPolicy":    s.handleSetDormantPolicy,
+		"PruneDormantClients": s.handlePruneDormantClients,
 		// Port Scanner
 		"ScanPorts": s.handleScanPorts,
 		"StopScan":  s.handleStopScan,
@@ -224,6 +269,9 @@ func (s *Service) handleGetClients(w http.ResponseWriter, r *http.Request) {
 	// Auto-sync to get fresh data from WireGuard and Headscale
 	s.SyncClients()
 
+	// Optional ?tag= filter
+	tagFilter := normalizeTag(router.QueryParam(r, "tag", ""))
+
 	db, err := database.GetDB()
 	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
@@ -233,7 +281,7 @@ func (s *Service) handleGetClients(w http.ResponseWriter, r *http.Request) {
 	rows, err := db.Query(`
 		SELECT c.id, c.name, c.ip, c.type, c.external_id, c.raw_data,
 		       c.acl_policy, c.total_tx, c.total_rx, COALESCE(c.block_internet, 0),
-		       c.created_at, c.updated_at,
+		       COALESCE(c.dns_server, ''), COALESCE(c.log_traffic, 1), COALESCE(c.tags, ''), c.created_at, c.updated_at,
 		       COALESCE(counts.cnt, 0) as allowed_count
 		FROM vpn_clients c
 		LEFT JOIN (
@@ -254,13 +302,20 @@ func (s *Service) handleGetClients(w http.ResponseWriter, r *http.Request) {
 		var c VPNClient
 		var externalID, rawData sql.NullString
 		var blockInternetInt int
-		if err := rows.Scan(&c.ID, &c.Name, &c.IP, &c.Type, &externalID, &rawData, &c.ACLPolicy, &c.TotalTx, &c.TotalRx, &blockInternetInt, &c.CreatedAt, &c.UpdatedAt, &c.AllowedCount); err != nil {
+		var tags string
+		if err := rows.Scan(&c.ID, &c.Name, &c.IP, &c.Type, &externalID, &rawData, &c.ACLPolicy, &c.TotalTx, &c.TotalRx, &blockInternetInt, &c.DNSServer, &c.LogTraffic, &tags, &c.CreatedAt, &c.UpdatedAt, &c.AllowedCount); err != nil {
+			continue
+		}
+		c.Tags = parseTags(tags)
+		if tagFilter != "" && !hasTag(c.Tags, tagFilter) {
 			continue
 		}
 		c.BlockInternet = blockInternetInt == 1
 		c.ExternalID = database.StringFromNull(externalID, "")
 		if rawData.Valid {
 			c.RawData = json.RawMessage(rawData.String)
+			conn := parseConnection(c.RawData)
+			c.Online, c.LastSeen = conn.Online, conn.LastSeen
 		}
 		clients = append(clients, c)
 	}
@@ -282,10 +337,11 @@ func (s *Service) handleGetClient(w http.ResponseWriter, r *http.Request) {
 	}
 	var c VPNClient
 	var externalID sql.NullString
+	var tags string
 	err = db.QueryRow(`
-		SELECT id, name, ip, type, external_id, acl_policy, total_tx, total_rx, created_at, updated_at
+		SELECT id, name, ip, type, external_id, acl_policy, total_tx, total_rx, COALESCE(dns_server, ''), COALESCE(log_traffic, 1), COALESCE(tags, ''), created_at, updated_at
 		FROM vpn_clients WHERE id = ?
-	`, id).Scan(&c.ID, &c.Name, &c.IP, &c.Type, &externalID, &c.ACLPolicy, &c.TotalTx, &c.TotalRx, &c.CreatedAt, &c.UpdatedAt)
+	`, id).Scan(&c.ID, &c.Name, &c.IP, &c.Type, &externalID, &c.ACLPolicy, &c.TotalTx, &c.TotalRx, &c.DNSServer, &c.LogTraffic, &tags, &c.CreatedAt, &c.UpdatedAt)
 	if err == sql.ErrNoRows {
 		router.JSONError(w, "client not found", http.StatusNotFound)
 		return
@@ -295,6 +351,7 @@ func (s *Service) handleGetClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	c.ExternalID = database.StringFromNull(externalID, "")
+	c.Tags = parseTags(tags)
 
 	// Get ACL view for this client (all other clients with enabled/bi state)
 	aclView := s.getClientACLView(c.ID)
@@ -352,6 +409,106 @@ func (s *Service) handleToggleDNS(w http.ResponseWriter, r *http.Request) {
 	router.JSON(w, map[string]bool{"enabled": req.Enabled})
 }
 
+// handleSetClientDNS sets the DNS server(s) pushed to a client ("" = server default).
+// Only WireGuard peers are supported: Headscale DNS is configured server-wide.
+func (s *Service) handleSetClientDNS(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/vpn/clients/")
+	id, ok := router.ParseIDOrError(w, strings.Split(path, "/")[0])
+	if !ok {
+		return
+	}
+
+	var req struct {
+		DNSServer string `json:"dnsServer"` // comma-separated IPs
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	var servers []string
+	for _, part := range strings.Split(req.DNSServer, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if err := helper.ValidateIP(part); err != nil {
+			router.JSONError(w, "invalid DNS server: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		servers = append(servers, part)
+	}
+	if len(servers) > 3 {
+		router.JSONError(w, "at most 3 DNS servers allowed", http.StatusBadRequest)
+		return
+	}
+	dns := strings.Join(servers, ", ")
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var clientType string
+	var externalID sql.NullString
+	err = db.QueryRow(`SELECT type, external_id FROM vpn_clients WHERE id = ?`, id).Scan(&clientType, &externalID)
+	if err != nil {
+		router.JSONError(w, "client not found", http.StatusNotFound)
+		return
+	}
+
+	if clientType != "wireguard" {
+		router.JSONError(w, "per-client DNS is only supported for WireGuard clients", http.StatusBadRequest)
+		return
+	}
+	wgSvc := wireguard.GetService()
+	if wgSvc == nil {
+		router.JSONError(w, "WireGuard service not available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := wgSvc.SetPeerDNSServer(externalID.String, dns); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	router.JSON(w, map[string]string{"dnsServer": dns})
+}
+
+// handleSetClientTrafficLogging turns connection logging on or off for one client.
+// Disabled clients are skipped by the outbound and conntrack watchers; WireGuard
+// byte totals are still collected.
+func (s *Service) handleSetClientTrafficLogging(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/vpn/clients/")
+	id, ok := router.ParseIDOrError(w, strings.Split(path, "/")[0])
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result, err := db.Exec(`UPDATE vpn_clients SET log_traffic = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, req.Enabled, id)
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		router.JSONError(w, "client not found", http.StatusNotFound)
+		return
+	}
+	sources.InvalidateClientLogging()
+
+	router.JSON(w, map[string]interface{}{"id": id, "logTraffic": req.Enabled})
+}
+
 func (s *Service) handleUpdateACL(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from path like /api/vpn/clients/123/acl
 	path := strings.TrimPrefix(r.URL.Path, "/api/vpn/clients/")
@@ -389,35 +546,127 @@ func (s *Service) handleUpdateACL(w http.ResponseWriter, r *http.Request) {
 	}
 	defer tx.Rollback()
 
-	// Update client policy
-	if _, err = tx.Exec(`UPDATE vpn_clients SET acl_policy = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, req.Policy, viewerID); err != nil {
+	// Update client policy, dropping rules a block_all/allow_all policy makes redundant
+	if err := setClientPolicy(tx, viewerID, req.Policy); err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Handle policy-specific logic
-	switch req.Policy {
-	case helper.ACLPolicyBlockAll:
-		// Isolated: delete all rules involving this client
-		if _, err = tx.Exec(`DELETE FROM vpn_acl_rules WHERE source_client_id = ? OR target_client_id = ?`, viewerID, viewerID); err != nil {
+	reverse := []ACLReverseResult{}
+	if req.Policy == helper.ACLPolicySelected {
+		// Apply state machine for each rule
+		if err := s.applyACLRules(tx, viewerID, req.AllowedRules); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if reverse, err = reverseRuleResults(tx, viewerID, req.AllowedRules); err != nil {
 			router.JSONError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Auto-apply unless the caller batches edits and applies itself (?apply=false)
+	applyQueued := r.URL.Query().Get("apply") != "false"
+	if applyQueued {
+		s.RequestApply()
+	}
+
+	router.JSON(w, map[string]interface{}{"status": "ok", "applyQueued": applyQueued, "reverse": reverse})
+}
 
+// setClientPolicy stores a client's ACL policy and deletes the rules it makes
+// redundant. Rules of a selected client are left to applyACLRules. An explicit
+// policy change also ends any temporary elevation.
+func setClientPolicy(tx *sql.Tx, clientID int, policy string) error {
+	if _, err := tx.Exec(`UPDATE vpn_clients SET acl_policy = ?, acl_elevation = '', acl_elevated_until = NULL,
+		updated_at = CURRENT_TIMESTAMP WHERE id = ?`, policy, clientID); err != nil {
+		return err
+	}
+
+	var err error
+	switch policy {
+	case helper.ACLPolicyBlockAll:
+		// Isolated: delete all rules involving this client
+		_, err = tx.Exec(`DELETE FROM vpn_acl_rules WHERE source_client_id = ? OR target_client_id = ?`, clientID, clientID)
 	case helper.ACLPolicyAllowAll:
-		// Can reach everyone: delete rules where viewer is source (blanket rule covers it)
-		// Keep rules where viewer is target (others explicitly allowed viewer)
-		if _, err = tx.Exec(`DELETE FROM vpn_acl_rules WHERE source_client_id = ?`, viewerID); err != nil {
+		// Can reach everyone: delete rules where the client is source (blanket rule covers it)
+		// Keep rules where it is target (others explicitly allowed it)
+		_, err = tx.Exec(`DELETE FROM vpn_acl_rules WHERE source_client_id = ?`, clientID)
+	}
+	return err
+}
+
+// handleBulkUpdateACL sets one policy on many clients in a single transaction
+// and queues one apply (unless ?apply=false). Unknown ids fail the whole request.
+func (s *Service) handleBulkUpdateACL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClientIDs []int  `json:"clientIds"`
+		Policy    string `json:"policy"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if !helper.IsValidACLPolicy(req.Policy) {
+		router.JSONError(w, "invalid policy", http.StatusBadRequest)
+		return
+	}
+	if len(req.ClientIDs) == 0 {
+		router.JSONError(w, "clientIds is required", http.StatusBadRequest)
+		return
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	seen := make(map[int]bool, len(req.ClientIDs))
+	updated := []int{}
+	missing := []int{}
+	unchanged := 0
+	for _, id := range req.ClientIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		var current string
+		if err := tx.QueryRow(`SELECT acl_policy FROM vpn_clients WHERE id = ?`, id).Scan(&current); err == sql.ErrNoRows {
+			missing = append(missing, id)
+			continue
+		} else if err != nil {
 			router.JSONError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-
-	case helper.ACLPolicySelected:
-		// Apply state machine for each rule
-		if err := s.applyACLRules(tx, viewerID, req.AllowedRules); err != nil {
+		if current == req.Policy {
+			unchanged++
+			continue
+		}
+		if err := setClientPolicy(tx, id, req.Policy); err != nil {
 			router.JSONError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		updated = append(updated, id)
+	}
+	if len(missing) > 0 {
+		router.JSONWithStatus(w, map[string]interface{}{
+			"error":   fmt.Sprintf("%d unknown client id(s), nothing changed", len(missing)),
+			"missing": missing,
+		}, http.StatusNotFound)
+		return
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -425,7 +674,65 @@ func (s *Service) handleUpdateACL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	router.JSON(w, map[string]string{"status": "ok"})
+	applyQueued := len(updated) > 0 && r.URL.Query().Get("apply") != "false"
+	if applyQueued {
+		s.RequestApply()
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"policy":      req.Policy,
+		"updated":     updated,
+		"unchanged":   unchanged,
+		"applyQueued": applyQueued,
+	})
+}
+
+// reverseRuleResults checks the target of each bidirectional rule. The reverse
+// direction only needs a rule when the target is selected: allow_all targets
+// already reach everyone, and block_all targets are isolated, so their reverse
+// rule is skipped when rules are generated. A block_all target is switched to
+// selected (keeping this rule as its only one) when the request sets flipBlocked.
+func reverseRuleResults(tx *sql.Tx, viewerID int, rules []ACLRuleReq) ([]ACLReverseResult, error) {
+	results := []ACLReverseResult{}
+	for _, rule := range rules {
+		if !rule.Bidirectional || rule.TargetID == viewerID {
+			continue
+		}
+		res := ACLReverseResult{TargetID: rule.TargetID}
+		err := tx.QueryRow(`SELECT acl_policy FROM vpn_clients WHERE id = ?`, rule.TargetID).Scan(&res.TargetPolicy)
+		if err == sql.ErrNoRows {
+			res.Reason = "target not found"
+			results = append(results, res)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch res.TargetPolicy {
+		case helper.ACLPolicySelected:
+			res.ReverseActive = true
+			res.Reason = "reverse rule created"
+		case helper.ACLPolicyAllowAll:
+			res.ReverseActive = true
+			res.Reason = "target is allow_all - it already reaches every client"
+		case helper.ACLPolicyBlockAll:
+			if !rule.FlipBlocked {
+				res.Reason = "target is block_all - cannot add reverse (set flipBlocked to switch it to selected)"
+				break
+			}
+			if _, err := tx.Exec(`UPDATE vpn_clients SET acl_policy = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+				helper.ACLPolicySelected, rule.TargetID); err != nil {
+				return nil, err
+			}
+			res.TargetPolicy = helper.ACLPolicySelected
+			res.ReverseActive = true
+			res.Flipped = true
+			res.Reason = "target switched from block_all to selected with this reverse rule only"
+		}
+		results = append(results, res)
+	}
+	return results, nil
 }
 
 // applyACLRules implements the ACL state machine
@@ -554,12 +861,35 @@ func (s *Service) applyACLRules(tx *sql.Tx, viewerID int, desiredRules []ACLRule
 }
 
 func (s *Service) handleApplyRules(w http.ResponseWriter, r *http.Request) {
-	if err := s.ApplyRules(); err != nil {
+	coalesced, err := s.ApplyNow()
+	if err != nil {
 		router.JSONError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	router.JSON(w, map[string]string{"status": "ok"})
+	router.JSON(w, map[string]interface{}{
+		"status":    "ok",
+		"applied":   true,
+		"coalesced": coalesced, // queued ACL changes this apply covered
+	})
+}
+
+func (s *Service) handleCleanOrphanedACL(w http.ResponseWriter, r *http.Request) {
+	removed, err := s.CleanOrphanedACL()
+	if err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := s.ApplyNow(); err != nil {
+		router.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"status":  "ok",
+		"removed": removed,
+	})
 }
 
 // --- Router Handlers ---
@@ -767,6 +1097,17 @@ func (s *Service) SyncClients() (added int, removed int, err error) {
 		}
 	}
 
+	// Drop ACL rules left behind by removed clients (cascade only works when
+	// foreign keys are enforced) and re-apply so no rules target ghosts
+	if removed > 0 {
+		if n, err := s.CleanOrphanedACL(); err != nil {
+			log.Printf("Warning: failed to clean orphaned ACL rules: %v", err)
+		} else if n > 0 {
+			log.Printf("Removed %d orphaned ACL rules", n)
+		}
+		s.RequestApply()
+	}
+
 	// Broadcast node stats update if anything changed
 	if added > 0 || removed > 0 {
 		ws.BroadcastNodeStats()
@@ -836,6 +1177,23 @@ func (s *Service) getHeadscaleNodesWithRaw() ([]hsNode, []string, error) {
 	return nodes, rawNodes, nil
 }
 
+// CleanOrphanedACL deletes ACL rules whose source or target client no longer exists
+func (s *Service) CleanOrphanedACL() (int64, error) {
+	db, err := database.GetDB()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(`DELETE FROM vpn_acl_rules
+		WHERE source_client_id NOT IN (SELECT id FROM vpn_clients)
+		OR target_client_id NOT IN (SELECT id FROM vpn_clients)`)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 // ApplyRules triggers nftables apply and generates Headscale ACL
 func (s *Service) ApplyRules() error {
 	// Request nftables apply (debounced, applies all registered tables including VPN ACL)
@@ -0,0 +1,149 @@
+package wireguard
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// maxPeerAllowedIPs bounds the extra networks of one peer
+const maxPeerAllowedIPs = 32
+
+// validateAllowedIPs normalizes a peer's extra networks (bare IPs become /32
+// or /128) and rejects default routes and overlaps with the VPN ranges or
+// another peer, since WireGuard routes each address to exactly one peer
+func (s *Service) validateAllowedIPs(peerID string, cidrs []string) ([]string, error) {
+	if len(cidrs) > maxPeerAllowedIPs {
+		return nil, fmt.Errorf("at most %d allowed IPs per peer", maxPeerAllowedIPs)
+	}
+
+	type claimed struct {
+		network *net.IPNet
+		owner   string
+	}
+	var taken []claimed
+	for _, r := range []string{s.config.IPRange, s.config.HeadscaleIPRange} {
+		if _, n, err := net.ParseCIDR(r); err == nil {
+			taken = append(taken, claimed{n, "the VPN range " + r})
+		}
+	}
+	for _, p := range s.peerStore.List() {
+		if p.ID == peerID {
+			continue
+		}
+		for _, c := range p.AllowedIPs {
+			if _, n, err := net.ParseCIDR(c); err == nil {
+				taken = append(taken, claimed{n, "peer " + p.Name})
+			}
+		}
+	}
+
+	result := []string{}
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if !strings.Contains(c, "/") {
+			if ip := net.ParseIP(c); ip != nil && ip.To4() != nil {
+				c += "/32"
+			} else {
+				c += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", c)
+		}
+		if ones, _ := n.Mask.Size(); ones == 0 {
+			return nil, fmt.Errorf("%s would route all traffic to the peer", n)
+		}
+		for _, t := range taken {
+			if t.network.Contains(n.IP) || n.Contains(t.network.IP) {
+				return nil, fmt.Errorf("%s overlaps %s", n, t.owner)
+			}
+		}
+		taken = append(taken, claimed{n, "another entry of this peer"})
+		result = append(result, n.String())
+	}
+	return result, nil
+}
+
+// routedNetworks returns the extra networks of all enabled peers
+func (s *Service) routedNetworks() []string {
+	var networks []string
+	for _, p := range s.peerStore.List() {
+		if p.Enabled {
+			networks = append(networks, p.AllowedIPs...)
+		}
+	}
+	return networks
+}
+
+// peerRouteProto tags the routes syncPeerRoutes manages, so cleanup never
+// touches static routes added by the admin or other tools. 250 isn't
+// assigned to any routing daemon in rt_protos.
+const peerRouteProto = "250"
+
+// normalizeRoute returns the canonical CIDR of a route destination, adding the
+// host prefix length the kernel omits for single addresses. "" if invalid.
+func normalizeRoute(dst string) string {
+	if !strings.Contains(dst, "/") {
+		if strings.Contains(dst, ":") {
+			dst += "/128"
+		} else {
+			dst += "/32"
+		}
+	}
+	_, n, err := net.ParseCIDR(dst)
+	if err != nil {
+		return ""
+	}
+	return n.String()
+}
+
+// routeDestinations parses the destinations out of `ip route show` output
+func routeDestinations(out string) []string {
+	var dsts []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if dst := normalizeRoute(fields[0]); dst != "" {
+			dsts = append(dsts, dst)
+		}
+	}
+	return dsts
+}
+
+// syncPeerRoutes points the extra networks of enabled peers at the WireGuard
+// interface and removes the routes of networks no longer assigned. Only routes
+// tagged with peerRouteProto are managed, the interface's own kernel routes and
+// any static ones are left alone.
+func (s *Service) syncPeerRoutes(networks []string) {
+	wanted := make(map[string]bool, len(networks))
+	for _, n := range networks {
+		if dst := normalizeRoute(n); dst != "" {
+			wanted[dst] = true
+		}
+		family := "-4"
+		if strings.Contains(n, ":") {
+			family = "-6"
+		}
+		if out, err := exec.Command("ip", family, "route", "replace", n, "dev", s.config.Interface, "proto", peerRouteProto).CombinedOutput(); err != nil {
+			log.Printf("Warning: failed to route %s via %s: %v - %s", n, s.config.Interface, err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	for _, family := range []string{"-4", "-6"} {
+		out, err := exec.Command("ip", family, "route", "show", "dev", s.config.Interface, "proto", peerRouteProto).Output()
+		if err != nil {
+			continue
+		}
+		for _, dst := range routeDestinations(string(out)) {
+			if !wanted[dst] {
+				exec.Command("ip", family, "route", "del", dst, "dev", s.config.Interface, "proto", peerRouteProto).Run()
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package wireguard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRouteDestinations(t *testing.T) {
+	out := `192.168.50.7 scope link
+10.20.0.0/16 scope link
+2001:db8::5 metric 1024 pref medium
+2001:db8:1::/48 metric 1024 pref medium
+
+`
+	want := "192.168.50.7/32,10.20.0.0/16,2001:db8::5/128,2001:db8:1::/48"
+	if got := strings.Join(routeDestinations(out), ","); got != want {
+		t.Errorf("routeDestinations = %s, want %s", got, want)
+	}
+}
+
+func TestNormalizeRoute(t *testing.T) {
+	for in, want := range map[string]string{
+		"192.168.50.7":    "192.168.50.7/32",
+		"192.168.50.7/32": "192.168.50.7/32",
+		"10.20.0.1/16":    "10.20.0.0/16",
+		"2001:db8::5":     "2001:db8::5/128",
+		"2001:DB8:1::/48": "2001:db8:1::/48",
+		"default":         "",
+	} {
+		if got := normalizeRoute(in); got != want {
+			t.Errorf("normalizeRoute(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
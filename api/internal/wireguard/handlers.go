@@ -2,6 +2,7 @@ package wireguard
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -98,13 +99,23 @@ func (s *Service) handleUpdatePeer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name    *string `json:"name"`
-		Enabled *bool   `json:"enabled"`
+		Name       *string   `json:"name"`
+		Enabled    *bool     `json:"enabled"`
+		AllowedIPs *[]string `json:"allowedIps"` // replaces the extra networks; [] clears them
 	}
 	if !router.DecodeJSONOrError(w, r, &req) {
 		return
 	}
 
+	var allowedIPs []string
+	if req.AllowedIPs != nil {
+		var err error
+		if allowedIPs, err = s.validateAllowedIPs(peer.ID, *req.AllowedIPs); err != nil {
+			router.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	if req.Name != nil {
 		peer.Name = *req.Name
 	}
@@ -113,21 +124,20 @@ func (s *Service) handleUpdatePeer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.peerStore.Add(peer)
+	if req.AllowedIPs != nil {
+		if err := s.peerStore.SetAllowedIPs(peer.ID, allowedIPs); err != nil {
+			router.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		peer.AllowedIPs = allowedIPs
+	}
 	s.syncConfig()
 	router.JSON(w, peer)
 }
 
 func (s *Service) handleDeletePeer(w http.ResponseWriter, r *http.Request) {
 	id := router.ExtractPathParam(r, "/api/wg/peers/")
-	s.peerStore.Delete(id)
-	s.syncConfig()
-
-	// Drop the peer's IP from any nftables sets (e.g. no_internet_peers).
-	requestFirewallApply()
-
-	// Broadcast node stats update
-	ws.BroadcastNodeStats()
-
+	s.DeletePeer(id)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -237,7 +247,7 @@ func (s *Service) handleGetPeerQR(w http.ResponseWriter, r *http.Request) {
 func (s *Service) handleGetServer(w http.ResponseWriter, r *http.Request) {
 	router.JSON(w, map[string]interface{}{
 		"publicKey":        s.config.ServerPubKey,
-		"endpoint":         s.config.Endpoint,
+		"endpoint":         s.endpoint(),
 		"port":             s.config.ListenPort,
 		"ipRange":          s.config.IPRange,
 		"serverIP":         s.config.ServerIP,
@@ -246,3 +256,109 @@ func (s *Service) handleGetServer(w http.ResponseWriter, r *http.Request) {
 		"headscaleIPRange": s.config.HeadscaleIPRange,
 	})
 }
+
+// handleRotateServerKeys generates a new server keypair and applies it to the
+// interface. Every peer loses its tunnel until it imports a re-issued config,
+// so the request must repeat the current server public key as confirmation.
+func (s *Service) handleRotateServerKeys(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ConfirmPublicKey string `json:"confirmPublicKey"`
+	}
+	if !router.DecodeJSONOrError(w, r, &req) {
+		return
+	}
+	if req.ConfirmPublicKey != s.config.ServerPubKey {
+		router.JSONError(w, "confirmPublicKey must be the current server public key: rotating disconnects every peer", http.StatusBadRequest)
+		return
+	}
+
+	oldKey := s.config.ServerPubKey
+	newKey, err := s.rotateServerKeys()
+	if err != nil && newKey == "" {
+		router.JSONError(w, "failed to rotate keys: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Every peer config embeds the old public key; downloads now carry the new one
+	type reissuePeer struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		IPAddress string `json:"ipAddress"`
+		Enabled   bool   `json:"enabled"`
+		Online    bool   `json:"online"` // handshake within 3 minutes, before the rotation
+	}
+	peers := []reissuePeer{}
+	for _, p := range s.ListPeersWithStatus() {
+		peers = append(peers, reissuePeer{p.ID, p.Name, p.IPAddress, p.Enabled, p.Online})
+	}
+
+	resp := map[string]interface{}{
+		"publicKey":    newKey,
+		"oldPublicKey": oldKey,
+		"reissue":      peers,
+		"count":        len(peers),
+		"message":      "all peers must import a re-issued config to reconnect",
+	}
+	if err != nil {
+		resp["warning"] = err.Error()
+	}
+	ws.BroadcastNodeStats()
+	router.JSON(w, resp)
+}
+
+// handleGetServerConfig returns the server interface config together with its
+// live status, and whether the running interface matches the config. The
+// private key is never included.
+func (s *Service) handleGetServerConfig(w http.ResponseWriter, r *http.Request) {
+	status := s.getInterfaceStatus()
+
+	var issues []string
+	if !status.Exists {
+		issues = append(issues, "interface "+s.config.Interface+" does not exist")
+	} else {
+		if !status.Up {
+			issues = append(issues, "interface "+s.config.Interface+" is down")
+		}
+		if status.PublicKey != "" && status.PublicKey != s.config.ServerPubKey {
+			issues = append(issues, "running public key differs from the configured server key")
+		}
+		if status.ListenPort != 0 && status.ListenPort != s.config.ListenPort {
+			issues = append(issues, fmt.Sprintf("listening on port %d, configured %d", status.ListenPort, s.config.ListenPort))
+		}
+		hasServerIP := false
+		for _, addr := range status.Addresses {
+			if ip, _, err := net.ParseCIDR(addr); err == nil && ip.String() == s.config.ServerIP {
+				hasServerIP = true
+			}
+		}
+		if !hasServerIP {
+			issues = append(issues, "server IP "+s.config.ServerIP+" is not assigned to the interface")
+		}
+	}
+	if issues == nil {
+		issues = []string{}
+	}
+
+	configured := 0
+	for _, p := range s.peerStore.List() {
+		if p.Enabled {
+			configured++
+		}
+	}
+
+	router.JSON(w, map[string]interface{}{
+		"config": map[string]interface{}{
+			"interface":  s.config.Interface,
+			"publicKey":  s.config.ServerPubKey,
+			"listenPort": s.config.ListenPort,
+			"address":    s.config.ServerIP,
+			"ipRange":    s.config.IPRange,
+			"endpoint":   s.endpoint(),
+			"dns":        s.config.DNS,
+		},
+		"status":       status,
+		"enabledPeers": configured,
+		"healthy":      len(issues) == 0,
+		"issues":       issues,
+	})
+}
@@ -45,6 +45,41 @@ func (s *Service) initServerKeys() error {
 	return nil
 }
 
+// rotateServerKeys replaces the server keypair on disk and on the running
+// interface. The old private key is not kept: rotation is for a compromised key.
+func (s *Service) rotateServerKeys() (string, error) {
+	priKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate private key: %v", err)
+	}
+	priKeyPath := filepath.Join(s.config.DataDir, "server_private.key")
+	pubKeyPath := filepath.Join(s.config.DataDir, "server_public.key")
+
+	// Write both files before replacing either, so a failure leaves the old pair intact
+	if err := os.WriteFile(priKeyPath+".new", []byte(priKey.String()), 0600); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(pubKeyPath+".new", []byte(priKey.PublicKey().String()), 0644); err != nil {
+		os.Remove(priKeyPath + ".new")
+		return "", err
+	}
+	if err := os.Rename(priKeyPath+".new", priKeyPath); err != nil {
+		return "", err
+	}
+	if err := os.Rename(pubKeyPath+".new", pubKeyPath); err != nil {
+		return "", err
+	}
+
+	s.config.ServerPriKey = priKey.String()
+	s.config.ServerPubKey = priKey.PublicKey().String()
+	log.Printf("Rotated WireGuard server keys, new public key: %s", s.config.ServerPubKey)
+
+	if err := s.syncConfig(); err != nil {
+		return s.config.ServerPubKey, fmt.Errorf("keys rotated but the interface was not updated: %v", err)
+	}
+	return s.config.ServerPubKey, nil
+}
+
 func (s *Service) initWireGuard() error {
 	// Check if wireguard module is loaded (don't try modprobe - container doesn't have it)
 	if _, err := os.Stat("/sys/module/wireguard"); os.IsNotExist(err) {
@@ -80,6 +115,8 @@ func (s *Service) initWireGuard() error {
 	if err := exec.Command("ip", "link", "set", "up", "dev", s.config.Interface).Run(); err != nil {
 		return fmt.Errorf("failed to bring up interface: %v", err)
 	}
+	// Routes can't be added while the interface is down, so repeat them now
+	s.syncPeerRoutes(s.routedNetworks())
 
 	s.setupNAT()
 	log.Printf("WireGuard interface %s initialized with IP %s", s.config.Interface, s.config.ServerIP)
@@ -159,7 +196,8 @@ ListenPort = %d
 			if peer.PresharedKey != "" {
 				peerConf += fmt.Sprintf("PresharedKey = %s\n", peer.PresharedKey)
 			}
-			peerConf += fmt.Sprintf("AllowedIPs = %s/32\n\n", peer.IPAddress)
+			allowedIPs := append([]string{peer.IPAddress + "/32"}, peer.AllowedIPs...)
+			peerConf += fmt.Sprintf("AllowedIPs = %s\n\n", strings.Join(allowedIPs, ", "))
 			conf += peerConf
 		}
 	}
@@ -177,7 +215,11 @@ ListenPort = %d
 		}
 	}
 
-	return exec.Command("wg", "syncconf", s.config.Interface, confPath).Run()
+	if err := exec.Command("wg", "syncconf", s.config.Interface, confPath).Run(); err != nil {
+		return err
+	}
+	s.syncPeerRoutes(s.routedNetworks())
+	return nil
 }
 
 func (s *Service) getCurrentPeers() []string {
@@ -232,3 +274,55 @@ func (s *Service) enrichPeersWithStatus(peers []*Peer) {
 		}
 	}
 }
+
+// InterfaceStatus is the live state of the server's WireGuard interface
+type InterfaceStatus struct {
+	Exists     bool     `json:"exists"`
+	Up         bool     `json:"up"`
+	MTU        int      `json:"mtu,omitempty"`
+	Addresses  []string `json:"addresses"`
+	PublicKey  string   `json:"publicKey,omitempty"` // key the kernel uses; the private key is never read
+	ListenPort int      `json:"listenPort,omitempty"`
+	Peers      int      `json:"peers"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// getInterfaceStatus reads the interface from the kernel (link, MTU, addresses)
+// and from wg show (key, port, peers)
+func (s *Service) getInterfaceStatus() InterfaceStatus {
+	status := InterfaceStatus{Addresses: []string{}}
+	iface, err := net.InterfaceByName(s.config.Interface)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Exists = true
+	status.Up = iface.Flags&net.FlagUp != 0
+	status.MTU = iface.MTU
+	if addrs, err := iface.Addrs(); err == nil {
+		for _, a := range addrs {
+			status.Addresses = append(status.Addresses, a.String())
+		}
+	}
+
+	// dump: interface line (private key, public key, port, fwmark), then one line per peer
+	out, err := exec.Command("wg", "show", s.config.Interface, "dump").Output()
+	if err != nil {
+		status.Error = fmt.Sprintf("wg show failed: %v", err)
+		return status
+	}
+	for i, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		if i == 0 {
+			if len(fields) >= 3 {
+				status.PublicKey = fields[1]
+				status.ListenPort, _ = strconv.Atoi(fields[2])
+			}
+			continue
+		}
+		if line != "" {
+			status.Peers++
+		}
+	}
+	return status
+}
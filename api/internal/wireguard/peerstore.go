@@ -117,7 +117,7 @@ func (ps *PeerStore) Load() error {
 	}
 
 	rows, err := db.Query(`
-		SELECT external_id, name, ip, public_key, private_key_enc, preshared_key_enc, enabled, COALESCE(block_internet, 0), created_at
+		SELECT external_id, name, ip, public_key, private_key_enc, preshared_key_enc, enabled, COALESCE(block_internet, 0), COALESCE(dns_server, ''), COALESCE(allowed_ips, ''), created_at
 		FROM vpn_clients
 		WHERE type = 'wireguard' AND external_id IS NOT NULL
 	`)
@@ -128,12 +128,12 @@ func (ps *PeerStore) Load() error {
 
 	ps.cache = make(map[string]*Peer)
 	for rows.Next() {
-		var id, name, ip string
+		var id, name, ip, dnsServer, allowedIPs string
 		var publicKey, privateKeyEnc, presharedKeyEnc sql.NullString
 		var enabled, blockInternet int
 		var createdAt time.Time
 
-		if err := rows.Scan(&id, &name, &ip, &publicKey, &privateKeyEnc, &presharedKeyEnc, &enabled, &blockInternet, &createdAt); err != nil {
+		if err := rows.Scan(&id, &name, &ip, &publicKey, &privateKeyEnc, &presharedKeyEnc, &enabled, &blockInternet, &dnsServer, &allowedIPs, &createdAt); err != nil {
 			log.Printf("Warning: failed to scan peer row: %v", err)
 			continue
 		}
@@ -145,8 +145,12 @@ func (ps *PeerStore) Load() error {
 			PublicKey:     publicKey.String,
 			Enabled:       enabled == 1,
 			BlockInternet: blockInternet == 1,
+			DNSServer:     dnsServer,
 			CreatedAt:     createdAt,
 		}
+		if allowedIPs != "" {
+			peer.AllowedIPs = strings.Split(allowedIPs, ",")
+		}
 
 		// Decrypt sensitive keys
 		if privateKeyEnc.Valid && privateKeyEnc.String != "" {
@@ -200,7 +204,7 @@ func (ps *PeerStore) Add(peer *Peer) {
 	}
 
 	// Upsert to database
-	// block_internet preserved across UPSERT (no `excluded.block_internet` on update — set via SetBlockInternet)
+	// block_internet, dns_server and allowed_ips preserved across UPSERT (set via SetBlockInternet / SetDNSServer / SetAllowedIPs)
 	_, err = db.Exec(`
 		INSERT INTO vpn_clients (name, ip, type, external_id, raw_data, acl_policy, public_key, private_key_enc, preshared_key_enc, enabled, block_internet)
 		VALUES (?, ?, 'wireguard', ?, ?, 'selected', ?, ?, ?, ?, ?)
@@ -252,6 +256,54 @@ func (ps *PeerStore) SetBlockInternet(id string, block bool) error {
 	return nil
 }
 
+// SetDNSServer updates the per-peer DNS override in DB and cache.
+func (ps *PeerStore) SetDNSServer(id, dns string) error {
+	ps.Lock()
+	defer ps.Unlock()
+
+	peer, ok := ps.cache[id]
+	if !ok {
+		return fmt.Errorf("peer not found: %s", id)
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`UPDATE vpn_clients SET dns_server = ?, updated_at = CURRENT_TIMESTAMP WHERE ip = ? AND type = 'wireguard'`, dns, peer.IPAddress)
+	if err != nil {
+		return err
+	}
+
+	peer.DNSServer = dns
+	return nil
+}
+
+// SetAllowedIPs replaces the extra networks routed to a peer in DB and cache.
+func (ps *PeerStore) SetAllowedIPs(id string, cidrs []string) error {
+	ps.Lock()
+	defer ps.Unlock()
+
+	peer, ok := ps.cache[id]
+	if !ok {
+		return fmt.Errorf("peer not found: %s", id)
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`UPDATE vpn_clients SET allowed_ips = ?, updated_at = CURRENT_TIMESTAMP WHERE ip = ? AND type = 'wireguard'`, strings.Join(cidrs, ","), peer.IPAddress)
+	if err != nil {
+		return err
+	}
+
+	peer.AllowedIPs = cidrs
+	return nil
+}
+
 // Get returns a copy of a peer by ID
 func (ps *PeerStore) Get(id string) *Peer {
 	ps.RLock()
@@ -3,11 +3,15 @@ package wireguard
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"api/internal/helper"
 	"api/internal/router"
+	"api/internal/ws"
 )
 
 // stripSensitiveKeys removes private and preshared keys from a peer for safe API response
@@ -69,6 +73,20 @@ func (s *Service) ListPeers() []SimplePeer {
 	return result
 }
 
+// SetPeerDNSServer sets the DNS server pushed in a peer's generated config ("" = WG_DNS)
+func (s *Service) SetPeerDNSServer(id, dns string) error {
+	return s.peerStore.SetDNSServer(id, dns)
+}
+
+// DeletePeer removes a peer, rewrites the WireGuard config and drops the
+// peer's IP from nftables sets (e.g. no_internet_peers)
+func (s *Service) DeletePeer(id string) {
+	s.peerStore.Delete(id)
+	s.syncConfig()
+	requestFirewallApply()
+	ws.BroadcastNodeStats()
+}
+
 // ListPeersWithStatus returns all peers with enriched online status
 func (s *Service) ListPeersWithStatus() []*Peer {
 	peers := s.peerStore.List()
@@ -82,10 +100,8 @@ type Config struct {
 	ListenPort       int
 	ServerPubKey     string
 	ServerPriKey     string
-	Endpoint         string
 	IPRange          string
 	ServerIP         string // WireGuard server IP (internal, e.g., 10.8.0.1)
-	PublicIP         string // Public server IP (e.g., 188.241.210.100)
 	DNS              string
 	DataDir          string
 	HeadscaleIPRange string
@@ -105,20 +121,33 @@ type Peer struct {
 	Online        bool      `json:"online"`
 	LastHandshake time.Time `json:"lastHandshake,omitempty"`
 	BlockInternet bool      `json:"blockInternet"`
+	DNSServer     string    `json:"dnsServer,omitempty"`  // overrides WG_DNS when set
+	AllowedIPs    []string  `json:"allowedIps,omitempty"` // networks routed to the peer besides its own IP (subnet routers)
+}
+
+// endpoint returns the address peers connect to, built from the effective
+// public IP so a detected address replaces a missing or stale SERVER_IP
+func (s *Service) endpoint() string {
+	return net.JoinHostPort(helper.ServerIP(), strconv.Itoa(s.config.ListenPort))
 }
 
 // New creates a new WireGuard service
 func New(dataDir string) (*Service, error) {
 	port := helper.GetEnvInt("WG_PORT")
-	serverIP := helper.GetEnv("SERVER_IP")
+	serverIP := helper.GetEnvOptional("SERVER_IP", "")
 	wgServerIP := helper.GetEnv("WG_SERVER_IP")
 	ipRange := helper.GetEnv("WG_IP_RANGE")
 	dns := helper.GetEnv("WG_DNS")
 	headscaleIPRange := helper.GetEnv("HEADSCALE_IP_RANGE")
 
 	// Validate IP configurations
-	if err := helper.ValidateIP(serverIP); err != nil {
-		return nil, fmt.Errorf("invalid SERVER_IP: %v", err)
+	if serverIP != "" {
+		if err := helper.ValidateIP(serverIP); err != nil {
+			return nil, fmt.Errorf("invalid SERVER_IP: %v", err)
+		}
+	} else if err := helper.RefreshPublicIP(); err != nil {
+		// Peer configs need an endpoint; background detection keeps retrying
+		log.Printf("Warning: SERVER_IP is not set and public IP detection failed: %v", err)
 	}
 	if err := helper.ValidateIP(wgServerIP); err != nil {
 		return nil, fmt.Errorf("invalid WG_SERVER_IP: %v", err)
@@ -135,16 +164,12 @@ func New(dataDir string) (*Service, error) {
 		}
 	}
 
-	endpoint := fmt.Sprintf("%s:%d", serverIP, port)
-
 	svc := &Service{
 		config: Config{
 			Interface:        helper.GetEnv("WG_INTERFACE"),
 			ListenPort:       port,
-			Endpoint:         endpoint,
 			IPRange:          ipRange,
 			ServerIP:         wgServerIP,
-			PublicIP:         serverIP,
 			DNS:              dns,
 			DataDir:          dataDir,
 			HeadscaleIPRange: headscaleIPRange,
@@ -183,24 +208,29 @@ func New(dataDir string) (*Service, error) {
 // Handlers returns the handler map for the router
 func (s *Service) Handlers() router.ServiceHandlers {
 	return router.ServiceHandlers{
-		"GetPeers":        s.handleGetPeers,
-		"CreatePeer":      s.handleCreatePeer,
-		"GetPeer":         s.handleGetPeer,
-		"UpdatePeer":      s.handleUpdatePeer,
-		"DeletePeer":      s.handleDeletePeer,
-		"EnablePeer":      s.handleEnablePeer,
-		"DisablePeer":     s.handleDisablePeer,
-		"BlockInternet":   s.handleBlockInternet,
-		"UnblockInternet": s.handleUnblockInternet,
-		"GetPeerConfig":   s.handleGetPeerConfig,
-		"GetPeerQR":       s.handleGetPeerQR,
-		"GetServer":       s.handleGetServer,
+		"GetPeers":         s.handleGetPeers,
+		"CreatePeer":       s.handleCreatePeer,
+		"GetPeer":          s.handleGetPeer,
+		"UpdatePeer":       s.handleUpdatePeer,
+		"DeletePeer":       s.handleDeletePeer,
+		"EnablePeer":       s.handleEnablePeer,
+		"DisablePeer":      s.handleDisablePeer,
+		"BlockInternet":    s.handleBlockInternet,
+		"UnblockInternet":  s.handleUnblockInternet,
+		"GetPeerConfig":    s.handleGetPeerConfig,
+		"GetPeerQR":        s.handleGetPeerQR,
+		"GetServer":        s.handleGetServer,
+		"GetServerConfig":  s.handleGetServerConfig,
+		"RotateServerKeys": s.handleRotateServerKeys,
 	}
 }
 
 func (s *Service) generateClientConfig(peer *Peer, mode string) string {
 	allowedIPs := "0.0.0.0/0, ::/0"
 	dns := s.config.DNS
+	if peer.DNSServer != "" {
+		dns = peer.DNSServer
+	}
 
 	if mode == "split" {
 		// Split tunnel: only route VPN and headscale traffic through VPN
@@ -212,6 +242,12 @@ func (s *Service) generateClientConfig(peer *Peer, mode string) string {
 		if s.config.HeadscaleIPRange != "" {
 			allowedIPs += ", " + s.config.HeadscaleIPRange
 		}
+		// Networks behind other peers (subnet routers) are reached through the server
+		for _, p := range s.peerStore.List() {
+			if p.Enabled && p.ID != peer.ID && len(p.AllowedIPs) > 0 {
+				allowedIPs += ", " + strings.Join(p.AllowedIPs, ", ")
+			}
+		}
 		// Keep DNS so AdGuard rewrites work for internal domains
 	}
 
@@ -231,7 +267,7 @@ PresharedKey = %s
 Endpoint = %s
 AllowedIPs = %s
 PersistentKeepalive = 25
-`, s.config.ServerPubKey, peer.PresharedKey, s.config.Endpoint, allowedIPs)
+`, s.config.ServerPubKey, peer.PresharedKey, s.endpoint(), allowedIPs)
 
 	return conf
 }
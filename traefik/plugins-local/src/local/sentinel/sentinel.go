@@ -1,8 +1,10 @@
 // Package sentinel provides a multi-feature Traefik middleware for access control.
-// Features: IP filtering, maintenance mode, robots.txt, header validation, user-agent blocking, time-based access.
+// Features: IP filtering, maintenance mode, robots.txt, header validation, user-agent blocking, JA3 fingerprint blocking,
+// time-based access, metrics.
 package sentinel
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,9 +12,11 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,6 +29,7 @@ const (
 	BlockReasonHeader
 	BlockReasonTime
 	BlockReasonMaintenance
+	BlockReasonJA3
 )
 
 // =============================================================================
@@ -48,17 +53,56 @@ type Config struct {
 	// UserAgents blocks requests by user-agent
 	UserAgents *UserAgentsConfig `json:"userAgents,omitempty"`
 
+	// JA3Filter blocks requests by TLS client fingerprint
+	JA3Filter *JA3FilterConfig `json:"ja3Filter,omitempty"`
+
 	// TimeAccess restricts access by time of day
 	TimeAccess *TimeAccessConfig `json:"timeAccess,omitempty"`
 
-	// ErrorMode determines response for blocked requests: silent, 401, 403, 404, 503
+	// ErrorMode determines response for blocked requests: silent, 401, 403, 404, 503, redirect, static
 	ErrorMode string `json:"errorMode,omitempty"`
+
+	// RedirectURL is where redirect mode sends blocked clients (must not be behind this middleware)
+	RedirectURL string `json:"redirectUrl,omitempty"`
+
+	// RedirectCode is the redirect status: 301, 302 (default), 303, 307, 308
+	RedirectCode int `json:"redirectCode,omitempty"`
+
+	// StaticBody is served with 200 by static mode instead of an error page
+	StaticBody string `json:"staticBody,omitempty"`
+
+	// StaticContentType for static mode (default "text/html; charset=utf-8")
+	StaticContentType string `json:"staticContentType,omitempty"`
+
+	// DropMode controls how silent mode drops connections: rst (default), close, tarpit
+	DropMode string `json:"dropMode,omitempty"`
+
+	// TarpitSeconds is how long tarpit mode holds a connection open (default 30, max 300)
+	TarpitSeconds int `json:"tarpitSeconds,omitempty"`
+
+	// TarpitMax caps concurrently held connections (default 100); overflow falls back to rst
+	TarpitMax int `json:"tarpitMax,omitempty"`
+
+	// Metrics counts allow/block decisions and writes them to a file
+	Metrics *MetricsConfig `json:"metrics,omitempty"`
+
+	// Slowlist delays otherwise allowed requests from listed IPs
+	Slowlist *SlowlistConfig `json:"slowlist,omitempty"`
+
+	// ViolationThreshold reports IPs that keep getting blocked to a ban feed file
+	ViolationThreshold *ViolationThresholdConfig `json:"violationThreshold,omitempty"`
 }
 
 // IPFilterConfig configures IP-based filtering.
 type IPFilterConfig struct {
 	// SourceRange is a list of allowed IP ranges in CIDR notation
 	SourceRange []string `json:"sourceRange,omitempty"`
+	// AllowASN is a list of allowed autonomous systems (e.g. "AS13335" or "13335")
+	AllowASN []string `json:"allowAsn,omitempty"`
+	// ASNFile is a local ASN->prefix dataset, one "CIDR ASN" pair per line
+	ASNFile string `json:"asnFile,omitempty"`
+	// ASNRefresh in seconds between dataset reloads (default 86400 = 24h)
+	ASNRefresh int `json:"asnRefresh,omitempty"`
 }
 
 // MaintenanceConfig configures maintenance mode.
@@ -71,6 +115,15 @@ type MaintenanceConfig struct {
 	Message string `json:"message,omitempty"`
 	// Title for the page
 	Title string `json:"title,omitempty"`
+	// Bypass lists IP ranges (CIDR) that skip the maintenance page
+	Bypass []string `json:"bypass,omitempty"`
+	// PageFile names a static HTML file in maintenancePagesDir served instead
+	// of the built-in page, for every path (asset requests get the page too, so
+	// inline CSS and images or load them from another host). Falls back to the
+	// built-in page if unreadable or not a bare file name.
+	PageFile string `json:"pageFile,omitempty"`
+	// PageSubstitute replaces {CODE}, {TITLE} and {MESSAGE} in PageFile
+	PageSubstitute bool `json:"pageSubstitute,omitempty"`
 }
 
 // RobotsConfig configures robots.txt serving.
@@ -119,6 +172,25 @@ type UserAgentsConfig struct {
 	Allow []string `json:"allow,omitempty"`
 }
 
+// JA3FilterConfig configures blocking by JA3 TLS fingerprint. Traefik doesn't
+// compute JA3 itself: the hash must be set in a header by whatever terminates
+// TLS in front of it, and that hop must overwrite any client-sent value.
+type JA3FilterConfig struct {
+	// Enabled activates JA3 checking
+	Enabled bool `json:"enabled,omitempty"`
+	// Header carrying the JA3 hash (default "X-JA3"); requests without it pass
+	Header string `json:"header,omitempty"`
+	// Block lists JA3 hashes (32 hex digits, case-insensitive)
+	Block []string `json:"block,omitempty"`
+	// ListURL to fetch more hashes: a JSON array of strings, or text/CSV with the hash first on each line
+	ListURL string `json:"listUrl,omitempty"`
+	// CacheTTL in seconds for remote list (default 86400 = 24h)
+	CacheTTL int `json:"cacheTTL,omitempty"`
+}
+
+// defaultJA3Header is read when JA3Filter.Header is empty
+const defaultJA3Header = "X-JA3"
+
 // TimeAccessConfig configures time-based access control.
 type TimeAccessConfig struct {
 	// Enabled activates time-based checking
@@ -133,6 +205,73 @@ type TimeAccessConfig struct {
 	Timezone string `json:"timezone,omitempty"`
 }
 
+// MetricsConfig configures request counters.
+type MetricsConfig struct {
+	// Enabled activates counting
+	Enabled bool `json:"enabled,omitempty"`
+	// File to write counters to as JSON, keyed by middleware name (shared by all middlewares using it)
+	File string `json:"file,omitempty"`
+	// Interval in seconds between file writes (default 10)
+	Interval int `json:"interval,omitempty"`
+}
+
+// SlowlistConfig configures response delays for suspicious IPs.
+type SlowlistConfig struct {
+	// Enabled activates the slowlist
+	Enabled bool `json:"enabled,omitempty"`
+	// File lists delayed IPs or CIDRs, one per line ("#" comments allowed)
+	File string `json:"file,omitempty"`
+	// Refresh in seconds between file reloads (default 60)
+	Refresh int `json:"refresh,omitempty"`
+	// DelayMs added before the request is passed on (default 3000, max 30000)
+	DelayMs int `json:"delayMs,omitempty"`
+	// MaxDelayed caps concurrently delayed requests (default 100); overflow is served without delay
+	MaxDelayed int `json:"maxDelayed,omitempty"`
+}
+
+// ViolationThresholdConfig escalates repeat offenders: once an IP collects
+// Count blocks for the listed reasons within Window seconds, one line
+// "IP<TAB>middleware<TAB>reason<TAB>time" is appended to File for the
+// firewall to ban. Counts are kept in memory and reset on Traefik restart.
+type ViolationThresholdConfig struct {
+	// Enabled activates violation tracking
+	Enabled bool `json:"enabled,omitempty"`
+	// Count of violations that triggers a report (default 10)
+	Count int `json:"count,omitempty"`
+	// Window in seconds the violations must fall into (default 600)
+	Window int `json:"window,omitempty"`
+	// File the offending IPs are appended to (the firewall's ban feed)
+	File string `json:"file,omitempty"`
+	// Reasons that count: header, userAgent, ja3, ip, time (default header, userAgent, ja3)
+	Reasons []string `json:"reasons,omitempty"`
+	// TrustedProxies lists peers (CIDR) whose forwarded headers name the
+	// reported client. Other requests report the TCP peer, so a forged
+	// X-Forwarded-For can't get a third party banned.
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+}
+
+// Violation threshold bounds
+const (
+	defaultViolationCount  = 10
+	defaultViolationWindow = 600
+	maxViolationTracked    = 10000
+)
+
+// Slowlist bounds
+const (
+	defaultSlowlistRefresh = 60
+	defaultSlowlistDelayMs = 3000
+	maxSlowlistDelayMs     = 30000
+	defaultSlowlistMax     = 100
+)
+
+// Tarpit bounds
+const (
+	defaultTarpitSeconds = 30
+	maxTarpitSeconds     = 300
+	defaultTarpitMax     = 100
+)
+
 // CreateConfig creates the default plugin configuration.
 func CreateConfig() *Config {
 	return &Config{
@@ -305,15 +444,28 @@ type Sentinel struct {
 	debug  bool
 
 	// Parsed data
-	networks     []*net.IPNet
-	headerRegex  []*regexp.Regexp
-	robotsCache  *remoteCache
-	agentsCache  *remoteCache
-	blockRegex   []*regexp.Regexp
-	allowRegex   []*regexp.Regexp
-	timeLocation *time.Location
-	timeAllow    *timeRange
-	timeDeny     *timeRange
+	networks          []*net.IPNet
+	asnNetworks       *prefixFile
+	maintenanceBypass []*net.IPNet
+	maintenancePage   *staticFile
+	headerRegex       []*regexp.Regexp
+	robotsCache       *remoteCache
+	agentsCache       *remoteCache
+	ja3Block          map[string]bool
+	ja3Cache          *remoteCache
+	blockRegex        []*regexp.Regexp
+	allowRegex        []*regexp.Regexp
+	timeLocation      *time.Location
+	timeAllow         *timeRange
+	timeDeny          *timeRange
+	metrics           *counters
+	violations        *violationTracker
+	banTrusted        []*net.IPNet
+	tarpitDelay       time.Duration
+	tarpitSlots       chan struct{} // semaphore bounding tarpit goroutines
+	slowNetworks      *prefixFile
+	slowDelay         time.Duration
+	slowSlots         chan struct{} // semaphore bounding delayed requests
 }
 
 // timeRange represents a parsed time range
@@ -344,24 +496,14 @@ func New(ctx context.Context, next http.Handler, config *Config, name string) (h
 
 	// Parse IP networks
 	if config.IPFilter != nil {
-		for _, cidr := range config.IPFilter.SourceRange {
-			cidr = strings.TrimSpace(cidr)
-			if cidr == "" {
-				continue
-			}
-			// Handle single IPs without CIDR notation
-			if !strings.Contains(cidr, "/") {
-				if strings.Contains(cidr, ":") {
-					cidr += "/128"
-				} else {
-					cidr += "/32"
-				}
-			}
-			_, network, err := net.ParseCIDR(cidr)
-			if err != nil {
-				continue
+		s.networks = parseNetworks(config.IPFilter.SourceRange)
+
+		// Expand allowed ASNs to their prefixes
+		if len(config.IPFilter.AllowASN) > 0 && config.IPFilter.ASNFile != "" {
+			s.asnNetworks = newASNPrefixes(config.IPFilter.ASNFile, config.IPFilter.AllowASN, config.IPFilter.ASNRefresh)
+			if err := s.asnNetworks.load(); err != nil {
+				s.log("ASN dataset load failed: %v", err)
 			}
-			s.networks = append(s.networks, network)
 		}
 	}
 
@@ -379,6 +521,18 @@ func New(ctx context.Context, next http.Handler, config *Config, name string) (h
 		}
 	}
 
+	// Parse maintenance bypass networks
+	if config.Maintenance != nil {
+		s.maintenanceBypass = parseNetworks(config.Maintenance.Bypass)
+		if config.Maintenance.PageFile != "" {
+			if path := maintenancePagePath(config.Maintenance.PageFile); path != "" {
+				s.maintenancePage = &staticFile{path: path}
+			} else {
+				s.log("maintenance pageFile %q ignored: must be a file name in %s", config.Maintenance.PageFile, maintenancePagesDir)
+			}
+		}
+	}
+
 	// Initialize robots cache
 	if config.Robots != nil && config.Robots.Enabled && config.Robots.ListURL != "" {
 		s.robotsCache = newCache(config.Robots.ListURL, config.Robots.CacheTTL)
@@ -403,6 +557,75 @@ func New(ctx context.Context, next http.Handler, config *Config, name string) (h
 		}
 	}
 
+	// Initialize JA3 blocklist and its remote cache
+	if config.JA3Filter != nil && config.JA3Filter.Enabled {
+		s.ja3Block = make(map[string]bool)
+		for _, hash := range config.JA3Filter.Block {
+			if h := normalizeJA3(hash); h != "" {
+				s.ja3Block[h] = true
+			}
+		}
+		if config.JA3Filter.ListURL != "" {
+			s.ja3Cache = newCache(config.JA3Filter.ListURL, config.JA3Filter.CacheTTL)
+		}
+	}
+
+	// Initialize tarpit budget
+	if config.DropMode == "tarpit" {
+		secs := config.TarpitSeconds
+		if secs <= 0 {
+			secs = defaultTarpitSeconds
+		}
+		if secs > maxTarpitSeconds {
+			secs = maxTarpitSeconds
+		}
+		slots := config.TarpitMax
+		if slots <= 0 {
+			slots = defaultTarpitMax
+		}
+		s.tarpitDelay = time.Duration(secs) * time.Second
+		s.tarpitSlots = make(chan struct{}, slots)
+	}
+
+	// Initialize slowlist
+	if config.Slowlist != nil && config.Slowlist.Enabled && config.Slowlist.File != "" {
+		refresh := config.Slowlist.Refresh
+		if refresh <= 0 {
+			refresh = defaultSlowlistRefresh
+		}
+		delay := config.Slowlist.DelayMs
+		if delay <= 0 {
+			delay = defaultSlowlistDelayMs
+		}
+		if delay > maxSlowlistDelayMs {
+			delay = maxSlowlistDelayMs
+		}
+		slots := config.Slowlist.MaxDelayed
+		if slots <= 0 {
+			slots = defaultSlowlistMax
+		}
+		s.slowNetworks = &prefixFile{file: config.Slowlist.File, ttl: time.Duration(refresh) * time.Second}
+		if err := s.slowNetworks.load(); err != nil {
+			s.log("slowlist load failed: %v", err)
+		}
+		s.slowDelay = time.Duration(delay) * time.Millisecond
+		s.slowSlots = make(chan struct{}, slots)
+	}
+
+	// Initialize metrics counters; without metrics they are counted but never
+	// written (ServeHTTP takes field addresses, so they must not be nil)
+	if config.Metrics != nil && config.Metrics.Enabled {
+		s.metrics = registerMetrics(name, config.Metrics)
+	} else {
+		s.metrics = &counters{}
+	}
+
+	// Initialize violation tracking
+	if config.ViolationThreshold != nil && config.ViolationThreshold.Enabled && config.ViolationThreshold.File != "" {
+		s.violations = registerViolations(name, config.ViolationThreshold)
+		s.banTrusted = parseNetworks(config.ViolationThreshold.TrustedProxies)
+	}
+
 	// Initialize time access config
 	if config.TimeAccess != nil && config.TimeAccess.Enabled {
 		tz := config.TimeAccess.Timezone
@@ -419,80 +642,187 @@ func New(ctx context.Context, next http.Handler, config *Config, name string) (h
 	}
 
 	if debug {
-		s.log("initialized: ipFilter=%d networks, headers=%d rules, robots=%v, userAgents=%v, timeAccess=%v",
-			len(s.networks), len(config.Headers),
+		asnCount := 0
+		if s.asnNetworks != nil {
+			asnCount = len(s.asnNetworks.get())
+		}
+		s.log("initialized: ipFilter=%d networks (+%d from ASN), headers=%d rules, robots=%v, userAgents=%v, ja3=%d hashes, timeAccess=%v",
+			len(s.networks), asnCount, len(config.Headers),
 			config.Robots != nil && config.Robots.Enabled,
 			config.UserAgents != nil && config.UserAgents.Enabled,
+			len(s.ja3Block),
 			config.TimeAccess != nil && config.TimeAccess.Enabled)
 	}
 
 	return s, nil
 }
 
-// ServeHTTP implements the http.Handler interface.
-func (s *Sentinel) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
-	s.log("request: %s %s from %s", req.Method, req.URL.Path, req.RemoteAddr)
+// Decision actions returned by Evaluate
+const (
+	ActionAllow       = "allow"
+	ActionBlock       = "block"
+	ActionMaintenance = "maintenance"
+	ActionRobots      = "robots"
+)
+
+// Decision is the outcome of evaluating a request against the configuration.
+type Decision struct {
+	Action string
+	// Reason is set for ActionBlock and ActionMaintenance
+	Reason BlockReason
+	// Check names the deciding check: maintenance, robots, ipFilter, userAgents, ja3Filter, headers, timeAccess, slowlist
+	Check string
+	// Detail explains the decision, e.g. which pattern matched
+	Detail string
+	// Delay is how long an allowed request is held before being passed on
+	Delay time.Duration
+}
 
+// Evaluate runs the checks in the order ServeHTTP applies them, at time now,
+// without writing a response or counting metrics.
+func (s *Sentinel) Evaluate(req *http.Request, now time.Time) Decision {
 	// 1. Maintenance check (highest priority)
-	if s.checkMaintenance() {
-		s.serveMaintenance(rw, req)
-		return
+	if s.checkMaintenance(req) {
+		return Decision{Action: ActionMaintenance, Reason: BlockReasonMaintenance, Check: "maintenance", Detail: "maintenance mode is enabled"}
 	}
 
 	// 2. Robots.txt handling
 	if s.config.Robots != nil && s.config.Robots.Enabled && req.URL.Path == "/robots.txt" {
-		s.serveRobots(rw, req)
-		return
+		return Decision{Action: ActionRobots, Check: "robots", Detail: "robots.txt is served by the middleware"}
 	}
 
 	// 3. IP filter check
-	if s.config.IPFilter != nil && len(s.networks) > 0 {
+	if s.config.IPFilter != nil && (len(s.networks) > 0 || s.asnNetworks != nil) {
 		clientIP := s.getClientIP(req)
-		if clientIP == nil || !s.isIPAllowed(clientIP) {
-			s.log("IP blocked: %v", clientIP)
-			s.blockRequest(rw, req, BlockReasonIP)
-			return
+		if clientIP == nil {
+			return Decision{Action: ActionBlock, Reason: BlockReasonIP, Check: "ipFilter", Detail: "client IP could not be determined"}
+		}
+		if !s.isIPAllowed(clientIP) {
+			return Decision{Action: ActionBlock, Reason: BlockReasonIP, Check: "ipFilter",
+				Detail: fmt.Sprintf("client IP %v is not in the allowed ranges or ASNs", clientIP)}
 		}
 	}
 
 	// 4. User-agent check
 	if s.config.UserAgents != nil && s.config.UserAgents.Enabled {
-		if s.isUserAgentBlocked(req.Header.Get("User-Agent")) {
-			s.log("User-Agent blocked: %s", req.Header.Get("User-Agent"))
-			s.blockRequest(rw, req, BlockReasonUserAgent)
-			return
+		if pattern := s.userAgentBlockMatch(req.Header.Get("User-Agent")); pattern != "" {
+			return Decision{Action: ActionBlock, Reason: BlockReasonUserAgent, Check: "userAgents",
+				Detail: fmt.Sprintf("user-agent matches %s", pattern)}
 		}
 	}
 
-	// 5. Header validation
+	// 5. JA3 fingerprint check
+	if s.ja3Block != nil {
+		if hash, source := s.ja3BlockMatch(req); source != "" {
+			return Decision{Action: ActionBlock, Reason: BlockReasonJA3, Check: "ja3Filter",
+				Detail: fmt.Sprintf("JA3 %s is on the %s", hash, source)}
+		}
+	}
+
+	// 6. Header validation
 	if len(s.config.Headers) > 0 {
-		if !s.validateHeaders(req) {
-			s.log("Headers validation failed")
-			s.blockRequest(rw, req, BlockReasonHeader)
-			return
+		if failure := s.headerFailure(req); failure != "" {
+			return Decision{Action: ActionBlock, Reason: BlockReasonHeader, Check: "headers", Detail: failure}
 		}
 	}
 
-	// 6. Time-based access
+	// 7. Time-based access
 	if s.config.TimeAccess != nil && s.config.TimeAccess.Enabled {
-		if !s.checkTimeAccess() {
-			s.log("Time access denied")
-			s.blockRequest(rw, req, BlockReasonTime)
-			return
+		if failure := s.timeAccessFailure(now); failure != "" {
+			return Decision{Action: ActionBlock, Reason: BlockReasonTime, Check: "timeAccess", Detail: failure}
+		}
+	}
+
+	// 8. Slowlist: allowed, but delayed
+	if s.slowNetworks != nil {
+		if clientIP := s.getClientIP(req); clientIP != nil {
+			for _, network := range s.slowNetworks.get() {
+				if network.Contains(clientIP) {
+					return Decision{Action: ActionAllow, Check: "slowlist", Delay: s.slowDelay,
+						Detail: fmt.Sprintf("client IP %v is slowlisted (%v)", clientIP, network)}
+				}
+			}
+		}
+	}
+
+	return Decision{Action: ActionAllow}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Sentinel) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	s.log("request: %s %s from %s", req.Method, req.URL.Path, req.RemoteAddr)
+	s.metrics.inc(&s.metrics.Total)
+
+	decision := s.Evaluate(req, time.Now())
+	switch decision.Action {
+	case ActionMaintenance:
+		s.metrics.inc(&s.metrics.Maintenance)
+		s.serveMaintenance(rw, req)
+		return
+	case ActionRobots:
+		s.metrics.inc(&s.metrics.Robots)
+		s.serveRobots(rw, req)
+		return
+	case ActionBlock:
+		s.log("%s blocked: %s", decision.Check, decision.Detail)
+		if clientIP := s.banClientIP(req); clientIP != nil {
+			s.violations.record(clientIP.String(), decision.Reason, time.Now())
 		}
+		s.blockRequest(rw, req, decision.Reason)
+		return
 	}
 
 	// All checks passed
+	s.metrics.inc(&s.metrics.Allowed)
+	if decision.Delay > 0 && !s.slowDown(req, decision.Delay) {
+		return
+	}
 	s.next.ServeHTTP(rw, req)
 }
 
+// slowDown holds a slowlisted request for delay. Returns false if the client
+// went away meanwhile. When the slot budget is exhausted the request is served
+// without delay rather than piling up goroutines.
+func (s *Sentinel) slowDown(req *http.Request, delay time.Duration) bool {
+	select {
+	case s.slowSlots <- struct{}{}:
+	default:
+		s.log("slowlist full (%d), not delaying", cap(s.slowSlots))
+		return true
+	}
+	defer func() { <-s.slowSlots }()
+
+	s.metrics.inc(&s.metrics.Slowed)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
+
 // =============================================================================
 // Maintenance Mode
 // =============================================================================
 
-func (s *Sentinel) checkMaintenance() bool {
+func (s *Sentinel) checkMaintenance(req *http.Request) bool {
 	m := s.config.Maintenance
-	return m != nil && m.Enabled
+	if m == nil || !m.Enabled {
+		return false
+	}
+	if len(s.maintenanceBypass) > 0 {
+		if ip := s.getClientIP(req); ip != nil {
+			for _, network := range s.maintenanceBypass {
+				if network.Contains(ip) {
+					s.log("maintenance bypassed for %v", ip)
+					return false
+				}
+			}
+		}
+	}
+	return true
 }
 
 func (s *Sentinel) serveMaintenance(rw http.ResponseWriter, req *http.Request) {
@@ -510,15 +840,28 @@ func (s *Sentinel) serveMaintenance(rw http.ResponseWriter, req *http.Request) {
 		message = "We're currently performing maintenance. Please check back soon."
 	}
 
-	// Try custom maintenance template
-	html := getTemplate(BlockReasonMaintenance)
+	// A configured page file wins over the built-in templates
+	html, substitute := "", true
+	if s.maintenancePage != nil {
+		page, err := s.maintenancePage.get()
+		if err != nil {
+			s.log("maintenance page file unavailable, using built-in page: %v", err)
+		} else {
+			html, substitute = page, m.PageSubstitute
+		}
+	}
+	if html == "" {
+		html = getTemplate(BlockReasonMaintenance)
+	}
 	if html == "" {
 		// Fallback to generic template
 		html = errorPageTemplate
 	}
-	html = strings.Replace(html, "{CODE}", fmt.Sprintf("%d", code), -1)
-	html = strings.Replace(html, "{TITLE}", title, -1)
-	html = strings.Replace(html, "{MESSAGE}", message, -1)
+	if substitute {
+		html = strings.Replace(html, "{CODE}", fmt.Sprintf("%d", code), -1)
+		html = strings.Replace(html, "{TITLE}", title, -1)
+		html = strings.Replace(html, "{MESSAGE}", message, -1)
+	}
 
 	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
 	rw.Header().Set("Retry-After", "300")
@@ -526,6 +869,51 @@ func (s *Sentinel) serveMaintenance(rw http.ResponseWriter, req *http.Request) {
 	rw.Write([]byte(html))
 }
 
+// maintenancePagesDir holds the custom maintenance pages (a variable so tests
+// can point it elsewhere)
+var maintenancePagesDir = "/etc/traefik/dynamic/maintenance-pages"
+
+// maintenancePagePath resolves a PageFile inside maintenancePagesDir, or
+// returns "" unless it is a bare file name
+func maintenancePagePath(name string) string {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return ""
+	}
+	return filepath.Join(maintenancePagesDir, name)
+}
+
+// staticFile caches a file's contents and re-reads it when its mtime changes,
+// so the page can be edited without restarting Traefik.
+type staticFile struct {
+	mu      sync.Mutex
+	path    string
+	content string
+	modTime time.Time
+}
+
+// get returns the file contents; an error means the file is missing or unreadable.
+func (f *staticFile) get() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.content != "" && info.ModTime().Equal(f.modTime) {
+		return f.content, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("%s is empty", f.path)
+	}
+	f.content = string(data)
+	f.modTime = info.ModTime()
+	return f.content, nil
+}
+
 // =============================================================================
 // Robots.txt
 // =============================================================================
@@ -615,7 +1003,11 @@ func (s *Sentinel) getClientIP(req *http.Request) net.IP {
 		}
 	}
 
-	// RemoteAddr
+	return peerIP(req)
+}
+
+// peerIP returns the TCP peer of the request
+func peerIP(req *http.Request) net.IP {
 	host, _, err := net.SplitHostPort(req.RemoteAddr)
 	if err != nil {
 		return net.ParseIP(req.RemoteAddr)
@@ -623,15 +1015,182 @@ func (s *Sentinel) getClientIP(req *http.Request) net.IP {
 	return net.ParseIP(host)
 }
 
+// banClientIP returns the IP reported to the ban feed: the TCP peer, or the
+// forwarded client when the peer is a trusted proxy. Unlike getClientIP it
+// never believes headers from an arbitrary peer, since the firewall bans
+// whatever lands in the feed.
+func (s *Sentinel) banClientIP(req *http.Request) net.IP {
+	peer := peerIP(req)
+	if peer == nil {
+		return nil
+	}
+	for _, network := range s.banTrusted {
+		if network.Contains(peer) {
+			return s.getClientIP(req)
+		}
+	}
+	return peer
+}
+
+// parseNetworks parses CIDR ranges, accepting single IPs without a prefix length.
+// Invalid entries are skipped.
+func parseNetworks(cidrs []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		if network := parseNetwork(cidr); network != nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// parseNetwork parses a CIDR or a single IP; nil if invalid
+func parseNetwork(cidr string) *net.IPNet {
+	cidr = strings.TrimSpace(cidr)
+	if cidr == "" {
+		return nil
+	}
+	// Handle single IPs without CIDR notation
+	if !strings.Contains(cidr, "/") {
+		if strings.Contains(cidr, ":") {
+			cidr += "/128"
+		} else {
+			cidr += "/32"
+		}
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil
+	}
+	return network
+}
+
 func (s *Sentinel) isIPAllowed(ip net.IP) bool {
 	for _, network := range s.networks {
 		if network.Contains(ip) {
 			return true
 		}
 	}
+	if s.asnNetworks != nil {
+		for _, network := range s.asnNetworks.get() {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
 	return false
 }
 
+// prefixFile holds prefixes loaded from a local file, one per line with
+// optional extra fields, reloaded in the background once stale.
+// On refresh failure the last-known prefixes are kept.
+type prefixFile struct {
+	mu         sync.RWMutex
+	networks   []*net.IPNet
+	loadedAt   time.Time
+	refreshing bool
+	file       string
+	accept     func(fields []string) bool // filters lines; nil accepts all
+	ttl        time.Duration
+}
+
+// newASNPrefixes loads the prefixes of allowed ASNs from a "CIDR ASN" dataset
+func newASNPrefixes(file string, asns []string, refreshSeconds int) *prefixFile {
+	ttl := time.Duration(refreshSeconds) * time.Second
+	if ttl == 0 {
+		ttl = 24 * time.Hour
+	}
+	allowed := make(map[string]bool)
+	for _, asn := range asns {
+		if n := normalizeASN(asn); n != "" {
+			allowed[n] = true
+		}
+	}
+	return &prefixFile{
+		file: file,
+		ttl:  ttl,
+		accept: func(fields []string) bool {
+			return len(fields) >= 2 && allowed[normalizeASN(fields[1])]
+		},
+	}
+}
+
+// normalizeASN strips the optional "AS" prefix: "AS13335" -> "13335"
+func normalizeASN(asn string) string {
+	asn = strings.TrimSpace(asn)
+	if len(asn) > 2 && strings.EqualFold(asn[:2], "AS") {
+		asn = asn[2:]
+	}
+	return asn
+}
+
+// get returns the current prefixes and triggers a background reload when stale.
+func (a *prefixFile) get() []*net.IPNet {
+	a.mu.Lock()
+	stale := time.Since(a.loadedAt) >= a.ttl && !a.refreshing
+	if stale {
+		a.refreshing = true
+	}
+	networks := a.networks
+	a.mu.Unlock()
+
+	if stale {
+		go func() {
+			a.load()
+			a.mu.Lock()
+			a.refreshing = false
+			a.mu.Unlock()
+		}()
+	}
+	return networks
+}
+
+// load reads the dataset and replaces the prefixes. Errors leave the previous set in place.
+func (a *prefixFile) load() error {
+	f, err := os.Open(a.file)
+	if err != nil {
+		a.markLoaded()
+		return err
+	}
+	defer f.Close()
+
+	var networks []*net.IPNet
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) == 0 || (a.accept != nil && !a.accept(fields)) {
+			continue
+		}
+		if network := parseNetwork(fields[0]); network != nil {
+			networks = append(networks, network)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		a.markLoaded()
+		return err
+	}
+
+	a.mu.Lock()
+	a.networks = networks
+	a.loadedAt = time.Now()
+	a.mu.Unlock()
+	return nil
+}
+
+// markLoaded resets the refresh timer without touching the prefixes, so a
+// missing or broken dataset isn't re-read on every request.
+func (a *prefixFile) markLoaded() {
+	a.mu.Lock()
+	a.loadedAt = time.Now()
+	a.mu.Unlock()
+}
+
 // =============================================================================
 // User-Agent Blocking
 // =============================================================================
@@ -643,22 +1202,23 @@ type CrawlerEntry struct {
 	Instances []string `json:"instances"`
 }
 
-func (s *Sentinel) isUserAgentBlocked(ua string) bool {
+// userAgentBlockMatch returns the pattern that blocks ua, or "" when it is allowed
+func (s *Sentinel) userAgentBlockMatch(ua string) string {
 	if ua == "" {
-		return false
+		return ""
 	}
 
 	// Check allow list first (whitelist)
 	for _, re := range s.allowRegex {
 		if re.MatchString(ua) {
-			return false
+			return ""
 		}
 	}
 
 	// Check custom block patterns
 	for _, re := range s.blockRegex {
 		if re.MatchString(ua) {
-			return true
+			return fmt.Sprintf("block pattern %q", strings.TrimPrefix(re.String(), "(?i)"))
 		}
 	}
 
@@ -681,28 +1241,98 @@ func (s *Sentinel) isUserAgentBlocked(ua string) bool {
 		if patterns, ok := data.([]*regexp.Regexp); ok {
 			for _, re := range patterns {
 				if re.MatchString(ua) {
-					return true
+					return fmt.Sprintf("crawler list pattern %q", re.String())
 				}
 			}
 		}
 	}
 
-	return false
+	return ""
+}
+
+// =============================================================================
+// JA3 Fingerprint Blocking
+// =============================================================================
+
+// normalizeJA3 lowercases a JA3 hash, returning "" unless it is 32 hex digits
+func normalizeJA3(hash string) string {
+	h := strings.ToLower(strings.TrimSpace(hash))
+	if len(h) != 32 {
+		return ""
+	}
+	for _, c := range h {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ""
+		}
+	}
+	return h
+}
+
+// parseJA3List reads a remote blocklist: a JSON array of hashes, or lines with
+// the hash as the first comma-separated field (abuse.ch SSLBL CSV, plain lists)
+func parseJA3List(body []byte) interface{} {
+	hashes := make(map[string]bool)
+	var list []string
+	if json.Unmarshal(body, &list) == nil {
+		for _, hash := range list {
+			if h := normalizeJA3(hash); h != "" {
+				hashes[h] = true
+			}
+		}
+	} else {
+		scanner := bufio.NewScanner(strings.NewReader(string(body)))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			field, _, _ := strings.Cut(line, ",")
+			if h := normalizeJA3(field); h != "" {
+				hashes[h] = true
+			}
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	return hashes
+}
+
+// ja3BlockMatch returns the request's JA3 hash and which list blocks it, or
+// an empty source when the header is missing or the hash isn't listed
+func (s *Sentinel) ja3BlockMatch(req *http.Request) (string, string) {
+	header := s.config.JA3Filter.Header
+	if header == "" {
+		header = defaultJA3Header
+	}
+	hash := normalizeJA3(req.Header.Get(header))
+	if hash == "" {
+		return "", ""
+	}
+	if s.ja3Block[hash] {
+		return hash, "block list"
+	}
+	if s.ja3Cache != nil {
+		if remote, ok := s.ja3Cache.fetch(parseJA3List).(map[string]bool); ok && remote[hash] {
+			return hash, "remote list"
+		}
+	}
+	return hash, ""
 }
 
 // =============================================================================
 // Header Validation
 // =============================================================================
 
-func (s *Sentinel) validateHeaders(req *http.Request) bool {
+// headerFailure returns why the request fails header validation, or "" when it passes
+func (s *Sentinel) headerFailure(req *http.Request) string {
 	for i, h := range s.config.Headers {
 		value := req.Header.Get(h.Name)
 
 		// Check if required
 		if value == "" {
 			if h.Required {
-				s.log("header %s required but missing", h.Name)
-				return false
+				return fmt.Sprintf("header %s is required but missing", h.Name)
 			}
 			continue
 		}
@@ -710,8 +1340,7 @@ func (s *Sentinel) validateHeaders(req *http.Request) bool {
 		// Regex check
 		if s.headerRegex[i] != nil {
 			if !s.headerRegex[i].MatchString(value) {
-				s.log("header %s failed regex", h.Name)
-				return false
+				return fmt.Sprintf("header %s does not match regex %q", h.Name, h.Regex)
 			}
 			continue
 		}
@@ -737,23 +1366,20 @@ func (s *Sentinel) validateHeaders(req *http.Request) bool {
 			switch h.MatchType {
 			case "all":
 				if matchCount != len(h.Values) {
-					s.log("header %s failed all match", h.Name)
-					return false
+					return fmt.Sprintf("header %s matches %d of %d required values", h.Name, matchCount, len(h.Values))
 				}
 			case "none":
 				if matched {
-					s.log("header %s matched blacklist", h.Name)
-					return false
+					return fmt.Sprintf("header %s matches a blocked value", h.Name)
 				}
 			default: // "one" or empty
 				if !matched {
-					s.log("header %s no match", h.Name)
-					return false
+					return fmt.Sprintf("header %s matches none of the allowed values", h.Name)
 				}
 			}
 		}
 	}
-	return true
+	return ""
 }
 
 // =============================================================================
@@ -761,15 +1387,26 @@ func (s *Sentinel) validateHeaders(req *http.Request) bool {
 // =============================================================================
 
 func (s *Sentinel) blockRequest(rw http.ResponseWriter, req *http.Request, reason BlockReason) {
+	s.metrics.countBlock(reason)
+
 	mode := s.config.ErrorMode
 	if mode == "" {
 		mode = "403"
 	}
 
-	// Silent drop
-	if mode == "silent" {
+	switch mode {
+	case "silent":
 		s.dropConnection(rw, req)
 		return
+	case "redirect":
+		// Without a target fall through to the default 403 page
+		if s.config.RedirectURL != "" {
+			s.redirectBlocked(rw, req)
+			return
+		}
+	case "static":
+		s.serveStatic(rw)
+		return
 	}
 
 	// Error page
@@ -796,15 +1433,46 @@ func (s *Sentinel) blockRequest(rw http.ResponseWriter, req *http.Request, reaso
 	rw.Write([]byte(html))
 }
 
+// redirectBlocked sends the client elsewhere instead of revealing the block
+func (s *Sentinel) redirectBlocked(rw http.ResponseWriter, req *http.Request) {
+	code := s.config.RedirectCode
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		code = http.StatusFound
+	}
+	rw.Header().Set("Cache-Control", "no-store")
+	http.Redirect(rw, req, s.config.RedirectURL, code)
+}
+
+// serveStatic answers with the configured content and a 200, so the client
+// can't tell it was blocked
+func (s *Sentinel) serveStatic(rw http.ResponseWriter) {
+	contentType := s.config.StaticContentType
+	if contentType == "" {
+		contentType = "text/html; charset=utf-8"
+	}
+	rw.Header().Set("Content-Type", contentType)
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write([]byte(s.config.StaticBody))
+}
+
 func (s *Sentinel) dropConnection(rw http.ResponseWriter, req *http.Request) {
 	hj, ok := rw.(http.Hijacker)
 	if ok {
 		conn, _, err := hj.Hijack()
 		if err == nil && conn != nil {
-			if tcpConn, ok := conn.(*net.TCPConn); ok {
-				tcpConn.SetLinger(0)
+			switch s.config.DropMode {
+			case "close":
+				// Graceful FIN
+				conn.Close()
+			case "tarpit":
+				s.tarpit(conn)
+			default:
+				resetConnection(conn)
 			}
-			conn.Close()
 			return
 		}
 	}
@@ -815,6 +1483,318 @@ func (s *Sentinel) dropConnection(rw http.ResponseWriter, req *http.Request) {
 	rw.WriteHeader(444)
 }
 
+// resetConnection closes with SO_LINGER=0 so the peer gets a TCP RST.
+func resetConnection(conn net.Conn) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// tarpit holds the connection open without responding, then drops it. When the
+// goroutine budget is exhausted (e.g. under a flood) it falls back to a reset.
+func (s *Sentinel) tarpit(conn net.Conn) {
+	select {
+	case s.tarpitSlots <- struct{}{}:
+	default:
+		s.log("tarpit full (%d), resetting", cap(s.tarpitSlots))
+		resetConnection(conn)
+		return
+	}
+
+	go func() {
+		defer func() { <-s.tarpitSlots }()
+		time.Sleep(s.tarpitDelay)
+		resetConnection(conn)
+	}()
+}
+
+// =============================================================================
+// Metrics
+// =============================================================================
+
+// counters holds per-middleware request counters. Fields are updated atomically.
+type counters struct {
+	Total       int64 `json:"total"`
+	Allowed     int64 `json:"allowed"`
+	Robots      int64 `json:"robots"`
+	IP          int64 `json:"ip"`
+	UserAgent   int64 `json:"userAgent"`
+	JA3         int64 `json:"ja3"`
+	Header      int64 `json:"header"`
+	Time        int64 `json:"time"`
+	Maintenance int64 `json:"maintenance"`
+	Slowed      int64 `json:"slowed"`
+}
+
+// metricsEntry ties a middleware's counters to the file they are written to.
+type metricsEntry struct {
+	counters *counters
+	file     string
+}
+
+// Traefik rebuilds middlewares on every dynamic config reload, so counters live
+// in a package-level registry keyed by middleware name and survive rebuilds.
+var (
+	metricsMu      sync.Mutex
+	metricsEntries = map[string]*metricsEntry{}
+	metricsWriters = map[string]bool{}
+)
+
+// registerMetrics returns the counters for a middleware name, creating them on
+// first use, and starts one writer goroutine per metrics file.
+func registerMetrics(name string, cfg *MetricsConfig) *counters {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	entry, ok := metricsEntries[name]
+	if !ok {
+		entry = &metricsEntry{counters: &counters{}}
+		metricsEntries[name] = entry
+	}
+	entry.file = cfg.File
+
+	if cfg.File != "" && !metricsWriters[cfg.File] {
+		metricsWriters[cfg.File] = true
+		interval := time.Duration(cfg.Interval) * time.Second
+		if interval <= 0 {
+			interval = 10 * time.Second
+		}
+		go writeMetricsLoop(cfg.File, interval)
+	}
+
+	return entry.counters
+}
+
+// inc increments a counter field. Nil-safe so callers don't check if metrics are enabled.
+func (c *counters) inc(field *int64) {
+	if c == nil {
+		return
+	}
+	atomic.AddInt64(field, 1)
+}
+
+// countBlock increments the counter for a block reason.
+func (c *counters) countBlock(reason BlockReason) {
+	if c == nil {
+		return
+	}
+	switch reason {
+	case BlockReasonIP:
+		c.inc(&c.IP)
+	case BlockReasonUserAgent:
+		c.inc(&c.UserAgent)
+	case BlockReasonJA3:
+		c.inc(&c.JA3)
+	case BlockReasonHeader:
+		c.inc(&c.Header)
+	case BlockReasonTime:
+		c.inc(&c.Time)
+	case BlockReasonMaintenance:
+		c.inc(&c.Maintenance)
+	}
+}
+
+// snapshot returns a consistent-enough copy of the counters for serialization.
+func (c *counters) snapshot() counters {
+	return counters{
+		Total:       atomic.LoadInt64(&c.Total),
+		Allowed:     atomic.LoadInt64(&c.Allowed),
+		Robots:      atomic.LoadInt64(&c.Robots),
+		IP:          atomic.LoadInt64(&c.IP),
+		UserAgent:   atomic.LoadInt64(&c.UserAgent),
+		JA3:         atomic.LoadInt64(&c.JA3),
+		Header:      atomic.LoadInt64(&c.Header),
+		Time:        atomic.LoadInt64(&c.Time),
+		Maintenance: atomic.LoadInt64(&c.Maintenance),
+		Slowed:      atomic.LoadInt64(&c.Slowed),
+	}
+}
+
+// writeMetricsLoop periodically writes counters of all middlewares using path.
+func writeMetricsLoop(path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		writeMetricsFile(path)
+	}
+}
+
+// writeMetricsFile writes counters to path via a temp file + rename so readers
+// never see a partially written file.
+func writeMetricsFile(path string) {
+	metricsMu.Lock()
+	data := make(map[string]counters)
+	for name, entry := range metricsEntries {
+		if entry.file == path {
+			data[name] = entry.counters.snapshot()
+		}
+	}
+	metricsMu.Unlock()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"updatedAt":   time.Now().UTC().Format(time.RFC3339),
+		"middlewares": data,
+	})
+	if err != nil {
+		return
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
+
+// =============================================================================
+// Violation Threshold
+// =============================================================================
+
+// blockReasonName is the config and metrics name of a block reason
+func blockReasonName(reason BlockReason) string {
+	switch reason {
+	case BlockReasonIP:
+		return "ip"
+	case BlockReasonUserAgent:
+		return "userAgent"
+	case BlockReasonJA3:
+		return "ja3"
+	case BlockReasonHeader:
+		return "header"
+	case BlockReasonTime:
+		return "time"
+	case BlockReasonMaintenance:
+		return "maintenance"
+	default:
+		return "unknown"
+	}
+}
+
+// ipViolations counts one IP's violations in the current window.
+type ipViolations struct {
+	count       int
+	windowStart time.Time
+	reported    bool
+}
+
+// violationTracker counts blocks per client IP for one middleware.
+type violationTracker struct {
+	mu      sync.Mutex
+	name    string
+	file    string
+	count   int
+	window  time.Duration
+	reasons map[string]bool
+	ips     map[string]*ipViolations
+}
+
+// Like metrics, trackers live in a package-level registry so counts survive
+// the middleware rebuild on every dynamic config reload.
+var (
+	violationMu       sync.Mutex
+	violationTrackers = map[string]*violationTracker{}
+	violationFileMu   sync.Mutex
+)
+
+// registerViolations returns the tracker for a middleware name, applying the
+// current config to it.
+func registerViolations(name string, cfg *ViolationThresholdConfig) *violationTracker {
+	violationMu.Lock()
+	defer violationMu.Unlock()
+
+	t, ok := violationTrackers[name]
+	if !ok {
+		t = &violationTracker{name: name, ips: map[string]*ipViolations{}}
+		violationTrackers[name] = t
+	}
+
+	count := cfg.Count
+	if count <= 0 {
+		count = defaultViolationCount
+	}
+	window := cfg.Window
+	if window <= 0 {
+		window = defaultViolationWindow
+	}
+	reasons := cfg.Reasons
+	if len(reasons) == 0 {
+		reasons = []string{"header", "userAgent", "ja3"}
+	}
+
+	t.mu.Lock()
+	t.file = cfg.File
+	t.count = count
+	t.window = time.Duration(window) * time.Second
+	t.reasons = map[string]bool{}
+	for _, r := range reasons {
+		t.reasons[strings.TrimSpace(r)] = true
+	}
+	t.mu.Unlock()
+	return t
+}
+
+// record counts a block for ip and reports the IP once it reaches the
+// threshold. Nil-safe so callers don't check if tracking is enabled.
+func (t *violationTracker) record(ip string, reason BlockReason, now time.Time) {
+	if t == nil {
+		return
+	}
+	name := blockReasonName(reason)
+
+	t.mu.Lock()
+	if !t.reasons[name] {
+		t.mu.Unlock()
+		return
+	}
+	v, ok := t.ips[ip]
+	if !ok || now.Sub(v.windowStart) > t.window {
+		if !ok && len(t.ips) >= maxViolationTracked {
+			t.prune(now)
+			if len(t.ips) >= maxViolationTracked {
+				// Tracking full of live entries: don't grow further
+				t.mu.Unlock()
+				return
+			}
+		}
+		v = &ipViolations{windowStart: now}
+		t.ips[ip] = v
+	}
+	v.count++
+	report := v.count >= t.count && !v.reported
+	if report {
+		v.reported = true
+	}
+	file := t.file
+	t.mu.Unlock()
+
+	if report {
+		appendViolation(file, fmt.Sprintf("%s\t%s\t%s\t%s\n", ip, t.name, name, now.UTC().Format(time.RFC3339)))
+	}
+}
+
+// prune drops IPs whose window has passed. Caller holds t.mu.
+func (t *violationTracker) prune(now time.Time) {
+	for ip, v := range t.ips {
+		if now.Sub(v.windowStart) > t.window {
+			delete(t.ips, ip)
+		}
+	}
+}
+
+// appendViolation appends a line to the ban feed. The file is opened per write
+// so the reader can rename it away without coordinating with the plugin.
+func appendViolation(path, line string) {
+	violationFileMu.Lock()
+	defer violationFileMu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	f.WriteString(line)
+	f.Close()
+}
+
 // =============================================================================
 // Time-Based Access
 // =============================================================================
@@ -865,13 +1845,13 @@ func (tr *timeRange) contains(hour, minute int) bool {
 	return current >= start && current < end
 }
 
-// checkTimeAccess verifies if current time allows access
-func (s *Sentinel) checkTimeAccess() bool {
+// timeAccessFailure returns why access is denied at now, or "" when it is allowed
+func (s *Sentinel) timeAccessFailure(now time.Time) string {
 	if s.timeLocation == nil {
-		return true
+		return ""
 	}
 
-	now := time.Now().In(s.timeLocation)
+	now = now.In(s.timeLocation)
 	hour := now.Hour()
 	minute := now.Minute()
 	weekday := strings.ToLower(now.Weekday().String()[:3]) // mon, tue, etc.
@@ -886,24 +1866,21 @@ func (s *Sentinel) checkTimeAccess() bool {
 			}
 		}
 		if !dayAllowed {
-			s.log("day %s not in allowed days", weekday)
-			return false
+			return fmt.Sprintf("day %s is not in the allowed days", weekday)
 		}
 	}
 
 	// Deny range takes precedence
 	if s.timeDeny != nil && s.timeDeny.contains(hour, minute) {
-		s.log("time %02d:%02d in deny range", hour, minute)
-		return false
+		return fmt.Sprintf("time %02d:%02d is in the deny range %s", hour, minute, s.config.TimeAccess.DenyRange)
 	}
 
 	// Check allow range (if specified, must be within it)
 	if s.timeAllow != nil {
 		if !s.timeAllow.contains(hour, minute) {
-			s.log("time %02d:%02d not in allow range", hour, minute)
-			return false
+			return fmt.Sprintf("time %02d:%02d is outside the allow range %s", hour, minute, s.config.TimeAccess.AllowRange)
 		}
 	}
 
-	return true
+	return ""
 }
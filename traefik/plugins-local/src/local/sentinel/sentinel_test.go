@@ -0,0 +1,198 @@
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// evaluateCase is one entry of testdata/evaluate_cases.json. The API's
+// EvaluateSentinel runs the same file, so the two implementations agree.
+type evaluateCase struct {
+	Name     string          `json:"name"`
+	Config   json.RawMessage `json:"config"`   // as generateDomainsConfig emits it, minus file paths
+	ASN      []string        `json:"asn"`      // ASN dataset lines, written to a temp file
+	Slowlist []string        `json:"slowlist"` // slowlist file lines, written to a temp file
+	Request  struct {
+		ClientIP  string            `json:"clientIp"`
+		UserAgent string            `json:"userAgent"`
+		Headers   map[string]string `json:"headers"`
+		Time      *time.Time        `json:"time"`
+	} `json:"request"`
+	Want struct {
+		Action  string `json:"action"`
+		Check   string `json:"check"`
+		DelayMs int    `json:"delayMs"`
+	} `json:"want"`
+}
+
+func writeLines(t *testing.T, name string, lines []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEvaluateCases(t *testing.T) {
+	data, err := os.ReadFile("testdata/evaluate_cases.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []evaluateCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := CreateConfig()
+			if err := json.Unmarshal(tc.Config, cfg); err != nil {
+				t.Fatal(err)
+			}
+			if tc.ASN != nil {
+				cfg.IPFilter.ASNFile = writeLines(t, "asn.txt", tc.ASN)
+			}
+			if cfg.Slowlist != nil {
+				cfg.Slowlist.File = writeLines(t, "slowlist.txt", tc.Slowlist)
+			}
+
+			handler, err := New(context.Background(), http.NotFoundHandler(), cfg, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = net.JoinHostPort(tc.Request.ClientIP, "0")
+			for name, value := range tc.Request.Headers {
+				req.Header.Set(name, value)
+			}
+			if tc.Request.UserAgent != "" {
+				req.Header.Set("User-Agent", tc.Request.UserAgent)
+			}
+			now := time.Now()
+			if tc.Request.Time != nil {
+				now = *tc.Request.Time
+			}
+
+			got := handler.(*Sentinel).Evaluate(req, now)
+			if got.Action != tc.Want.Action || got.Check != tc.Want.Check {
+				t.Errorf("Evaluate = %s/%s (%s), want %s/%s", got.Action, got.Check, got.Detail, tc.Want.Action, tc.Want.Check)
+			}
+			if delay := int(got.Delay / time.Millisecond); delay != tc.Want.DelayMs {
+				t.Errorf("delay = %dms, want %dms", delay, tc.Want.DelayMs)
+			}
+		})
+	}
+}
+
+func TestServeHTTPWithoutMetrics(t *testing.T) {
+	handler, err := New(context.Background(), http.NotFoundHandler(), CreateConfig(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want the next handler's 404", rec.Code)
+	}
+}
+
+func TestViolationBanIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted []string
+		want    string
+	}{
+		{"untrusted peer reports itself", nil, "203.0.113.5"},
+		{"trusted proxy reports forwarded client", []string{"203.0.113.0/24"}, "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := filepath.Join(t.TempDir(), "bans")
+			cfg := CreateConfig()
+			cfg.UserAgents = &UserAgentsConfig{Enabled: true, Block: []string{"badbot"}}
+			cfg.ViolationThreshold = &ViolationThresholdConfig{
+				Enabled: true, Count: 1, File: feed, TrustedProxies: tt.trusted,
+			}
+			handler, err := New(context.Background(), http.NotFoundHandler(), cfg, "ban-"+tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "203.0.113.5:40000"
+			req.Header.Set("User-Agent", "badbot/1.0")
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			req.Header.Set("CF-Connecting-IP", "198.51.100.7")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			data, err := os.ReadFile(feed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ip, _, _ := strings.Cut(string(data), "\t"); ip != tt.want {
+				t.Errorf("ban feed = %q, want IP %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenancePageFile(t *testing.T) {
+	dir := t.TempDir()
+	pages := filepath.Join(dir, "maintenance-pages")
+	if err := os.Mkdir(pages, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pages, "page.html"), []byte("custom page"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "acme.json")
+	if err := os.WriteFile(secret, []byte("private key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old string) { maintenancePagesDir = old }(maintenancePagesDir)
+	maintenancePagesDir = pages
+
+	tests := []struct {
+		pageFile string
+		want     string
+	}{
+		{"page.html", "custom page"},
+		{"../acme.json", "Maintenance"},
+		{secret, "Maintenance"},
+		{"sub/../../acme.json", "Maintenance"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pageFile, func(t *testing.T) {
+			cfg := CreateConfig()
+			cfg.Maintenance = &MaintenanceConfig{Enabled: true, PageFile: tt.pageFile}
+			handler, err := New(context.Background(), http.NotFoundHandler(), cfg, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "203.0.113.10:1234"
+			handler.ServeHTTP(rec, req)
+
+			body := rec.Body.String()
+			if strings.Contains(body, "private key") {
+				t.Fatalf("pageFile %q served a file outside the pages directory", tt.pageFile)
+			}
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.want)
+			}
+		})
+	}
+}
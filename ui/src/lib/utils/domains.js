@@ -29,6 +29,12 @@ export function normalizeSentinelConfig(config) {
   return {
     enabled: config.enabled ?? true,
     errorMode: config.errorMode || '403',
+    dropMode: config.dropMode || '',
+    tarpitSeconds: config.tarpitSeconds || 0,
+    redirectUrl: config.redirectUrl || '',
+    redirectCode: config.redirectCode || 302,
+    staticBody: config.staticBody || '',
+    staticContentType: config.staticContentType || 'text/html',
     ipFilter: { sourceRange: config.ipFilter?.sourceRange || [] },
     maintenance: {
       enabled: config.maintenance?.enabled || false,
@@ -66,7 +72,12 @@ export function buildRoutePayload(formData) {
     accessMode: formData.accessMode,
     frontendSsl: formData.frontendSsl,
     certResolver: formData.certResolver || '',
-    sentinelConfig: formData.sentinelConfig
+    tlsMinVersion: formData.tlsMinVersion || '',
+    tlsServerName: formData.tlsServerName || '',
+    sentinelConfig: formData.sentinelConfig,
+    backends: (formData.backends || [])
+      .filter(b => b.targetIp && b.targetPort)
+      .map(b => ({ targetIp: b.targetIp.trim(), targetPort: parseInt(b.targetPort), weight: parseInt(b.weight) || 0 }))
   }
 }
 
@@ -87,7 +98,10 @@ export function defaultRouteForm() {
     accessMode: 'vpn',
     frontendSsl: false,
     certResolver: '',
-    sentinelConfig: null
+    tlsMinVersion: '',
+    tlsServerName: '',
+    sentinelConfig: null,
+    backends: []
   }
 }
 
@@ -109,7 +123,10 @@ export function routeToFormData(route) {
     accessMode: route.accessMode || 'vpn',
     frontendSsl: route.frontendSsl || false,
     certResolver: route.certResolver || '',
-    sentinelConfig: normalizeSentinelConfig(route.sentinelConfig)
+    tlsMinVersion: route.tlsMinVersion || '',
+    tlsServerName: route.tlsServerName || '',
+    sentinelConfig: normalizeSentinelConfig(route.sentinelConfig),
+    backends: (route.backends || []).map(b => ({ ...b, weight: b.weight || '' }))
   }
 }
 
@@ -126,5 +143,17 @@ export const ERROR_MODES = [
   { value: '403', label: '403 Forbidden' },
   { value: '404', label: '404 Not Found' },
   { value: '503', label: '503 Service Unavailable' },
-  { value: 'silent', label: 'Silent (close connection)' }
+  { value: 'silent', label: 'Silent (close connection)' },
+  { value: 'redirect', label: 'Redirect to URL' },
+  { value: 'static', label: 'Static page (200 OK)' }
 ]
+
+export const REDIRECT_CODES = [
+  { value: 302, label: '302 Found' },
+  { value: 301, label: '301 Moved Permanently' },
+  { value: 303, label: '303 See Other' },
+  { value: 307, label: '307 Temporary Redirect' },
+  { value: 308, label: '308 Permanent Redirect' }
+]
+
+export const STATIC_CONTENT_TYPES = ['text/html', 'text/plain', 'application/json']